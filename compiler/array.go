@@ -0,0 +1,345 @@
+package compiler
+
+import (
+	"github.com/lth-go/gogogogo/vm"
+)
+
+// ==============================
+// LengthExpression
+// ==============================
+
+// LengthExpression 数组或字符串的长度, eg, arr.length, str.length
+type LengthExpression struct {
+	ExpressionImpl
+
+	expression Expression
+}
+
+func (expr *LengthExpression) show(indent int) {
+	printWithIndent("LengthExpr", indent)
+
+	subIndent := indent + 2
+	expr.expression.show(subIndent)
+}
+
+func (expr *LengthExpression) fix(c *Compiler, currentBlock *Block) Expression {
+	return expr
+}
+
+func (expr *LengthExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	expr.expression.generate(exe, currentBlock, ob)
+
+	if isArray(expr.expression.typeS()) {
+		ob.generateCode(expr.Position(), vm.VM_ARRAY_LENGTH)
+	} else {
+		ob.generateCode(expr.Position(), vm.VM_STRING_LENGTH)
+	}
+}
+
+func createLengthExpression(member *MemberExpression) *LengthExpression {
+	expr := &LengthExpression{expression: member.expression}
+	expr.SetPosition(member.Position())
+	expr.setType(createTypeSpecifier(vm.IntType, member.Position()))
+
+	return expr
+}
+
+// arr.length等内置的成员和字符串的字符不能赋值
+func checkBuiltinMember(expr Expression) {
+	switch expr.(type) {
+	case *LengthExpression, *ArrayMethodExpression, *MapMethodExpression, *StringIndexExpression:
+		compileError(expr.Position(), NOT_LVALUE_ERR, "")
+	}
+}
+
+// 数组的成员, 只有length和insert, remove方法
+func fixArrayMemberExpression(member *MemberExpression) Expression {
+	if member.memberName == "length" {
+		return createLengthExpression(member)
+	}
+	return createArrayMethodExpression(member)
+}
+
+// 字符串的成员, 只有length
+func fixStringMemberExpression(member *MemberExpression) Expression {
+	if member.memberName != "length" {
+		compileError(member.Position(), STRING_METHOD_NOT_FOUND_ERR, member.memberName)
+	}
+	return createLengthExpression(member)
+}
+
+// ==============================
+// StringIndexExpression
+// ==============================
+
+// StringIndexExpression 按下标取字符串中的字符, eg, str[i]
+// 下标按字符计算, 字符串不可变, 不能赋值
+type StringIndexExpression struct {
+	ExpressionImpl
+
+	str   Expression
+	index Expression
+}
+
+func (expr *StringIndexExpression) show(indent int) {
+	printWithIndent("StringIndexExpr", indent)
+
+	subIndent := indent + 2
+	expr.str.show(subIndent)
+	expr.index.show(subIndent)
+}
+
+func (expr *StringIndexExpression) fix(c *Compiler, currentBlock *Block) Expression {
+	return expr
+}
+
+func (expr *StringIndexExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	expr.str.generate(exe, currentBlock, ob)
+	expr.index.generate(exe, currentBlock, ob)
+
+	ob.generateCode(expr.Position(), vm.VM_PUSH_STRING_CHAR)
+}
+
+// 字符串和下标已经修正
+func fixStringIndexExpression(c *Compiler, index *IndexExpression) Expression {
+	if !isInteger(index.index.typeS()) {
+		compileError(index.Position(), INDEX_NOT_INT_ERR)
+	}
+
+	expr := &StringIndexExpression{str: index.array, index: index.index}
+	expr.SetPosition(index.Position())
+	expr.setType(createTypeSpecifier(vm.CharType, index.Position()))
+	expr.typeS().fix(c)
+
+	return expr
+}
+
+// ==============================
+// ArrayMethodExpression
+// ==============================
+
+// ArrayMethodExpression 数组的内置方法, 只能直接调用
+// eg, arr.insert(index, value), arr.remove(index)
+type ArrayMethodExpression struct {
+	ExpressionImpl
+
+	expression Expression
+	methodName string
+}
+
+func (expr *ArrayMethodExpression) show(indent int) {
+	printWithIndent("ArrayMethodExpr: "+expr.methodName, indent)
+
+	subIndent := indent + 2
+	expr.expression.show(subIndent)
+}
+
+func (expr *ArrayMethodExpression) fix(c *Compiler, currentBlock *Block) Expression {
+	return expr
+}
+
+func (expr *ArrayMethodExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	compileError(expr.Position(), METHOD_IS_NOT_CALLED_ERR, expr.methodName)
+}
+
+// 依次压入数组和参数
+func (expr *ArrayMethodExpression) generateCall(exe *vm.Executable, currentBlock *Block, argumentList []Expression, ob *OpCodeBuf) {
+	expr.expression.generate(exe, currentBlock, ob)
+	generatePushArgument(argumentList, exe, currentBlock, ob)
+
+	offset := getOpcodeTypeOffset(getElementType(expr.expression.typeS()))
+
+	switch expr.methodName {
+	case "insert":
+		ob.generateCode(expr.Position(), vm.VM_ARRAY_INSERT_INT+offset)
+	case "remove":
+		ob.generateCode(expr.Position(), vm.VM_ARRAY_REMOVE_INT+offset)
+	default:
+		panic("TODO")
+	}
+}
+
+// 方法的类型为函数类型, 调用时按函数类型检查参数
+func createArrayMethodExpression(member *MemberExpression) *ArrayMethodExpression {
+	expr := &ArrayMethodExpression{
+		expression: member.expression,
+		methodName: member.memberName,
+	}
+	expr.SetPosition(member.Position())
+
+	pos := member.Position()
+	elemType := getElementType(member.expression.typeS())
+
+	var typ *TypeSpecifier
+	switch expr.methodName {
+	case "insert":
+		typ = createFunctionTypeSpecifier(
+			createTypeSpecifier(vm.VoidType, pos),
+			[]*TypeSpecifier{createTypeSpecifier(vm.IntType, pos), elemType},
+		)
+	case "remove":
+		typ = createFunctionTypeSpecifier(elemType, []*TypeSpecifier{createTypeSpecifier(vm.IntType, pos)})
+	default:
+		compileError(pos, ARRAY_METHOD_NOT_FOUND_ERR, expr.methodName)
+	}
+	expr.setType(typ)
+
+	return expr
+}
+
+// 数组元素的类型
+func getElementType(arrayType *TypeSpecifier) *TypeSpecifier {
+	typ := cloneTypeSpecifier(arrayType)
+	typ.deriveList = arrayType.deriveList[1:]
+	return typ
+}
+
+// ==============================
+// SliceExpression
+// ==============================
+
+// SliceExpression 数组切片, 与原数组共享存储, eg, arr[1:3], arr[:n], arr[1:]
+type SliceExpression struct {
+	ExpressionImpl
+
+	array Expression
+	// 省略时分别为0和数组的长度
+	begin Expression
+	end   Expression
+}
+
+func (expr *SliceExpression) show(indent int) {
+	printWithIndent("SliceExpr", indent)
+
+	subIndent := indent + 2
+	expr.array.show(subIndent)
+	if expr.begin != nil {
+		expr.begin.show(subIndent)
+	}
+	if expr.end != nil {
+		expr.end.show(subIndent)
+	}
+}
+
+func (expr *SliceExpression) fix(c *Compiler, currentBlock *Block) Expression {
+	expr.array = expr.array.fix(c, currentBlock)
+
+	if !isArray(expr.array.typeS()) {
+		compileError(expr.Position(), INDEX_LEFT_OPERAND_NOT_ARRAY_ERR)
+	}
+
+	if expr.begin != nil {
+		expr.begin = expr.begin.fix(c, currentBlock)
+		if !isInteger(expr.begin.typeS()) {
+			compileError(expr.Position(), INDEX_NOT_INT_ERR)
+		}
+	}
+	if expr.end != nil {
+		expr.end = expr.end.fix(c, currentBlock)
+		if !isInteger(expr.end.typeS()) {
+			compileError(expr.Position(), INDEX_NOT_INT_ERR)
+		}
+	}
+
+	expr.setType(cloneTypeSpecifier(expr.array.typeS()))
+	expr.typeS().fix(c)
+
+	return expr
+}
+
+func (expr *SliceExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	expr.array.generate(exe, currentBlock, ob)
+
+	if expr.begin != nil {
+		expr.begin.generate(exe, currentBlock, ob)
+	} else {
+		ob.generateCode(expr.Position(), vm.VM_PUSH_INT_1BYTE, 0)
+	}
+
+	if expr.end != nil {
+		expr.end.generate(exe, currentBlock, ob)
+	} else {
+		// 复制数组并取长度
+		ob.generateCode(expr.Position(), vm.VM_DUPLICATE_OFFSET, 1)
+		ob.generateCode(expr.Position(), vm.VM_ARRAY_LENGTH)
+	}
+
+	ob.generateCode(expr.Position(), vm.VM_ARRAY_SLICE)
+}
+
+func createSliceExpression(array, begin, end Expression, pos Position) *SliceExpression {
+	expr := &SliceExpression{
+		array: array,
+		begin: begin,
+		end:   end,
+	}
+	expr.SetPosition(pos)
+
+	return expr
+}
+
+// ==============================
+// AppendExpression
+// ==============================
+
+// AppendExpression 向数组末尾追加元素, 返回数组本身, eg, append(arr, v)
+type AppendExpression struct {
+	ExpressionImpl
+
+	array Expression
+	value Expression
+}
+
+func (expr *AppendExpression) show(indent int) {
+	printWithIndent("AppendExpr", indent)
+
+	subIndent := indent + 2
+	expr.array.show(subIndent)
+	expr.value.show(subIndent)
+}
+
+func (expr *AppendExpression) fix(c *Compiler, currentBlock *Block) Expression {
+	return expr
+}
+
+func (expr *AppendExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	expr.array.generate(exe, currentBlock, ob)
+	expr.value.generate(exe, currentBlock, ob)
+
+	offset := getOpcodeTypeOffset(getElementType(expr.typeS()))
+	ob.generateCode(expr.Position(), vm.VM_ARRAY_APPEND_INT+offset)
+}
+
+// 没有同名的变量和函数时, append为内置的追加操作
+func (c *Compiler) isAppendCall(function Expression, currentBlock *Block) bool {
+	identifierExpr, ok := function.(*IdentifierExpression)
+	if !ok || identifierExpr.name != "append" {
+		return false
+	}
+
+	name := identifierExpr.name
+	return c.searchDeclaration(name, currentBlock) == nil && c.searchFunction(name) == nil && c.searchGeneric(name) == nil
+}
+
+func fixAppendCall(c *Compiler, currentBlock *Block, call *FunctionCallExpression) Expression {
+	if len(call.argumentList) != 2 {
+		compileError(call.Position(), ARGUMENT_COUNT_MISMATCH_ERR, 2, len(call.argumentList))
+	}
+
+	expr := &AppendExpression{
+		array: call.argumentList[0].fix(c, currentBlock),
+		value: call.argumentList[1].fix(c, currentBlock),
+	}
+	expr.SetPosition(call.Position())
+
+	arrayType := expr.array.typeS()
+	if !isArray(arrayType) {
+		compileError(expr.array.Position(), APPEND_ARGUMENT_TYPE_ERR, getTypeName(arrayType))
+	}
+	expr.value = createAssignCast(expr.value, getElementType(arrayType))
+
+	expr.setType(cloneTypeSpecifier(arrayType))
+	expr.typeS().fix(c)
+
+	return expr
+}
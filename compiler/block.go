@@ -42,8 +42,8 @@ func (b *Block) show(indent int) {
 	}
 }
 
-func (b *Block) addDeclaration(declaration *Declaration, fd *FunctionDefinition, pos Position) {
-	if searchDeclaration(declaration.name, b) != nil {
+func (b *Block) addDeclaration(c *Compiler, declaration *Declaration, fd *FunctionDefinition, pos Position) {
+	if b.searchDeclaration(c, declaration.name) != nil {
 		compileError(pos, VARIABLE_MULTIPLE_DEFINE_ERR, declaration.name)
 	}
 
@@ -54,15 +54,39 @@ func (b *Block) addDeclaration(declaration *Declaration, fd *FunctionDefinition,
 		declaration.isLocal = true
 		fd.addLocalVariable(declaration)
 	} else {
-		compiler := getCurrentCompiler()
 		declaration.isLocal = false
-		compiler.declarationList = append(compiler.declarationList, declaration)
+		declaration.isBlockScoped = b != nil
+		declaration.packageName = c.getPackageName()
+		c.declarationList = append(c.declarationList, declaration)
 	}
 }
 
+// 查找当前作用域内的声明, 顶层块之外只查找本包的全局声明
+func (b *Block) searchDeclaration(c *Compiler, name string) *Declaration {
+	if b == nil {
+		return c.searchGlobalDeclaration(name)
+	}
+
+	for block := b; block != nil; block = block.outerBlock {
+		for _, declaration := range block.declarationList {
+			if declaration.name == name {
+				return declaration
+			}
+		}
+	}
+
+	return nil
+}
+
+// lambda的函数块嵌套在外层函数中, break, continue和return不能跨越函数块
+func (b *Block) isFunctionBlock() bool {
+	_, ok := b.parent.(*FunctionBlockInfo)
+	return ok
+}
+
 func (b *Block) getCurrentFunction() *FunctionDefinition {
 
-	for block := b; ; block = block.outerBlock {
+	for block := b; block != nil; block = block.outerBlock {
 		fdBlockInfo, ok := block.parent.(*FunctionBlockInfo)
 		if ok {
 			return fdBlockInfo.function
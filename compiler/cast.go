@@ -7,15 +7,35 @@ import (
 func createCastExpression(castType CastType, expr Expression) Expression {
 	var typ *TypeSpecifier
 
+	// 字符常量和int常量直接转换
+	switch e := expr.(type) {
+	case *CharExpression:
+		if castType == CharToIntCast {
+			newExpr := &IntExpression{intValue: int(e.charValue)}
+			newExpr.SetPosition(e.Position())
+			newExpr.setType(&TypeSpecifier{basicType: vm.IntType})
+			return newExpr
+		}
+	case *IntExpression:
+		if castType == IntToCharCast {
+			newExpr := &CharExpression{charValue: rune(e.intValue)}
+			newExpr.SetPosition(e.Position())
+			newExpr.setType(&TypeSpecifier{basicType: vm.CharType})
+			return newExpr
+		}
+	}
+
 	castExpr := &CastExpression{castType: castType, operand: expr}
 	castExpr.SetPosition(expr.Position())
 
 	switch castType {
 	case IntToDoubleCast:
 		typ = &TypeSpecifier{basicType: vm.DoubleType}
-	case DoubleToIntCast:
+	case DoubleToIntCast, CharToIntCast:
 		typ = &TypeSpecifier{basicType: vm.IntType}
-	case BooleanToStringCast, IntToStringCast, DoubleToStringCast:
+	case IntToCharCast:
+		typ = &TypeSpecifier{basicType: vm.CharType}
+	case BooleanToStringCast, IntToStringCast, DoubleToStringCast, CharToStringCast:
 		typ = &TypeSpecifier{basicType: vm.StringType}
 	}
 	castExpr.setType(typ)
@@ -30,6 +50,14 @@ func createAssignCast(src Expression, destTye *TypeSpecifier) Expression {
 	srcTye := src.typeS()
 
 	if compareType(src.typeS(), destTye) {
+		// 类只能赋值给自身, 父类或实现的接口
+		if isClass(srcTye) && len(srcTye.deriveList) == 0 && !isAssignableClass(srcTye, destTye) {
+			castMismatchError(src.Position(), srcTye, destTye)
+		}
+		// 函数类型的返回值是类时, 也必须是同一个类
+		if isFunction(srcTye) && !isSameType(srcTye, destTye) {
+			castMismatchError(src.Position(), srcTye, destTye)
+		}
 		return src
 	}
 
@@ -40,12 +68,37 @@ func createAssignCast(src Expression, destTye *TypeSpecifier) Expression {
 		return src
 	}
 
-	if isInt(srcTye) && isDouble(destTye) {
+	// 数组等派生类型之间不能转换, 字面量除外
+	if len(srcTye.deriveList) > 0 || len(destTye.deriveList) > 0 {
+		if castExpr = createLiteralCast(src, destTye); castExpr != nil {
+			return castExpr
+		}
+		castMismatchError(src.Position(), srcTye, destTye)
+	}
+
+	if isInteger(srcTye) && isInteger(destTye) {
+		// 常量在范围内时也可以转换为较窄的类型
+		if getIntegerBits(srcTye) <= getIntegerBits(destTye) || isIntegerConstantFit(src, destTye) {
+			return createIntegerCast(src, destTye)
+		}
+		compileError(src.Position(), IMPLICIT_NARROWING_ERR, getTypeName(srcTye), getTypeName(destTye), getTypeName(destTye))
+
+	} else if isInteger(srcTye) && isDouble(destTye) {
 		castExpr = createCastExpression(IntToDoubleCast, src)
 		return castExpr
 
-	} else if isDouble(srcTye) && isInt(destTye) {
-		castExpr = createCastExpression(DoubleToIntCast, src)
+	} else if isDouble(srcTye) && isInteger(destTye) {
+		compileError(src.Position(), IMPLICIT_NARROWING_ERR, getTypeName(srcTye), getTypeName(destTye), getTypeName(destTye))
+
+	} else if isChar(srcTye) && isInteger(destTye) {
+		castExpr = createCastExpression(CharToIntCast, src)
+		if getIntegerBits(destTye) < 32 && !isIntegerConstantFit(castExpr, destTye) {
+			compileError(src.Position(), IMPLICIT_NARROWING_ERR, getTypeName(srcTye), getTypeName(destTye), getTypeName(destTye))
+		}
+		return createIntegerCast(castExpr, destTye)
+
+	} else if isChar(srcTye) && isDouble(destTye) {
+		castExpr = createCastExpression(IntToDoubleCast, createCastExpression(CharToIntCast, src))
 		return castExpr
 
 	} else if isString(destTye) {
@@ -59,41 +112,227 @@ func createAssignCast(src Expression, destTye *TypeSpecifier) Expression {
 	return nil
 }
 
+// 修正赋值的右侧, 空的{}赋值给map时作为空map, eg, map<string, int> m = {};
+func fixAssignOperand(c *Compiler, currentBlock *Block, src Expression, destTye *TypeSpecifier) Expression {
+	if literal, ok := src.(*ArrayLiteralExpression); ok && len(literal.arrayLiteral) == 0 && isMap(destTye) {
+		return createEmptyMapLiteral(destTye, src.Position())
+	}
+	return src.fix(c, currentBlock)
+}
+
+// 数组和map字面量按目标类型转换每一项, eg, byte[] data = {1, 2};
+func createLiteralCast(src Expression, destTye *TypeSpecifier) Expression {
+	switch e := src.(type) {
+	case *ArrayLiteralExpression:
+		if !isArray(destTye) {
+			return nil
+		}
+		elemType := getElementType(destTye)
+		for i := range e.arrayLiteral {
+			e.arrayLiteral[i] = createAssignCast(e.arrayLiteral[i], elemType)
+		}
+	case *MapLiteralExpression:
+		if !isMap(destTye) || len(e.keyList) == 0 {
+			return nil
+		}
+		keyType := destTye.deriveList[0].(*MapDerive).keyType
+		valueType := getElementType(destTye)
+		for i := range e.keyList {
+			e.keyList[i] = createAssignCast(e.keyList[i], keyType)
+			e.valueList[i] = createAssignCast(e.valueList[i], valueType)
+		}
+	default:
+		return nil
+	}
+
+	src.setType(cloneTypeSpecifier(destTye))
+
+	return src
+}
+
+// 赋值时类型转换的代价, 用于选择重载的构造方法
+// 类型一致为0, 数值提升和向上转型为1, 其余转换为2, 不能转换时为-1
+func getAssignCost(src Expression, destTye *TypeSpecifier) int {
+	srcTye := src.typeS()
+
+	if compareType(srcTye, destTye) {
+		switch {
+		case !isClass(srcTye) || len(srcTye.deriveList) > 0:
+			return 0
+		case srcTye.classRef.classDefinition == destTye.classRef.classDefinition:
+			return 0
+		case isAssignableClass(srcTye, destTye):
+			return 1
+		}
+		return -1
+	}
+
+	if isObject(destTye) && srcTye.basicType == vm.NullType {
+		return 1
+	}
+
+	if len(srcTye.deriveList) > 0 || len(destTye.deriveList) > 0 {
+		return -1
+	}
+
+	switch {
+	case isInteger(srcTye) && isInteger(destTye):
+		if getIntegerBits(srcTye) <= getIntegerBits(destTye) || isIntegerConstantFit(src, destTye) {
+			return 1
+		}
+	case isInteger(srcTye) && isDouble(destTye):
+		return 1
+	case isChar(srcTye) && (getIntegerBits(destTye) >= 32 && isInteger(destTye) || isDouble(destTye)):
+		return 1
+	case isString(destTye) && (isBoolean(srcTye) || isInteger(srcTye) || isDouble(srcTye) || isChar(srcTye)):
+		return 2
+	}
+	return -1
+}
+
+func isAssignableClass(src, dest *TypeSpecifier) bool {
+	srcCd := src.classRef.classDefinition
+	destCd := dest.classRef.classDefinition
+
+	// 类型未修正时无法判断
+	if srcCd == nil || destCd == nil {
+		return true
+	}
+	return srcCd.isSubClassOf(destCd)
+}
+
 func createToStringCast(src Expression) Expression {
 	var cast Expression
 
 	if isBoolean(src.typeS()) {
 		cast = createCastExpression(BooleanToStringCast, src)
-	} else if isInt(src.typeS()) {
+	} else if isInteger(src.typeS()) {
 		cast = createCastExpression(IntToStringCast, src)
 	} else if isDouble(src.typeS()) {
 		cast = createCastExpression(DoubleToStringCast, src)
-	} else {
-		panic("TODO")
+	} else if isChar(src.typeS()) {
+		cast = createCastExpression(CharToStringCast, src)
 	}
 
 	return cast
 }
 
+// 显式类型转换, 除了赋值时允许的转换, 数值和char之间可以任意转换, 超出范围时截断
+func createExplicitCast(src Expression, destType *TypeSpecifier) Expression {
+	srcType := src.typeS()
+
+	if len(srcType.deriveList) > 0 || len(destType.deriveList) > 0 {
+		return createAssignCast(src, destType)
+	}
+
+	// 先转换为int
+	if isDouble(srcType) && (isInteger(destType) || isChar(destType)) {
+		src = createCastExpression(DoubleToIntCast, src)
+	} else if isChar(srcType) && isInteger(destType) {
+		src = createCastExpression(CharToIntCast, src)
+	}
+
+	switch {
+	case isInteger(src.typeS()) && isInteger(destType):
+		return createIntegerCast(src, destType)
+	case isInteger(src.typeS()) && isChar(destType):
+		return createCastExpression(IntToCharCast, src)
+	}
+
+	return createAssignCast(src, destType)
+}
+
+// 整数类型之间的转换, 转换为较窄的类型时截断
+func createIntegerCast(src Expression, destType *TypeSpecifier) Expression {
+	if src.typeS().basicType == destType.basicType {
+		return src
+	}
+
+	typ := createTypeSpecifier(destType.basicType, src.Position())
+
+	// 常量直接转换
+	if intExpr, ok := src.(*IntExpression); ok {
+		newExpr := &IntExpression{intValue: wrapIntegerValue(intExpr.intValue, typ)}
+		newExpr.SetPosition(src.Position())
+		newExpr.setType(typ)
+		return newExpr
+	}
+
+	castExpr := &CastExpression{castType: IntegerCast, operand: src}
+	castExpr.SetPosition(src.Position())
+	castExpr.setType(typ)
+
+	return castExpr
+}
+
+// 按类型的位数截断, byte为无符号数
+func wrapIntegerValue(value int, typ *TypeSpecifier) int {
+	switch typ.basicType {
+	case vm.ByteType:
+		return int(uint8(value))
+	case vm.Int32Type:
+		return int(int32(value))
+	}
+	return value
+}
+
+// 整数常量能否不丢失数据地转换为typ
+func isIntegerConstantFit(expr Expression, typ *TypeSpecifier) bool {
+	intExpr, ok := expr.(*IntExpression)
+	return ok && isInteger(typ) && wrapIntegerValue(intExpr.intValue, typ) == intExpr.intValue
+}
+
+// 不同的整数类型运算时转换为位数较多的类型, 常量转换为另一边的类型
+func castIntegerBinaryExpression(binaryExpr *BinaryExpression) {
+	leftType := binaryExpr.left.typeS()
+	rightType := binaryExpr.right.typeS()
+
+	switch {
+	case isIntegerConstantFit(binaryExpr.right, leftType):
+		binaryExpr.right = createIntegerCast(binaryExpr.right, leftType)
+	case isIntegerConstantFit(binaryExpr.left, rightType):
+		binaryExpr.left = createIntegerCast(binaryExpr.left, rightType)
+	case getIntegerBits(leftType) > getIntegerBits(rightType) || isLong(leftType):
+		binaryExpr.right = createIntegerCast(binaryExpr.right, leftType)
+	default:
+		binaryExpr.left = createIntegerCast(binaryExpr.left, rightType)
+	}
+}
+
 func castBinaryExpression(binaryExpr *BinaryExpression) *BinaryExpression {
 
 	leftType := binaryExpr.left.typeS()
 	rightType := binaryExpr.right.typeS()
 
-	if isInt(leftType) && isDouble(rightType) {
+	// char与数值运算时提升为int
+	if isChar(leftType) && (isInteger(rightType) || isDouble(rightType)) {
+		binaryExpr.left = createCastExpression(CharToIntCast, binaryExpr.left)
+		leftType = binaryExpr.left.typeS()
+	} else if isChar(rightType) && (isInteger(leftType) || isDouble(leftType)) {
+		binaryExpr.right = createCastExpression(CharToIntCast, binaryExpr.right)
+		rightType = binaryExpr.right.typeS()
+	}
+
+	if isInteger(leftType) && isInteger(rightType) && leftType.basicType != rightType.basicType {
+		castIntegerBinaryExpression(binaryExpr)
+
+	} else if isInteger(leftType) && isDouble(rightType) {
 		binaryExpr.left = createCastExpression(IntToDoubleCast, binaryExpr.left)
 
-	} else if isDouble(leftType) && isInt(rightType) {
+	} else if isDouble(leftType) && isInteger(rightType) {
 		binaryExpr.right = createCastExpression(IntToDoubleCast, binaryExpr.right)
 
 	} else if isString(leftType) && isBoolean(rightType) {
 		binaryExpr.right = createCastExpression(BooleanToStringCast, binaryExpr.right)
 
-	} else if isString(leftType) && isInt(rightType) {
+	} else if isString(leftType) && isInteger(rightType) {
 		binaryExpr.right = createCastExpression(IntToStringCast, binaryExpr.right)
 
 	} else if isString(leftType) && isDouble(rightType) {
 		binaryExpr.right = createCastExpression(DoubleToStringCast, binaryExpr.right)
+
+	} else if isString(leftType) && isChar(rightType) {
+		binaryExpr.right = createCastExpression(CharToStringCast, binaryExpr.right)
 	}
 
 	return binaryExpr
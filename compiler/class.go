@@ -6,7 +6,8 @@ import (
 	"github.com/lth-go/gogogogo/vm"
 )
 
-const defaultConstructorName = "init"
+// 字段初始化方法名, 由各个构造方法调用
+const fieldInitializerName = "<fields>"
 
 //
 // ClassDefinition
@@ -17,10 +18,22 @@ type ClassDefinition struct {
 	packageNameList []string
 	name            string
 
-	extendList []*Extend
-	superClass *ClassDefinition
+	isAbstract  bool
+	isInterface bool
+
+	extendList    []*Extend
+	superClass    *ClassDefinition
+	interfaceList []*ClassDefinition
 
 	memberList []MemberDeclaration
+
+	// 构造方法, 不在memberList中, 不参与虚表
+	constructorList []*MethodMember
+	// 有初始值的字段由该方法初始化, 没有时为nil
+	fieldInitializer *FunctionDefinition
+
+	// 泛型类的实例
+	instance *genericInstance
 }
 
 func (cd *ClassDefinition) getPackageName() string {
@@ -28,31 +41,35 @@ func (cd *ClassDefinition) getPackageName() string {
 }
 
 // 添加类到当前compiler
-func (cd *ClassDefinition) addToCurrentCompiler() int {
+func (cd *ClassDefinition) addToCompiler(c *Compiler) int {
 	var dummy int
 
-	compiler := getCurrentCompiler()
-
 	srcPackageName := cd.getPackageName()
 
-	for i, vmClass := range compiler.vmClassList {
+	for i, vmClass := range c.vmClassList {
 		if (srcPackageName == vmClass.PackageName) && (cd.name == vmClass.Name) {
 			return i
 		}
 	}
 
-	ret := len(compiler.vmClassList)
+	ret := len(c.vmClassList)
 
 	dest := &vm.Class{
 		PackageName:   srcPackageName,
 		Name:          cd.name,
 		IsImplemented: false,
+		IsAbstract:    cd.isAbstract,
+		IsInterface:   cd.isInterface,
 	}
 
-	compiler.vmClassList = append(compiler.vmClassList, dest)
+	c.vmClassList = append(c.vmClassList, dest)
 
 	for _, extend := range cd.extendList {
-		searchClassAndAdd(cd.Position(), extend.identifier, &dummy)
+		// 泛型父类在fixExtends中实例化
+		if extend.typeSpecifier != nil {
+			continue
+		}
+		c.searchClassAndAdd(cd.Position(), extend.identifier, &dummy)
 	}
 
 	return ret
@@ -66,11 +83,11 @@ func (cd *ClassDefinition) getSuperFieldMethodCount() (int, int) {
 		for _, memberIfs := range superCd.memberList {
 			switch member := memberIfs.(type) {
 			case *MethodMember:
-				if member.methodIndex > methodIndex {
+				if !member.isStatic && member.methodIndex > methodIndex {
 					methodIndex = member.methodIndex
 				}
 			case *FieldMember:
-				if member.fieldIndex > fieldIndex {
+				if !member.isStatic && member.fieldIndex > fieldIndex {
 					fieldIndex = member.fieldIndex
 				}
 			default:
@@ -81,16 +98,20 @@ func (cd *ClassDefinition) getSuperFieldMethodCount() (int, int) {
 	return fieldIndex + 1, methodIndex + 1
 }
 
+// 在父类和实现的接口中查找成员, 父类优先
 func (cd *ClassDefinition) searchMemberInSuper(memberName string) MemberDeclaration {
-	var member MemberDeclaration
-
-	if cd.superClass == nil {
-		return nil
+	if cd.superClass != nil {
+		member := cd.superClass.searchMember(memberName)
+		if member != nil {
+			return member
+		}
 	}
 
-	member = cd.superClass.searchMember(memberName)
-	if member != nil {
-		return member
+	for _, iface := range cd.interfaceList {
+		member := iface.searchMember(memberName)
+		if member != nil {
+			return member
+		}
 	}
 
 	return nil
@@ -114,24 +135,55 @@ func (cd *ClassDefinition) searchMember(memberName string) MemberDeclaration {
 	}
 
 	// 递归查找
-	if cd.superClass != nil {
-		member := cd.superClass.searchMember(memberName)
-		if member != nil {
-			return member
+	return cd.searchMemberInSuper(memberName)
+}
+
+// 是否是super或super的子类, super为接口时判断是否实现了该接口
+func (cd *ClassDefinition) isSubClassOf(super *ClassDefinition) bool {
+	for pos := cd; pos != nil; pos = pos.superClass {
+		if pos == super {
+			return true
+		}
+		for _, iface := range pos.interfaceList {
+			if iface == super {
+				return true
+			}
 		}
 	}
+	return false
+}
 
-	return nil
+// 两个类之间可能存在继承关系
+// 涉及接口时, 任意类的子类都可能实现该接口, 无法在编译时判断
+func (cd *ClassDefinition) mayBeRelatedTo(other *ClassDefinition) bool {
+	if cd.isInterface || other.isInterface {
+		return true
+	}
+	return cd.isSubClassOf(other) || other.isSubClassOf(cd)
 }
 
-func (cd *ClassDefinition) fixExtends() {
+func (cd *ClassDefinition) fixExtends(c *Compiler) {
 	var dummyClassIndex int
 
 	for _, extend := range cd.extendList {
-		super := searchClassAndAdd(cd.Position(), extend.identifier, &dummyClassIndex)
+		if extend.typeSpecifier != nil {
+			extend.typeSpecifier.fix(c)
+			extend.identifier = extend.typeSpecifier.classRef.identifier
+		}
+		super := c.searchClassAndAdd(cd.Position(), extend.identifier, &dummyClassIndex)
 
 		extend.classDefinition = super
 
+		if cd.isInterface {
+			compileError(cd.Position(), INTERFACE_INHERIT_ERR, cd.name)
+		}
+
+		// 只能继承一个类, 其余的必须是接口
+		if super.isInterface {
+			cd.interfaceList = append(cd.interfaceList, super)
+			continue
+		}
+
 		if cd.superClass != nil {
 			compileError(cd.Position(), MULTIPLE_INHERITANCE_ERR, super.name)
 		}
@@ -140,6 +192,140 @@ func (cd *ClassDefinition) fixExtends() {
 	}
 }
 
+// 收集父类和接口中没有被实现的abstract方法
+func (cd *ClassDefinition) getAbstractMethodList() []*MethodMember {
+	var list []*MethodMember
+
+	for pos := cd; pos != nil; pos = pos.superClass {
+		for _, md := range pos.memberList {
+			if member, ok := md.(*MethodMember); ok && member.isAbstract {
+				list = append(list, member)
+			}
+		}
+		for _, iface := range pos.interfaceList {
+			for _, md := range iface.memberList {
+				list = append(list, md.(*MethodMember))
+			}
+		}
+	}
+
+	return list
+}
+
+// 在类及其父类中查找方法的实现, 不包括接口
+func (cd *ClassDefinition) searchMethodImplementation(name string) *MethodMember {
+	for pos := cd; pos != nil; pos = pos.superClass {
+		for _, md := range pos.memberList {
+			if member, ok := md.(*MethodMember); ok && member.functionDefinition.name == name {
+				if member.isAbstract || member.isStatic {
+					return nil
+				}
+				return member
+			}
+		}
+	}
+	return nil
+}
+
+// 检查方法体, abstract方法不能有方法体, 其余方法必须有
+func checkMethodBody(cd *ClassDefinition, member *MethodMember) {
+	fd := member.functionDefinition
+
+	if !member.isAbstract {
+		if fd.block == nil {
+			compileError(member.Position(), CONCRETE_METHOD_HAS_NO_BODY_ERR, fd.name)
+		}
+		return
+	}
+
+	if fd.block != nil {
+		compileError(member.Position(), ABSTRACT_METHOD_HAS_BODY_ERR, fd.name)
+	}
+	if !cd.isAbstract {
+		compileError(member.Position(), ABSTRACT_METHOD_IN_CONCRETE_CLASS_ERR, cd.name, fd.name)
+	}
+}
+
+// 检查方法覆盖, 构造方法除外
+func checkOverride(cd *ClassDefinition, member *MethodMember) {
+	name := member.functionDefinition.name
+
+	if cd.isInterface {
+		return
+	}
+
+	superMember, ok := cd.searchMemberInSuper(name).(*MethodMember)
+
+	// static方法不能覆盖父类的方法, 也不能被覆盖
+	if member.isStatic || (ok && superMember.isStatic) {
+		if ok {
+			compileError(member.Position(), STATIC_METHOD_OVERRIDE_ERR, name)
+		}
+		return
+	}
+
+	if !ok {
+		if member.isOverride {
+			compileError(member.Position(), OVERRIDE_METHOD_NOT_FOUND_ERR, name)
+		}
+		return
+	}
+
+	if !member.isOverride {
+		compileError(member.Position(), NEED_OVERRIDE_ERR, name)
+	}
+	// override的方法同样可以被覆盖
+	if !superMember.isVirtual && !superMember.isAbstract && !superMember.isOverride {
+		compileError(member.Position(), NON_VIRTUAL_METHOD_OVERRIDED_ERR, name)
+	}
+	if member.accessModifier.isNarrowerThan(superMember.accessModifier) {
+		compileError(member.Position(), OVERRIDE_METHOD_ACCESSIBILITY_ERR, name)
+	}
+
+	checkMethodSignature(superMember, member)
+}
+
+// 非abstract类必须实现父类和接口中所有的abstract方法
+func checkAbstractMethodImplemented(cd *ClassDefinition) {
+	if cd.isAbstract {
+		return
+	}
+
+	for _, abstractMember := range cd.getAbstractMethodList() {
+		name := abstractMember.functionDefinition.name
+
+		impl := cd.searchMethodImplementation(name)
+		if impl == nil {
+			compileError(cd.Position(), ABSTRACT_METHOD_NOT_IMPLEMENTED_ERR, cd.name, name)
+		}
+
+		// 父类中的方法实现了子类才声明的接口, 覆盖检查时没有比较过
+		if !impl.functionDefinition.classDefinition.isSubClassOf(abstractMember.functionDefinition.classDefinition) {
+			checkMethodSignature(abstractMember, impl)
+		}
+	}
+}
+
+// 覆盖的方法必须与被覆盖的方法参数和返回值一致
+func checkMethodSignature(superMember, member *MethodMember) {
+	superFd := superMember.functionDefinition
+	fd := member.functionDefinition
+
+	if len(superFd.parameterList) != len(fd.parameterList) {
+		compileError(member.Position(), BAD_PARAMETER_COUNT_ERR, fd.name)
+	}
+
+	for i, param := range fd.parameterList {
+		if !isSameType(superFd.parameterList[i].typeSpecifier, param.typeSpecifier) {
+			compileError(member.Position(), BAD_PARAMETER_TYPE_ERR, fd.name, i+1, param.name)
+		}
+	}
+
+	if !isSameType(superFd.typeSpecifier, fd.typeSpecifier) {
+		compileError(member.Position(), BAD_RETURN_TYPE_ERR, fd.name)
+	}
+}
+
 // ==============================
 // Extend
 // ==============================
@@ -147,6 +333,9 @@ func (cd *ClassDefinition) fixExtends() {
 type Extend struct {
 	identifier      string
 	classDefinition *ClassDefinition
+
+	// 继承泛型类, eg, class IntList : List<int>
+	typeSpecifier *TypeSpecifier
 }
 
 func createExtendList(identifier string) []*Extend {
@@ -176,29 +365,160 @@ func chainMemberDeclaration(list []MemberDeclaration, add []MemberDeclaration) [
 	return list
 }
 
+// ==============================
+// ClassOrMemberModifierList
+// ==============================
+type ClassOrMemberModifierKind int
+
+const (
+	AbstractModifier ClassOrMemberModifierKind = iota
+	VirtualModifier
+	OverrideModifier
+	PublicModifier
+	PrivateModifier
+	ProtectedModifier
+	StaticModifier
+)
+
+// 访问修饰符, 未指定时为public
+type AccessModifierKind int
+
+const (
+	NotSpecifiedAccess AccessModifierKind = iota
+	PublicAccess
+	ProtectedAccess
+	PrivateAccess
+)
+
+// 类或成员的修饰符
+type ClassOrMemberModifierList struct {
+	PosImpl
+
+	isAbstract     bool
+	isVirtual      bool
+	isOverride     bool
+	isStatic       bool
+	accessModifier AccessModifierKind
+}
+
+func createClassOrMemberModifier(kind ClassOrMemberModifierKind, pos Position) *ClassOrMemberModifierList {
+	ret := &ClassOrMemberModifierList{}
+	ret.SetPosition(pos)
+
+	switch kind {
+	case AbstractModifier:
+		ret.isAbstract = true
+	case VirtualModifier:
+		ret.isVirtual = true
+	case OverrideModifier:
+		ret.isOverride = true
+	case PublicModifier:
+		ret.accessModifier = PublicAccess
+	case PrivateModifier:
+		ret.accessModifier = PrivateAccess
+	case ProtectedModifier:
+		ret.accessModifier = ProtectedAccess
+	case StaticModifier:
+		ret.isStatic = true
+	default:
+		panic("TODO")
+	}
+
+	return ret
+}
+
+func chainClassOrMemberModifier(list, add *ClassOrMemberModifierList) *ClassOrMemberModifierList {
+	if add.isAbstract {
+		if list.isAbstract {
+			compileError(add.Position(), ABSTRACT_MULTIPLE_SPECIFIED_ERR)
+		}
+		list.isAbstract = true
+	}
+	if add.isVirtual {
+		if list.isVirtual {
+			compileError(add.Position(), VIRTUAL_MODIFIER_MULTIPLE_SPECIFIED_ERR)
+		}
+		list.isVirtual = true
+	}
+	if add.isOverride {
+		if list.isOverride {
+			compileError(add.Position(), OVERRIDE_MODIFIER_MULTIPLE_SPECIFIED_ERR)
+		}
+		list.isOverride = true
+	}
+	if add.isStatic {
+		if list.isStatic {
+			compileError(add.Position(), STATIC_MODIFIER_MULTIPLE_SPECIFIED_ERR)
+		}
+		list.isStatic = true
+	}
+	if add.accessModifier != NotSpecifiedAccess {
+		if list.accessModifier != NotSpecifiedAccess {
+			compileError(add.Position(), ACCESS_MODIFIER_MULTIPLE_SPECIFIED_ERR)
+		}
+		list.accessModifier = add.accessModifier
+	}
+
+	return list
+}
+
+// 访问权限从宽到窄依次为public, protected, private
+func (kind AccessModifierKind) isNarrowerThan(other AccessModifierKind) bool {
+	return kind.normalize() > other.normalize()
+}
+
+func (kind AccessModifierKind) normalize() AccessModifierKind {
+	if kind == NotSpecifiedAccess {
+		return PublicAccess
+	}
+	return kind
+}
+
 //
 // MethodMember
 //
 type MethodMember struct {
 	PosImpl
 
+	isAbstract     bool
+	isVirtual      bool
+	isOverride     bool
+	isStatic       bool
+	accessModifier AccessModifierKind
+
 	functionDefinition *FunctionDefinition
 	methodIndex        int
 }
 
-func createMethodMember(functionDefinition *FunctionDefinition, pos Position) []MemberDeclaration {
-	compiler := getCurrentCompiler()
-
+func (c *Compiler) createMethodMember(modifier *ClassOrMemberModifierList, functionDefinition *FunctionDefinition, pos Position) []MemberDeclaration {
 	ret := &MethodMember{}
 	ret.SetPosition(pos)
 
 	ret.functionDefinition = functionDefinition
 
-	if functionDefinition.block == nil {
-		compileError(pos, CONCRETE_METHOD_HAS_NO_BODY_ERR)
+	if modifier != nil {
+		ret.isAbstract = modifier.isAbstract
+		ret.isVirtual = modifier.isVirtual
+		ret.isOverride = modifier.isOverride
+		ret.accessModifier = modifier.accessModifier
+	}
+
+	// 接口的方法都是abstract和virtual的
+	if c.currentClassDefinition.isInterface {
+		ret.isAbstract = true
+		ret.isVirtual = true
 	}
 
-	functionDefinition.classDefinition = compiler.currentClassDefinition
+	// static方法通过类名调用, 不在虚表中
+	if modifier != nil && modifier.isStatic {
+		if ret.isAbstract || ret.isVirtual || ret.isOverride {
+			compileError(modifier.Position(), STATIC_METHOD_MODIFIER_ERR, functionDefinition.name)
+		}
+		ret.isStatic = true
+		functionDefinition.isStatic = true
+	}
+
+	functionDefinition.classDefinition = c.currentClassDefinition
 
 	return []MemberDeclaration{ret}
 }
@@ -209,17 +529,339 @@ func createMethodMember(functionDefinition *FunctionDefinition, pos Position) []
 type FieldMember struct {
 	PosImpl
 
+	accessModifier AccessModifierKind
+	isStatic       bool
+
 	name          string
 	typeSpecifier *TypeSpecifier
-	fieldIndex    int
+	// static字段为在类的静态字段中的下标
+	fieldIndex  int
+	initializer Expression
+	// 初始值不是常量的static字段, 由声明它的类的exe在顶层代码开始时赋值
+	classIndex int
+
+	// 声明字段的类
+	classDefinition *ClassDefinition
 }
 
-func createFieldMember(typ *TypeSpecifier, name string, pos Position) []MemberDeclaration {
+func (c *Compiler) createFieldMember(modifier *ClassOrMemberModifierList, typ *TypeSpecifier, name string, initializer Expression, pos Position) []MemberDeclaration {
 	ret := &FieldMember{
-		name:          name,
-		typeSpecifier: typ,
+		name:            name,
+		typeSpecifier:   typ,
+		initializer:     initializer,
+		classDefinition: c.currentClassDefinition,
 	}
 	ret.SetPosition(pos)
 
+	// 字段只能使用访问修饰符和static
+	if modifier != nil {
+		if modifier.isAbstract || modifier.isVirtual || modifier.isOverride {
+			compileError(modifier.Position(), FIELD_MODIFIER_ERR, name)
+		}
+		ret.accessModifier = modifier.accessModifier
+		ret.isStatic = modifier.isStatic
+	}
+
 	return []MemberDeclaration{ret}
 }
+
+// static字段的初始值是常量时在加载exe时初始化, 常量表达式在fix时已经合并
+// 其他初始值在声明它的类的exe的顶层代码开始时按声明顺序执行
+func fixStaticFieldInitializer(c *Compiler, field *FieldMember) {
+	initializer := field.initializer.fix(c, nil)
+
+	field.initializer = createAssignCast(initializer, field.typeSpecifier)
+
+	if !isStaticFieldConstant(field) {
+		field.classIndex = field.classDefinition.addToCompiler(c)
+	}
+}
+
+func isStaticFieldConstant(field *FieldMember) bool {
+	if _, ok := field.initializer.(*NullExpression); ok {
+		return true
+	}
+	return getStaticFieldConstant(field.initializer) != nil
+}
+
+// 常量初始值对应的常量池中的值, 不是常量时返回nil
+func getStaticFieldConstant(initializer Expression) vm.Constant {
+	switch e := initializer.(type) {
+	case *BooleanExpression:
+		if e.booleanValue {
+			return vm.NewConstantInt(1)
+		}
+		return vm.NewConstantInt(0)
+	case *IntExpression:
+		return vm.NewConstantInt(e.intValue)
+	case *CharExpression:
+		return vm.NewConstantInt(int(e.charValue))
+	case *DoubleExpression:
+		return vm.NewConstantDouble(e.doubleValue)
+	case *StringExpression:
+		return vm.NewConstantString(e.stringValue)
+	case *CastExpression:
+		switch e.castType {
+		case IntToDoubleCast:
+			if operand, ok := e.operand.(*IntExpression); ok {
+				return vm.NewConstantDouble(float64(operand.intValue))
+			}
+		case DoubleToIntCast:
+			if operand, ok := e.operand.(*DoubleExpression); ok {
+				return vm.NewConstantInt(int(operand.doubleValue))
+			}
+		case BooleanToStringCast, IntToStringCast, DoubleToStringCast, CharToStringCast:
+			if getStaticFieldConstant(e.operand) != nil {
+				return vm.NewConstantString(expressionToString(e.operand))
+			}
+		}
+	}
+	return nil
+}
+
+// 将static字段的常量初始值添加到常量池, 没有初始值, 初始值为null或不是常量时返回-1
+func addStaticFieldConstant(exe *vm.Executable, field *FieldMember) int {
+	if field.initializer == nil {
+		return -1
+	}
+
+	constant := getStaticFieldConstant(field.initializer)
+	if constant == nil {
+		return -1
+	}
+
+	return exe.AddConstantPool(constant)
+}
+
+// 初始值不是常量的static字段, 在顶层代码之前按声明顺序赋值
+func generateStaticFieldInitializer(exe *vm.Executable, cd *ClassDefinition, ob *OpCodeBuf) {
+	for _, md := range cd.memberList {
+		field, ok := md.(*FieldMember)
+		if !ok || !field.isStatic || field.initializer == nil || isStaticFieldConstant(field) {
+			continue
+		}
+
+		field.initializer.generate(exe, nil, ob)
+		ob.generateCode(field.Position(), vm.VM_POP_CLASS_STATIC_INT+getOpcodeTypeOffset(field.typeSpecifier), field.classIndex, field.fieldIndex)
+	}
+}
+
+// 检查当前类能否访问成员, 类外访问时currentCd为nil
+func checkMemberAccessibility(pos Position, currentCd *ClassDefinition, member MemberDeclaration, memberName string) {
+	var access AccessModifierKind
+	var ownerCd *ClassDefinition
+
+	switch m := member.(type) {
+	case *MethodMember:
+		access, ownerCd = m.accessModifier, m.functionDefinition.classDefinition
+	case *FieldMember:
+		access, ownerCd = m.accessModifier, m.classDefinition
+	default:
+		panic("TODO")
+	}
+
+	switch access {
+	case PrivateAccess:
+		if currentCd != ownerCd {
+			compileError(pos, PRIVATE_MEMBER_ACCESS_ERR, memberName)
+		}
+	case ProtectedAccess:
+		if currentCd == nil || !currentCd.isSubClassOf(ownerCd) {
+			compileError(pos, PROTECTED_MEMBER_ACCESS_ERR, memberName)
+		}
+	}
+}
+
+// ==============================
+// 构造方法
+// ==============================
+
+// 构造方法与类同名, 没有返回值, 可以按参数类型重载
+func (c *Compiler) createConstructorMember(modifier *ClassOrMemberModifierList, name string, parameterList []*Parameter, block *Block, pos Position) []MemberDeclaration {
+	cd := c.currentClassDefinition
+
+	// 泛型类实例的构造方法与实例同名
+	if c.currentInstance != nil && name == c.currentInstance.generic.name {
+		name = cd.name
+	}
+
+	typ := createTypeSpecifier(vm.VoidType, pos)
+	fd := c.createFunctionDefinition(typ, name, parameterList, block)
+	fd.classDefinition = cd
+	fd.isConstructor = true
+
+	ret := &MethodMember{functionDefinition: fd}
+	ret.SetPosition(pos)
+
+	if modifier != nil {
+		if modifier.isAbstract || modifier.isVirtual || modifier.isOverride || modifier.isStatic {
+			compileError(modifier.Position(), CONSTRUCTOR_MODIFIER_ERR, name)
+		}
+		ret.accessModifier = modifier.accessModifier
+	}
+
+	cd.constructorList = append(cd.constructorList, ret)
+
+	return nil
+}
+
+// 没有声明构造方法的类使用无参数的默认构造方法
+func (c *Compiler) addDefaultConstructor(cd *ClassDefinition) {
+	typ := createTypeSpecifier(vm.VoidType, cd.Position())
+	fd := c.createFunctionDefinition(typ, cd.name, nil, &Block{})
+	fd.classDefinition = cd
+	fd.isConstructor = true
+
+	member := &MethodMember{functionDefinition: fd}
+	member.SetPosition(cd.Position())
+
+	cd.constructorList = append(cd.constructorList, member)
+}
+
+// 按声明顺序将字段的初始值赋值给this的字段
+func (c *Compiler) addFieldInitializer(cd *ClassDefinition) {
+	var statementList []Statement
+
+	for _, md := range cd.memberList {
+		field, ok := md.(*FieldMember)
+		if !ok || field.isStatic || field.initializer == nil {
+			continue
+		}
+
+		left := createMemberExpression(createThisExpression(field.Position()), field.name)
+		assign := &AssignExpression{left: left, operator: NormalAssign, operand: field.initializer}
+		assign.SetPosition(field.Position())
+
+		stmt := &ExpressionStatement{expression: assign}
+		stmt.SetPosition(field.Position())
+
+		statementList = append(statementList, stmt)
+	}
+
+	if statementList == nil {
+		return
+	}
+
+	typ := createTypeSpecifier(vm.VoidType, cd.Position())
+	fd := c.createFunctionDefinition(typ, fieldInitializerName, nil, &Block{statementList: statementList})
+	fd.classDefinition = cd
+
+	cd.fieldInitializer = fd
+}
+
+// 修正构造方法, 在方法体之前插入父类构造方法和字段初始化的调用
+func fixConstructor(c *Compiler, cd *ClassDefinition, index int) {
+	member := cd.constructorList[index]
+	fd := member.functionDefinition
+
+	if fd.name != cd.name {
+		compileError(member.Position(), CONSTRUCTOR_NAME_ERR, fd.name, cd.name)
+	}
+
+	for _, param := range fd.parameterList {
+		param.typeSpecifier.fix(c)
+	}
+	for _, other := range cd.constructorList[:index] {
+		if compareParameterType(other.functionDefinition.parameterList, fd.parameterList) {
+			compileError(member.Position(), CONSTRUCTOR_MULTIPLE_DEFINE_ERR, cd.name)
+		}
+	}
+
+	var prologue []Statement
+	statementList := fd.block.statementList
+
+	// 第一条语句为super(...)时显式调用父类构造方法, 否则调用父类的无参数构造方法
+	if len(statementList) > 0 && isSuperConstructorCall(statementList[0]) {
+		call := statementList[0].(*ExpressionStatement).expression.(*FunctionCallExpression)
+		prologue = append(prologue, createSuperConstructorCall(call.argumentList, call.Position()))
+		statementList = statementList[1:]
+	} else if cd.superClass != nil {
+		prologue = append(prologue, createSuperConstructorCall(nil, member.Position()))
+	}
+
+	if cd.fieldInitializer != nil {
+		call := &DirectMethodCallExpression{functionDefinition: cd.fieldInitializer}
+		call.SetPosition(member.Position())
+		stmt := &ExpressionStatement{expression: call}
+		stmt.SetPosition(member.Position())
+		prologue = append(prologue, stmt)
+	}
+
+	fd.block.statementList = append(prologue, statementList...)
+
+	fd.fix(c)
+}
+
+func isSuperConstructorCall(stmt Statement) bool {
+	exprStmt, ok := stmt.(*ExpressionStatement)
+	if !ok {
+		return false
+	}
+	call, ok := exprStmt.expression.(*FunctionCallExpression)
+	if !ok {
+		return false
+	}
+	_, ok = call.function.(*SuperExpression)
+	return ok
+}
+
+func createSuperConstructorCall(argumentList []Expression, pos Position) Statement {
+	call := &DirectMethodCallExpression{isSuper: true, argumentList: argumentList}
+	call.SetPosition(pos)
+
+	stmt := &ExpressionStatement{expression: call}
+	stmt.SetPosition(pos)
+
+	return stmt
+}
+
+// 按参数类型选择构造方法, 实参需要已经修正
+// 每个实参按转换的代价计分, 选择总分最低的构造方法
+func (cd *ClassDefinition) selectConstructor(pos Position, argumentList []Expression) *MethodMember {
+	var selected *MethodMember
+	minCost := -1
+	ambiguous := false
+
+	for _, member := range cd.constructorList {
+		parameterList := member.functionDefinition.parameterList
+		if len(parameterList) != len(argumentList) {
+			continue
+		}
+
+		cost := 0
+		for i, arg := range argumentList {
+			argCost := getAssignCost(arg, parameterList[i].typeSpecifier)
+			if argCost < 0 {
+				cost = -1
+				break
+			}
+			cost += argCost
+		}
+
+		switch {
+		case cost < 0:
+			// 参数不匹配
+		case minCost < 0 || cost < minCost:
+			selected, minCost, ambiguous = member, cost, false
+		case cost == minCost:
+			ambiguous = true
+		}
+	}
+
+	if selected == nil {
+		compileError(pos, CONSTRUCTOR_NOT_FOUND_ERR, cd.name, getArgumentTypeNames(argumentList))
+	}
+	if ambiguous {
+		compileError(pos, AMBIGUOUS_CONSTRUCTOR_ERR, cd.name, getArgumentTypeNames(argumentList))
+	}
+
+	return selected
+}
+
+func getArgumentTypeNames(argumentList []Expression) string {
+	nameList := []string{}
+	for _, arg := range argumentList {
+		nameList = append(nameList, getTypeName(arg.typeS()))
+	}
+	return strings.Join(nameList, ", ")
+}
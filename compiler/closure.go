@@ -0,0 +1,216 @@
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/lth-go/gogogogo/vm"
+)
+
+// ==============================
+// LambdaExpression
+// ==============================
+
+// LambdaExpression 匿名函数, eg, (int a, int b) -> int { return a + b; }
+// 引用外层函数的局部变量时按引用捕获, 外层函数返回后仍然有效
+type LambdaExpression struct {
+	ExpressionImpl
+
+	functionDefinition *FunctionDefinition
+
+	// 虚拟机中的函数下标
+	functionIndex int
+}
+
+func (expr *LambdaExpression) show(indent int) {
+	printWithIndent("LambdaExpr", indent)
+
+	subIndent := indent + 2
+
+	expr.functionDefinition.block.show(subIndent)
+}
+
+func (expr *LambdaExpression) fix(c *Compiler, currentBlock *Block) Expression {
+	fd := expr.functionDefinition
+
+	// lambda在所在的语句中修正, 修正时外层的变量声明已经确定
+	fd.packageNameList = c.packageNameList
+	fd.name = fmt.Sprintf("lambda$%d", len(c.funcList))
+	fd.outerFunction = currentBlock.getCurrentFunction()
+	c.funcList = append(c.funcList, fd)
+
+	fd.fix(c)
+
+	expr.functionIndex = c.addToVmFunctionList(fd)
+
+	expr.setType(createFunctionDeriveType(fd))
+	expr.typeS().fix(c)
+
+	return expr
+}
+
+// 依次压入捕获的变量, 由VM_NEW_CLOSURE保存到closure中
+func (expr *LambdaExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	captureList := expr.functionDefinition.captureList
+
+	for _, decl := range captureList {
+		generatePushCapture(decl, currentBlock, expr.Position(), ob)
+	}
+
+	ob.generateCode(expr.Position(), vm.VM_NEW_CLOSURE, expr.functionIndex, len(captureList))
+}
+
+func createLambdaExpression(typ *TypeSpecifier, parameterList []*Parameter, block *Block, pos Position) *LambdaExpression {
+	fd := &FunctionDefinition{
+		typeSpecifier: typ,
+		parameterList: parameterList,
+		block:         block,
+		isLambda:      true,
+	}
+	block.parent = &FunctionBlockInfo{function: fd}
+
+	expr := &LambdaExpression{functionDefinition: fd}
+	expr.SetPosition(pos)
+
+	return expr
+}
+
+// ==============================
+// 变量捕获
+// ==============================
+
+// 在lambda中引用外层函数的变量时, 从内到外经过的每一层lambda都要捕获该变量
+// 顶层块中的变量同样需要捕获, 否则循环中的lambda共享同一个全局变量
+func captureDeclaration(currentBlock *Block, decl *Declaration) {
+	if !decl.isLocal && !decl.isBlockScoped {
+		return
+	}
+
+	for block := currentBlock; block != nil; block = block.outerBlock {
+		for _, d := range block.declarationList {
+			if d == decl {
+				return
+			}
+		}
+
+		fdBlockInfo, ok := block.parent.(*FunctionBlockInfo)
+		if !ok {
+			continue
+		}
+		if !fdBlockInfo.function.isLambda {
+			return
+		}
+		fdBlockInfo.function.addCapture(decl)
+	}
+}
+
+// lambda中的this和super引用所在方法的this, 不在lambda中时返回nil
+func captureThis(currentBlock *Block) *Declaration {
+	fd := currentBlock.getCurrentFunction()
+	if fd == nil || !fd.isLambda {
+		return nil
+	}
+
+	decl := fd.getOwnerFunction().getThisDeclaration()
+	captureDeclaration(currentBlock, decl)
+
+	return decl
+}
+
+func (fd *FunctionDefinition) addCapture(decl *Declaration) {
+	if fd.searchCapture(decl) >= 0 {
+		return
+	}
+	fd.captureList = append(fd.captureList, decl)
+	decl.isCaptured = true
+}
+
+// 被捕获的局部变量和顶层块中的变量保存在cell中
+func (decl *Declaration) isCell() bool {
+	return decl.isCaptured && (decl.isLocal || decl.isBlockScoped)
+}
+
+func (fd *FunctionDefinition) searchCapture(decl *Declaration) int {
+	if fd == nil {
+		return -1
+	}
+	for i, d := range fd.captureList {
+		if d == decl {
+			return i
+		}
+	}
+	return -1
+}
+
+// 方法中this的位置, this不会被修改, closure中直接保存对象而不是cell
+func (fd *FunctionDefinition) getThisDeclaration() *Declaration {
+	if fd.thisDeclaration == nil {
+		fd.thisDeclaration = &Declaration{
+			name:          "this",
+			variableIndex: len(fd.parameterList),
+			isLocal:       true,
+		}
+	}
+	return fd.thisDeclaration
+}
+
+// ==============================
+// 生成
+// ==============================
+
+// 压入被捕获变量的cell, 捕获的this压入对象本身
+// 在lambda中从closure中获取, 在声明所在的函数中从栈上获取, 顶层块中的变量从静态区获取
+func generatePushCapture(decl *Declaration, currentBlock *Block, pos Position, ob *OpCodeBuf) {
+	fd := currentBlock.getCurrentFunction()
+
+	if index := fd.searchCapture(decl); index >= 0 {
+		ob.generateCode(pos, vm.VM_PUSH_STACK_OBJECT, len(fd.parameterList))
+		ob.generateCode(pos, vm.VM_PUSH_ENV, index)
+		return
+	}
+
+	if decl.isLocal {
+		ob.generateCode(pos, vm.VM_PUSH_STACK_OBJECT, decl.variableIndex)
+	} else {
+		ob.generateCode(pos, vm.VM_PUSH_STATIC_OBJECT, decl.variableIndex)
+	}
+}
+
+// 变量被捕获时压入cell并返回true, 否则按普通变量访问
+func generatePushCell(decl *Declaration, currentBlock *Block, pos Position, ob *OpCodeBuf) bool {
+	if !decl.isCell() {
+		return false
+	}
+	generatePushCapture(decl, currentBlock, pos, ob)
+	return true
+}
+
+// 被捕获的变量每次执行声明时创建新的cell, 循环中的lambda各自捕获不同的变量
+func generateInitializeIdentifier(decl *Declaration, currentBlock *Block, pos Position, ob *OpCodeBuf) {
+	if !decl.isCell() {
+		generatePopToIdentifier(decl, currentBlock, pos, ob)
+		return
+	}
+
+	ob.generateCode(pos, vm.VM_NEW_CELL_INT+getOpcodeTypeOffset(decl.typeSpecifier))
+	if decl.isLocal {
+		ob.generateCode(pos, vm.VM_POP_STACK_OBJECT, decl.variableIndex)
+	} else {
+		ob.generateCode(pos, vm.VM_POP_STATIC_OBJECT, decl.variableIndex)
+	}
+}
+
+// 被捕获的形参在函数开始时移到cell中
+func generateParameterCell(fd *FunctionDefinition, ob *OpCodeBuf) {
+	pos := fd.typeS().Position()
+
+	for _, decl := range fd.localVariableList[:len(fd.parameterList)] {
+		if !decl.isCaptured {
+			continue
+		}
+		offset := getOpcodeTypeOffset(decl.typeSpecifier)
+
+		ob.generateCode(pos, vm.VM_PUSH_STACK_INT+offset, decl.variableIndex)
+		ob.generateCode(pos, vm.VM_NEW_CELL_INT+offset)
+		ob.generateCode(pos, vm.VM_POP_STACK_OBJECT, decl.variableIndex)
+	}
+}
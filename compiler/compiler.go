@@ -1,24 +1,35 @@
 package compiler
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/lth-go/gogogogo/vm"
 )
 
-// 全局compiler列表
-var stCompilerList []*Compiler
-
-// 设置全局compiler
-var stCurrentCompiler *Compiler
-
-func getCurrentCompiler() *Compiler { return stCurrentCompiler }
-
-func setCurrentCompiler(c *Compiler) { stCurrentCompiler = c }
+// 一次编译中所有compiler共享的状态
+type compileContext struct {
+	options Options
+
+	// 已加载的compiler列表
+	compilerList []*Compiler
+	// 用到的原生函数
+	nativeFunctionList []*FunctionDefinition
+	// 泛型类的实例, 按定义的包和类型参数区分, 所有包共享
+	instanceList []*genericInstance
+
+	// 编译错误列表
+	diagnosticList []*Diagnostic
+	// 出现语法错误等无法继续修正语法树的错误
+	fatal bool
+}
 
 // Compiler 编译器
 type Compiler struct {
+	ctx *compileContext
+
 	// 词法解析器
 	lexer *Lexer
 
@@ -43,12 +54,29 @@ type Compiler struct {
 	// 当前类
 	currentClassDefinition *ClassDefinition
 
-	//current_try_statement *TryStatement
-	//current_catch_clause *CatchClause
+	// 泛型定义列表
+	genericList []*GenericDefinition
+	// 正在解析的泛型类定义
+	currentGeneric *GenericDefinition
+	// 正在解析的泛型实例
+	currentInstance *genericInstance
+	// 正在修正的泛型实例, 其中的错误在最外层实例的使用位置报告
+	instanceStack []*genericInstance
+	// 等待修正的泛型类实例
+	pendingInstanceList []*genericInstance
+	// 普通类的继承和构造方法已经修正, 之后实例化的泛型类立即修正
+	classReady bool
 
 	// 已加载compiler列表
 	requiredList []*Compiler
 
+	// 交互模式下, 之前的输入中已经编译过的函数和类的数量
+	// 只修正和生成之后新增的部分
+	funcStart  int
+	classStart int
+	// 交互模式
+	interactive bool
+
 	// arrayMethodList  []*FunctionDefinition
 	// stringMethodList []*FunctionDefinition
 
@@ -57,11 +85,13 @@ type Compiler struct {
 	vmFunctionList []*vm.Function
 	// vm类
 	vmClassList []*vm.Class
+	// 引用的其他包的全局变量
+	importedVariableList []*vm.ImportedVariable
 }
 
-func newCompiler() *Compiler {
-	compilerBackup := getCurrentCompiler()
+func newCompiler(ctx *compileContext) *Compiler {
 	c := &Compiler{
+		ctx:                 ctx,
 		requireList:         []*Require{},
 		funcList:            []*FunctionDefinition{},
 		vmFunctionList:      []*vm.Function{},
@@ -71,10 +101,8 @@ func newCompiler() *Compiler {
 		classDefinitionList: []*ClassDefinition{},
 		requiredList:        []*Compiler{},
 	}
-	setCurrentCompiler(c)
 	// TODO 添加默认函数
 
-	setCurrentCompiler(compilerBackup)
 	return c
 }
 func (c *Compiler) getPackageName() string {
@@ -85,8 +113,20 @@ func (c *Compiler) addLexer(lexer *Lexer) {
 	c.lexer = lexer
 }
 
-func (c *Compiler) addLexerByPath(path string) {
-	lexer := newLexerByFilePath(path)
+func (c *Compiler) addLexerByPath(path string) error {
+	lexer, err := newLexerByFilePath(path)
+	if err != nil {
+		return err
+	}
+	c.addLexer(lexer)
+
+	c.path = path
+
+	return nil
+}
+
+func (c *Compiler) addLexerBySource(path string, source string) {
+	lexer := newLexerBySource(source)
 	c.addLexer(lexer)
 
 	c.path = path
@@ -95,9 +135,9 @@ func (c *Compiler) addLexerByPath(path string) {
 //////////////////////////////
 // 函数定义
 //////////////////////////////
-func (c *Compiler) functionDefine(typ *TypeSpecifier, identifier string, parameterList []*Parameter, block *Block) {
-	// 定义重复
-	if searchFunction(identifier) != nil || searchDeclaration(identifier, nil) != nil {
+func (c *Compiler) functionDefine(typ *TypeSpecifier, identifier string, parameterList []*Parameter, block *Block) *FunctionDefinition {
+	// 定义重复, 原生函数可以再次声明
+	if fd := c.searchFunction(identifier); fd != nil && !(fd.isNative && block == nil) || c.searchDeclaration(identifier, nil) != nil {
 		compileError(typ.Position(), FUNCTION_MULTIPLE_DEFINE_ERR, identifier)
 	}
 
@@ -116,45 +156,43 @@ func (c *Compiler) functionDefine(typ *TypeSpecifier, identifier string, paramet
 	}
 
 	c.funcList = append(c.funcList, fd)
+
+	return fd
 }
 
 //////////////////////////////
 // 编译
 //////////////////////////////
 func (c *Compiler) compile(exeList *vm.ExecutableList, isRequired bool) *vm.Executable {
-	compilerBackup := getCurrentCompiler()
-	setCurrentCompiler(c)
-
 	// 开始解析文件
-	if yyParse(c.lexer) != 0 {
-		panic(c.lexer.e)
+	if !c.parse() {
+		return nil
 	}
 
-	for _, require := range c.requireList {
-		// 判断是否已经被解析过
-		requireCompiler := searchCompiler(stCompilerList, require.packageNameList)
-		if requireCompiler != nil {
-			c.requiredList = append(c.requiredList, requireCompiler)
-			continue
-		}
-
-		requireCompiler = newCompiler()
-
-		requireCompiler.packageNameList = require.packageNameList
-
-		c.requiredList = append(c.requiredList, requireCompiler)
-		stCompilerList = append(stCompilerList, requireCompiler)
+	// 交互模式下输出最后一个表达式的值
+	if c.interactive {
+		c.addResultStatement()
+	}
 
-		// 获取要导入的全路径
-		foundPath := require.getFullPath()
+	for _, require := range c.requireList {
+		c.catchCompileError(func() {
+			c.compileRequire(exeList, require)
+		})
+	}
 
-		// 编译导入的包
-		requireCompiler.addLexerByPath(foundPath)
-		requireCompiler.compile(exeList, true)
+	// 依赖的包无法解析时, 不再继续检查
+	if c.ctx.fatal {
+		return nil
 	}
 
 	// fix and generate
 	c.fixTree()
+
+	// 语法树有错误时不生成字节码
+	if len(c.ctx.diagnosticList) > 0 {
+		return nil
+	}
+
 	exe := c.generate()
 
 	exe.Path = c.path
@@ -162,11 +200,94 @@ func (c *Compiler) compile(exeList *vm.ExecutableList, isRequired bool) *vm.Exec
 
 	exeList.AddExe(exe)
 
-	setCurrentCompiler(compilerBackup)
-
 	return exe
 }
 
+// 语法解析, 解析失败时后续步骤无法进行
+func (c *Compiler) parse() bool {
+	ok := c.catchCompileError(func() {
+		yyParse(c.lexer)
+	})
+
+	// 词法错误时语法解析可能仍然成功, 同样需要报告
+	if e := c.lexer.e; e != nil {
+		c.addDiagnostic(e.Pos, PARSE_ERR, e.Message)
+	}
+
+	if !ok || c.lexer.e != nil {
+		c.ctx.fatal = true
+		return false
+	}
+	return true
+}
+
+// 编译依赖的包
+func (c *Compiler) compileRequire(exeList *vm.ExecutableList, require *Require) {
+	// 交互模式下之前的输入中已经导入过
+	if searchCompiler(c.requiredList, require.packageNameList) != nil {
+		return
+	}
+
+	// 判断是否已经被解析过
+	requireCompiler := searchCompiler(c.ctx.compilerList, require.packageNameList)
+	if requireCompiler != nil {
+		c.requiredList = append(c.requiredList, requireCompiler)
+		return
+	}
+
+	requireCompiler = newCompiler(c.ctx)
+
+	requireCompiler.packageNameList = require.packageNameList
+
+	if require.isDefaultPackage() {
+		// 默认包使用内置源码
+		requireCompiler.addLexerBySource(defaultPackagePath, defaultPackageSource)
+	} else if require.isNativePackage(c.ctx.options.SearchPath) {
+		requireCompiler.addLexerBySource(nativePackagePath(require.getPackageName()), nativePackageSource)
+	} else {
+		// 获取要导入的全路径
+		foundPath := require.getFullPath(c.ctx.options.SearchPath)
+
+		err := requireCompiler.addLexerByPath(foundPath)
+		if err != nil {
+			c.ctx.fatal = true
+			compileError(require.Position(), REQUIRE_FILE_ERR, err)
+		}
+	}
+
+	c.requiredList = append(c.requiredList, requireCompiler)
+	c.ctx.compilerList = append(c.ctx.compilerList, requireCompiler)
+
+	// 编译导入的包
+	requireCompiler.compile(exeList, true)
+}
+
+func (c *Compiler) addDiagnostic(pos Position, errorNumber int, message string) {
+	// 泛型实例中的错误在使用的位置报告
+	if len(c.instanceStack) > 0 {
+		instance := c.instanceStack[0]
+		message = vm.FormatMessage(errMessageList[GENERIC_INSTANTIATION_ERR], instance.name, pos.Line, message)
+		pos = instance.pos
+		errorNumber = GENERIC_INSTANTIATION_ERR
+	}
+
+	d := &Diagnostic{
+		Filename: c.path,
+		Line:     pos.Line,
+		Column:   pos.Column,
+		Code:     errorNumber,
+		Message:  message,
+	}
+
+	// 同一个类型等可能被多次修正, 相同的错误只报告一次
+	for _, reported := range c.ctx.diagnosticList {
+		if *reported == *d {
+			return
+		}
+	}
+	c.ctx.diagnosticList = append(c.ctx.diagnosticList, d)
+}
+
 //////////////////////////////
 // 打印语法树
 //////////////////////////////
@@ -187,20 +308,25 @@ func (c *Compiler) fixTree() {
 	// class
 	c.fixClassList()
 
-	// TODO remove
-	// add function
-	for _, fd := range c.funcList {
-		c.addToVmFunctionList(fd)
-	}
-
 	// 修正表达式列表
-	fixStatementList(nil, c.statementList, nil)
+	fixStatementList(c, nil, c.statementList, nil)
+
+	// 修正函数, lambda在所在的表达式中修正, 泛型函数的实例在调用时修正
+	for _, fd := range c.funcList[c.funcStart:] {
+		if fd.classDefinition == nil && !fd.isLambda && fd.instance == nil {
+			c.catchCompileError(func() {
+				fd.fix(c)
+			})
+		}
+	}
 
-	// 修正函数
-	for _, fd := range c.funcList {
-		if fd.classDefinition == nil {
-			fd.fix()
+	// TODO remove
+	// add function, 包括修正过程中实例化的泛型类和泛型函数
+	for _, fd := range c.funcList[c.funcStart:] {
+		if fd.isAbstract() {
+			continue
 		}
+		c.addToVmFunctionList(fd)
 	}
 
 	// 修正全局声明
@@ -210,24 +336,110 @@ func (c *Compiler) fixTree() {
 }
 
 func (c *Compiler) fixClassList() {
+	classDefinitionList := c.classDefinitionList[c.classStart:]
+
+	c.classReady = false
 
-	// 修正继承
-	for _, cd := range c.classDefinitionList {
-		cd.addToCurrentCompiler()
-		cd.fixExtends()
+	for _, cd := range classDefinitionList {
+		c.fixClassExtends(cd)
+	}
+	for _, cd := range classDefinitionList {
+		c.addClassConstructors(cd)
 	}
 
-	// 修正方法和属性, 搜索父类, 设置索引
-	for _, cd := range c.classDefinitionList {
+	// 继承的泛型类实例, 实例化的顺序是子类在前
+	instanceList := []*ClassDefinition{}
+	for len(c.pendingInstanceList) > 0 {
+		instance := c.pendingInstanceList[0]
+		c.pendingInstanceList = c.pendingInstanceList[1:]
+
+		cd := instance.classDefinition
+		c.fixInstance(instance, func() {
+			c.fixClassExtends(cd)
+			c.addClassConstructors(cd)
+		})
+		instanceList = append([]*ClassDefinition{cd}, instanceList...)
+	}
 
-		c.currentClassDefinition = cd
+	// 之后使用的泛型类立即修正
+	c.classReady = true
 
-		fieldIndex, methodIndex := cd.getSuperFieldMethodCount()
+	// 父类先于子类修正
+	classDefinitionList = append(instanceList, classDefinitionList...)
+
+	for _, cd := range classDefinitionList {
+		c.fixClassStep(cd, c.fixClassMembers)
+	}
+	for _, cd := range classDefinitionList {
+		c.fixClassStep(cd, c.checkClassMembers)
+	}
+}
 
-		for _, memberIfs := range cd.memberList {
+// 泛型类实例中的错误在使用的位置报告
+func (c *Compiler) fixClassStep(cd *ClassDefinition, f func(cd *ClassDefinition)) {
+	if cd.instance == nil {
+		f(cd)
+		return
+	}
+	c.fixInstance(cd.instance, func() {
+		f(cd)
+	})
+}
+
+// 依次完成类的所有修正步骤
+func (c *Compiler) fixClassDefinition(cd *ClassDefinition) {
+	c.fixClassExtends(cd)
+	c.addClassConstructors(cd)
+	c.fixClassMembers(cd)
+	c.checkClassMembers(cd)
+}
+
+// 修正继承
+func (c *Compiler) fixClassExtends(cd *ClassDefinition) {
+	cd.addToCompiler(c)
+	c.catchCompileError(func() {
+		if c.searchClass(cd.name) != cd {
+			compileError(cd.Position(), CLASS_MULTIPLE_DEFINE_ERR, cd.name)
+		}
+		cd.fixExtends(c)
+	})
+}
+
+// 添加默认构造方法和字段初始化方法
+func (c *Compiler) addClassConstructors(cd *ClassDefinition) {
+	c.catchCompileError(func() {
+		if cd.isInterface {
+			if len(cd.constructorList) > 0 {
+				compileError(cd.constructorList[0].Position(), INTERFACE_HAS_CONSTRUCTOR_ERR, cd.name)
+			}
+			return
+		}
+		if len(cd.constructorList) == 0 {
+			c.addDefaultConstructor(cd)
+		}
+		c.addFieldInitializer(cd)
+	})
+}
+
+// 修正方法和属性, 搜索父类, 设置索引
+func (c *Compiler) fixClassMembers(cd *ClassDefinition) {
+	c.currentClassDefinition = cd
+
+	fieldIndex, methodIndex := cd.getSuperFieldMethodCount()
+	staticFieldIndex := 0
+
+	for _, memberIfs := range cd.memberList {
+		c.catchCompileError(func() {
 			switch member := memberIfs.(type) {
 			case *MethodMember:
-				member.functionDefinition.fix()
+				member.functionDefinition.fix(c)
+
+				checkMethodBody(cd, member)
+
+				// static方法不在虚表中, 覆盖在checkOverride中检查
+				if member.isStatic {
+					return
+				}
 
 				superMember := cd.searchMemberInSuper(member.functionDefinition.name)
 
@@ -236,19 +448,33 @@ func (c *Compiler) fixClassList() {
 					if !ok {
 						compileError(member.Position(), FIELD_OVERRIDED_ERR, member.functionDefinition.name)
 					}
-					member.methodIndex = superMethodMember.methodIndex
+					// 接口方法在类的虚表中没有位置, 需要新增
+					if superMethodMember.functionDefinition.classDefinition.isInterface {
+						member.methodIndex = methodIndex
+						methodIndex++
+					} else {
+						member.methodIndex = superMethodMember.methodIndex
+					}
 				} else {
 					member.methodIndex = methodIndex
 					methodIndex++
 				}
 			case *FieldMember:
-				member.typeSpecifier.fix()
+				if cd.isInterface {
+					compileError(member.Position(), INTERFACE_HAS_FIELD_ERR, cd.name, member.name)
+				}
+				member.typeSpecifier.fix(c)
 
 				superMember := cd.searchMemberInSuper(member.name)
 
 				// 不允许重复定义字段
 				if superMember != nil {
 					compileError(member.Position(), FIELD_NAME_DUPLICATE_ERR, member.name)
+				}
+
+				if member.isStatic {
+					member.fieldIndex = staticFieldIndex
+					staticFieldIndex++
 				} else {
 					member.fieldIndex = fieldIndex
 					fieldIndex++
@@ -256,9 +482,47 @@ func (c *Compiler) fixClassList() {
 			default:
 				panic("TODO")
 			}
+		})
+	}
+	c.currentClassDefinition = nil
+}
+
+// 父类的方法全部修正后才能检查覆盖, 字段索引确定后才能修正构造方法
+func (c *Compiler) checkClassMembers(cd *ClassDefinition) {
+	c.currentClassDefinition = cd
+
+	if cd.fieldInitializer != nil {
+		c.catchCompileError(func() {
+			cd.fieldInitializer.fix(c)
+		})
+	}
+	for i := range cd.constructorList {
+		c.catchCompileError(func() {
+			fixConstructor(c, cd, i)
+		})
+	}
+
+	// 构造方法修正后static字段的初始值才能创建对象
+	for _, memberIfs := range cd.memberList {
+		if member, ok := memberIfs.(*FieldMember); ok && member.isStatic && member.initializer != nil {
+			c.catchCompileError(func() {
+				fixStaticFieldInitializer(c, member)
+			})
 		}
-		c.currentClassDefinition = nil
 	}
+
+	for _, memberIfs := range cd.memberList {
+		if member, ok := memberIfs.(*MethodMember); ok {
+			c.catchCompileError(func() {
+				checkOverride(cd, member)
+			})
+		}
+	}
+	c.catchCompileError(func() {
+		checkAbstractMethodImplemented(cd)
+	})
+
+	c.currentClassDefinition = nil
 }
 
 // 添加VmFunction
@@ -290,8 +554,9 @@ func (c *Compiler) generate() *vm.Executable {
 	exe := vm.NewExecutable()
 	exe.PackageName = c.getPackageName()
 
-	exe.FunctionList = c.vmFunctionList
-	exe.ClassDefinitionList = c.vmClassList
+	// 交互模式下每段输入生成新的exe, 不能修改之前的exe中的函数和类
+	exe.FunctionList = copyVmFunctionList(c.vmFunctionList)
+	exe.ClassDefinitionList = copyVmClassList(c.vmClassList)
 
 	// 添加全局变量声明
 	c.addGlobalVariable(exe)
@@ -301,6 +566,8 @@ func (c *Compiler) generate() *vm.Executable {
 	c.addFunctions(exe)
 	// 添加顶层代码
 	c.addTopLevel(exe)
+	// 生成字节码时记录的其他包的全局变量, 交互模式下之前的输入中的下标保持不变
+	exe.ImportedVariableList = append([]*vm.ImportedVariable{}, c.importedVariableList...)
 
 	return exe
 }
@@ -314,22 +581,38 @@ func (c *Compiler) addGlobalVariable(exe *vm.Executable) {
 	}
 }
 
+// 其他包的全局变量在字节码中使用引用列表的下标
+func (c *Compiler) addImportedVariable(decl *Declaration) int {
+	for i, v := range c.importedVariableList {
+		if v.PackageName == decl.packageName && v.Name == decl.name {
+			return i
+		}
+	}
+	c.importedVariableList = append(c.importedVariableList, &vm.ImportedVariable{PackageName: decl.packageName, Name: decl.name})
+	return len(c.importedVariableList) - 1
+}
+
 // 添加类
 func (c *Compiler) addClasses(exe *vm.Executable) {
-	for _, cd := range c.classDefinitionList {
-		vmClass := c.searchVmClass(cd)
+	for _, cd := range c.classDefinitionList[c.classStart:] {
+		vmClass := searchVmClass(exe.ClassDefinitionList, cd)
 		vmClass.IsImplemented = true
 	}
 
-	for _, vmClass := range c.vmClassList {
-		cd := searchClass(vmClass.Name)
-		addClass(cd, vmClass)
+	for _, vmClass := range exe.ClassDefinitionList {
+		cd := c.searchClass(vmClass.Name)
+		if cd == nil {
+			cd = c.ctx.searchInstanceClass(vmClass.PackageName, vmClass.Name)
+		}
+		addClass(exe, cd, vmClass)
 	}
 }
 
 // TODO 改名
 // 完善vmClass信息
-func addClass(cd *ClassDefinition, dest *vm.Class) {
+func addClass(exe *vm.Executable, cd *ClassDefinition, dest *vm.Class) {
+	dest.IsAbstract = cd.isAbstract
+	dest.IsInterface = cd.isInterface
 
 	if cd.superClass != nil {
 		dest.SuperClass = &vm.ClassIdentifier{
@@ -340,14 +623,40 @@ func addClass(cd *ClassDefinition, dest *vm.Class) {
 		dest.SuperClass = nil
 	}
 
+	dest.InterfaceList = nil
+	for _, iface := range cd.interfaceList {
+		dest.InterfaceList = append(dest.InterfaceList, &vm.ClassIdentifier{
+			Name:        iface.name,
+			PackageName: iface.getPackageName(),
+		})
+	}
+
 	for _, memberIfs := range cd.memberList {
 		switch member := memberIfs.(type) {
 		case *MethodMember:
+			// static方法是普通函数, 不在虚表中
+			if member.isStatic {
+				continue
+			}
 			newMethod := &vm.Method{
-				Name: member.functionDefinition.name,
+				Name:       member.functionDefinition.name,
+				IsAbstract: member.isAbstract,
 			}
 			dest.MethodList = append(dest.MethodList, newMethod)
 		case *FieldMember:
+			if member.isStatic {
+				// 导入的类的static字段由实现它的exe初始化
+				constantIndex := -1
+				if dest.IsImplemented {
+					constantIndex = addStaticFieldConstant(exe, member)
+				}
+				dest.StaticFieldList = append(dest.StaticFieldList, &vm.StaticField{
+					Name:          member.name,
+					Typ:           copyTypeSpecifier(member.typeSpecifier),
+					ConstantIndex: constantIndex,
+				})
+				continue
+			}
 			newField := &vm.Field{
 				Name: member.name,
 				Typ:  copyTypeSpecifier(member.typeSpecifier),
@@ -362,49 +671,61 @@ func addClass(cd *ClassDefinition, dest *vm.Class) {
 // 添加函数
 func (c *Compiler) addFunctions(exe *vm.Executable) {
 
-	inThisExes := make([]bool, len(c.vmFunctionList))
+	inThisExes := make([]bool, len(exe.FunctionList))
 
-	for _, fd := range c.funcList {
-		// TODO 为什么block是空
-		if fd.classDefinition != nil && fd.block == nil {
+	for i, fd := range c.funcList {
+		if fd.isAbstract() {
 			continue
 		}
 
 		destIdx := c.getFunctionIndex(fd)
 		inThisExes[destIdx] = true
 
-		addFunction(exe, fd, c.vmFunctionList[destIdx], true)
+		// 交互模式下, 之前的输入中的函数已经加载到虚拟机
+		c.addFunction(exe, fd, exe.FunctionList[destIdx], i >= c.funcStart)
 	}
 
-	for i, vmFunc := range c.vmFunctionList {
+	for i, vmFunc := range exe.FunctionList {
 		if inThisExes[i] {
 			continue
 		}
 
-		fd := searchFunction(vmFunc.Name)
-		addFunction(exe, fd, vmFunc, false)
+		fd := c.searchFunction(vmFunc.Name)
+		if fd == nil {
+			fd = c.searchRequiredMethod(vmFunc.PackageName, vmFunc.Name)
+		}
+		if fd == nil {
+			fd = c.ctx.searchInstanceMethod(vmFunc.PackageName, vmFunc.Name)
+		}
+		if fd == nil {
+			fd = c.searchNativeFunction(vmFunc.PackageName, vmFunc.Name)
+		}
+		c.addFunction(exe, fd, vmFunc, false)
 	}
 }
 
-func addFunction(exe *vm.Executable, src *FunctionDefinition, dest *vm.Function, inThisExe bool) {
-	ob := newCodeBuf()
+func (c *Compiler) addFunction(exe *vm.Executable, src *FunctionDefinition, dest *vm.Function, inThisExe bool) {
+	ob := newCodeBuf(c)
 
 	dest.TypeSpecifier = copyTypeSpecifier(src.typeS())
 	dest.ParameterList = copyParameterList(src.parameterList)
 
 	if src.block != nil && inThisExe {
+		generateParameterCell(src, ob)
 		generateStatementList(exe, src.block, src.block.statementList, ob)
 
 		dest.IsImplemented = true
 		dest.CodeList = ob.fixOpcodeBuf()
 		dest.LineNumberList = ob.lineNumberList
+		dest.TryList = ob.tryList
+		dest.SwitchTableList = ob.switchTableList
 		dest.LocalVariableList = copyLocalVariables(src)
 	} else {
 		dest.IsImplemented = false
 		dest.LocalVariableList = nil
 	}
 
-	if src.classDefinition != nil {
+	if src.hasThis() {
 		dest.IsMethod = true
 	} else {
 		dest.IsMethod = false
@@ -412,15 +733,8 @@ func addFunction(exe *vm.Executable, src *FunctionDefinition, dest *vm.Function,
 }
 
 func (c *Compiler) getFunctionIndex(src *FunctionDefinition) int {
-	var funcName string
-
 	srcPackageName := src.getPackageName()
-
-	if src.classDefinition != nil {
-		funcName = createMethodFunctionName(src.classDefinition.name, src.name)
-	} else {
-		funcName = src.name
-	}
+	funcName := src.getVmFuncName()
 
 	for i, vmFunc := range c.vmFunctionList {
 		if srcPackageName == vmFunc.PackageName && funcName == vmFunc.Name {
@@ -433,19 +747,24 @@ func (c *Compiler) getFunctionIndex(src *FunctionDefinition) int {
 
 // 添加字节码
 func (c *Compiler) addTopLevel(exe *vm.Executable) {
-	ob := newCodeBuf()
+	ob := newCodeBuf(c)
+	for _, cd := range c.classDefinitionList[c.classStart:] {
+		generateStaticFieldInitializer(exe, cd, ob)
+	}
 	generateStatementList(exe, nil, c.statementList, ob)
 
 	exe.CodeList = ob.fixOpcodeBuf()
 	exe.LineNumberList = ob.lineNumberList
+	exe.TryList = ob.tryList
+	exe.SwitchTableList = ob.switchTableList
 }
 
 // other
-func (c *Compiler) searchVmClass(src *ClassDefinition) *vm.Class {
+func searchVmClass(vmClassList []*vm.Class, src *ClassDefinition) *vm.Class {
 
 	srcPackageName := src.getPackageName()
 
-	for _, vmClass := range c.vmClassList {
+	for _, vmClass := range vmClassList {
 		if srcPackageName == vmClass.PackageName && src.name == vmClass.Name {
 			return vmClass
 		}
@@ -453,7 +772,7 @@ func (c *Compiler) searchVmClass(src *ClassDefinition) *vm.Class {
 	panic("TODO")
 }
 
-func (c *Compiler) searchFunction(name string) *FunctionDefinition {
+func (c *Compiler) searchPackageFunction(name string) *FunctionDefinition {
 
 	// 当前compiler查找
 	for _, pos := range c.funcList {
@@ -462,46 +781,69 @@ func (c *Compiler) searchFunction(name string) *FunctionDefinition {
 		}
 	}
 
-	return nil
+	return c.searchNativeFunction(c.getPackageName(), name)
 }
 
-func (c *Compiler) searchClass(identifier string) *ClassDefinition {
-
-	for _, cd := range c.classDefinitionList {
-		if cd.name == identifier {
-			return cd
-		}
-	}
-
-	return nil
-}
 
 // ==============================
 // 编译文件
 // ==============================
 
-func CompileFile(path string) *vm.ExecutableList {
-	// 输出yacc错误信息
-	yyErrorVerbose = true
+// Options 编译选项
+type Options struct {
+	// require的搜索路径, 为空时使用环境变量REQUIRE_SEARCH_PATH, 都没有设置时为当前目录
+	SearchPath string
+}
 
-	compiler := createCompilerByPath(path)
+// 未设置的选项使用默认值
+func (opts Options) withDefault() Options {
+	if opts.SearchPath == "" {
+		opts.SearchPath = os.Getenv("REQUIRE_SEARCH_PATH")
+	}
+	if opts.SearchPath == "" {
+		opts.SearchPath = "."
+	}
+	return opts
+}
 
-	exeList := compiler.Compile()
+// 没有编译错误却没有生成字节码, 不能当作编译成功
+var errNoTopLevel = errors.New("编译器内部错误: 没有生成字节码")
 
-	return exeList
-}
+// Compile 编译文件及其依赖的包
+// 编译失败时返回所有的编译错误, 此时error为DiagnosticList
+func Compile(path string, opts Options) (exeList *vm.ExecutableList, diagnosticList []*Diagnostic, err error) {
+	// 每次编译重新加载依赖
+	ctx := &compileContext{options: opts.withDefault()}
 
-func createCompilerByPath(path string) *Compiler {
-	compiler := newCompiler()
-	compiler.addLexerByPath(path)
+	compiler := newCompiler(ctx)
+	err = compiler.addLexerByPath(path)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	return compiler
-}
+	defer func() {
+		if r := recover(); r != nil {
+			exeList = nil
+			diagnosticList = ctx.diagnosticList
+			err = fmt.Errorf("编译器内部错误: %v", r)
+		}
+	}()
+
+	exeList = vm.NewExecutableList()
+	exeList.TopLevel = compiler.compile(exeList, false)
 
-func (c *Compiler) Compile() *vm.ExecutableList {
-	exeList := vm.NewExecutableList()
-	exe := c.compile(exeList, false)
-	exeList.TopLevel = exe
+	if len(ctx.diagnosticList) > 0 {
+		return nil, ctx.diagnosticList, DiagnosticList(ctx.diagnosticList)
+	}
+	if exeList.TopLevel == nil {
+		return nil, nil, errNoTopLevel
+	}
+
+	return exeList, nil, nil
+}
 
-	return exeList
+// CompileFile 使用默认选项编译文件
+func CompileFile(path string) (*vm.ExecutableList, error) {
+	exeList, _, err := Compile(path, Options{})
+	return exeList, err
 }
@@ -12,21 +12,100 @@ var testFile = "../test/shape.4g"
 //}
 
 func TestParse(t *testing.T) {
-	yyErrorVerbose = true
-
-	compiler := createCompilerByPath(testFile)
-	//exeList := compiler.Compile()
-	compiler.Compile()
-
-	//for _, todo := range compiler.funcList {
-	//    if todo.block != nil {
-	//        println("HHHHHHHHH")
-	//        println(todo.name)
-	//        todo.block.show(0)
-	//    }
-	//}
+	_, _, err := Compile(testFile, Options{SearchPath: "../test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	//for _, c := range stCompilerList {
 	//    println("=======")
 	//    c.Show()
 	//}
 }
+
+func TestCompileError(t *testing.T) {
+	exeList, diagnosticList, err := Compile("../test/compile_error.4g", Options{SearchPath: "../test"})
+	if err == nil || exeList != nil {
+		t.Fatal("compile error expected")
+	}
+
+	expectList := []struct {
+		line int
+		code int
+	}{
+		// 类的检查在语句之前
+		{71, THIS_IN_STATIC_METHOD_ERR},
+		{47, ABSTRACT_METHOD_NOT_IMPLEMENTED_ERR},
+		{134, STATIC_FIELD_INITIALIZER_ERR},
+		{8, IDENTIFIER_NOT_FOUND_ERR},
+		{11, VARIABLE_MULTIPLE_DEFINE_ERR},
+		{14, IF_CONDITION_NOT_BOOLEAN_ERR},
+		{19, BREAK_OUT_OF_LOOP_ERR},
+		{23, LABEL_NOT_FOUND_ERR},
+		{27, BIT_TYPE_MISMATCH_ERR},
+		{33, CASE_DUPLICATE_ERR},
+		{38, SUPER_OUT_OF_CLASS_ERR},
+		{56, PRIVATE_MEMBER_ACCESS_ERR},
+		{57, PACKAGE_MEMBER_ACCESS_ERR},
+		{64, CONSTRUCTOR_NOT_FOUND_ERR},
+		{75, INSTANCE_MEMBER_ACCESS_ERR},
+		{79, BREAK_OUT_OF_LOOP_ERR},
+		{91, GENERIC_INSTANTIATION_ERR},
+		{98, GENERIC_TYPE_INFERENCE_ERR},
+		{101, MAP_KEY_TYPE_ERR},
+		{104, MAP_METHOD_NOT_FOUND_ERR},
+		{107, NOT_LVALUE_ERR},
+		{108, APPEND_ARGUMENT_TYPE_ERR},
+		{109, ARRAY_METHOD_NOT_FOUND_ERR},
+		{110, FOREACH_TYPE_ERR},
+		{113, NOT_LVALUE_ERR},
+		{114, IMPLICIT_NARROWING_ERR},
+		{115, IMPLICIT_NARROWING_ERR},
+		{117, IMPLICIT_NARROWING_ERR},
+		{118, CHAR_LITERAL_LENGTH_ERR},
+		{119, CHAR_LITERAL_LENGTH_ERR},
+		{121, CHAR_LITERAL_UNTERMINATED_ERR},
+		{123, IDENTIFIER_NOT_FOUND_ERR},
+		{125, TYPE_NAME_NOT_FOUND_ERR},
+		{130, ARRAY_LITERAL_EMPTY_ERR},
+		{131, IDENTIFIER_NOT_FOUND_ERR},
+		{140, GENERIC_INSTANTIATION_ERR},
+	}
+
+	if len(diagnosticList) != len(expectList) {
+		t.Fatalf("diagnostic count: %d, expect %d\n%v", len(diagnosticList), len(expectList), err)
+	}
+
+	for i, expect := range expectList {
+		d := diagnosticList[i]
+		if d.Line != expect.line || d.Code != expect.code {
+			t.Errorf("diagnostic %d: %v (code %d), expect line %d code %d", i, d, d.Code, expect.line, expect.code)
+		}
+		if d.Filename != "../test/compile_error.4g" {
+			t.Errorf("diagnostic %d: bad filename %s", i, d.Filename)
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	_, diagnosticList, err := Compile("../test/parse_error.4g", Options{})
+	if err == nil {
+		t.Fatal("parse error expected")
+	}
+
+	if len(diagnosticList) != 1 || diagnosticList[0].Code != PARSE_ERR || diagnosticList[0].Line != 3 {
+		t.Fatalf("unexpected diagnostics: %v", err)
+	}
+}
+
+// 词法错误时语法解析可能成功, 也必须报告错误
+func TestLexError(t *testing.T) {
+	exeList, diagnosticList, err := Compile("../test/lex_error.4g", Options{})
+	if err == nil || exeList != nil {
+		t.Fatal("lex error expected")
+	}
+
+	if len(diagnosticList) != 1 || diagnosticList[0].Code != PARSE_ERR || diagnosticList[0].Line != 3 {
+		t.Fatalf("unexpected diagnostics: %v", err)
+	}
+}
@@ -1,28 +1,24 @@
 package compiler
 
-const defaultPackage = "gogogogo.lang"
-
-func setRequireList(requireList []*Require) {
-
-	compiler := getCurrentCompiler()
-
-	//current_package_name = strings.Join(compiler.packageNameList, '.')
+func (c *Compiler) setRequireList(requireList []*Require) {
+	// 泛型实例只解析定义
+	if c.currentInstance != nil {
+		return
+	}
 
 	// 添加默认包
-	//if current_package_name != defaultPackage {
-	//    requireLists = add_default_package(requireLists)
-	//}
+	if c.getPackageName() != defaultPackage {
+		requireList = addDefaultPackage(requireList)
+	}
 
-	compiler.requireList = requireList
+	c.requireList = requireList
 }
 
-func createFunctionDefinition(typ *TypeSpecifier, identifier string, parameterLists []*Parameter, block *Block) *FunctionDefinition {
-	compiler := getCurrentCompiler()
-
+func (c *Compiler) createFunctionDefinition(typ *TypeSpecifier, identifier string, parameterLists []*Parameter, block *Block) *FunctionDefinition {
 	fd := &FunctionDefinition{}
 
 	fd.typeSpecifier = typ
-	fd.packageNameList = compiler.packageNameList
+	fd.packageNameList = c.packageNameList
 	fd.name = identifier
 	fd.parameterList = parameterLists
 	fd.block = block
@@ -31,7 +27,12 @@ func createFunctionDefinition(typ *TypeSpecifier, identifier string, parameterLi
 		block.parent = &FunctionBlockInfo{function: fd}
 	}
 
-	compiler.funcList = append(compiler.funcList, fd)
+	// 泛型类的方法在实例化时定义
+	if c.currentGeneric != nil {
+		return fd
+	}
+
+	c.funcList = append(c.funcList, fd)
 
 	return fd
 }
@@ -51,46 +52,54 @@ func createFunctionDeriveType(fd *FunctionDefinition) *TypeSpecifier {
 }
 
 // yacc类创建
-func startClassDefine(identifier string, extends []*Extend, pos Position) {
-	compiler := getCurrentCompiler()
-
+func (c *Compiler) startClassDefine(modifier *ClassOrMemberModifierList, isInterface bool, identifier string, extends []*Extend, pos Position) {
 	cd := &ClassDefinition{}
 
-	cd.packageNameList = compiler.packageNameList
+	cd.packageNameList = c.packageNameList
 	cd.name = identifier
 	cd.extendList = extends
+	cd.isInterface = isInterface
+
+	if modifier != nil {
+		if modifier.isVirtual || modifier.isOverride || modifier.isStatic || modifier.accessModifier != NotSpecifiedAccess {
+			compileError(modifier.Position(), CLASS_MODIFIER_ERR, identifier)
+		}
+		cd.isAbstract = modifier.isAbstract
+	}
+	// 接口不能被实例化, 视为abstract类
+	if isInterface {
+		cd.isAbstract = true
+	}
 
 	cd.SetPosition(pos)
 
-	if compiler.currentClassDefinition != nil {
+	if c.currentClassDefinition != nil {
 		panic("TODO")
 	}
 
-	compiler.currentClassDefinition = cd
+	c.currentClassDefinition = cd
 }
 
-func endClassDefine(memberList []MemberDeclaration) {
-	compiler := getCurrentCompiler()
-
-	cd := compiler.currentClassDefinition
+func (c *Compiler) endClassDefine(memberList []MemberDeclaration) {
+	cd := c.currentClassDefinition
 
 	if cd == nil {
 		panic("TODO")
 	}
 
-	if compiler.classDefinitionList == nil {
-		compiler.classDefinitionList = []*ClassDefinition{}
+	if c.classDefinitionList == nil {
+		c.classDefinitionList = []*ClassDefinition{}
 	}
-	compiler.classDefinitionList = append(compiler.classDefinitionList, cd)
+	c.classDefinitionList = append(c.classDefinitionList, cd)
 
 	cd.memberList = memberList
-	compiler.currentClassDefinition = nil
+	c.currentClassDefinition = nil
 }
 
 // 类方法定义
-func methodFunctionDefine(typ *TypeSpecifier, identifier string, parameterList []*Parameter, block *Block) *FunctionDefinition {
+func (c *Compiler) methodFunctionDefine(typ *TypeSpecifier, identifier string, parameterList []*Parameter, block *Block) *FunctionDefinition {
 
-	fd := createFunctionDefinition(typ, identifier, parameterList, block)
+	fd := c.createFunctionDefinition(typ, identifier, parameterList, block)
 
 	return fd
 }
@@ -2,17 +2,73 @@ package compiler
 
 import (
 	"fmt"
-	"os"
+	"strings"
+
+	"github.com/lth-go/gogogogo/vm"
 )
 
+// ==============================
+// Diagnostic
+// ==============================
+
+// Diagnostic 编译错误信息
+type Diagnostic struct {
+	Filename string
+	Line     int
+	Column   int
+	// 错误码, 对应PARSE_ERR等常量
+	Code    int
+	Message string
+}
+
+func (d *Diagnostic) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", d.Filename, d.Line, d.Column, d.Message)
+}
+
+// DiagnosticList 编译失败时作为error返回
+type DiagnosticList []*Diagnostic
+
+func (list DiagnosticList) Error() string {
+	messageList := make([]string, 0, len(list))
+	for _, d := range list {
+		messageList = append(messageList, d.Error())
+	}
+	return strings.Join(messageList, "\n")
+}
+
+// 中断当前语句的编译, 由catchCompileError捕获并记录
+type compileAbort struct {
+	pos         Position
+	errorNumber int
+	message     string
+}
+
 func compileError(pos Position, errorNumber int, a ...interface{}) {
-	fmt.Println("编译错误")
-	fmt.Printf("Line: %d:%d\n", pos.Line, pos.Column)
-	//fmt.Printf(errMessageMap[errorNumber], a...)
-	println(errorNumber)
-	println(errMessageList[errorNumber])
-	panic("TODO")
-	os.Exit(1)
+	panic(&compileAbort{
+		pos:         pos,
+		errorNumber: errorNumber,
+		message:     vm.FormatMessage(errMessageList[errorNumber], a...),
+	})
+}
+
+// 执行f, 发生编译错误时记录错误并跳过f的剩余部分
+func (c *Compiler) catchCompileError(f func()) (ok bool) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		abort, isAbort := r.(*compileAbort)
+		if !isAbort {
+			panic(r)
+		}
+		c.addDiagnostic(abort.pos, abort.errorNumber, abort.message)
+		ok = false
+	}()
+
+	f()
+
+	return true
 }
 
 const (
@@ -108,6 +164,53 @@ const (
 	EOF_IN_C_COMMENT_ERR
 	EOF_IN_STRING_LITERAL_ERR
 	TOO_LONG_CHARACTER_LITERAL_ERR
+	EXCEPTION_CLASS_IS_NOT_EXCEPTION_ERR
+	THROW_TYPE_IS_NOT_EXCEPTION_ERR
+	CLASS_MULTIPLE_DEFINE_ERR
+	BREAK_OUT_OF_LOOP_ERR
+	CONTINUE_OUT_OF_LOOP_ERR
+	LABEL_MULTIPLE_DEFINE_ERR
+	BIT_TYPE_MISMATCH_ERR
+	BIT_NOT_TYPE_MISMATCH_ERR
+	SWITCH_EXPRESSION_TYPE_ERR
+	CASE_TYPE_MISMATCH_ERR
+	CASE_NOT_CONSTANT_ERR
+	CASE_NOT_CLASS_ERR
+	CASE_DUPLICATE_ERR
+	DEFAULT_MULTIPLE_DEFINE_ERR
+	CLASS_MODIFIER_ERR
+	INTERFACE_HAS_FIELD_ERR
+	OVERRIDE_METHOD_NOT_FOUND_ERR
+	ABSTRACT_METHOD_NOT_IMPLEMENTED_ERR
+	SUPER_ABSTRACT_METHOD_CALLED_ERR
+	FIELD_MODIFIER_ERR
+	PROTECTED_MEMBER_ACCESS_ERR
+	CONSTRUCTOR_NAME_ERR
+	CONSTRUCTOR_MODIFIER_ERR
+	CONSTRUCTOR_MULTIPLE_DEFINE_ERR
+	CONSTRUCTOR_NOT_FOUND_ERR
+	AMBIGUOUS_CONSTRUCTOR_ERR
+	SUPER_CONSTRUCTOR_CALL_ERR
+	INTERFACE_HAS_CONSTRUCTOR_ERR
+	STATIC_MODIFIER_MULTIPLE_SPECIFIED_ERR
+	STATIC_METHOD_MODIFIER_ERR
+	STATIC_METHOD_OVERRIDE_ERR
+	STATIC_FIELD_INITIALIZER_ERR
+	STATIC_MEMBER_ACCESS_ERR
+	INSTANCE_MEMBER_ACCESS_ERR
+	THIS_IN_STATIC_METHOD_ERR
+	GENERIC_TYPE_ARGUMENT_COUNT_ERR
+	GENERIC_TYPE_INFERENCE_ERR
+	GENERIC_FUNCTION_VALUE_ERR
+	GENERIC_INSTANTIATION_ERR
+	MAP_KEY_TYPE_ERR
+	MAP_METHOD_NOT_FOUND_ERR
+	APPEND_ARGUMENT_TYPE_ERR
+	FOREACH_TYPE_ERR
+	IMPLICIT_NARROWING_ERR
+	CHAR_LITERAL_LENGTH_ERR
+	CHAR_LITERAL_UNTERMINATED_ERR
+	GENERIC_INSTANTIATION_DEPTH_ERR
 	COMPILE_ERROR_COUNT_PLUS_1
 )
 
@@ -167,8 +270,8 @@ var errMessageList []string = []string{
 	"标签$(label)不存在。",
 	"数组字面量必须至少有一个元素",
 	"下标运算符[]的左边不是数组类型",
-	"数组的下标不是int。",
-	"数组的大小不是int。",
+	"数组的下标不是整数。",
+	"数组的大小不是整数。",
 	"整数值不能被0除。",
 	"package名称过长",
 	"被require的文件不存在($(file))",
@@ -182,11 +285,11 @@ var errMessageList []string = []string{
 	"该类型不能使用成员运算符。",
 	"在类型$(class_name)中不存在成员$(member_name)。",
 	"成员$(member_name)是private的，不能访问。",
-	"没有实现abstract方法。",
-	"必须实现非abstract方法。",
-	"继承了多个类。",
+	"abstract方法$(name)不能有方法体。",
+	"非abstract方法$(name)必须有方法体。",
+	"继承了多个类($(name))。",
 	"Diksam中只能继承abstract类(类$(name)不是abstract类)。",
-	"不能对abstract类($(name))使用new。",
+	"不能对abstract类或接口($(name))使用new。",
 	"void类型的函数不能有返回值。",
 	"没有找到类$(name)。",
 	"被指定为构造方法的成员$(member_name)不是一个方法。",
@@ -196,14 +299,14 @@ var errMessageList []string = []string{
 	"尝试为方法$(member_name)赋值。",
 	"不能覆盖非virtual方法$(name)。",
 	"覆盖方法时必须使用override关键字($(name))。",
-	"在abstract类中，存在非abstract方法$(method_name)。",
+	"非abstract类$(class_name)中不能有abstract方法$(method_name)。",
 	"在没有超类的类中使用了super。",
 	"方法调用以外不能使用super。",
 	"不能引用super的字段。",
 	"$(name)是字段，不能覆盖。",
 	"重复的字段名$(name)。",
 	"数组中没有$(name)方法。",
-	"数组中没有$(name)方法。",
+	"字符串中没有$(name)方法。",
 	"instanceof的操作数必须是引用类型。",
 	"instanceof的右边的类型必须是引用类型。",
 	"instanceof的目标必须是类。",
@@ -213,7 +316,7 @@ var errMessageList []string = []string{
 	"向下转型的源类型必须是类。",
 	"向下转型的目标类型必须是类。",
 	"不需要进行向下转型。",
-	"尝试将父类转换为子类。",
+	"不需要将子类向下转型为父类$(name)。",
 	"尝试转换没有继承关系的类。",
 	"因为Diksam的接口间没有父子关系, 不能向下转型。",
 	"不能require文件本身。",
@@ -221,13 +324,13 @@ var errMessageList []string = []string{
 	"while语句的条件表达式不是boolean型。",
 	"for语句的条件表达式不是boolean型。",
 	"do while语句的条件表达式不是boolean型。",
-	"被覆盖的方法$(name)的访问修饰符必须比超类的更严格。",
+	"覆盖方法$(name)时不能降低访问权限。",
 	"方法或函数$(name)的参数数量错误。",
 	"方法或函数$(func_name)的第$(index)个参数, $(param_name)的类型错误。",
 	"方法或函数$(name)的返回值类型错误。",
 	"不能直接调用构造方法。",
 	"找不到类型名$(name)。",
-	"Diksam的接口之间不能继承（至今为止）。",
+	"接口$(name)不能继承其他类或接口。",
 	"不能从包外访问成员$(member_name)。",
 	"不能从包外访问类$(class_name)。",
 	"不能在类外使用this。",
@@ -235,4 +338,51 @@ var errMessageList []string = []string{
 	"在C样式的注释中终止了文件。",
 	"在字符串字面量中终止了文件。",
 	"字符字面量中包含了2个以上的字符。",
+	"catch的类型$(class_name)不是Exception的子类。",
+	"throw的表达式必须是Exception的子类。",
+	"类名重复($(name))。",
+	"break语句不在循环中。",
+	"continue语句不在循环中。",
+	"标签$(label)重复。",
+	"位运算符的操作数类型不正确。",
+	"按位取反运算符的操作数类型不正确。",
+	"switch的表达式必须是int, string或类, 而不是$(type)。",
+	"case的值的类型与switch的表达式不一致。",
+	"case的值必须是常量。",
+	"switch的表达式是类时, case的值必须是类名。",
+	"case的值$(value)重复。",
+	"switch语句中有多个default。",
+	"类$(name)只能使用abstract修饰。",
+	"接口$(class_name)中不能声明字段$(name)。",
+	"方法$(name)使用了override, 但没有可以覆盖的方法。",
+	"类$(class_name)没有实现abstract方法$(method_name)。",
+	"不能通过super调用abstract方法$(name)。",
+	"字段$(name)只能使用访问修饰符和static。",
+	"成员$(member_name)是protected的, 只能在类及其子类中访问。",
+	"方法$(name)没有返回值类型, 构造方法必须与类名$(class_name)相同。",
+	"构造方法$(name)只能使用访问修饰符。",
+	"类$(class_name)中有参数类型相同的构造方法。",
+	"类$(class_name)中没有与参数($(type_list))匹配的构造方法。",
+	"类$(class_name)中有多个与参数($(type_list))匹配的构造方法。",
+	"super(...)只能作为构造方法的第一条语句。",
+	"接口$(name)不能有构造方法。",
+	"重复声明了static。",
+	"static方法$(name)不能使用abstract, virtual或override修饰。",
+	"static方法$(name)不能覆盖父类的方法, 也不能被覆盖。",
+	"static字段的初始值中不能使用this或super。",
+	"static成员$(member_name)只能通过类名访问。",
+	"不能通过类名访问非static成员$(member_name)。",
+	"static方法中不能使用this或super。",
+	"$(name)需要$(count)个类型参数。",
+	"无法推导泛型函数$(name)的类型参数$(type_parameter)。",
+	"泛型函数$(name)只能直接调用。",
+	"实例化$(name)时出错, 第$(line)行: $(message)",
+	"map的键必须是整数, double, boolean, char或string, 而不是$(type)。",
+	"map中没有$(name)方法。",
+	"append的第一个参数必须是数组, 而不是$(type)。",
+	"for-each只能遍历数组, 字符串或map, 而不是$(type)。",
+	"$(src)转换为$(dest)可能丢失数据, 需要显式转换, eg, ($(dest))x。",
+	"字符字面量必须只包含一个字符。",
+	"字符字面量缺少结束的'。",
+	"实例化$(name)时嵌套超过$(depth)层, 泛型不能无限地实例化。",
 }
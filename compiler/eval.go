@@ -1,6 +1,7 @@
 package compiler
 
 import (
+	"math"
 	"strconv"
 
 	"github.com/lth-go/gogogogo/vm"
@@ -13,6 +14,10 @@ func evalMathExpression(currentBlock *Block, binaryExpr *BinaryExpression) Expre
 		switch rightExpr := binaryExpr.right.(type) {
 
 		case *IntExpression:
+			// 不同的整数类型需要先转换
+			if leftExpr.typeS().basicType != rightExpr.typeS().basicType {
+				return binaryExpr
+			}
 			newExpr := evalMathExpressionInt(binaryExpr, leftExpr.intValue, rightExpr.intValue)
 			return newExpr
 
@@ -58,12 +63,19 @@ func evalMathExpressionInt(binaryExpr *BinaryExpression, left, right int) Expres
 			compileError(binaryExpr.Position(), DIVISION_BY_ZERO_IN_COMPILE_ERR)
 		}
 		value = left / right
+	case ModOperator:
+		if right == 0 {
+			compileError(binaryExpr.Position(), DIVISION_BY_ZERO_IN_COMPILE_ERR)
+		}
+		value = left % right
 	default:
 		compileError(binaryExpr.Position(), MATH_TYPE_MISMATCH_ERR)
 	}
 
-	newExpr := &IntExpression{intValue: value}
-	newExpr.setType(&TypeSpecifier{basicType: vm.IntType})
+	typ := &TypeSpecifier{basicType: binaryExpr.left.typeS().basicType}
+	newExpr := &IntExpression{intValue: wrapIntegerValue(value, typ)}
+	newExpr.setType(typ)
+	newExpr.SetPosition(binaryExpr.Position())
 
 	return newExpr
 }
@@ -82,11 +94,53 @@ func evalMathExpressionDouble(binaryExpr *BinaryExpression, left, right float64)
 			compileError(binaryExpr.Position(), DIVISION_BY_ZERO_IN_COMPILE_ERR)
 		}
 		value = left / right
+	case ModOperator:
+		value = math.Mod(left, right)
 	default:
 		compileError(binaryExpr.Position(), MATH_TYPE_MISMATCH_ERR)
 	}
 	newExpr := &DoubleExpression{doubleValue: value}
 	newExpr.setType(&TypeSpecifier{basicType: vm.DoubleType})
+	newExpr.SetPosition(binaryExpr.Position())
+
+	return newExpr
+}
+
+func evalBitExpression(binaryExpr *BinaryExpression) Expression {
+	leftExpr, ok := binaryExpr.left.(*IntExpression)
+	if !ok {
+		return binaryExpr
+	}
+	rightExpr, ok := binaryExpr.right.(*IntExpression)
+	if !ok {
+		return binaryExpr
+	}
+
+	var value int
+
+	left := leftExpr.intValue
+	right := rightExpr.intValue
+
+	// 移位位数与虚拟机一致, 只取低6位
+	switch binaryExpr.operator {
+	case BitAndOperator:
+		value = left & right
+	case BitOrOperator:
+		value = left | right
+	case BitXorOperator:
+		value = left ^ right
+	case LeftShiftOperator:
+		value = left << (uint(right) & 63)
+	case RightShiftOperator:
+		value = left >> (uint(right) & 63)
+	default:
+		compileError(binaryExpr.Position(), BIT_TYPE_MISMATCH_ERR)
+	}
+
+	typ := &TypeSpecifier{basicType: leftExpr.typeS().basicType}
+	newExpr := &IntExpression{intValue: wrapIntegerValue(value, typ)}
+	newExpr.setType(typ)
+	newExpr.SetPosition(binaryExpr.Position())
 
 	return newExpr
 }
@@ -104,6 +158,7 @@ func chainBinaryExpressionString(binaryExpr *BinaryExpression) Expression {
 
 	newExpr := &StringExpression{stringValue: newStr}
 	newExpr.setType(&TypeSpecifier{basicType: vm.StringType})
+	newExpr.SetPosition(binaryExpr.Position())
 
 	return newExpr
 }
@@ -124,6 +179,8 @@ func expressionToString(expr Expression) string {
 		newStr = strconv.FormatFloat(e.doubleValue, 'f', -1, 64)
 	case *StringExpression:
 		newStr = e.stringValue
+	case *CharExpression:
+		newStr = string(e.charValue)
 	default:
 		newStr = ""
 	}
@@ -173,6 +230,7 @@ func evalCompareExpression(binaryExpr *BinaryExpression) Expression {
 		case *NullExpression:
 			newExpr := &BooleanExpression{booleanValue: true}
 			newExpr.setType(&TypeSpecifier{basicType: vm.BooleanType})
+			newExpr.SetPosition(binaryExpr.Position())
 			return newExpr
 		}
 	}
@@ -194,6 +252,7 @@ func evalCompareExpressionBoolean(binaryExpr *BinaryExpression, left, right bool
 
 	newExpr := &BooleanExpression{booleanValue: value}
 	newExpr.setType(&TypeSpecifier{basicType: vm.BooleanType})
+	newExpr.SetPosition(binaryExpr.Position())
 
 	return newExpr
 }
@@ -220,6 +279,7 @@ func evalCompareExpressionInt(binaryExpr *BinaryExpression, left, right int) Exp
 
 	newExpr := &BooleanExpression{booleanValue: value}
 	newExpr.setType(&TypeSpecifier{basicType: vm.BooleanType})
+	newExpr.SetPosition(binaryExpr.Position())
 	return newExpr
 }
 
@@ -245,6 +305,7 @@ func evalCompareExpressionDouble(binaryExpr *BinaryExpression, left, right float
 
 	newExpr := &BooleanExpression{booleanValue: value}
 	newExpr.setType(&TypeSpecifier{basicType: vm.BooleanType})
+	newExpr.SetPosition(binaryExpr.Position())
 	return newExpr
 }
 
@@ -270,13 +331,14 @@ func evalCompareExpressionString(binaryExpr *BinaryExpression, left, right strin
 
 	newExpr := &BooleanExpression{booleanValue: value}
 	newExpr.setType(&TypeSpecifier{basicType: vm.BooleanType})
+	newExpr.SetPosition(binaryExpr.Position())
 
 	return newExpr
 }
 
-func fixMathBinaryExpression(expr *BinaryExpression, currentBlock *Block) Expression {
-	expr.left = expr.left.fix(currentBlock)
-	expr.right = expr.right.fix(currentBlock)
+func fixMathBinaryExpression(c *Compiler, expr *BinaryExpression, currentBlock *Block) Expression {
+	expr.left = expr.left.fix(c, currentBlock)
+	expr.right = expr.right.fix(c, currentBlock)
 
 	// 能否合并计算
 	newExpr := evalMathExpression(currentBlock, expr)
@@ -285,14 +347,20 @@ func fixMathBinaryExpression(expr *BinaryExpression, currentBlock *Block) Expres
 		return newExpr
 	}
 
+	// 两个char的运算结果为int
+	if isChar(expr.left.typeS()) && isChar(expr.right.typeS()) {
+		expr.left = createCastExpression(CharToIntCast, expr.left)
+		expr.right = createCastExpression(CharToIntCast, expr.right)
+	}
+
 	// 类型转换
 	newBinaryExpr := castBinaryExpression(expr)
 
 	newBinaryExprLeftType := newBinaryExpr.left.typeS()
 	newBinaryExprRightType := newBinaryExpr.right.typeS()
 
-	if isInt(newBinaryExprLeftType) && isInt(newBinaryExprRightType) {
-		newBinaryExpr.setType(&TypeSpecifier{basicType: vm.IntType})
+	if isInteger(newBinaryExprLeftType) && newBinaryExprLeftType.basicType == newBinaryExprRightType.basicType {
+		newBinaryExpr.setType(&TypeSpecifier{basicType: newBinaryExprLeftType.basicType})
 
 	} else if isDouble(newBinaryExprLeftType) && isDouble(newBinaryExprRightType) {
 		newBinaryExpr.setType(&TypeSpecifier{basicType: vm.DoubleType})
@@ -309,9 +377,36 @@ func fixMathBinaryExpression(expr *BinaryExpression, currentBlock *Block) Expres
 	return newBinaryExpr
 }
 
-func fixCompareBinaryExpression(expr *BinaryExpression, currentBlock *Block) Expression {
-	expr.left = expr.left.fix(currentBlock)
-	expr.right = expr.right.fix(currentBlock)
+func fixBitBinaryExpression(c *Compiler, expr *BinaryExpression, currentBlock *Block) Expression {
+	expr.left = expr.left.fix(c, currentBlock)
+	expr.right = expr.right.fix(c, currentBlock)
+
+	leftType := expr.left.typeS()
+	rightType := expr.right.typeS()
+
+	if !isInteger(leftType) || !isInteger(rightType) {
+		compileError(expr.Position(), BIT_TYPE_MISMATCH_ERR)
+	}
+
+	// 移位的结果为左边的类型, 其他运算先转换为相同的类型
+	isShift := expr.operator == LeftShiftOperator || expr.operator == RightShiftOperator
+	if !isShift && leftType.basicType != rightType.basicType {
+		castIntegerBinaryExpression(expr)
+	}
+
+	newExpr := evalBitExpression(expr)
+	if _, ok := newExpr.(*IntExpression); ok {
+		return newExpr
+	}
+
+	expr.setType(&TypeSpecifier{basicType: expr.left.typeS().basicType})
+
+	return expr
+}
+
+func fixCompareBinaryExpression(c *Compiler, expr *BinaryExpression, currentBlock *Block) Expression {
+	expr.left = expr.left.fix(c, currentBlock)
+	expr.right = expr.right.fix(c, currentBlock)
 
 	newExpr := evalCompareExpression(expr)
 	switch newExpr.(type) {
@@ -336,13 +431,13 @@ func fixCompareBinaryExpression(expr *BinaryExpression, currentBlock *Block) Exp
 	return newBinaryExpr
 }
 
-func fixLogicalBinaryExpression(expr *BinaryExpression, currentBlock *Block) Expression {
-	expr.left = expr.left.fix(currentBlock)
-	expr.right = expr.right.fix(currentBlock)
+func fixLogicalBinaryExpression(c *Compiler, expr *BinaryExpression, currentBlock *Block) Expression {
+	expr.left = expr.left.fix(c, currentBlock)
+	expr.right = expr.right.fix(c, currentBlock)
 
 	if isBoolean(expr.left.typeS()) && isBoolean(expr.right.typeS()) {
 		expr.typeSpecifier = &TypeSpecifier{basicType: vm.BooleanType}
-		expr.typeS().fix()
+		expr.typeS().fix(c)
 		return expr
 	}
 
@@ -24,6 +24,12 @@ const (
 	SubOperator
 	MulOperator
 	DivOperator
+	ModOperator
+	BitAndOperator
+	BitOrOperator
+	BitXorOperator
+	LeftShiftOperator
+	RightShiftOperator
 )
 
 var operatorCodeMap = map[BinaryOperatorKind]byte{
@@ -37,6 +43,46 @@ var operatorCodeMap = map[BinaryOperatorKind]byte{
 	SubOperator: vm.VM_SUB_INT,
 	MulOperator: vm.VM_MUL_INT,
 	DivOperator: vm.VM_DIV_INT,
+	ModOperator: vm.VM_MOD_INT,
+
+	BitAndOperator:     vm.VM_BIT_AND,
+	BitOrOperator:      vm.VM_BIT_OR,
+	BitXorOperator:     vm.VM_BIT_XOR,
+	LeftShiftOperator:  vm.VM_LEFT_SHIFT,
+	RightShiftOperator: vm.VM_RIGHT_SHIFT,
+}
+
+//
+// AssignmentOperatorKind
+//
+type AssignmentOperatorKind int
+
+const (
+	NormalAssign AssignmentOperatorKind = iota
+	AddAssign
+	SubAssign
+	MulAssign
+	DivAssign
+	ModAssign
+	BitAndAssign
+	BitOrAssign
+	BitXorAssign
+	LeftShiftAssign
+	RightShiftAssign
+)
+
+// 复合赋值对应的二元运算
+var assignmentOperatorMap = map[AssignmentOperatorKind]BinaryOperatorKind{
+	AddAssign:        AddOperator,
+	SubAssign:        SubOperator,
+	MulAssign:        MulOperator,
+	DivAssign:        DivOperator,
+	ModAssign:        ModOperator,
+	BitAndAssign:     BitAndOperator,
+	BitOrAssign:      BitOrOperator,
+	BitXorAssign:     BitXorOperator,
+	LeftShiftAssign:  LeftShiftOperator,
+	RightShiftAssign: RightShiftOperator,
 }
 
 //
@@ -50,6 +96,11 @@ const (
 	DoubleToStringCast
 	IntToDoubleCast
 	DoubleToIntCast
+	CharToStringCast
+	CharToIntCast
+	IntToCharCast
+	// 整数类型之间的转换
+	IntegerCast
 )
 
 //
@@ -60,7 +111,7 @@ type Expression interface {
 	Pos
 
 	// 用于类型修正,以及简单的类型转换
-	fix(*Block) Expression
+	fix(*Compiler, *Block) Expression
 	// 生成字节码
 	generate(*vm.Executable, *Block, *OpCodeBuf)
 
@@ -81,7 +132,7 @@ type ExpressionImpl struct {
 	typeSpecifier *TypeSpecifier
 }
 
-func (expr *ExpressionImpl) fix(currentBlock *Block) Expression { return nil }
+func (expr *ExpressionImpl) fix(c *Compiler, currentBlock *Block) Expression { return nil }
 
 func (expr *ExpressionImpl) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {}
 
@@ -106,9 +157,9 @@ func (expr *BooleanExpression) show(indent int) {
 	printWithIndent("BoolExpr", indent)
 }
 
-func (expr *BooleanExpression) fix(currentBlock *Block) Expression {
+func (expr *BooleanExpression) fix(c *Compiler, currentBlock *Block) Expression {
 	expr.setType(&TypeSpecifier{basicType: vm.BooleanType})
-	expr.typeS().fix()
+	expr.typeS().fix(c)
 	return expr
 }
 
@@ -142,9 +193,13 @@ func (expr *IntExpression) show(indent int) {
 	printWithIndent("IntExpr", indent)
 }
 
-func (expr *IntExpression) fix(currentBlock *Block) Expression {
+func (expr *IntExpression) fix(c *Compiler, currentBlock *Block) Expression {
+	// 常量转换为其他整数类型后保留类型
+	if expr.typeS() != nil {
+		return expr
+	}
 	expr.setType(&TypeSpecifier{basicType: vm.IntType})
-	expr.typeS().fix()
+	expr.typeS().fix(c)
 	return expr
 }
 func (expr *IntExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
@@ -183,9 +238,9 @@ func (expr *DoubleExpression) show(indent int) {
 	printWithIndent("DoubleExpr", indent)
 }
 
-func (expr *DoubleExpression) fix(currentBlock *Block) Expression {
+func (expr *DoubleExpression) fix(c *Compiler, currentBlock *Block) Expression {
 	expr.setType(&TypeSpecifier{basicType: vm.DoubleType})
-	expr.typeS().fix()
+	expr.typeS().fix(c)
 	return expr
 }
 func (expr *DoubleExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
@@ -211,6 +266,68 @@ func createDoubleExpression(pos Position) *DoubleExpression {
 	return expr
 }
 
+// ==============================
+// CharExpression
+// ==============================
+
+// CharExpression 字符表达式, eg, 'a', '你'
+type CharExpression struct {
+	ExpressionImpl
+
+	charValue rune
+	// 字面量不合法, 在修正时报告
+	unterminated bool
+	invalid      bool
+}
+
+func (expr *CharExpression) show(indent int) {
+	printWithIndent("CharExpr", indent)
+}
+
+func (expr *CharExpression) fix(c *Compiler, currentBlock *Block) Expression {
+	if expr.unterminated {
+		compileError(expr.Position(), CHAR_LITERAL_UNTERMINATED_ERR)
+	}
+	if expr.invalid {
+		compileError(expr.Position(), CHAR_LITERAL_LENGTH_ERR)
+	}
+
+	expr.setType(&TypeSpecifier{basicType: vm.CharType})
+	expr.typeS().fix(c)
+	return expr
+}
+
+// 字符在虚拟机中与int相同
+func (expr *CharExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	intExpr := &IntExpression{intValue: int(expr.charValue)}
+	intExpr.SetPosition(expr.Position())
+	intExpr.generate(exe, currentBlock, ob)
+}
+
+func createCharExpression(pos Position) *CharExpression {
+	expr := &CharExpression{}
+	expr.SetPosition(pos)
+
+	return expr
+}
+
+// 字符字面量必须只包含一个字符
+func createCharLiteralExpression(token Token) *CharExpression {
+	expr := createCharExpression(token.Position())
+
+	runeList := []rune(token.Lit)
+	switch {
+	case token.unterminated:
+		expr.unterminated = true
+	case len(runeList) != 1:
+		expr.invalid = true
+	default:
+		expr.charValue = runeList[0]
+	}
+
+	return expr
+}
+
 // ==============================
 // StringExpression
 // ==============================
@@ -225,9 +342,9 @@ func (expr *StringExpression) show(indent int) {
 	printWithIndent("StringExpr", indent)
 }
 
-func (expr *StringExpression) fix(currentBlock *Block) Expression {
+func (expr *StringExpression) fix(c *Compiler, currentBlock *Block) Expression {
 	expr.setType(&TypeSpecifier{basicType: vm.StringType})
-	expr.typeS().fix()
+	expr.typeS().fix(c)
 	return expr
 }
 
@@ -257,9 +374,9 @@ func (expr *NullExpression) show(indent int) {
 	printWithIndent("NullExpr", indent)
 }
 
-func (expr *NullExpression) fix(currentBlock *Block) Expression {
+func (expr *NullExpression) fix(c *Compiler, currentBlock *Block) Expression {
 	expr.setType(&TypeSpecifier{basicType: vm.NullType})
-	expr.typeS().fix()
+	expr.typeS().fix(c)
 	return expr
 }
 
@@ -291,43 +408,57 @@ type IdentifierExpression struct {
 
 	// 声明要么是变量，要么是函数, 要么是包(FunctionIdentifier Declaration Module)
 	inner IdentifierInner
+
+	// 在括号中, (a)(b)中的a可能是向下转型的类名
+	parenthesized bool
 }
 
 func (expr *IdentifierExpression) show(indent int) {
 	printWithIndent("IdentifierExpr", indent)
 }
 
-func (expr *IdentifierExpression) fix(currentBlock *Block) Expression {
+func (expr *IdentifierExpression) fix(c *Compiler, currentBlock *Block) Expression {
+	// 编译器生成的变量, 已经绑定声明
+	if declaration, ok := expr.inner.(*Declaration); ok {
+		expr.setType(declaration.typeSpecifier)
+		return expr
+	}
+
 	// 判断是否是变量
-	declaration := searchDeclaration(expr.name, currentBlock)
+	declaration := c.searchDeclaration(expr.name, currentBlock)
 	if declaration != nil {
+		captureDeclaration(currentBlock, declaration)
+
 		expr.setType(declaration.typeSpecifier)
 		expr.inner = declaration
-		expr.typeS().fix()
+		expr.typeS().fix(c)
 		return expr
 	}
 
 	// 判断是否是函数
-	fd := searchFunction(expr.name)
+	fd := c.searchFunction(expr.name)
 	if fd != nil {
-		compiler := getCurrentCompiler()
-
 		expr.setType(createFunctionDeriveType(fd))
 		expr.inner = &FunctionIdentifier{
 			functionDefinition: fd,
-			functionIndex:      compiler.addToVmFunctionList(fd),
+			functionIndex:      c.addToVmFunctionList(fd),
 		}
-		expr.typeS().fix()
+		expr.typeS().fix(c)
 
 		return expr
 	}
 
+	// 泛型函数需要根据实参实例化, 不能作为值使用
+	if g := c.searchGeneric(expr.name); g != nil && !g.isClass {
+		compileError(expr.Position(), GENERIC_FUNCTION_VALUE_ERR, expr.name)
+	}
+
 	// TODO 判断是否是包
-	module := searchModule(expr.name)
+	module := c.searchModule(expr.name)
 	if module != nil {
 		expr.setType(module.typ)
 		expr.inner = module
-		expr.typeS().fix()
+		expr.typeS().fix(c)
 		return expr
 	}
 
@@ -338,14 +469,22 @@ func (expr *IdentifierExpression) fix(currentBlock *Block) Expression {
 
 func (expr *IdentifierExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
 	switch inner := expr.inner.(type) {
-	// 函数
+	// 函数作为值使用
 	case *FunctionIdentifier:
-		ob.generateCode(expr.Position(), vm.VM_PUSH_FUNCTION, inner.functionIndex)
+		ob.generateCode(expr.Position(), vm.VM_NEW_CLOSURE, inner.functionIndex, 0)
 		// 变量
 	case *Declaration:
 		var code byte
 
 		offset := getOpcodeTypeOffset(inner.typeSpecifier)
+		if generatePushCell(inner, currentBlock, expr.Position(), ob) {
+			ob.generateCode(expr.Position(), vm.VM_PUSH_CELL_INT+offset)
+			return
+		}
+		if ob.isImported(inner) {
+			ob.generateCode(expr.Position(), vm.VM_PUSH_PACKAGE_STATIC_INT+offset, ob.compiler.addImportedVariable(inner))
+			return
+		}
 		if inner.isLocal {
 			code = vm.VM_PUSH_STACK_INT
 		} else {
@@ -361,6 +500,13 @@ func createIdentifierExpression(name string, pos Position) *IdentifierExpression
 	return expr
 }
 
+// 直接引用声明的变量, 不按名字查找
+func createDeclarationExpression(decl *Declaration, pos Position) *IdentifierExpression {
+	expr := &IdentifierExpression{name: decl.name, inner: decl}
+	expr.SetPosition(pos)
+	return expr
+}
+
 // ==============================
 // CommaExpression
 // ==============================
@@ -381,13 +527,13 @@ func (expr *CommaExpression) show(indent int) {
 	expr.right.show(subIndent)
 }
 
-func (expr *CommaExpression) fix(currentBlock *Block) Expression {
+func (expr *CommaExpression) fix(c *Compiler, currentBlock *Block) Expression {
 
-	expr.left = expr.left.fix(currentBlock)
-	expr.right = expr.right.fix(currentBlock)
+	expr.left = expr.left.fix(c, currentBlock)
+	expr.right = expr.right.fix(c, currentBlock)
 
 	expr.setType(expr.right.typeS())
-	expr.typeS().fix()
+	expr.typeS().fix(c)
 
 	return expr
 }
@@ -408,6 +554,8 @@ type AssignExpression struct {
 
 	// 左值
 	left Expression
+	// 赋值运算符
+	operator AssignmentOperatorKind
 	// 操作数
 	operand Expression
 }
@@ -420,7 +568,7 @@ func (expr *AssignExpression) show(indent int) {
 	expr.operand.show(subIndent)
 }
 
-func (expr *AssignExpression) fix(currentBlock *Block) Expression {
+func (expr *AssignExpression) fix(c *Compiler, currentBlock *Block) Expression {
 	switch expr.left.(type) {
 	case *IdentifierExpression, *IndexExpression, *MemberExpression:
 		// pass
@@ -428,13 +576,30 @@ func (expr *AssignExpression) fix(currentBlock *Block) Expression {
 		compileError(expr.left.Position(), NOT_LVALUE_ERR, "")
 	}
 
-	expr.left = expr.left.fix(currentBlock)
+	expr.left = expr.left.fix(c, currentBlock)
+	checkBuiltinMember(expr.left)
 
-	expr.operand = expr.operand.fix(currentBlock)
+	// 复合赋值, a += b 按 a = a + b 计算, 左边只计算一次
+	if expr.operator != NormalAssign {
+		binaryExpr := &BinaryExpression{
+			operator: assignmentOperatorMap[expr.operator],
+			left:     createPushedLvalueExpression(expr.left),
+			right:    expr.operand,
+		}
+		binaryExpr.SetPosition(expr.Position())
+		expr.operand = binaryExpr
+	}
+
+	expr.operand = fixAssignOperand(c, currentBlock, expr.operand, expr.left.typeS())
+
+	// char的复合赋值, 结果转换回char, eg, c += 1
+	if expr.operator != NormalAssign && isChar(expr.left.typeS()) && isInt(expr.operand.typeS()) {
+		expr.operand = createCastExpression(IntToCharCast, expr.operand)
+	}
 	expr.operand = createAssignCast(expr.operand, expr.left.typeS())
 
 	expr.setType(expr.left.typeS())
-	expr.typeS().fix()
+	expr.typeS().fix(c)
 
 	return expr
 }
@@ -445,6 +610,14 @@ func (expr *AssignExpression) generate(exe *vm.Executable, currentBlock *Block,
 
 // 顶层
 func (expr *AssignExpression) generateEx(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf, isTopLevel bool) {
+	// 复合赋值时先压入左边的数组和下标等, 取值和保存时共用
+	if expr.operator != NormalAssign {
+		generatePushLvalueOperand(exe, currentBlock, expr.left, ob)
+		expr.operand.generate(exe, currentBlock, ob)
+		generateStoreToPushedLvalue(currentBlock, expr.left, !isTopLevel, ob)
+		return
+	}
+
 	expr.operand.generate(exe, currentBlock, ob)
 
 	if !isTopLevel {
@@ -454,6 +627,38 @@ func (expr *AssignExpression) generateEx(exe *vm.Executable, currentBlock *Block
 	generatePopToLvalue(exe, currentBlock, expr.left, ob)
 }
 
+// ==============================
+// PushedLvalueExpression
+// ==============================
+
+// PushedLvalueExpression 复合赋值中左边的值, 数组和下标或对象已经由赋值表达式压栈
+type PushedLvalueExpression struct {
+	ExpressionImpl
+
+	lvalue Expression
+}
+
+func (expr *PushedLvalueExpression) show(indent int) {
+	expr.lvalue.show(indent)
+}
+
+func (expr *PushedLvalueExpression) fix(c *Compiler, currentBlock *Block) Expression {
+	return expr
+}
+
+func (expr *PushedLvalueExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	generatePushFromPushedLvalue(exe, currentBlock, expr.lvalue, ob)
+}
+
+// 左边已经修正
+func createPushedLvalueExpression(lvalue Expression) *PushedLvalueExpression {
+	expr := &PushedLvalueExpression{lvalue: lvalue}
+	expr.SetPosition(lvalue.Position())
+	expr.setType(lvalue.typeS())
+
+	return expr
+}
+
 // ==============================
 // BinaryExpression
 // ==============================
@@ -476,24 +681,27 @@ func (expr *BinaryExpression) show(indent int) {
 	expr.right.show(subIndent)
 }
 
-func (expr *BinaryExpression) fix(currentBlock *Block) Expression {
+func (expr *BinaryExpression) fix(c *Compiler, currentBlock *Block) Expression {
 	var newExpr Expression
 
 	switch expr.operator {
 	// 数学计算
-	case AddOperator, SubOperator, MulOperator, DivOperator:
-		newExpr = fixMathBinaryExpression(expr, currentBlock)
+	case AddOperator, SubOperator, MulOperator, DivOperator, ModOperator:
+		newExpr = fixMathBinaryExpression(c, expr, currentBlock)
+		// 位运算
+	case BitAndOperator, BitOrOperator, BitXorOperator, LeftShiftOperator, RightShiftOperator:
+		newExpr = fixBitBinaryExpression(c, expr, currentBlock)
 		// 比较
 	case EqOperator, NeOperator, GtOperator, GeOperator, LtOperator, LeOperator:
-		newExpr = fixCompareBinaryExpression(expr, currentBlock)
+		newExpr = fixCompareBinaryExpression(c, expr, currentBlock)
 		// && ||
 	case LogicalAndOperator, LogicalOrOperator:
-		newExpr = fixLogicalBinaryExpression(expr, currentBlock)
+		newExpr = fixLogicalBinaryExpression(c, expr, currentBlock)
 	default:
 		panic("TODO")
 	}
 
-	newExpr.typeS().fix()
+	newExpr.typeS().fix(c)
 
 	return newExpr
 }
@@ -502,7 +710,7 @@ func (expr *BinaryExpression) generate(exe *vm.Executable, currentBlock *Block,
 
 	switch operator := expr.operator; operator {
 	case GtOperator, GeOperator, LtOperator, LeOperator,
-		AddOperator, SubOperator, MulOperator, DivOperator,
+		AddOperator, SubOperator, MulOperator, DivOperator, ModOperator,
 		EqOperator, NeOperator:
 
 		var offset byte
@@ -532,6 +740,7 @@ func (expr *BinaryExpression) generate(exe *vm.Executable, currentBlock *Block,
 
 		// 入栈
 		ob.generateCode(expr.Position(), code+offset)
+		generateIntegerWrap(expr.typeS(), expr.Position(), ob)
 
 	case LogicalAndOperator, LogicalOrOperator:
 		var jumpCode, logicalCode byte
@@ -556,6 +765,13 @@ func (expr *BinaryExpression) generate(exe *vm.Executable, currentBlock *Block,
 		ob.generateCode(expr.Position(), logicalCode)
 
 		ob.setLabel(label)
+
+	case BitAndOperator, BitOrOperator, BitXorOperator, LeftShiftOperator, RightShiftOperator:
+		expr.left.generate(exe, currentBlock, ob)
+		expr.right.generate(exe, currentBlock, ob)
+
+		ob.generateCode(expr.Position(), operatorCodeMap[operator])
+		generateIntegerWrap(expr.typeS(), expr.Position(), ob)
 	}
 }
 
@@ -577,12 +793,12 @@ func (expr *MinusExpression) show(indent int) {
 	expr.operand.show(subIndent)
 }
 
-func (expr *MinusExpression) fix(currentBlock *Block) Expression {
+func (expr *MinusExpression) fix(c *Compiler, currentBlock *Block) Expression {
 	var newExpr Expression
 
-	expr.operand = expr.operand.fix(currentBlock)
+	expr.operand = expr.operand.fix(c, currentBlock)
 
-	if !isInt(expr.operand.typeS()) && !isDouble(expr.operand.typeS()) {
+	if !isInteger(expr.operand.typeS()) && !isDouble(expr.operand.typeS()) {
 		compileError(expr.Position(), MINUS_TYPE_MISMATCH_ERR, "")
 	}
 
@@ -590,7 +806,7 @@ func (expr *MinusExpression) fix(currentBlock *Block) Expression {
 
 	switch operand := expr.operand.(type) {
 	case *IntExpression:
-		operand.intValue = -operand.intValue
+		operand.intValue = wrapIntegerValue(-operand.intValue, operand.typeS())
 		newExpr = operand
 	case *DoubleExpression:
 		operand.doubleValue = -operand.doubleValue
@@ -599,7 +815,7 @@ func (expr *MinusExpression) fix(currentBlock *Block) Expression {
 		newExpr = expr
 	}
 
-	newExpr.typeS().fix()
+	newExpr.typeS().fix(c)
 
 	return newExpr
 }
@@ -608,6 +824,7 @@ func (expr *MinusExpression) generate(exe *vm.Executable, currentBlock *Block, o
 	expr.operand.generate(exe, currentBlock, ob)
 	code := vm.VM_MINUS_INT + getOpcodeTypeOffset(expr.typeS())
 	ob.generateCode(expr.Position(), code)
+	generateIntegerWrap(expr.typeS(), expr.Position(), ob)
 }
 
 // ==============================
@@ -629,10 +846,10 @@ func (expr *LogicalNotExpression) show(indent int) {
 	expr.operand.show(subIndent)
 }
 
-func (expr *LogicalNotExpression) fix(currentBlock *Block) Expression {
+func (expr *LogicalNotExpression) fix(c *Compiler, currentBlock *Block) Expression {
 	var newExpr Expression
 
-	expr.operand = expr.operand.fix(currentBlock)
+	expr.operand = expr.operand.fix(c, currentBlock)
 
 	switch operand := expr.operand.(type) {
 	case *BooleanExpression:
@@ -647,7 +864,7 @@ func (expr *LogicalNotExpression) fix(currentBlock *Block) Expression {
 		newExpr = expr
 	}
 
-	newExpr.typeS().fix()
+	newExpr.typeS().fix(c)
 
 	return newExpr
 }
@@ -657,6 +874,144 @@ func (expr *LogicalNotExpression) generate(exe *vm.Executable, currentBlock *Blo
 	ob.generateCode(expr.Position(), vm.VM_LOGICAL_NOT)
 }
 
+// ==============================
+// BitNotExpression
+// ==============================
+
+// BitNotExpression 按位取反表达式
+type BitNotExpression struct {
+	ExpressionImpl
+
+	operand Expression
+}
+
+func (expr *BitNotExpression) show(indent int) {
+	printWithIndent("BitNotExpr", indent)
+
+	subIndent := indent + 2
+
+	expr.operand.show(subIndent)
+}
+
+func (expr *BitNotExpression) fix(c *Compiler, currentBlock *Block) Expression {
+	var newExpr Expression
+
+	expr.operand = expr.operand.fix(c, currentBlock)
+
+	if !isInteger(expr.operand.typeS()) {
+		compileError(expr.Position(), BIT_NOT_TYPE_MISMATCH_ERR)
+	}
+
+	switch operand := expr.operand.(type) {
+	case *IntExpression:
+		operand.intValue = wrapIntegerValue(^operand.intValue, operand.typeS())
+		newExpr = operand
+	default:
+		expr.setType(expr.operand.typeS())
+		newExpr = expr
+	}
+
+	newExpr.typeS().fix(c)
+
+	return newExpr
+}
+
+func (expr *BitNotExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	expr.operand.generate(exe, currentBlock, ob)
+	ob.generateCode(expr.Position(), vm.VM_BIT_NOT)
+	generateIntegerWrap(expr.typeS(), expr.Position(), ob)
+}
+
+// ==============================
+// IncrementExpression
+// ==============================
+
+// IncrementExpression 自增自减表达式
+type IncrementExpression struct {
+	ExpressionImpl
+
+	operand Expression
+	// false为自减
+	isIncrement bool
+	// 前置时值为运算后的结果, 后置时为运算前的值
+	isPrefix bool
+}
+
+func (expr *IncrementExpression) show(indent int) {
+	if expr.isIncrement {
+		printWithIndent("IncrementExpr", indent)
+	} else {
+		printWithIndent("DecrementExpr", indent)
+	}
+
+	subIndent := indent + 2
+
+	expr.operand.show(subIndent)
+}
+
+func (expr *IncrementExpression) fix(c *Compiler, currentBlock *Block) Expression {
+	switch expr.operand.(type) {
+	case *IdentifierExpression, *IndexExpression, *MemberExpression:
+		// pass
+	default:
+		compileError(expr.operand.Position(), NOT_LVALUE_ERR, "")
+	}
+
+	expr.operand = expr.operand.fix(c, currentBlock)
+	checkBuiltinMember(expr.operand)
+
+	if !isInteger(expr.operand.typeS()) && !isChar(expr.operand.typeS()) {
+		compileError(expr.Position(), INC_DEC_TYPE_MISMATCH_ERR)
+	}
+
+	expr.setType(expr.operand.typeS())
+	expr.typeS().fix(c)
+
+	return expr
+}
+
+func (expr *IncrementExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	expr.generateEx(exe, currentBlock, ob, false)
+}
+
+// 顶层不需要保留表达式的值
+func (expr *IncrementExpression) generateEx(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf, isTopLevel bool) {
+	var code byte
+
+	if expr.isIncrement {
+		code = vm.VM_INCREMENT
+	} else {
+		code = vm.VM_DECREMENT
+	}
+
+	// 数组和下标等只计算一次
+	count := generatePushLvalueOperand(exe, currentBlock, expr.operand, ob)
+	generatePushFromPushedLvalue(exe, currentBlock, expr.operand, ob)
+
+	// 后置时保留运算前的值
+	if !isTopLevel && !expr.isPrefix {
+		ob.generateCode(expr.Position(), vm.VM_DUPLICATE)
+		if count > 0 {
+			ob.generateCode(expr.Position(), vm.VM_ROTATE, count+1)
+		}
+	}
+
+	ob.generateCode(expr.Position(), code)
+	generateIntegerWrap(expr.typeS(), expr.Position(), ob)
+
+	generateStoreToPushedLvalue(currentBlock, expr.operand, !isTopLevel && expr.isPrefix, ob)
+}
+
+func createIncrementExpression(operand Expression, isIncrement bool, isPrefix bool, pos Position) *IncrementExpression {
+	expr := &IncrementExpression{
+		operand:     operand,
+		isIncrement: isIncrement,
+		isPrefix:    isPrefix,
+	}
+	expr.SetPosition(pos)
+	return expr
+}
+
 // ==============================
 // FunctionCallExpression
 // ==============================
@@ -683,23 +1038,47 @@ func (expr *FunctionCallExpression) show(indent int) {
 	}
 }
 
-func (expr *FunctionCallExpression) fix(currentBlock *Block) Expression {
+func (expr *FunctionCallExpression) fix(c *Compiler, currentBlock *Block) Expression {
 	var fd *FunctionDefinition
 	var arrayBase *TypeSpecifier
 	var name string
 
-	funcIfs := expr.function.fix(currentBlock)
+	// 合法的super(...)已经在修正构造方法时替换
+	if _, ok := expr.function.(*SuperExpression); ok {
+		compileError(expr.Position(), SUPER_CONSTRUCTOR_CALL_ERR)
+	}
+
+	if c.isDownCastCall(expr, currentBlock) {
+		return createDownCastExpression(expr.function, expr.argumentList[0], expr.Position()).fix(c, currentBlock)
+	}
+
+	// 泛型函数根据实参类型实例化
+	if g := c.searchGenericFunction(expr.function, currentBlock); g != nil {
+		return expr.fixGenericCall(c, currentBlock, g)
+	}
+
+	if c.isAppendCall(expr.function, currentBlock) {
+		return fixAppendCall(c, currentBlock, expr)
+	}
+
+	funcIfs := expr.function.fix(c, currentBlock)
 
 	expr.function = funcIfs
 
 	switch funcExpr := funcIfs.(type) {
 	case *IdentifierExpression:
-		fd = searchFunction(funcExpr.name)
+		// 包中的函数已经在修正成员表达式时找到
+		if f, ok := funcExpr.inner.(*FunctionIdentifier); ok {
+			fd = f.functionDefinition
+		}
 		name = funcExpr.name
 	case *MemberExpression:
 		switch member := funcExpr.memberDeclaration.(type) {
 		case *FieldMember:
-			compileError(expr.Position(), FIELD_CAN_NOT_CALL_ERR, member.name)
+			// 函数类型的字段按closure调用
+			if !isFunction(member.typeSpecifier) {
+				compileError(expr.Position(), FIELD_CAN_NOT_CALL_ERR, member.name)
+			}
 		case *MethodMember:
 			fd = member.functionDefinition
 			name = fd.name
@@ -708,40 +1087,96 @@ func (expr *FunctionCallExpression) fix(currentBlock *Block) Expression {
 		}
 	}
 
+	if fd == nil && isFunction(funcIfs.typeS()) {
+		return expr.fixClosureCall(c, currentBlock)
+	}
+
 	if fd == nil {
 		compileError(expr.Position(), FUNCTION_NOT_FOUND_ERR, name)
 	}
 
-	fd.checkArgument(currentBlock, expr.argumentList, arrayBase)
+	fd.checkArgument(c, currentBlock, expr.argumentList, arrayBase)
 
 	expr.setType(&TypeSpecifier{basicType: fd.typeS().basicType})
 
 	expr.typeSpecifier.deriveList = fd.typeS().deriveList
 
+	// 返回值与第一个参数的类型相同
+	if isAnyArray(fd.typeS()) {
+		typ := *expr.argumentList[0].typeS()
+		expr.setType(&typ)
+	}
+
 	if expr.typeS().basicType == vm.ClassType {
 		expr.typeS().classRef.identifier = fd.typeS().classRef.identifier
-		expr.typeS().fix()
+		expr.typeS().fix(c)
 	}
 
-	expr.typeS().fix()
+	expr.typeS().fix(c)
 	return expr
 }
+
+// 调用函数类型的值, 参数类型由函数类型决定
+func (expr *FunctionCallExpression) fixClosureCall(c *Compiler, currentBlock *Block) Expression {
+	funcType := expr.function.typeS()
+	parameterList := funcType.deriveList[0].(*FunctionDerive).parameterList
+
+	paramLen := len(parameterList)
+	argLen := len(expr.argumentList)
+
+	if argLen != paramLen {
+		compileError(expr.Position(), ARGUMENT_COUNT_MISMATCH_ERR, paramLen, argLen)
+	}
+
+	for i, param := range parameterList {
+		expr.argumentList[i] = fixAssignOperand(c, currentBlock, expr.argumentList[i], param.typeSpecifier)
+		expr.argumentList[i] = createAssignCast(expr.argumentList[i], param.typeSpecifier)
+	}
+
+	typ := cloneTypeSpecifier(funcType)
+	typ.deriveList = funcType.deriveList[1:]
+	expr.setType(typ)
+
+	expr.typeS().fix(c)
+	return expr
+}
+
 func (expr *FunctionCallExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
 
-	switch memberExpr := expr.function.(type) {
+	switch funcExpr := expr.function.(type) {
+	case *MapMethodExpression:
+		funcExpr.generateCall(exe, currentBlock, expr.argumentList, ob)
+		return
+	case *ArrayMethodExpression:
+		funcExpr.generateCall(exe, currentBlock, expr.argumentList, ob)
+		return
 	case *MemberExpression:
-		_, ok := memberExpr.memberDeclaration.(*MethodMember)
-		if ok {
-			generateMethodCallExpression(expr, exe, currentBlock, ob)
+		// static方法与函数相同, 不需要this
+		if member, ok := funcExpr.memberDeclaration.(*MethodMember); ok {
+			if !member.isStatic {
+				generateMethodCallExpression(expr, exe, currentBlock, ob)
+				return
+			}
+			generatePushArgument(expr.argumentList, exe, currentBlock, ob)
+			ob.generateCode(expr.Position(), vm.VM_PUSH_FUNCTION, funcExpr.functionIndex)
+			ob.generateCode(expr.Position(), vm.VM_INVOKE)
+			return
+		}
+	case *IdentifierExpression:
+		if f, ok := funcExpr.inner.(*FunctionIdentifier); ok {
+			generatePushArgument(expr.argumentList, exe, currentBlock, ob)
+			ob.generateCode(expr.Position(), vm.VM_PUSH_FUNCTION, f.functionIndex)
+			ob.generateCode(expr.Position(), vm.VM_INVOKE)
 			return
 		}
 	}
 
+	// 函数类型的值
 	generatePushArgument(expr.argumentList, exe, currentBlock, ob)
 
 	expr.function.generate(exe, currentBlock, ob)
 
-	ob.generateCode(expr.Position(), vm.VM_INVOKE)
+	ob.generateCode(expr.Position(), vm.VM_INVOKE_CLOSURE)
 }
 
 // ==============================
@@ -759,6 +1194,12 @@ type MemberExpression struct {
 	// 用于类成员
 	memberDeclaration MemberDeclaration
 	methodIndex       int
+	// 通过接口调用方法时, 接口的类下标
+	interfaceIndex int
+	// static字段所属类的下标
+	classIndex int
+	// static方法的函数下标
+	functionIndex int
 
 	// module func
 	moduleFunc *FunctionDefinition
@@ -778,24 +1219,41 @@ func (expr *MemberExpression) show(indent int) {
 	expr.expression.show(subIndent)
 }
 
-func (expr *MemberExpression) fix(currentBlock *Block) Expression {
+func (expr *MemberExpression) fix(c *Compiler, currentBlock *Block) Expression {
 	var newExpr Expression
 
-	expr.expression = expr.expression.fix(currentBlock)
+	// 类名.成员, 访问static字段或调用static方法
+	if cd := searchStaticMemberClass(c, currentBlock, expr.expression); cd != nil {
+		newExpr = fixStaticMemberExpression(c, currentBlock, expr, cd)
+		newExpr.typeS().fix(c)
+		return newExpr
+	}
+
+	if superExpr, ok := expr.expression.(*SuperExpression); ok {
+		expr.expression = superExpr.fixSuper(c, currentBlock)
+	} else {
+		expr.expression = expr.expression.fix(c, currentBlock)
+	}
 
 	typ := expr.expression.typeS()
 
 	switch {
-	case isClass(typ):
-		newExpr = fixClassMemberExpression(expr, expr.memberName)
+	case isClass(typ) && len(typ.deriveList) == 0:
+		newExpr = fixClassMemberExpression(c, currentBlock, expr, expr.memberName)
+	case isMap(typ):
+		newExpr = createMapMethodExpression(expr)
+	case isArray(typ):
+		newExpr = fixArrayMemberExpression(expr)
+	case isString(typ) && len(typ.deriveList) == 0:
+		newExpr = fixStringMemberExpression(expr)
 		// 目前仅限函数
 	case typ.isModule():
-		newExpr = fixModuleMemberExpression(expr, expr.memberName)
+		newExpr = fixModuleMemberExpression(c, expr, expr.memberName)
 	default:
 		compileError(expr.Position(), MEMBER_EXPRESSION_TYPE_ERR)
 	}
 
-	newExpr.typeS().fix()
+	newExpr.typeS().fix(c)
 
 	return newExpr
 }
@@ -803,9 +1261,18 @@ func (expr *MemberExpression) fix(currentBlock *Block) Expression {
 func (expr *MemberExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
 	switch member := expr.memberDeclaration.(type) {
 	case *FieldMember:
+		if member.isStatic {
+			ob.generateCode(expr.Position(), vm.VM_PUSH_CLASS_STATIC_INT+getOpcodeTypeOffset(expr.typeS()), expr.classIndex, member.fieldIndex)
+			return
+		}
 		expr.expression.generate(exe, currentBlock, ob)
 		ob.generateCode(expr.Position(), vm.VM_PUSH_FIELD_INT+getOpcodeTypeOffset(expr.typeS()), member.fieldIndex)
 	case *MethodMember:
+		// static方法作为值使用
+		if member.isStatic {
+			ob.generateCode(expr.Position(), vm.VM_NEW_CLOSURE, expr.functionIndex, 0)
+			return
+		}
 		compileError(expr.Position(), METHOD_IS_NOT_CALLED_ERR, member.functionDefinition.name)
 	}
 }
@@ -825,19 +1292,24 @@ func createMemberExpression(expression Expression, memberName string) *MemberExp
 // ==============================
 type ThisExpression struct {
 	ExpressionImpl
+
+	// lambda中捕获的this
+	capture *Declaration
 }
 
 func (expr *ThisExpression) show(indent int) {
 	printWithIndent("ThisExpr", indent)
 }
 
-func (expr *ThisExpression) fix(currentBlock *Block) Expression {
+func (expr *ThisExpression) fix(c *Compiler, currentBlock *Block) Expression {
 
-	cd := getCurrentCompiler().currentClassDefinition
+	cd := c.currentClassDefinition
 
 	if cd == nil {
 		compileError(expr.Position(), THIS_OUT_OF_CLASS_ERR)
 	}
+	checkNotInStaticMethod(expr.Position(), currentBlock)
+	expr.capture = captureThis(currentBlock)
 
 	typ := &TypeSpecifier{basicType: vm.ClassType}
 	typ.classRef = classRef{
@@ -846,12 +1318,16 @@ func (expr *ThisExpression) fix(currentBlock *Block) Expression {
 	}
 	expr.setType(typ)
 
-	expr.typeS().fix()
+	expr.typeS().fix(c)
 
 	return expr
 }
 
 func (expr *ThisExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	if expr.capture != nil {
+		generatePushCapture(expr.capture, currentBlock, expr.Position(), ob)
+		return
+	}
 
 	fd := currentBlock.getCurrentFunction()
 	paramCount := len(fd.parameterList)
@@ -866,6 +1342,76 @@ func createThisExpression(pos Position) *ThisExpression {
 	return expr
 }
 
+// ==============================
+// SuperExpression
+// ==============================
+
+// SuperExpression 只能用于调用父类的方法, super.method()
+type SuperExpression struct {
+	ExpressionImpl
+
+	// 父类在当前compiler中的下标
+	classIndex int
+
+	// lambda中捕获的this
+	capture *Declaration
+}
+
+func (expr *SuperExpression) show(indent int) {
+	printWithIndent("SuperExpr", indent)
+}
+
+// 单独出现的super, 成员表达式中的super由fixSuper修正
+func (expr *SuperExpression) fix(c *Compiler, currentBlock *Block) Expression {
+	compileError(expr.Position(), SUPER_NOT_IN_MEMBER_EXPRESSION_ERR)
+	return nil
+}
+
+func (expr *SuperExpression) fixSuper(c *Compiler, currentBlock *Block) Expression {
+	cd := c.currentClassDefinition
+
+	if cd == nil {
+		compileError(expr.Position(), SUPER_OUT_OF_CLASS_ERR)
+	}
+	checkNotInStaticMethod(expr.Position(), currentBlock)
+	expr.capture = captureThis(currentBlock)
+
+	if cd.superClass == nil {
+		compileError(expr.Position(), HASNT_SUPER_CLASS_ERR)
+	}
+
+	typ := &TypeSpecifier{basicType: vm.ClassType}
+	typ.classRef = classRef{
+		identifier:      cd.superClass.name,
+		classDefinition: cd.superClass,
+	}
+	expr.setType(typ)
+
+	expr.classIndex = cd.superClass.addToCompiler(c)
+
+	return expr
+}
+
+// 与this相同, 方法由VM_SUPER从父类的虚表中获取
+func (expr *SuperExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	if expr.capture != nil {
+		generatePushCapture(expr.capture, currentBlock, expr.Position(), ob)
+		return
+	}
+
+	fd := currentBlock.getCurrentFunction()
+	paramCount := len(fd.parameterList)
+	ob.generateCode(expr.Position(), vm.VM_PUSH_STACK_OBJECT, paramCount)
+}
+
+func createSuperExpression(pos Position) *SuperExpression {
+	expr := &SuperExpression{}
+
+	expr.SetPosition(pos)
+
+	return expr
+}
+
 // ==============================
 // CastExpression
 // ==============================
@@ -882,7 +1428,7 @@ func (expr *CastExpression) show(indent int) {
 	printWithIndent("CastExpr", indent)
 }
 
-func (expr *CastExpression) fix(currentBlock *Block) Expression { return expr }
+func (expr *CastExpression) fix(c *Compiler, currentBlock *Block) Expression { return expr }
 
 func (expr *CastExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
 	expr.operand.generate(exe, currentBlock, ob)
@@ -898,11 +1444,209 @@ func (expr *CastExpression) generate(exe *vm.Executable, currentBlock *Block, ob
 		ob.generateCode(expr.Position(), vm.VM_CAST_INT_TO_STRING)
 	case DoubleToStringCast:
 		ob.generateCode(expr.Position(), vm.VM_CAST_DOUBLE_TO_STRING)
+	case CharToStringCast:
+		ob.generateCode(expr.Position(), vm.VM_CAST_CHAR_TO_STRING)
+	case CharToIntCast, IntToCharCast:
+		// 字符与int的值相同, 只改变类型
+	case IntegerCast:
+		generateIntegerWrap(expr.typeS(), expr.Position(), ob)
 	default:
 		panic("TODO")
 	}
 }
 
+// ==============================
+// TypeCastExpression
+// ==============================
+
+// TypeCastExpression 基本类型之间的显式转换, eg, (int)c, (char)65, (int)3.5
+type TypeCastExpression struct {
+	ExpressionImpl
+
+	operand Expression
+}
+
+func (expr *TypeCastExpression) show(indent int) {
+	printWithIndent("TypeCastExpr", indent)
+
+	subIndent := indent + 2
+	expr.operand.show(subIndent)
+}
+
+// 替换为对应的CastExpression
+func (expr *TypeCastExpression) fix(c *Compiler, currentBlock *Block) Expression {
+	expr.operand = expr.operand.fix(c, currentBlock)
+	expr.typeS().fix(c)
+
+	return createExplicitCast(expr.operand, expr.typeS())
+}
+
+func (expr *TypeCastExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	panic("TODO")
+}
+
+func createTypeCastExpression(typ *TypeSpecifier, operand Expression, pos Position) *TypeCastExpression {
+	expr := &TypeCastExpression{operand: operand}
+	expr.SetPosition(pos)
+	expr.setType(typ)
+
+	return expr
+}
+
+// ==============================
+// DownCastExpression
+// ==============================
+
+// DownCastExpression 向下转型, eg, (Line)shape
+// 运行时类型不匹配时抛出ClassCastException
+type DownCastExpression struct {
+	ExpressionImpl
+
+	// 括号中的类名, 语法分析时无法与括号表达式区分
+	target  Expression
+	operand Expression
+
+	classIndex int
+}
+
+func (expr *DownCastExpression) show(indent int) {
+	printWithIndent("DownCastExpr", indent)
+
+	subIndent := indent + 2
+
+	expr.target.show(subIndent)
+	expr.operand.show(subIndent)
+}
+
+func (expr *DownCastExpression) fix(c *Compiler, currentBlock *Block) Expression {
+	identifier, ok := expr.target.(*IdentifierExpression)
+	if !ok {
+		compileError(expr.target.Position(), DOWN_CAST_TARGET_IS_NOT_CLASS_ERR)
+	}
+
+	targetCd := c.searchClassAndAdd(identifier.Position(), identifier.name, &expr.classIndex)
+
+	expr.operand = expr.operand.fix(c, currentBlock)
+
+	operandType := expr.operand.typeS()
+	if !isClass(operandType) || len(operandType.deriveList) > 0 {
+		compileError(expr.Position(), DOWN_CAST_OPERAND_IS_NOT_CLASS_ERR)
+	}
+
+	operandCd := operandType.classRef.classDefinition
+
+	switch {
+	case operandCd == targetCd:
+		compileError(expr.Position(), DOWN_CAST_DO_NOTHING_ERR)
+	case operandCd.isSubClassOf(targetCd):
+		compileError(expr.Position(), DOWN_CAST_TO_SUPER_CLASS_ERR, targetCd.name)
+	case !targetCd.mayBeRelatedTo(operandCd):
+		compileError(expr.Position(), DOWN_CAST_TO_BAD_CLASS_ERR)
+	}
+
+	typ := &TypeSpecifier{basicType: vm.ClassType}
+	typ.classRef = classRef{
+		identifier:      targetCd.name,
+		classDefinition: targetCd,
+	}
+	expr.setType(typ)
+
+	expr.typeS().fix(c)
+
+	return expr
+}
+
+func (expr *DownCastExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	expr.operand.generate(exe, currentBlock, ob)
+	ob.generateCode(expr.Position(), vm.VM_DOWN_CAST, expr.classIndex)
+}
+
+// (a)(b)中的a不是变量或函数时按向下转型处理, 例如(Line)(shape)
+func (c *Compiler) isDownCastCall(expr *FunctionCallExpression, currentBlock *Block) bool {
+	identifier, ok := expr.function.(*IdentifierExpression)
+	if !ok || !identifier.parenthesized || len(expr.argumentList) != 1 {
+		return false
+	}
+
+	return c.searchDeclaration(identifier.name, currentBlock) == nil && c.searchFunction(identifier.name) == nil
+}
+
+func createDownCastExpression(target Expression, operand Expression, pos Position) *DownCastExpression {
+	expr := &DownCastExpression{
+		target:  target,
+		operand: operand,
+	}
+	expr.SetPosition(pos)
+
+	return expr
+}
+
+// ==============================
+// InstanceofExpression
+// ==============================
+
+// InstanceofExpression 判断对象是否是类或其子类的实例, null时为false
+type InstanceofExpression struct {
+	ExpressionImpl
+
+	operand Expression
+	target  *TypeSpecifier
+}
+
+func (expr *InstanceofExpression) show(indent int) {
+	printWithIndent("InstanceofExpr", indent)
+
+	subIndent := indent + 2
+
+	expr.operand.show(subIndent)
+}
+
+func (expr *InstanceofExpression) fix(c *Compiler, currentBlock *Block) Expression {
+	expr.operand = expr.operand.fix(c, currentBlock)
+	expr.target.fix(c)
+
+	operandType := expr.operand.typeS()
+	if !isClass(operandType) || len(operandType.deriveList) > 0 {
+		compileError(expr.Position(), INSTANCEOF_OPERAND_NOT_REFERENCE_ERR)
+	}
+
+	switch {
+	case isArray(expr.target):
+		compileError(expr.Position(), INSTANCEOF_FOR_NOT_CLASS_ERR)
+	case !isClass(expr.target):
+		compileError(expr.Position(), INSTANCEOF_TYPE_NOT_REFERENCE_ERR)
+	}
+
+	operandCd := operandType.classRef.classDefinition
+	targetCd := expr.target.classRef.classDefinition
+
+	switch {
+	case operandCd.isSubClassOf(targetCd):
+		compileError(expr.Position(), INSTANCEOF_MUST_RETURN_TRUE_ERR)
+	case !targetCd.mayBeRelatedTo(operandCd):
+		compileError(expr.Position(), INSTANCEOF_MUST_RETURN_FALSE_ERR)
+	}
+
+	expr.setType(&TypeSpecifier{basicType: vm.BooleanType})
+
+	return expr
+}
+
+func (expr *InstanceofExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	expr.operand.generate(exe, currentBlock, ob)
+	ob.generateCode(expr.Position(), vm.VM_INSTANCEOF, expr.target.classRef.classIndex)
+}
+
+func createInstanceofExpression(operand Expression, target *TypeSpecifier, pos Position) *InstanceofExpression {
+	expr := &InstanceofExpression{
+		operand: operand,
+		target:  target,
+	}
+	expr.SetPosition(pos)
+
+	return expr
+}
+
 // ==============================
 // ArrayLiteralExpression
 // ==============================
@@ -917,18 +1661,17 @@ func (expr *ArrayLiteralExpression) show(indent int) {
 	printWithIndent("ArrayLiteralExpr", indent)
 }
 
-func (expr *ArrayLiteralExpression) fix(currentBlock *Block) Expression {
+func (expr *ArrayLiteralExpression) fix(c *Compiler, currentBlock *Block) Expression {
 	if expr.arrayLiteral == nil || len(expr.arrayLiteral) == 0 {
 		compileError(expr.Position(), ARRAY_LITERAL_EMPTY_ERR)
 	}
 
-	firstElem := expr.arrayLiteral[0]
-	firstElem = firstElem.fix(currentBlock)
+	expr.arrayLiteral[0] = expr.arrayLiteral[0].fix(c, currentBlock)
 
-	elemType := firstElem.typeS()
+	elemType := expr.arrayLiteral[0].typeS()
 
 	for i := 1; i < len(expr.arrayLiteral); i++ {
-		expr.arrayLiteral[i] = expr.arrayLiteral[i].fix(currentBlock)
+		expr.arrayLiteral[i] = expr.arrayLiteral[i].fix(c, currentBlock)
 		expr.arrayLiteral[i] = createAssignCast(expr.arrayLiteral[i], elemType)
 	}
 
@@ -937,7 +1680,7 @@ func (expr *ArrayLiteralExpression) fix(currentBlock *Block) Expression {
 	expr.typeS().deriveList = []TypeDerive{&ArrayDerive{}}
 	expr.typeS().deriveList = append(expr.typeS().deriveList, elemType.deriveList...)
 
-	expr.typeS().fix()
+	expr.typeS().fix(c)
 
 	return expr
 }
@@ -989,26 +1732,27 @@ func (expr *ArrayCreation) show(indent int) {
 	printWithIndent("ArrayCreationExpr", indent)
 }
 
-func (expr *ArrayCreation) fix(currentBlock *Block) Expression {
-	expr.typeS().fix()
+func (expr *ArrayCreation) fix(c *Compiler, currentBlock *Block) Expression {
+	expr.typeS().fix(c)
 
 	deriveList := []TypeDerive{}
 
 	for _, dim := range expr.dimensionList {
 		if dim.expression != nil {
-			dim.expression = dim.expression.fix(currentBlock)
+			dim.expression = dim.expression.fix(c, currentBlock)
 
-			if !isInt(dim.expression.typeS()) {
+			if !isInteger(dim.expression.typeS()) {
 				compileError(expr.Position(), ARRAY_SIZE_NOT_INT_ERR)
 			}
 		}
 		deriveList = append([]TypeDerive{&ArrayDerive{}}, deriveList...)
 	}
 
+	// 元素本身可以是函数类型
 	expr.setType(cloneTypeSpecifier(expr.typeS()))
-	expr.typeS().deriveList = deriveList
+	expr.typeS().deriveList = append(deriveList, expr.typeS().deriveList...)
 
-	expr.typeS().fix()
+	expr.typeS().fix(c)
 
 	return expr
 }
@@ -1072,10 +1816,26 @@ func (expr *IndexExpression) show(indent int) {
 	expr.index.show(subIndent)
 }
 
-func (expr *IndexExpression) fix(currentBlock *Block) Expression {
+func (expr *IndexExpression) fix(c *Compiler, currentBlock *Block) Expression {
+
+	expr.array = expr.array.fix(c, currentBlock)
+	expr.index = expr.index.fix(c, currentBlock)
+
+	// map按键访问, 键转换为map的键类型
+	if isMap(expr.array.typeS()) {
+		keyType := expr.array.typeS().deriveList[0].(*MapDerive).keyType
+		expr.index = createAssignCast(expr.index, keyType)
 
-	expr.array = expr.array.fix(currentBlock)
-	expr.index = expr.index.fix(currentBlock)
+		expr.setType(cloneTypeSpecifier(expr.array.typeS()))
+		expr.typeS().deriveList = expr.array.typeS().deriveList[1:]
+		expr.typeS().fix(c)
+
+		return expr
+	}
+
+	if isString(expr.array.typeS()) && len(expr.array.typeS().deriveList) == 0 {
+		return fixStringIndexExpression(c, expr)
+	}
 
 	if !expr.array.typeS().isArrayDerive() {
 		compileError(expr.Position(), INDEX_LEFT_OPERAND_NOT_ARRAY_ERR)
@@ -1085,11 +1845,11 @@ func (expr *IndexExpression) fix(currentBlock *Block) Expression {
 
 	expr.typeS().deriveList = expr.array.typeS().deriveList[1:]
 
-	if !isInt(expr.index.typeS()) {
+	if !isInteger(expr.index.typeS()) {
 		compileError(expr.Position(), INDEX_NOT_INT_ERR)
 	}
 
-	expr.typeS().fix()
+	expr.typeS().fix(c)
 
 	return expr
 }
@@ -1098,6 +1858,11 @@ func (expr *IndexExpression) generate(exe *vm.Executable, currentBlock *Block, o
 	expr.array.generate(exe, currentBlock, ob)
 	expr.index.generate(exe, currentBlock, ob)
 
+	if isMap(expr.array.typeS()) {
+		ob.generateCode(expr.Position(), vm.VM_PUSH_MAP_INT+getOpcodeTypeOffset(expr.typeS()))
+		return
+	}
+
 	code := vm.VM_PUSH_ARRAY_INT + getOpcodeTypeOffset(expr.typeS())
 	ob.generateCode(expr.Position(), code)
 }
@@ -1126,20 +1891,21 @@ type NewExpression struct {
 	classDefinition *ClassDefinition
 	classIndex      int
 
-	// 类初始化方便名
-	methodName string
-	// 类初始化方法
-	methodDeclaration MemberDeclaration
+	// 按参数类型选择的构造方法
+	constructor   *MethodMember
+	functionIndex int
 	// 参数
 	argumentList []Expression
+
+	// 泛型类, eg, new List<int>()
+	genericType *TypeSpecifier
 }
 
-func (expr *NewExpression) fix(currentBlock *Block) Expression {
+func (expr *NewExpression) fix(c *Compiler, currentBlock *Block) Expression {
 	// 判断包是否已导入
 	if expr.packageName != "" {
-		currentCompiler := getCurrentCompiler()
 		found := false
-		for _, requiredCompiler := range currentCompiler.requiredList {
+		for _, requiredCompiler := range c.requiredList {
 			if expr.packageName == requiredCompiler.getPackageName() {
 				found = true
 				break
@@ -1150,30 +1916,23 @@ func (expr *NewExpression) fix(currentBlock *Block) Expression {
 		}
 	}
 
-	expr.classDefinition = searchClassAndAdd(expr.Position(), expr.className, &expr.classIndex)
-
-	if expr.methodName == "" {
-		expr.methodName = defaultConstructorName
-	}
-
-	member := expr.classDefinition.searchMember(expr.methodName)
-
-	if member == nil {
-		compileError(expr.Position(), MEMBER_NOT_FOUND_ERR, expr.className, expr.methodName)
+	if expr.genericType != nil {
+		if isMap(expr.genericType) {
+			return fixNewMapExpression(c, expr)
+		}
+		expr.genericType.fix(c)
+		expr.className = expr.genericType.classRef.identifier
 	}
 
-	methodMember, ok := member.(*MethodMember)
-	if !ok {
-		compileError(expr.Position(), CONSTRUCTOR_IS_FIELD_ERR, expr.methodName)
-	}
+	expr.classDefinition = c.searchClassAndAdd(expr.Position(), expr.className, &expr.classIndex)
 
-	if !(methodMember.functionDefinition.typeS().deriveList == nil && methodMember.functionDefinition.typeS().basicType == vm.VoidType) {
-		panic("TODO")
+	if expr.classDefinition.isAbstract {
+		compileError(expr.Position(), NEW_ABSTRACT_CLASS_ERR, expr.className)
 	}
 
-	methodMember.functionDefinition.checkArgument(currentBlock, expr.argumentList, nil)
+	expr.constructor = fixConstructorArgument(c, currentBlock, expr.classDefinition, expr.argumentList, expr.Position())
+	expr.functionIndex = c.addToVmFunctionList(expr.constructor.functionDefinition)
 
-	expr.methodDeclaration = member
 	typ := &TypeSpecifier{
 		basicType: vm.ClassType,
 		classRef: classRef{
@@ -1183,25 +1942,42 @@ func (expr *NewExpression) fix(currentBlock *Block) Expression {
 	}
 	expr.setType(typ)
 
-	expr.typeS().fix()
+	expr.typeS().fix(c)
 
 	return expr
 }
 
 func (expr *NewExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
 
-	methodMember := expr.methodDeclaration.(*MethodMember)
-	paramCount := len(methodMember.functionDefinition.parameterList)
+	paramCount := len(expr.argumentList)
 
+	// 构造方法不经过虚表, 直接调用
 	ob.generateCode(expr.Position(), vm.VM_NEW, expr.classIndex)
 	generatePushArgument(expr.argumentList, exe, currentBlock, ob)
 	ob.generateCode(expr.Position(), vm.VM_DUPLICATE_OFFSET, paramCount)
 
-	ob.generateCode(expr.Position(), vm.VM_PUSH_METHOD, methodMember.methodIndex)
+	ob.generateCode(expr.Position(), vm.VM_PUSH_FUNCTION, expr.functionIndex)
 	ob.generateCode(expr.Position(), vm.VM_INVOKE)
 	ob.generateCode(expr.Position(), vm.VM_POP)
 }
 
+// 修正实参并选择构造方法, 检查访问权限后将实参转换为形参的类型
+func fixConstructorArgument(c *Compiler, currentBlock *Block, cd *ClassDefinition, argumentList []Expression, pos Position) *MethodMember {
+	for i, arg := range argumentList {
+		argumentList[i] = arg.fix(c, currentBlock)
+	}
+
+	member := cd.selectConstructor(pos, argumentList)
+
+	checkMemberAccessibility(pos, getCurrentClass(currentBlock), member, cd.name)
+
+	for i, param := range member.functionDefinition.parameterList {
+		argumentList[i] = createAssignCast(argumentList[i], param.typeSpecifier)
+	}
+
+	return member
+}
+
 func createNewExpression(fullyClassName []string, argumentList []Expression, pos Position) *NewExpression {
 	className := fullyClassName[len(fullyClassName)-1]
 
@@ -1209,9 +1985,8 @@ func createNewExpression(fullyClassName []string, argumentList []Expression, pos
 	packageName := strings.Join(packageNameList, ".")
 
 	expr := &NewExpression{
-		packageName: packageName,
-		className:   className,
-		//methodName:   memthodName,
+		packageName:  packageName,
+		className:    className,
 		argumentList: argumentList,
 	}
 
@@ -1220,6 +1995,60 @@ func createNewExpression(fullyClassName []string, argumentList []Expression, pos
 	return expr
 }
 
+// ==============================
+// DirectMethodCallExpression
+// ==============================
+
+// DirectMethodCallExpression 不经过虚表调用this的方法
+// 用于构造方法中调用父类构造方法和字段初始化方法
+type DirectMethodCallExpression struct {
+	ExpressionImpl
+
+	// 为true时按参数选择父类的构造方法
+	isSuper bool
+
+	functionDefinition *FunctionDefinition
+	functionIndex      int
+	argumentList       []Expression
+}
+
+func (expr *DirectMethodCallExpression) show(indent int) {
+	printWithIndent("DirectMethodCallExpr", indent)
+
+	subIndent := indent + 2
+	for _, arg := range expr.argumentList {
+		arg.show(subIndent)
+	}
+}
+
+func (expr *DirectMethodCallExpression) fix(c *Compiler, currentBlock *Block) Expression {
+	if expr.isSuper {
+		cd := c.currentClassDefinition
+		if cd.superClass == nil {
+			compileError(expr.Position(), HASNT_SUPER_CLASS_ERR)
+		}
+
+		member := fixConstructorArgument(c, currentBlock, cd.superClass, expr.argumentList, expr.Position())
+		expr.functionDefinition = member.functionDefinition
+	}
+
+	expr.functionIndex = c.addToVmFunctionList(expr.functionDefinition)
+	expr.setType(&TypeSpecifier{basicType: vm.VoidType})
+
+	return expr
+}
+
+func (expr *DirectMethodCallExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	generatePushArgument(expr.argumentList, exe, currentBlock, ob)
+
+	// this位于当前方法的形参之后
+	fd := currentBlock.getCurrentFunction()
+	ob.generateCode(expr.Position(), vm.VM_PUSH_STACK_OBJECT, len(fd.parameterList))
+
+	ob.generateCode(expr.Position(), vm.VM_PUSH_FUNCTION, expr.functionIndex)
+	ob.generateCode(expr.Position(), vm.VM_INVOKE)
+}
+
 //
 // TODO Module
 type Module struct {
@@ -1,15 +1,18 @@
 package compiler
 
-func fixStatementList(currentBlock *Block, statementList []Statement, fd *FunctionDefinition) {
+func fixStatementList(c *Compiler, currentBlock *Block, statementList []Statement, fd *FunctionDefinition) {
 	for _, statement := range statementList {
-		statement.fix(currentBlock, fd)
+		// 出错时跳过该语句, 继续检查后面的语句
+		c.catchCompileError(func() {
+			statement.fix(c, currentBlock, fd)
+		})
 	}
 }
 
-func fixClassMemberExpression(expr *MemberExpression, memberName string) Expression {
+func fixClassMemberExpression(c *Compiler, currentBlock *Block, expr *MemberExpression, memberName string) Expression {
 	obj := expr.expression
 
-	obj.typeS().fix()
+	obj.typeS().fix(c)
 
 	cd := obj.typeS().classRef.classDefinition
 
@@ -18,12 +21,27 @@ func fixClassMemberExpression(expr *MemberExpression, memberName string) Express
 		compileError(expr.Position(), MEMBER_NOT_FOUND_ERR, cd.name, memberName)
 	}
 
+	checkMemberAccessibility(expr.Position(), getCurrentClass(currentBlock), member, memberName)
+
+	if isStaticMember(member) {
+		compileError(expr.Position(), STATIC_MEMBER_ACCESS_ERR, memberName)
+	}
+
 	expr.memberDeclaration = member
 
 	switch m := member.(type) {
 	case *MethodMember:
+		if _, ok := obj.(*SuperExpression); ok && m.isAbstract {
+			compileError(expr.Position(), SUPER_ABSTRACT_METHOD_CALLED_ERR, memberName)
+		}
+		if methodCd := m.functionDefinition.classDefinition; methodCd.isInterface {
+			expr.interfaceIndex = methodCd.addToCompiler(c)
+		}
 		expr.setType(createFunctionDeriveType(m.functionDefinition))
 	case *FieldMember:
+		if _, ok := obj.(*SuperExpression); ok {
+			compileError(expr.Position(), FIELD_OF_SUPER_REFERENCED_ERR)
+		}
 		expr.setType(m.typeSpecifier)
 	}
 
@@ -31,34 +49,110 @@ func fixClassMemberExpression(expr *MemberExpression, memberName string) Express
 
 }
 
+// 表达式是类名时返回类定义, 同名的变量, 函数和包优先
+func searchStaticMemberClass(c *Compiler, currentBlock *Block, expr Expression) *ClassDefinition {
+	identifier, ok := expr.(*IdentifierExpression)
+	if !ok {
+		return nil
+	}
+
+	name := identifier.name
+	if c.searchDeclaration(name, currentBlock) != nil || c.searchFunction(name) != nil || c.searchModule(name) != nil {
+		return nil
+	}
+
+	return c.searchClass(name)
+}
+
+// 类名.成员只能访问static成员
+func fixStaticMemberExpression(c *Compiler, currentBlock *Block, expr *MemberExpression, cd *ClassDefinition) Expression {
+	memberName := expr.memberName
+
+	member := cd.searchMember(memberName)
+	if member == nil {
+		compileError(expr.Position(), MEMBER_NOT_FOUND_ERR, cd.name, memberName)
+	}
+
+	checkMemberAccessibility(expr.Position(), getCurrentClass(currentBlock), member, memberName)
+
+	if !isStaticMember(member) {
+		compileError(expr.Position(), INSTANCE_MEMBER_ACCESS_ERR, memberName)
+	}
+
+	expr.memberDeclaration = member
+
+	switch m := member.(type) {
+	case *MethodMember:
+		expr.functionIndex = c.addToVmFunctionList(m.functionDefinition)
+		expr.setType(createFunctionDeriveType(m.functionDefinition))
+	case *FieldMember:
+		expr.classIndex = m.classDefinition.addToCompiler(c)
+		expr.setType(m.typeSpecifier)
+	}
+
+	return expr
+}
+
+func isStaticMember(member MemberDeclaration) bool {
+	switch m := member.(type) {
+	case *MethodMember:
+		return m.isStatic
+	case *FieldMember:
+		return m.isStatic
+	}
+	return false
+}
+
+// static方法和static字段的初始值中没有this, 不能使用this和super
+func checkNotInStaticMethod(pos Position, currentBlock *Block) {
+	fd := currentBlock.getCurrentFunction().getOwnerFunction()
+	// 类中不在方法里的代码只有static字段的初始值
+	if fd == nil {
+		compileError(pos, STATIC_FIELD_INITIALIZER_ERR)
+	}
+	if fd.isStatic {
+		compileError(pos, THIS_IN_STATIC_METHOD_ERR)
+	}
+}
+
+// 当前代码所在的类, 不在方法中时为nil
+// 方法中的lambda与方法属于同一个类
+func getCurrentClass(currentBlock *Block) *ClassDefinition {
+	fd := currentBlock.getCurrentFunction().getOwnerFunction()
+	if fd == nil {
+		return nil
+	}
+	return fd.classDefinition
+}
+
 // 仅限函数
-func fixModuleMemberExpression(expr *MemberExpression, memberName string) Expression {
+func fixModuleMemberExpression(c *Compiler, expr *MemberExpression, memberName string) Expression {
 	innerExpr := expr.expression
 
-	innerExpr.typeS().fix()
+	innerExpr.typeS().fix(c)
 
 	module := innerExpr.(*IdentifierExpression).inner.(*Module)
 
 	moduleCompiler := module.compiler
 
-	fd := moduleCompiler.searchFunction(memberName)
+	fd := moduleCompiler.searchPackageFunction(memberName)
 	if fd == nil {
-		panic("TODO")
+		compileError(expr.Position(), FUNCTION_NOT_FOUND_ERR, memberName)
+	}
+	if fd.isPrivate {
+		compileError(expr.Position(), PACKAGE_MEMBER_ACCESS_ERR, memberName)
 	}
-
-	// TODO 得用当前compiler来添加
-	currentCompiler := getCurrentCompiler()
 
 	newExpr := &IdentifierExpression{
 		name: memberName,
 		inner: &FunctionIdentifier{
 			functionDefinition: fd,
-			functionIndex:      currentCompiler.addToVmFunctionList(fd),
+			functionIndex:      c.addToVmFunctionList(fd),
 		},
 	}
 
 	newExpr.setType(createFunctionDeriveType(fd))
-	newExpr.typeS().fix()
+	newExpr.typeS().fix(c)
 
 	return newExpr
 }
@@ -31,43 +31,95 @@ type FunctionDefinition struct {
 	classDefinition   *ClassDefinition
 
 	index int
+
+	// 虚拟机中注册的原生函数
+	isNative bool
+
+	// 只能在包内访问
+	isPrivate bool
+
+	// 构造方法不在虚表中, 按参数类型区分
+	isConstructor bool
+
+	// static方法没有this
+	isStatic bool
+
+	// lambda以closure作为this, 通过closure访问捕获的变量
+	isLambda bool
+	// 定义lambda时所在的函数, 顶层代码中为nil
+	outerFunction *FunctionDefinition
+	// 捕获的外层变量, 下标与closure中的顺序一致
+	captureList []*Declaration
+	// 方法中的lambda捕获this时使用的声明
+	thisDeclaration *Declaration
+
+	// 泛型函数的实例
+	instance *genericInstance
 }
 
-func (fd *FunctionDefinition) fix() {
+func (fd *FunctionDefinition) fix(c *Compiler) {
+	fd.typeSpecifier.fix(c)
+
+	// abstract方法没有方法体, 只修正形参类型
+	if fd.block == nil {
+		for _, param := range fd.parameterList {
+			param.typeSpecifier.fix(c)
+		}
+		return
+	}
+
 	// 添加形参声明
-	fd.addParameterAsDeclaration()
-	fd.typeSpecifier.fix()
+	fd.addParameterAsDeclaration(c)
+
+	// 修正表达式列表
+	fixStatementList(c, fd.block, fd.block.statementList, fd)
+
+	// 修正返回值
+	fd.addReturnFunction(c)
+}
+
+// abstract方法没有对应的虚拟机函数
+func (fd *FunctionDefinition) isAbstract() bool {
+	return fd.classDefinition != nil && fd.block == nil
+}
 
-	if fd.block != nil {
-		// 修正表达式列表
-		fixStatementList(fd.block, fd.block.statementList, fd)
+// 方法的this位于形参之后, static方法和函数没有this
+// lambda的closure同样位于形参之后
+func (fd *FunctionDefinition) hasThis() bool {
+	return fd.isLambda || (fd.classDefinition != nil && !fd.isStatic)
+}
 
-		// 修正返回值
-		fd.addReturnFunction()
+// lambda所在的函数或方法, 不在lambda中时为自身
+func (fd *FunctionDefinition) getOwnerFunction() *FunctionDefinition {
+	for fd != nil && fd.isLambda {
+		fd = fd.outerFunction
 	}
+	return fd
 }
 
 func (fd *FunctionDefinition) typeS() *TypeSpecifier {
 	return fd.typeSpecifier
 }
 
-func (fd *FunctionDefinition) addParameterAsDeclaration() {
+func (fd *FunctionDefinition) addParameterAsDeclaration(c *Compiler) {
 
 	for _, param := range fd.parameterList {
-		if searchDeclaration(param.name, fd.block) != nil {
+		// 形参可以与全局变量同名
+		if fd.block.searchDeclaration(c, param.name) != nil {
 			compileError(param.typeSpecifier.Position(), PARAMETER_MULTIPLE_DEFINE_ERR, param.name)
 		}
 		decl := &Declaration{name: param.name, typeSpecifier: param.typeSpecifier}
 
-		fd.block.addDeclaration(decl, fd, param.typeSpecifier.Position())
+		fd.block.addDeclaration(c, decl, fd, param.typeSpecifier.Position())
 	}
 }
 
-func (fd *FunctionDefinition) addReturnFunction() {
+func (fd *FunctionDefinition) addReturnFunction(c *Compiler) {
 
 	if fd.block.statementList == nil {
 		ret := &ReturnStatement{returnValue: nil}
-		ret.fix(fd.block, fd)
+		ret.SetPosition(fd.typeSpecifier.Position())
+		ret.fix(c, fd.block, fd)
 		fd.block.statementList = []Statement{ret}
 		return
 	}
@@ -85,16 +137,22 @@ func (fd *FunctionDefinition) addReturnFunction() {
 	if ret.returnValue != nil {
 		ret.returnValue.SetPosition(fd.typeSpecifier.Position())
 	}
-	ret.fix(fd.block, fd)
+	ret.fix(c, fd.block, fd)
 	fd.block.statementList = append(fd.block.statementList, ret)
 }
 
 func (fd *FunctionDefinition) addLocalVariable(decl *Declaration) {
 	decl.variableIndex = len(fd.localVariableList)
+
+	// 方法的this位于形参之后, 局部变量需要跳过
+	if fd.hasThis() && decl.variableIndex >= len(fd.parameterList) {
+		decl.variableIndex++
+	}
+
 	fd.localVariableList = append(fd.localVariableList, decl)
 }
 
-func (fd *FunctionDefinition) checkArgument(currentBlock *Block, argumentList []Expression, arrayBase *TypeSpecifier) {
+func (fd *FunctionDefinition) checkArgument(c *Compiler, currentBlock *Block, argumentList []Expression, arrayBase *TypeSpecifier) {
 	var tempType *TypeSpecifier
 
 	parameterList := fd.parameterList
@@ -107,9 +165,17 @@ func (fd *FunctionDefinition) checkArgument(currentBlock *Block, argumentList []
 	}
 
 	for i := 0; i < paramLen; i++ {
-		argumentList[i] = argumentList[i].fix(currentBlock)
-
 		paramType := parameterList[i].typeSpecifier
+		argumentList[i] = fixAssignOperand(c, currentBlock, argumentList[i], paramType)
+
+		if isAnyArray(paramType) {
+			// 原生函数的参数可以是任意类型的数组
+			argType := argumentList[i].typeS()
+			if !isArray(argType) && argType.basicType != vm.NullType {
+				compileError(argumentList[i].Position(), BAD_PARAMETER_TYPE_ERR, fd.name, i+1, parameterList[i].name)
+			}
+			continue
+		}
 		if paramType.basicType == vm.BaseType {
 			tempType = arrayBase
 		} else {
@@ -123,10 +189,21 @@ func (fd *FunctionDefinition) getPackageName() string {
 	return strings.Join(fd.packageNameList, ".")
 }
 
+// 重载的构造方法以参数类型区分, 如Line(double,double)
+func (fd *FunctionDefinition) getConstructorSignature() string {
+	typeNameList := []string{}
+	for _, param := range fd.parameterList {
+		typeNameList = append(typeNameList, getTypeName(param.typeSpecifier))
+	}
+	return fd.name + "(" + strings.Join(typeNameList, ",") + ")"
+}
+
 func (fd *FunctionDefinition) getVmFuncName() string {
 	var name string
 
-	if fd.classDefinition != nil {
+	if fd.isConstructor {
+		name = createMethodFunctionName(fd.classDefinition.name, fd.getConstructorSignature())
+	} else if fd.classDefinition != nil {
 		name = createMethodFunctionName(fd.classDefinition.name, fd.name)
 	} else {
 		name = fd.name
@@ -7,20 +7,31 @@ import (
 )
 
 type OpCodeBuf struct {
+	// 生成字节码的compiler, 用于记录编译错误
+	compiler *Compiler
+
 	codeList       []byte
 	labelTableList []*LabelTable
 	lineNumberList []*vm.LineNumber
+	// 异常表, 地址暂时为label
+	tryList []*vm.Try
+	// switch跳转表, 地址暂时为label
+	switchTableList []*vm.SwitchTable
 }
 
 type LabelTable struct {
 	labelAddress int
 }
 
-func newCodeBuf() *OpCodeBuf {
+func newCodeBuf(c *Compiler) *OpCodeBuf {
 	ob := &OpCodeBuf{
+		compiler:       c,
 		codeList:       []byte{},
 		labelTableList: []*LabelTable{},
 		lineNumberList: []*vm.LineNumber{},
+		tryList:        []*vm.Try{},
+
+		switchTableList: []*vm.SwitchTable{},
 	}
 	return ob
 }
@@ -91,6 +102,8 @@ func (ob *OpCodeBuf) addLineNumber(lineNumber int, startPc int) {
 func (ob *OpCodeBuf) fixOpcodeBuf() []byte {
 
 	ob.fixLabels()
+	ob.fixTryList()
+	ob.fixSwitchTableList()
 	ob.labelTableList = nil
 
 	return ob.codeList
@@ -102,7 +115,8 @@ func (ob *OpCodeBuf) fixLabels() {
 	for i := 0; i < len(ob.codeList); i++ {
 		if ob.codeList[i] == vm.VM_JUMP ||
 			ob.codeList[i] == vm.VM_JUMP_IF_TRUE ||
-			ob.codeList[i] == vm.VM_JUMP_IF_FALSE {
+			ob.codeList[i] == vm.VM_JUMP_IF_FALSE ||
+			ob.codeList[i] == vm.VM_GO_FINALLY {
 
 			label := get2ByteInt(ob.codeList[i+1:])
 			address := ob.labelTableList[label].labelAddress
@@ -122,12 +136,52 @@ func (ob *OpCodeBuf) fixLabels() {
 	}
 }
 
+// 修正异常表, 将label替换为地址
+func (ob *OpCodeBuf) fixTryList() {
+	getAddress := func(label int) int {
+		if label < 0 {
+			return label
+		}
+		return ob.labelTableList[label].labelAddress
+	}
+
+	for _, try := range ob.tryList {
+		try.StartPc = getAddress(try.StartPc)
+		try.EndPc = getAddress(try.EndPc)
+		try.FinallyStartPc = getAddress(try.FinallyStartPc)
+		try.FinallyEndPc = getAddress(try.FinallyEndPc)
+
+		for _, catch := range try.CatchList {
+			catch.StartPc = getAddress(catch.StartPc)
+			catch.EndPc = getAddress(catch.EndPc)
+		}
+	}
+}
+
+// 修正switch跳转表, 将label替换为地址
+func (ob *OpCodeBuf) fixSwitchTableList() {
+	for _, table := range ob.switchTableList {
+		for i, label := range table.AddressList {
+			table.AddressList[i] = ob.labelTableList[label].labelAddress
+		}
+		table.DefaultPc = ob.labelTableList[table.DefaultPc].labelAddress
+	}
+}
+
+// 添加switch跳转表, 返回下标
+func (ob *OpCodeBuf) addSwitchTable(table *vm.SwitchTable) int {
+	ob.switchTableList = append(ob.switchTableList, table)
+	return len(ob.switchTableList) - 1
+}
+
 //
 // generateStatementList
 //
 func generateStatementList(exe *vm.Executable, currentBlock *Block, statementList []Statement, ob *OpCodeBuf) {
 	for _, stmt := range statementList {
-		stmt.generate(exe, currentBlock, ob)
+		ob.compiler.catchCompileError(func() {
+			stmt.generate(exe, currentBlock, ob)
+		})
 	}
 }
 
@@ -146,6 +200,8 @@ func copyTypeSpecifierNoAlloc(src *TypeSpecifier, dest *vm.TypeSpecifier) {
 			dest.AppendDerive(newDerive)
 		case *ArrayDerive:
 			dest.AppendDerive(&vm.ArrayDerive{})
+		case *MapDerive:
+			dest.AppendDerive(&vm.MapDerive{KeyType: copyTypeSpecifier(realDerive.keyType)})
 		default:
 			panic("TODO")
 		}
@@ -175,12 +231,10 @@ func copyParameterList(src []*Parameter) []*vm.LocalVariable {
 }
 
 func copyLocalVariables(fd *FunctionDefinition) []*vm.LocalVariable {
-	// TODO 形参占用位置
 	var dest = []*vm.LocalVariable{}
 
-	localVariableCount := len(fd.localVariableList) - len(fd.parameterList)
-
-	for _, v := range fd.localVariableList[0:localVariableCount] {
+	// 形参由调用者压栈, 只复制局部变量
+	for _, v := range fd.localVariableList[len(fd.parameterList):] {
 		vmV := &vm.LocalVariable{
 			Name:          v.name,
 			TypeSpecifier: copyTypeSpecifier(v.typeSpecifier),
@@ -191,6 +245,25 @@ func copyLocalVariables(fd *FunctionDefinition) []*vm.LocalVariable {
 	return dest
 }
 
+// 只复制名称, 其余信息生成时填写
+func copyVmFunctionList(src []*vm.Function) []*vm.Function {
+	dest := []*vm.Function{}
+
+	for _, f := range src {
+		dest = append(dest, &vm.Function{PackageName: f.PackageName, Name: f.Name})
+	}
+	return dest
+}
+
+func copyVmClassList(src []*vm.Class) []*vm.Class {
+	dest := []*vm.Class{}
+
+	for _, class := range src {
+		dest = append(dest, &vm.Class{PackageName: class.PackageName, Name: class.Name})
+	}
+	return dest
+}
+
 // TODO 作为exe的方法
 func AddTypeSpecifier(src *TypeSpecifier, exe *vm.Executable) int {
 	ret := len(exe.TypeSpecifierList)
@@ -203,25 +276,106 @@ func AddTypeSpecifier(src *TypeSpecifier, exe *vm.Executable) int {
 }
 
 func generatePopToLvalue(exe *vm.Executable, block *Block, expr Expression, ob *OpCodeBuf) {
+	generatePushLvalueOperand(exe, block, expr, ob)
+	generatePopToPushedLvalue(block, expr, ob)
+}
 
+// 左边值需要压栈的数组和下标, 或者对象的个数
+func getLvalueOperandCount(expr Expression) int {
+	switch e := expr.(type) {
+	case *IndexExpression:
+		return 2
+	case *MemberExpression:
+		if member, ok := e.memberDeclaration.(*FieldMember); ok && !member.isStatic {
+			return 1
+		}
+	}
+	return 0
+}
+
+// 压入左边值的数组和下标, 或者对象
+func generatePushLvalueOperand(exe *vm.Executable, block *Block, expr Expression, ob *OpCodeBuf) int {
 	switch e := expr.(type) {
-	case *IdentifierExpression:
-		generatePopToIdentifier(e.inner.(*Declaration), expr.Position(), ob)
 	case *IndexExpression:
 		e.array.generate(exe, block, ob)
 		e.index.generate(exe, block, ob)
+	case *MemberExpression:
+		if getLvalueOperandCount(e) > 0 {
+			e.expression.generate(exe, block, ob)
+		}
+	}
+	return getLvalueOperandCount(expr)
+}
+
+// 复制已经压栈的数组和下标或对象, 取出左边的值
+// 复合赋值和自增自减时, 左边的数组和下标等只计算一次
+func generatePushFromPushedLvalue(exe *vm.Executable, block *Block, expr Expression, ob *OpCodeBuf) {
+	count := getLvalueOperandCount(expr)
+	for i := 0; i < count; i++ {
+		ob.generateCode(expr.Position(), vm.VM_DUPLICATE_OFFSET, count-1)
+	}
+
+	offset := getOpcodeTypeOffset(expr.typeS())
+
+	switch e := expr.(type) {
+	case *IndexExpression:
+		if isMap(e.array.typeS()) {
+			ob.generateCode(expr.Position(), vm.VM_PUSH_MAP_INT+offset)
+			return
+		}
+		ob.generateCode(expr.Position(), vm.VM_PUSH_ARRAY_INT+offset)
+	case *MemberExpression:
+		if count == 0 {
+			e.generate(exe, block, ob)
+			return
+		}
+		ob.generateCode(expr.Position(), vm.VM_PUSH_FIELD_INT+offset, e.memberDeclaration.(*FieldMember).fieldIndex)
+	default:
+		expr.generate(exe, block, ob)
+	}
+}
+
+// 栈顶的值保存到左边, 数组和下标或对象已经在值的下面
+func generatePopToPushedLvalue(block *Block, expr Expression, ob *OpCodeBuf) {
+	switch e := expr.(type) {
+	case *IdentifierExpression:
+		generatePopToIdentifier(e.inner.(*Declaration), block, expr.Position(), ob)
+	case *IndexExpression:
+		if isMap(e.array.typeS()) {
+			ob.generateCode(expr.Position(), vm.VM_POP_MAP_INT+getOpcodeTypeOffset(expr.typeS()))
+			return
+		}
 		ob.generateCode(expr.Position(), vm.VM_POP_ARRAY_INT+getOpcodeTypeOffset(expr.typeS()))
 	case *MemberExpression:
-        generatePopToMember(exe, block, e, ob)
+		generatePopToMember(e, ob)
+	}
+}
+
+// 值在栈顶, 移动到已经压栈的数组和下标或对象的下面再保存, keepValue时保留一份作为表达式的值
+func generateStoreToPushedLvalue(block *Block, expr Expression, keepValue bool, ob *OpCodeBuf) {
+	count := getLvalueOperandCount(expr)
+
+	if keepValue {
+		ob.generateCode(expr.Position(), vm.VM_DUPLICATE)
+		if count > 0 {
+			ob.generateCode(expr.Position(), vm.VM_ROTATE, count+1)
+		}
+	}
+	if count > 0 {
+		ob.generateCode(expr.Position(), vm.VM_ROTATE, count)
 	}
+
+	generatePopToPushedLvalue(block, expr, ob)
 }
 
-func generatePopToMember(exe *vm.Executable,block *Block, expr *MemberExpression ,ob  *OpCodeBuf) {
-	
+func generatePopToMember(expr *MemberExpression, ob *OpCodeBuf) {
 	switch member := expr.memberDeclaration.(type) {
 	case *FieldMember:
-		expr.expression.generate(exe, block, ob)
-		ob.generateCode(expr.Position(),vm.VM_POP_FIELD_INT + getOpcodeTypeOffset(member.typeSpecifier), member.fieldIndex)
+		if member.isStatic {
+			ob.generateCode(expr.Position(), vm.VM_POP_CLASS_STATIC_INT+getOpcodeTypeOffset(member.typeSpecifier), expr.classIndex, member.fieldIndex)
+			return
+		}
+		ob.generateCode(expr.Position(), vm.VM_POP_FIELD_INT+getOpcodeTypeOffset(member.typeSpecifier), member.fieldIndex)
 	case *MethodMember:
 		compileError(expr.Position(), ASSIGN_TO_METHOD_ERR, member.functionDefinition.name)
 	default:
@@ -229,10 +383,18 @@ func generatePopToMember(exe *vm.Executable,block *Block, expr *MemberExpression
 	}
 }
 
-func generatePopToIdentifier(decl *Declaration, pos Position, ob *OpCodeBuf) {
+func generatePopToIdentifier(decl *Declaration, currentBlock *Block, pos Position, ob *OpCodeBuf) {
 	var code byte
 
 	offset := getOpcodeTypeOffset(decl.typeSpecifier)
+	if generatePushCell(decl, currentBlock, pos, ob) {
+		ob.generateCode(pos, vm.VM_POP_CELL_INT+offset)
+		return
+	}
+	if ob.isImported(decl) {
+		ob.generateCode(pos, vm.VM_POP_PACKAGE_STATIC_INT+offset, ob.compiler.addImportedVariable(decl))
+		return
+	}
 	if decl.isLocal {
 		code = vm.VM_POP_STACK_INT
 	} else {
@@ -241,6 +403,11 @@ func generatePopToIdentifier(decl *Declaration, pos Position, ob *OpCodeBuf) {
 	ob.generateCode(pos, code+offset, decl.variableIndex)
 }
 
+// 其他包的全局变量保存在定义它的包中
+func (ob *OpCodeBuf) isImported(decl *Declaration) bool {
+	return !decl.isLocal && decl.packageName != ob.compiler.getPackageName()
+}
+
 func generatePushArgument(argList []Expression, exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
 	for _, arg := range argList {
 		arg.generate(exe, currentBlock, ob)
@@ -255,7 +422,16 @@ func generateMethodCallExpression(expr *FunctionCallExpression, exe *vm.Executab
 
 	generatePushArgument(expr.argumentList, exe, block, ob)
 	member.expression.generate(exe, block, ob)
-	ob.generateCode(expr.Position(), vm.VM_PUSH_METHOD, methodIndex)
+
+	// super.method()不经过对象的虚表, 直接调用父类的实现
+	// 接口方法在各个类的虚表中位置不同, 需要通过接口表查找
+	if superExpr, ok := member.expression.(*SuperExpression); ok {
+		ob.generateCode(expr.Position(), vm.VM_SUPER, superExpr.classIndex, methodIndex)
+	} else if member.memberDeclaration.(*MethodMember).functionDefinition.classDefinition.isInterface {
+		ob.generateCode(expr.Position(), vm.VM_PUSH_INTERFACE_METHOD, member.interfaceIndex, methodIndex)
+	} else {
+		ob.generateCode(expr.Position(), vm.VM_PUSH_METHOD, methodIndex)
+	}
 	ob.generateCode(expr.Position(), vm.VM_INVOKE)
 }
 
@@ -264,3 +440,17 @@ func getMethodIndex(member *MemberExpression) int {
 
 	return methodIndex
 }
+
+// byte和int32运算后按位数截断, int和long不需要
+func generateIntegerWrap(typ *TypeSpecifier, pos Position, ob *OpCodeBuf) {
+	if len(typ.deriveList) != 0 {
+		return
+	}
+
+	switch typ.basicType {
+	case vm.ByteType:
+		ob.generateCode(pos, vm.VM_CAST_INT_TO_BYTE)
+	case vm.Int32Type:
+		ob.generateCode(pos, vm.VM_CAST_INT_TO_INT32)
+	}
+}
@@ -0,0 +1,517 @@
+package compiler
+
+import (
+	"strings"
+
+	"github.com/lth-go/gogogogo/vm"
+)
+
+// ==============================
+// GenericDefinition
+// ==============================
+
+// GenericDefinition 泛型类或泛型函数, eg, class List<T> {...}, T max<T>(T a, T b) {...}
+// 定义时只保存token, 使用时按类型参数重新解析, 每次实例化得到独立的类或函数
+type GenericDefinition struct {
+	PosImpl
+
+	name              string
+	typeParameterList []string
+
+	// 定义的token, 从修饰符或返回类型到结束的大括号
+	tokenList []Token
+
+	isClass bool
+	// 泛型函数的定义, 只用于根据实参推导类型参数, 不参与修正和生成
+	functionDefinition *FunctionDefinition
+
+	// 定义泛型的compiler, 泛型类的实例属于这个包
+	compiler *Compiler
+}
+
+func (g *GenericDefinition) searchTypeParameter(name string) int {
+	for i, typeParameter := range g.typeParameterList {
+		if typeParameter == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// 泛型的一次实例化, eg, List<int>
+type genericInstance struct {
+	generic          *GenericDefinition
+	name             string
+	typeArgumentList []*TypeSpecifier
+
+	// 第一次使用的位置, 实例化过程中的错误在这里报告
+	pos Position
+	// 生成实例代码的compiler, 其他包使用同一个实例
+	compiler *Compiler
+	// 在其他实例中使用时的嵌套层数
+	depth int
+
+	classDefinition    *ClassDefinition
+	functionDefinition *FunctionDefinition
+}
+
+// 实例化的最大嵌套层数, eg, class L<T> { L<L<T>> next; }会无限地实例化
+const maxInstantiationDepth = 32
+
+// 实例名, eg, List<int>, Map<string,int[]>
+func getInstanceName(name string, typeArgumentList []*TypeSpecifier) string {
+	nameList := []string{}
+	for _, typ := range typeArgumentList {
+		nameList = append(nameList, getTypeName(typ))
+	}
+	return name + "<" + strings.Join(nameList, ",") + ">"
+}
+
+// private的泛型函数只能在包内使用
+func (g *GenericDefinition) isPrivate() bool {
+	return g.functionDefinition != nil && g.functionDefinition.isPrivate
+}
+
+// 在当前compiler及导入的compiler中查找泛型, 实例在使用的compiler中生成
+func (c *Compiler) searchGeneric(name string) *GenericDefinition {
+	for _, g := range c.genericList {
+		if g.name == name {
+			return g
+		}
+	}
+
+	for _, required := range c.requiredList {
+		for _, g := range required.genericList {
+			if g.name == name && !g.isPrivate() {
+				return g
+			}
+		}
+	}
+
+	if owner := c.getInstanceOwner(); owner != nil {
+		for _, g := range owner.genericList {
+			if g.name == name {
+				return g
+			}
+		}
+	}
+	return nil
+}
+
+// 正在修正的泛型实例定义在其他包中时, 返回定义泛型的compiler
+// 实例中的代码按定义泛型的包查找名字, 可以使用该包的private成员
+func (c *Compiler) getInstanceOwner() *Compiler {
+	if len(c.instanceStack) == 0 {
+		return nil
+	}
+	owner := c.instanceStack[len(c.instanceStack)-1].generic.compiler
+	if owner == c {
+		return nil
+	}
+	return owner
+}
+
+// ==============================
+// 语法解析
+// ==============================
+
+// 类型名, 解析泛型实例时类型参数替换为实际的类型
+func (c *Compiler) createNamedTypeSpecifier(identifier string, pos Position) *TypeSpecifier {
+	if instance := c.currentInstance; instance != nil {
+		if index := instance.generic.searchTypeParameter(identifier); index >= 0 {
+			typ := cloneTypeSpecifier(instance.typeArgumentList[index])
+			typ.SetPosition(pos)
+			return typ
+		}
+	}
+	return createClassTypeSpecifier(identifier, pos)
+}
+
+// 泛型类的类型, 修正时实例化
+func createGenericTypeSpecifier(identifier string, typeArgumentList []*TypeSpecifier, pos Position) *TypeSpecifier {
+	// 内置的map类型
+	if identifier == "map" && len(typeArgumentList) == 2 {
+		typ := createMapTypeSpecifier(typeArgumentList[0], typeArgumentList[1])
+		typ.SetPosition(pos)
+		return typ
+	}
+
+	typ := createClassTypeSpecifier(identifier, pos)
+	typ.typeArgumentList = typeArgumentList
+
+	return typ
+}
+
+func (c *Compiler) genericFunctionDefine(typ *TypeSpecifier, identifier string, typeParameterList []string, parameterList []*Parameter, block *Block) *FunctionDefinition {
+	// 实例化时按实例名定义
+	if instance := c.currentInstance; instance != nil {
+		fd := c.functionDefine(typ, instance.name, parameterList, block)
+		instance.functionDefinition = fd
+		return fd
+	}
+
+	if c.searchFunction(identifier) != nil || c.searchGeneric(identifier) != nil {
+		compileError(typ.Position(), FUNCTION_MULTIPLE_DEFINE_ERR, identifier)
+	}
+
+	fd := &FunctionDefinition{
+		typeSpecifier:   typ,
+		name:            identifier,
+		packageNameList: c.packageNameList,
+		parameterList:   parameterList,
+		block:           block,
+	}
+
+	g := &GenericDefinition{
+		name:               identifier,
+		typeParameterList:  typeParameterList,
+		tokenList:          c.lexer.copyDefinitionTokens(typ.Position()),
+		functionDefinition: fd,
+		compiler:           c,
+	}
+	g.SetPosition(typ.Position())
+
+	c.genericList = append(c.genericList, g)
+
+	return fd
+}
+
+func (c *Compiler) startGenericClassDefine(modifier *ClassOrMemberModifierList, isInterface bool, identifier string, typeParameterList []string, extends []*Extend, pos Position) {
+	if instance := c.currentInstance; instance != nil {
+		c.startClassDefine(modifier, isInterface, instance.name, extends, pos)
+		return
+	}
+
+	if c.searchGeneric(identifier) != nil {
+		compileError(pos, CLASS_MULTIPLE_DEFINE_ERR, identifier)
+	}
+
+	c.startClassDefine(modifier, isInterface, identifier, extends, pos)
+
+	g := &GenericDefinition{
+		name:              identifier,
+		typeParameterList: typeParameterList,
+		isClass:           true,
+		compiler:          c,
+	}
+	// 定义从修饰符开始
+	if modifier != nil {
+		g.SetPosition(modifier.Position())
+	} else {
+		g.SetPosition(pos)
+	}
+
+	c.currentGeneric = g
+}
+
+func (c *Compiler) endGenericClassDefine(memberList []MemberDeclaration) {
+	if instance := c.currentInstance; instance != nil {
+		instance.classDefinition = c.currentClassDefinition
+		c.endClassDefine(memberList)
+		return
+	}
+
+	g := c.currentGeneric
+	g.tokenList = c.lexer.copyDefinitionTokens(g.Position())
+
+	c.genericList = append(c.genericList, g)
+
+	// 模板本身不是类
+	c.currentGeneric = nil
+	c.currentClassDefinition = nil
+}
+
+func createGenericExtendList(typ *TypeSpecifier) []*Extend {
+	extend := &Extend{
+		identifier:    typ.classRef.identifier,
+		typeSpecifier: typ,
+	}
+
+	return []*Extend{extend}
+}
+
+func chainGenericExtendList(list []*Extend, add *TypeSpecifier) []*Extend {
+	return append(list, createGenericExtendList(add)...)
+}
+
+func createGenericNewExpression(typ *TypeSpecifier, argumentList []Expression, pos Position) *NewExpression {
+	expr := createNewExpression([]string{typ.classRef.identifier}, argumentList, pos)
+	expr.genericType = typ
+
+	return expr
+}
+
+// ==============================
+// 实例化
+// ==============================
+
+// 按类型参数重新解析泛型定义
+func (c *Compiler) parseInstance(g *GenericDefinition, typeArgumentList []*TypeSpecifier, pos Position) *genericInstance {
+	instance := &genericInstance{
+		generic:          g,
+		name:             getInstanceName(g.name, typeArgumentList),
+		typeArgumentList: typeArgumentList,
+		pos:              pos,
+		compiler:         c,
+	}
+
+	if len(c.instanceStack) > 0 {
+		instance.depth = c.instanceStack[len(c.instanceStack)-1].depth + 1
+	}
+	if instance.depth > maxInstantiationDepth {
+		compileError(pos, GENERIC_INSTANTIATION_DEPTH_ERR, instance.name, maxInstantiationDepth)
+	}
+
+	// 修正过程中的当前类不影响解析
+	currentClassDefinition := c.currentClassDefinition
+	c.currentClassDefinition = nil
+	c.currentInstance = instance
+	// 泛型类的实例属于定义泛型的包, 在哪个包中实例化都是同一个类
+	packageNameList := c.packageNameList
+	if g.isClass {
+		c.packageNameList = g.compiler.packageNameList
+	}
+	defer func() {
+		c.currentInstance = nil
+		c.currentClassDefinition = currentClassDefinition
+		c.packageNameList = packageNameList
+	}()
+
+	lexer := newLexerByTokenList(g.tokenList)
+	lexer.compiler = c
+
+	// 定义已经解析过, 替换类型参数后出现的语法错误在使用的位置报告
+	if yyParse(lexer) != 0 {
+		e := lexer.e
+		compileError(pos, GENERIC_INSTANTIATION_ERR, instance.name, e.Pos.Line, e.Message)
+	}
+
+	return instance
+}
+
+// 修正类型参数并检查数量
+func (c *Compiler) fixTypeArgumentList(g *GenericDefinition, typeArgumentList []*TypeSpecifier, pos Position) {
+	for _, typ := range typeArgumentList {
+		typ.fix(c)
+	}
+
+	if len(typeArgumentList) != len(g.typeParameterList) {
+		compileError(pos, GENERIC_TYPE_ARGUMENT_COUNT_ERR, g.name, len(g.typeParameterList))
+	}
+}
+
+// 泛型类在使用时实例化, 相同类型参数的实例只有一个
+func (c *Compiler) instantiateClass(typ *TypeSpecifier) *ClassDefinition {
+	g := c.searchGeneric(typ.classRef.identifier)
+	if g == nil && typ.classRef.identifier == "map" {
+		compileError(typ.Position(), GENERIC_TYPE_ARGUMENT_COUNT_ERR, "map", 2)
+	}
+	if g == nil || !g.isClass {
+		compileError(typ.Position(), TYPE_NAME_NOT_FOUND_ERR, typ.classRef.identifier)
+	}
+
+	c.fixTypeArgumentList(g, typ.typeArgumentList, typ.Position())
+
+	name := getInstanceName(g.name, typ.typeArgumentList)
+	for _, instance := range c.ctx.instanceList {
+		if instance.generic == g && instance.name == name {
+			return instance.classDefinition
+		}
+	}
+
+	instance := c.parseInstance(g, typ.typeArgumentList, typ.Position())
+
+	cd := instance.classDefinition
+	cd.instance = instance
+	c.ctx.instanceList = append(c.ctx.instanceList, instance)
+
+	// 普通类的继承关系确定之前使用的实例, 与普通类一起修正
+	if !c.classReady {
+		c.pendingInstanceList = append(c.pendingInstanceList, instance)
+		return cd
+	}
+
+	c.fixInstance(instance, func() {
+		c.fixClassDefinition(cd)
+	})
+
+	return cd
+}
+
+// 泛型函数在调用时实例化, 并立即修正
+func (c *Compiler) instantiateFunction(g *GenericDefinition, typeArgumentList []*TypeSpecifier, pos Position) *FunctionDefinition {
+	name := getInstanceName(g.name, typeArgumentList)
+	if fd := c.searchFunction(name); fd != nil {
+		return fd
+	}
+
+	instance := c.parseInstance(g, typeArgumentList, pos)
+
+	fd := instance.functionDefinition
+	fd.instance = instance
+
+	c.fixInstance(instance, func() {
+		for _, param := range fd.parameterList {
+			param.typeSpecifier.fix(c)
+		}
+		fd.fix(c)
+	})
+
+	return fd
+}
+
+// 修正泛型实例, 实例中的错误在最外层实例的使用位置报告
+func (c *Compiler) fixInstance(instance *genericInstance, f func()) {
+	currentClassDefinition := c.currentClassDefinition
+	c.currentClassDefinition = nil
+	c.instanceStack = append(c.instanceStack, instance)
+
+	c.catchCompileError(f)
+
+	c.instanceStack = c.instanceStack[:len(c.instanceStack)-1]
+	c.currentClassDefinition = currentClassDefinition
+}
+
+// 其他包中实例化的泛型类的方法
+func (ctx *compileContext) searchInstanceMethod(packageName string, vmFuncName string) *FunctionDefinition {
+	for _, instance := range ctx.instanceList {
+		for _, fd := range instance.compiler.funcList {
+			if fd.classDefinition != instance.classDefinition {
+				continue
+			}
+			if fd.getPackageName() == packageName && fd.getVmFuncName() == vmFuncName {
+				return fd
+			}
+		}
+	}
+	return nil
+}
+
+// 其他包中实例化的泛型类
+func (ctx *compileContext) searchInstanceClass(packageName string, name string) *ClassDefinition {
+	for _, instance := range ctx.instanceList {
+		cd := instance.classDefinition
+		if cd.getPackageName() == packageName && cd.name == name {
+			return cd
+		}
+	}
+	return nil
+}
+
+// ==============================
+// 类型推导
+// ==============================
+
+// 调用泛型函数时根据实参类型推导类型参数, eg, max(1, 2)实例化为max<int>
+func (expr *FunctionCallExpression) fixGenericCall(c *Compiler, currentBlock *Block, g *GenericDefinition) Expression {
+	identifierExpr := expr.function.(*IdentifierExpression)
+	parameterList := g.functionDefinition.parameterList
+
+	if len(expr.argumentList) != len(parameterList) {
+		compileError(expr.Position(), ARGUMENT_COUNT_MISMATCH_ERR, len(parameterList), len(expr.argumentList))
+	}
+
+	typeArgumentMap := map[string]*TypeSpecifier{}
+	for i, param := range parameterList {
+		expr.argumentList[i] = expr.argumentList[i].fix(c, currentBlock)
+		g.inferTypeArgument(param.typeSpecifier, expr.argumentList[i].typeS(), typeArgumentMap)
+	}
+
+	typeArgumentList := []*TypeSpecifier{}
+	for _, name := range g.typeParameterList {
+		typ, ok := typeArgumentMap[name]
+		if !ok {
+			compileError(expr.Position(), GENERIC_TYPE_INFERENCE_ERR, g.name, name)
+		}
+		typeArgumentList = append(typeArgumentList, typ)
+	}
+
+	fd := c.instantiateFunction(g, typeArgumentList, expr.Position())
+
+	identifierExpr.inner = &FunctionIdentifier{
+		functionDefinition: fd,
+		functionIndex:      c.addToVmFunctionList(fd),
+	}
+	identifierExpr.setType(createFunctionDeriveType(fd))
+	identifierExpr.typeS().fix(c)
+
+	// 实参已经修正, 只做类型转换
+	for i, param := range fd.parameterList {
+		expr.argumentList[i] = createAssignCast(expr.argumentList[i], param.typeSpecifier)
+	}
+
+	expr.setType(cloneTypeSpecifier(fd.typeS()))
+	expr.typeS().fix(c)
+
+	return expr
+}
+
+// 用实参类型匹配形参中的类型参数, 支持T, T[]和List<T>等形式
+func (g *GenericDefinition) inferTypeArgument(param, arg *TypeSpecifier, typeArgumentMap map[string]*TypeSpecifier) {
+	if arg.basicType == vm.NullType || len(param.deriveList) > len(arg.deriveList) {
+		return
+	}
+	for i, derive := range param.deriveList {
+		switch d := derive.(type) {
+		case *ArrayDerive:
+			if _, ok := arg.deriveList[i].(*ArrayDerive); !ok {
+				return
+			}
+		case *MapDerive:
+			argDerive, ok := arg.deriveList[i].(*MapDerive)
+			if !ok {
+				return
+			}
+			g.inferTypeArgument(d.keyType, argDerive.keyType, typeArgumentMap)
+		default:
+			return
+		}
+	}
+
+	if param.basicType != vm.ClassType {
+		return
+	}
+
+	// 类型参数, 第一次匹配的实参类型为准
+	if param.typeArgumentList == nil && g.searchTypeParameter(param.classRef.identifier) >= 0 {
+		if _, ok := typeArgumentMap[param.classRef.identifier]; ok {
+			return
+		}
+		typ := cloneTypeSpecifier(arg)
+		typ.deriveList = arg.deriveList[len(param.deriveList):]
+		typeArgumentMap[param.classRef.identifier] = typ
+		return
+	}
+
+	// 泛型类的实例, 逐个匹配类型参数
+	if param.typeArgumentList == nil || len(param.deriveList) != len(arg.deriveList) || arg.basicType != vm.ClassType {
+		return
+	}
+	instance := arg.classRef.classDefinition.instance
+	if instance == nil || instance.generic.name != param.classRef.identifier {
+		return
+	}
+	for i, typ := range param.typeArgumentList {
+		g.inferTypeArgument(typ, instance.typeArgumentList[i], typeArgumentMap)
+	}
+}
+
+// 调用的是泛型函数时返回其定义, 同名的变量和函数优先
+func (c *Compiler) searchGenericFunction(function Expression, currentBlock *Block) *GenericDefinition {
+	identifierExpr, ok := function.(*IdentifierExpression)
+	if !ok {
+		return nil
+	}
+
+	name := identifierExpr.name
+	if c.searchDeclaration(name, currentBlock) != nil || c.searchFunction(name) != nil {
+		return nil
+	}
+
+	g := c.searchGeneric(name)
+	if g == nil || g.isClass {
+		return nil
+	}
+
+	return g
+}
@@ -0,0 +1,87 @@
+package compiler
+
+// 默认包, 所有文件都会自动导入
+const defaultPackage = "gogogogo.lang"
+
+// 默认包没有对应的文件, 使用内置源码
+const defaultPackagePath = "<gogogogo.lang>"
+
+const defaultPackageSource = `
+class Exception {
+    string message;
+
+    Exception() {}
+
+    Exception(string message) {
+        this.message = message;
+    }
+
+    string getMessage() {
+        return this.message;
+    }
+}
+
+class RuntimeException : Exception {
+    RuntimeException() {}
+
+    RuntimeException(string message) {
+        super(message);
+    }
+}
+
+class NullPointerException : RuntimeException {
+    NullPointerException() {}
+
+    NullPointerException(string message) {
+        super(message);
+    }
+}
+
+class ArrayIndexOutOfBoundsException : RuntimeException {
+    ArrayIndexOutOfBoundsException() {}
+
+    ArrayIndexOutOfBoundsException(string message) {
+        super(message);
+    }
+}
+
+class DivisionByZeroException : RuntimeException {
+    DivisionByZeroException() {}
+
+    DivisionByZeroException(string message) {
+        super(message);
+    }
+}
+
+class ClassCastException : RuntimeException {
+    ClassCastException() {}
+
+    ClassCastException(string message) {
+        super(message);
+    }
+}
+
+class IllegalArgumentException : RuntimeException {
+    IllegalArgumentException() {}
+
+    IllegalArgumentException(string message) {
+        super(message);
+    }
+}
+
+class NumberFormatException : IllegalArgumentException {
+    NumberFormatException() {}
+
+    NumberFormatException(string message) {
+        super(message);
+    }
+}
+
+class IOException : Exception {
+    IOException() {}
+
+    IOException(string message) {
+        super(message);
+    }
+}
+`
@@ -2,6 +2,8 @@ package compiler
 
 import (
 	"fmt"
+
+	"github.com/lth-go/gogogogo/vm"
 )
 
 // ==============================
@@ -13,6 +15,8 @@ type Token struct {
 	PosImpl // StmtImpl provide Pos() function.
 	Tok     int
 	Lit     string
+	// 字符字面量没有结束
+	unterminated bool
 }
 
 // ==============================
@@ -30,8 +34,7 @@ type Error struct {
 
 // Error returns the error message.
 func (e *Error) Error() string {
-	msg := fmt.Sprintf("\nLine: %d\nMessage:%s\n", e.Pos.Line, e.Message)
-	return msg
+	return fmt.Sprintf("%s:%d:%d: %s", e.Filename, e.Pos.Line, e.Pos.Column, e.Message)
 }
 
 // ==============================
@@ -43,27 +46,170 @@ type Lexer struct {
 	s        *Scanner
 	lit      string
 	pos      Position
-	e        error
+	e        *Error
 	compiler *Compiler
+
+	// 预读的token, 用于区分类型参数的<>和比较运算符
+	aheadList []scannedToken
+	// 已读取的token, 泛型定义在实例化时重新解析
+	tokenList []Token
+	// 第一个没有结束的字符字面量, 通常是语法错误的原因
+	unterminated *Token
+}
+
+type scannedToken struct {
+	token Token
+	err   error
 }
 
-func newLexerByFilePath(path string) *Lexer {
+func newLexerByFilePath(path string) (*Lexer, error) {
+	s, err := newScannerByFilePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Lexer{s: s}, nil
+}
+
+func newLexerBySource(source string) *Lexer {
 	return &Lexer{
-		s: newScannerByFilePath(path),
+		s: newScannerBySource(source),
+	}
+}
+
+// 重新解析已读取的token, 不再扫描源码
+func newLexerByTokenList(tokenList []Token) *Lexer {
+	l := &Lexer{}
+	for _, token := range tokenList {
+		l.aheadList = append(l.aheadList, scannedToken{token: token})
 	}
+	return l
 }
 
 // Lex scans the token and literals.
 func (l *Lexer) Lex(lval *yySymType) int {
-	tok, lit, pos, err := l.s.Scan()
-	if err != nil {
-		l.e = &Error{Message: fmt.Sprintf("%s", err.Error()), Pos: pos, Fatal: true}
+	st := l.next()
+	if st.err != nil && l.e == nil {
+		l.e = &Error{Message: st.err.Error(), Pos: st.token.Position(), Filename: l.compiler.path, Fatal: true}
+	}
+	if st.token.Tok == IDENTIFIER {
+		l.markTypeArgument()
 	}
-	lval.tok = Token{Tok: tok, Lit: lit}
-	lval.tok.SetPosition(pos)
-	l.lit = lit
-	l.pos = pos
-	return tok
+	if st.token.unterminated && l.unterminated == nil {
+		l.unterminated = &st.token
+	}
+	l.tokenList = append(l.tokenList, st.token)
+
+	lval.tok = st.token
+	l.lit = st.token.Lit
+	l.pos = st.token.Position()
+	return st.token.Tok
+}
+
+// 预读第i个token
+func (l *Lexer) peek(i int) *scannedToken {
+	for len(l.aheadList) <= i {
+		st := scannedToken{}
+		if l.s == nil {
+			st.token = Token{Tok: EOF}
+		} else {
+			tok, lit, pos, err := l.s.Scan()
+			st.token = Token{Tok: tok, Lit: lit}
+			st.token.SetPosition(pos)
+			if err == errUnterminatedChar {
+				st.token.unterminated = true
+				err = nil
+			}
+			st.err = err
+		}
+		l.aheadList = append(l.aheadList, st)
+	}
+	return &l.aheadList[i]
+}
+
+func (l *Lexer) next() scannedToken {
+	st := *l.peek(0)
+	l.aheadList = l.aheadList[1:]
+	return st
+}
+
+// 标识符之后的<...>能作为类型参数时, 将<替换为TYPE_LT, eg, List<int> list
+// 嵌套的类型参数结尾的>>拆分为两个>
+func (l *Lexer) markTypeArgument() {
+	if l.peek(0).token.Tok != LT {
+		return
+	}
+
+	depth := 0
+	end := 0
+	for i := 0; end == 0; i++ {
+		st := l.peek(i)
+		if st.err != nil {
+			return
+		}
+		switch st.token.Tok {
+		case LT:
+			depth++
+		case GT:
+			depth--
+		case RIGHT_SHIFT:
+			depth -= 2
+		case IDENTIFIER, DOT, COMMA, LB, RB, LP, RP,
+			VOID_T, BOOLEAN_T, INT_T, DOUBLE_T, CHAR_T, STRING_T, BYTE_T, INT32_T, LONG_T:
+		default:
+			return
+		}
+		if depth < 0 {
+			return
+		}
+		if depth == 0 {
+			end = i + 1
+		}
+	}
+
+	switch l.peek(end).token.Tok {
+	case IDENTIFIER, LP, RP, LB, LC, COLON, COMMA, GT, SEMICOLON, LOGICAL_AND, LOGICAL_OR:
+	default:
+		return
+	}
+
+	l.aheadList[0].token.Tok = TYPE_LT
+
+	for i := 1; i < end; i++ {
+		st := l.aheadList[i]
+		if st.token.Tok != RIGHT_SHIFT {
+			continue
+		}
+		first := Token{Tok: GT, Lit: ">"}
+		first.SetPosition(st.token.Position())
+		second := Token{Tok: GT, Lit: ">"}
+		second.SetPosition(Position{Line: st.token.Position().Line, Column: st.token.Position().Column + 1})
+
+		rest := append([]scannedToken{{token: second}}, l.aheadList[i+1:]...)
+		l.aheadList = append(append(l.aheadList[:i], scannedToken{token: first}), rest...)
+		end++
+	}
+}
+
+// 复制从pos开始到第一个{对应的}为止的token
+func (l *Lexer) copyDefinitionTokens(pos Position) []Token {
+	start := 0
+	for start < len(l.tokenList) && l.tokenList[start].Position() != pos {
+		start++
+	}
+
+	depth := 0
+	for i := start; i < len(l.tokenList); i++ {
+		switch l.tokenList[i].Tok {
+		case LC:
+			depth++
+		case RC:
+			depth--
+			if depth == 0 {
+				return append([]Token{}, l.tokenList[start:i+1]...)
+			}
+		}
+	}
+	panic("TODO")
 }
 
 func (l *Lexer) show() {
@@ -80,8 +226,30 @@ func (l *Lexer) show() {
 	}
 }
 
+func init() {
+	// 输出yacc错误信息
+	// 只在初始化时设置, 多个compiler并发解析时只读取
+	yyErrorVerbose = true
+}
+
 // Error sets parse error.
 // parse的错误
 func (l *Lexer) Error(msg string) {
-	l.e = &Error{Message: msg, Pos: l.pos, Fatal: false}
+	// 保留词法错误
+	if l.e != nil {
+		return
+	}
+
+	if l.unterminated != nil {
+		message := errMessageList[CHAR_LITERAL_UNTERMINATED_ERR]
+		l.e = &Error{Message: message, Pos: l.unterminated.Position(), Filename: l.compiler.path, Fatal: true}
+		return
+	}
+
+	message := vm.FormatMessage(errMessageList[PARSE_ERR], l.lit)
+	if yyErrorVerbose {
+		message = fmt.Sprintf("%s: %s", message, msg)
+	}
+
+	l.e = &Error{Message: message, Pos: l.pos, Filename: l.compiler.path, Fatal: true}
 }
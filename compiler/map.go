@@ -0,0 +1,194 @@
+package compiler
+
+import (
+	"github.com/lth-go/gogogogo/vm"
+)
+
+// ==============================
+// MapLiteralExpression
+// ==============================
+
+// MapLiteralExpression map字面量, eg, {"a": 1, "b": 2}
+// 键和值的类型由第一项决定, 其余的项转换为相同的类型
+type MapLiteralExpression struct {
+	ExpressionImpl
+
+	keyList   []Expression
+	valueList []Expression
+}
+
+func (expr *MapLiteralExpression) show(indent int) {
+	printWithIndent("MapLiteralExpr", indent)
+
+	subIndent := indent + 2
+	for i, key := range expr.keyList {
+		key.show(subIndent)
+		expr.valueList[i].show(subIndent)
+	}
+}
+
+func (expr *MapLiteralExpression) fix(c *Compiler, currentBlock *Block) Expression {
+	fixed := 0
+
+	// new map<K, V>()创建的空map已经确定类型
+	if expr.typeS() == nil {
+		expr.keyList[0] = expr.keyList[0].fix(c, currentBlock)
+		expr.valueList[0] = expr.valueList[0].fix(c, currentBlock)
+
+		expr.setType(createMapTypeSpecifier(
+			cloneTypeSpecifier(expr.keyList[0].typeS()),
+			cloneTypeSpecifier(expr.valueList[0].typeS()),
+		))
+		expr.typeS().SetPosition(expr.Position())
+		fixed = 1
+	}
+	expr.typeS().fix(c)
+
+	keyType := expr.typeS().deriveList[0].(*MapDerive).keyType
+	valueType := cloneTypeSpecifier(expr.typeS())
+	valueType.deriveList = expr.typeS().deriveList[1:]
+
+	for i := range expr.keyList {
+		if i >= fixed {
+			expr.keyList[i] = expr.keyList[i].fix(c, currentBlock)
+			expr.valueList[i] = expr.valueList[i].fix(c, currentBlock)
+		}
+		expr.keyList[i] = createAssignCast(expr.keyList[i], keyType)
+		expr.valueList[i] = createAssignCast(expr.valueList[i], valueType)
+	}
+
+	return expr
+}
+
+// 键和值交替压栈, 由VM_NEW_MAP_LITERAL创建map
+func (expr *MapLiteralExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	for i, key := range expr.keyList {
+		key.generate(exe, currentBlock, ob)
+		expr.valueList[i].generate(exe, currentBlock, ob)
+	}
+
+	ob.generateCode(expr.Position(), vm.VM_NEW_MAP_LITERAL, len(expr.keyList))
+}
+
+// 语法解析时键和值交替排列
+func createMapLiteralExpression(entryList []Expression, pos Position) *MapLiteralExpression {
+	expr := &MapLiteralExpression{}
+	for i := 0; i < len(entryList); i += 2 {
+		expr.keyList = append(expr.keyList, entryList[i])
+		expr.valueList = append(expr.valueList, entryList[i+1])
+	}
+	expr.SetPosition(pos)
+
+	return expr
+}
+
+// new map<K, V>(), 创建空的map
+func fixNewMapExpression(c *Compiler, expr *NewExpression) Expression {
+	if len(expr.argumentList) != 0 {
+		compileError(expr.Position(), ARGUMENT_COUNT_MISMATCH_ERR, 0, len(expr.argumentList))
+	}
+
+	newExpr := createEmptyMapLiteral(expr.genericType, expr.Position())
+	newExpr.typeS().fix(c)
+
+	return newExpr
+}
+
+// 空的map字面量, 类型由new或赋值的目标类型决定
+func createEmptyMapLiteral(typ *TypeSpecifier, pos Position) *MapLiteralExpression {
+	expr := &MapLiteralExpression{}
+	expr.SetPosition(pos)
+	expr.setType(typ)
+
+	return expr
+}
+
+// ==============================
+// MapMethodExpression
+// ==============================
+
+// MapMethodExpression map的内置方法, 只能直接调用
+// eg, m.size(), m.contains(k), m.delete(k), m.keys(), m.values()
+type MapMethodExpression struct {
+	ExpressionImpl
+
+	expression Expression
+	methodName string
+}
+
+func (expr *MapMethodExpression) show(indent int) {
+	printWithIndent("MapMethodExpr: "+expr.methodName, indent)
+
+	subIndent := indent + 2
+	expr.expression.show(subIndent)
+}
+
+func (expr *MapMethodExpression) fix(c *Compiler, currentBlock *Block) Expression {
+	return expr
+}
+
+func (expr *MapMethodExpression) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	compileError(expr.Position(), METHOD_IS_NOT_CALLED_ERR, expr.methodName)
+}
+
+// 依次压入map和参数
+func (expr *MapMethodExpression) generateCall(exe *vm.Executable, currentBlock *Block, argumentList []Expression, ob *OpCodeBuf) {
+	expr.expression.generate(exe, currentBlock, ob)
+	generatePushArgument(argumentList, exe, currentBlock, ob)
+
+	keyType, valueType := expr.getKeyValueType()
+
+	switch expr.methodName {
+	case "size":
+		ob.generateCode(expr.Position(), vm.VM_MAP_SIZE)
+	case "contains":
+		ob.generateCode(expr.Position(), vm.VM_MAP_CONTAINS)
+	case "delete":
+		ob.generateCode(expr.Position(), vm.VM_MAP_DELETE)
+	case "keys":
+		ob.generateCode(expr.Position(), vm.VM_MAP_KEYS_INT+getOpcodeTypeOffset(keyType))
+	case "values":
+		ob.generateCode(expr.Position(), vm.VM_MAP_VALUES_INT+getOpcodeTypeOffset(valueType))
+	default:
+		panic("TODO")
+	}
+}
+
+func (expr *MapMethodExpression) getKeyValueType() (*TypeSpecifier, *TypeSpecifier) {
+	mapType := expr.expression.typeS()
+
+	keyType := mapType.deriveList[0].(*MapDerive).keyType
+	valueType := cloneTypeSpecifier(mapType)
+	valueType.deriveList = mapType.deriveList[1:]
+
+	return keyType, valueType
+}
+
+// 方法的类型为函数类型, 调用时按函数类型检查参数
+func createMapMethodExpression(member *MemberExpression) *MapMethodExpression {
+	expr := &MapMethodExpression{
+		expression: member.expression,
+		methodName: member.memberName,
+	}
+	expr.SetPosition(member.Position())
+
+	pos := member.Position()
+	keyType, valueType := expr.getKeyValueType()
+
+	var typ *TypeSpecifier
+	switch expr.methodName {
+	case "size":
+		typ = createFunctionTypeSpecifier(createTypeSpecifier(vm.IntType, pos), nil)
+	case "contains", "delete":
+		typ = createFunctionTypeSpecifier(createTypeSpecifier(vm.BooleanType, pos), []*TypeSpecifier{keyType})
+	case "keys":
+		typ = createFunctionTypeSpecifier(createArrayTypeSpecifier(cloneTypeSpecifier(keyType)), nil)
+	case "values":
+		typ = createFunctionTypeSpecifier(createArrayTypeSpecifier(valueType), nil)
+	default:
+		compileError(pos, MAP_METHOD_NOT_FOUND_ERR, expr.methodName)
+	}
+	expr.setType(typ)
+
+	return expr
+}
@@ -0,0 +1,70 @@
+package compiler
+
+import (
+	"strings"
+
+	"github.com/lth-go/gogogogo/vm"
+)
+
+// ==============================
+// 原生函数
+// ==============================
+
+// 只有原生函数的包没有源文件
+const nativePackageSource = ""
+
+func nativePackagePath(packageName string) string {
+	return "<native " + packageName + ">"
+}
+
+// 查找虚拟机中注册的原生函数, 根据签名创建没有函数体的函数定义
+// 同一次编译中的函数定义只创建一次
+func (c *Compiler) searchNativeFunction(packageName string, name string) *FunctionDefinition {
+	for _, fd := range c.ctx.nativeFunctionList {
+		if fd.getPackageName() == packageName && fd.name == name {
+			return fd
+		}
+	}
+
+	signature := vm.SearchNative(packageName, name)
+	if signature == nil {
+		return nil
+	}
+
+	fd := createNativeFunctionDefinition(signature)
+	c.ctx.nativeFunctionList = append(c.ctx.nativeFunctionList, fd)
+
+	return fd
+}
+
+func createNativeFunctionDefinition(signature *vm.Function) *FunctionDefinition {
+	fd := &FunctionDefinition{
+		typeSpecifier: createNativeTypeSpecifier(signature.TypeSpecifier),
+		name:          signature.Name,
+		parameterList: []*Parameter{},
+		isNative:      true,
+	}
+
+	if signature.PackageName != "" {
+		fd.packageNameList = strings.Split(signature.PackageName, ".")
+	}
+
+	for _, param := range signature.ParameterList {
+		fd.parameterList = append(fd.parameterList, &Parameter{
+			name:          param.Name,
+			typeSpecifier: createNativeTypeSpecifier(param.TypeSpecifier),
+		})
+	}
+
+	return fd
+}
+
+func createNativeTypeSpecifier(src *vm.TypeSpecifier) *TypeSpecifier {
+	typ := &TypeSpecifier{basicType: src.BasicType}
+
+	for range src.DeriveList {
+		typ.deriveList = append(typ.deriveList, &ArrayDerive{})
+	}
+
+	return typ
+}
@@ -1,15 +1,17 @@
+// Code generated by goyacc -o parser.go parser.go.y. DO NOT EDIT.
+
 //line parser.go.y:2
 package compiler
 
 import __yyfmt__ "fmt"
 
 //line parser.go.y:2
+
 import (
 	"github.com/lth-go/gogogogo/vm"
-	"strconv"
 )
 
-//line parser.go.y:10
+//line parser.go.y:9
 type yySymType struct {
 	yys            int
 	parameter_list []*Parameter
@@ -26,6 +28,8 @@ type yySymType struct {
 
 	basic_type_specifier *TypeSpecifier
 	type_specifier       *TypeSpecifier
+	type_specifier_list  []*TypeSpecifier
+	identifier_list      []string
 
 	array_dimension      *ArrayDimension
 	array_dimension_list []*ArrayDimension
@@ -37,7 +41,16 @@ type yySymType struct {
 	member_declaration  []MemberDeclaration
 	function_definition *FunctionDefinition
 
-	class_name []string
+	class_name    []string
+	modifier_list *ClassOrMemberModifierList
+
+	catch_clause *CatchClause
+	catch_list   []*CatchClause
+
+	case_clause *CaseClause
+	case_list   []*CaseClause
+
+	assignment_operator AssignmentOperatorKind
 
 	tok Token
 }
@@ -46,49 +59,95 @@ const IF = 57346
 const ELSE = 57347
 const ELIF = 57348
 const FOR = 57349
-const RETURN_T = 57350
-const BREAK = 57351
-const CONTINUE = 57352
-const LP = 57353
-const RP = 57354
-const LC = 57355
-const RC = 57356
-const LB = 57357
-const RB = 57358
-const SEMICOLON = 57359
-const COMMA = 57360
-const COLON = 57361
-const ASSIGN_T = 57362
-const LOGICAL_AND = 57363
-const LOGICAL_OR = 57364
-const EQ = 57365
-const NE = 57366
-const GT = 57367
-const GE = 57368
-const LT = 57369
-const LE = 57370
-const ADD = 57371
-const SUB = 57372
-const MUL = 57373
-const DIV = 57374
-const INT_LITERAL = 57375
-const DOUBLE_LITERAL = 57376
-const STRING_LITERAL = 57377
-const TRUE_T = 57378
-const FALSE_T = 57379
-const NULL_T = 57380
-const IDENTIFIER = 57381
-const EXCLAMATION = 57382
-const DOT = 57383
-const VOID_T = 57384
-const BOOLEAN_T = 57385
-const INT_T = 57386
-const DOUBLE_T = 57387
-const STRING_T = 57388
-const NEW = 57389
-const REQUIRE = 57390
-const CLASS_T = 57391
-const THIS_T = 57392
+const WHILE = 57350
+const DO_T = 57351
+const SWITCH = 57352
+const CASE = 57353
+const DEFAULT_T = 57354
+const RETURN_T = 57355
+const BREAK = 57356
+const CONTINUE = 57357
+const LP = 57358
+const RP = 57359
+const LC = 57360
+const RC = 57361
+const LB = 57362
+const RB = 57363
+const SEMICOLON = 57364
+const COMMA = 57365
+const COLON = 57366
+const ASSIGN_T = 57367
+const ADD_ASSIGN_T = 57368
+const SUB_ASSIGN_T = 57369
+const MUL_ASSIGN_T = 57370
+const DIV_ASSIGN_T = 57371
+const MOD_ASSIGN_T = 57372
+const BIT_AND_ASSIGN_T = 57373
+const BIT_OR_ASSIGN_T = 57374
+const BIT_XOR_ASSIGN_T = 57375
+const LEFT_SHIFT_ASSIGN_T = 57376
+const RIGHT_SHIFT_ASSIGN_T = 57377
+const LOGICAL_AND = 57378
+const LOGICAL_OR = 57379
+const EQ = 57380
+const NE = 57381
+const GT = 57382
+const GE = 57383
+const LT = 57384
+const LE = 57385
+const ADD = 57386
+const SUB = 57387
+const MUL = 57388
+const DIV = 57389
+const MOD = 57390
+const BIT_AND = 57391
+const BIT_OR = 57392
+const BIT_XOR = 57393
+const BIT_NOT = 57394
+const LEFT_SHIFT = 57395
+const RIGHT_SHIFT = 57396
+const INCREMENT = 57397
+const DECREMENT = 57398
+const INT_LITERAL = 57399
+const DOUBLE_LITERAL = 57400
+const CHAR_LITERAL = 57401
+const STRING_LITERAL = 57402
+const TRUE_T = 57403
+const FALSE_T = 57404
+const NULL_T = 57405
+const IDENTIFIER = 57406
+const EXCLAMATION = 57407
+const DOT = 57408
+const VOID_T = 57409
+const BOOLEAN_T = 57410
+const INT_T = 57411
+const DOUBLE_T = 57412
+const CHAR_T = 57413
+const STRING_T = 57414
+const BYTE_T = 57415
+const INT32_T = 57416
+const LONG_T = 57417
+const NEW = 57418
+const REQUIRE = 57419
+const CLASS_T = 57420
+const INTERFACE_T = 57421
+const THIS_T = 57422
+const SUPER_T = 57423
+const INSTANCEOF = 57424
+const ABSTRACT_T = 57425
+const VIRTUAL_T = 57426
+const OVERRIDE_T = 57427
+const PUBLIC_T = 57428
+const PRIVATE_T = 57429
+const PROTECTED_T = 57430
+const STATIC_T = 57431
+const TRY = 57432
+const CATCH = 57433
+const FINALLY = 57434
+const THROW = 57435
+const ARROW = 57436
+const TYPE_LT = 57437
+const PAREN_EXPRESSION = 57438
 
 var yyToknames = [...]string{
 	"$end",
@@ -98,6 +157,11 @@ var yyToknames = [...]string{
 	"ELSE",
 	"ELIF",
 	"FOR",
+	"WHILE",
+	"DO_T",
+	"SWITCH",
+	"CASE",
+	"DEFAULT_T",
 	"RETURN_T",
 	"BREAK",
 	"CONTINUE",
@@ -111,6 +175,16 @@ var yyToknames = [...]string{
 	"COMMA",
 	"COLON",
 	"ASSIGN_T",
+	"ADD_ASSIGN_T",
+	"SUB_ASSIGN_T",
+	"MUL_ASSIGN_T",
+	"DIV_ASSIGN_T",
+	"MOD_ASSIGN_T",
+	"BIT_AND_ASSIGN_T",
+	"BIT_OR_ASSIGN_T",
+	"BIT_XOR_ASSIGN_T",
+	"LEFT_SHIFT_ASSIGN_T",
+	"RIGHT_SHIFT_ASSIGN_T",
 	"LOGICAL_AND",
 	"LOGICAL_OR",
 	"EQ",
@@ -123,8 +197,18 @@ var yyToknames = [...]string{
 	"SUB",
 	"MUL",
 	"DIV",
+	"MOD",
+	"BIT_AND",
+	"BIT_OR",
+	"BIT_XOR",
+	"BIT_NOT",
+	"LEFT_SHIFT",
+	"RIGHT_SHIFT",
+	"INCREMENT",
+	"DECREMENT",
 	"INT_LITERAL",
 	"DOUBLE_LITERAL",
+	"CHAR_LITERAL",
 	"STRING_LITERAL",
 	"TRUE_T",
 	"FALSE_T",
@@ -136,227 +220,452 @@ var yyToknames = [...]string{
 	"BOOLEAN_T",
 	"INT_T",
 	"DOUBLE_T",
+	"CHAR_T",
 	"STRING_T",
+	"BYTE_T",
+	"INT32_T",
+	"LONG_T",
 	"NEW",
 	"REQUIRE",
 	"CLASS_T",
+	"INTERFACE_T",
 	"THIS_T",
+	"SUPER_T",
+	"INSTANCEOF",
+	"ABSTRACT_T",
+	"VIRTUAL_T",
+	"OVERRIDE_T",
+	"PUBLIC_T",
+	"PRIVATE_T",
+	"PROTECTED_T",
+	"STATIC_T",
+	"TRY",
+	"CATCH",
+	"FINALLY",
+	"THROW",
+	"ARROW",
+	"TYPE_LT",
+	"PAREN_EXPRESSION",
 }
+
 var yyStatenames = [...]string{}
 
 const yyEofCode = 1
 const yyErrCode = 2
 const yyInitialStackSize = 16
 
-//line parser.go.y:709
+//line parser.go.y:1340
 
 //line yacctab:1
-var yyExca = [...]int{
+var yyExca = [...]int16{
 	-1, 1,
 	1, -1,
-	-2, 0,
-	-1, 33,
-	39, 18,
-	-2, 63,
-	-1, 88,
-	15, 18,
-	-2, 80,
-	-1, 152,
-	14, 122,
-	-2, 120,
+	-2, 235,
+	-1, 36,
+	64, 24,
+	-2, 116,
+	-1, 161,
+	64, 24,
+	-2, 116,
+	-1, 167,
+	20, 24,
+	-2, 145,
+	-1, 345,
+	19, 227,
+	-2, 225,
+	-1, 440,
+	19, 231,
+	-2, 229,
 }
 
 const yyPrivate = 57344
 
-const yyLast = 465
-
-var yyAct = [...]int{
-
-	112, 10, 71, 147, 12, 202, 168, 9, 133, 22,
-	132, 35, 122, 50, 54, 21, 36, 23, 53, 39,
-	24, 25, 26, 27, 40, 5, 51, 213, 68, 150,
-	72, 52, 28, 29, 30, 31, 32, 79, 102, 220,
-	19, 217, 214, 56, 208, 84, 41, 42, 43, 44,
-	45, 46, 33, 57, 179, 28, 29, 30, 31, 32,
-	49, 94, 103, 48, 167, 87, 154, 78, 100, 100,
-	146, 99, 101, 115, 109, 72, 121, 63, 88, 62,
-	118, 28, 29, 30, 31, 32, 126, 120, 100, 124,
-	86, 61, 100, 119, 100, 100, 127, 128, 135, 227,
-	125, 100, 100, 100, 100, 130, 149, 100, 100, 100,
-	100, 151, 144, 145, 142, 143, 79, 97, 98, 118,
-	80, 136, 137, 138, 139, 77, 150, 95, 96, 28,
-	29, 30, 31, 32, 76, 131, 82, 83, 108, 171,
-	124, 169, 172, 166, 169, 105, 78, 184, 106, 177,
-	64, 174, 89, 90, 91, 92, 185, 197, 104, 64,
-	191, 215, 188, 192, 105, 72, 190, 106, 113, 160,
-	218, 229, 193, 64, 194, 171, 198, 176, 200, 175,
-	162, 181, 64, 116, 206, 176, 164, 209, 64, 211,
-	163, 191, 210, 65, 64, 150, 162, 212, 28, 29,
-	30, 31, 32, 206, 161, 140, 64, 219, 113, 141,
-	23, 221, 233, 24, 25, 26, 27, 40, 222, 51,
-	72, 74, 73, 129, 216, 149, 228, 226, 230, 64,
-	232, 117, 223, 113, 111, 148, 56, 231, 224, 41,
-	42, 43, 44, 45, 46, 33, 57, 110, 28, 29,
-	30, 31, 32, 49, 23, 11, 48, 24, 25, 26,
-	27, 40, 150, 51, 195, 28, 29, 30, 31, 32,
-	113, 113, 225, 207, 199, 178, 180, 170, 134, 114,
-	56, 81, 75, 41, 42, 43, 44, 45, 46, 33,
-	57, 67, 28, 29, 30, 31, 32, 49, 66, 40,
-	48, 51, 70, 159, 196, 113, 152, 186, 187, 155,
-	157, 4, 183, 6, 182, 59, 58, 40, 56, 51,
-	173, 41, 42, 43, 44, 45, 46, 69, 57, 158,
-	8, 7, 2, 1, 205, 49, 56, 204, 48, 41,
-	42, 43, 44, 45, 46, 69, 57, 40, 165, 51,
-	203, 201, 107, 49, 153, 20, 48, 156, 189, 18,
-	17, 16, 15, 14, 13, 93, 56, 38, 47, 41,
-	42, 43, 44, 45, 46, 69, 57, 40, 123, 51,
-	37, 55, 34, 49, 3, 60, 48, 85, 0, 0,
-	0, 0, 0, 0, 0, 0, 56, 0, 0, 41,
-	42, 43, 44, 45, 46, 69, 57, 40, 0, 51,
-	0, 0, 117, 49, 0, 0, 48, 0, 0, 0,
-	0, 0, 0, 0, 0, 40, 56, 51, 0, 41,
-	42, 43, 44, 45, 46, 69, 57, 0, 0, 0,
-	0, 0, 0, 49, 56, 0, 48, 41, 42, 43,
-	44, 45, 46, 69, 57, 0, 0, 0, 0, 0,
-	0, 49, 0, 0, 48,
+const yyLast = 1292
+
+var yyAct = [...]int16{
+	130, 407, 306, 408, 10, 305, 52, 443, 158, 160,
+	412, 11, 392, 125, 28, 13, 287, 284, 11, 26,
+	255, 219, 234, 327, 226, 89, 86, 254, 84, 88,
+	85, 82, 64, 203, 67, 451, 116, 16, 87, 195,
+	122, 195, 439, 121, 90, 118, 195, 196, 319, 249,
+	122, 30, 51, 198, 123, 132, 199, 5, 152, 124,
+	450, 289, 438, 68, 415, 83, 336, 42, 43, 44,
+	45, 46, 50, 47, 48, 49, 227, 301, 227, 225,
+	251, 24, 156, 58, 59, 60, 61, 117, 62, 63,
+	105, 106, 92, 429, 168, 163, 418, 396, 382, 94,
+	165, 155, 95, 96, 69, 70, 71, 72, 73, 74,
+	75, 119, 93, 138, 121, 121, 121, 370, 362, 213,
+	205, 121, 205, 80, 205, 121, 197, 78, 79, 193,
+	252, 205, 288, 166, 215, 171, 218, 187, 189, 190,
+	191, 192, 175, 176, 177, 178, 207, 359, 210, 128,
+	157, 348, 135, 136, 126, 216, 285, 324, 172, 201,
+	238, 220, 164, 137, 311, 239, 231, 281, 242, 250,
+	233, 133, 237, 194, 200, 103, 243, 58, 59, 60,
+	61, 117, 62, 63, 179, 99, 232, 244, 241, 273,
+	236, 129, 257, 258, 259, 153, 127, 260, 180, 181,
+	267, 268, 448, 266, 342, 282, 182, 183, 276, 277,
+	274, 275, 131, 286, 269, 270, 271, 272, 184, 185,
+	186, 386, 342, 291, 173, 174, 303, 452, 218, 278,
+	279, 280, 425, 465, 198, 452, 466, 199, 307, 343,
+	298, 453, 13, 387, 454, 442, 441, 366, 365, 302,
+	321, 487, 107, 483, 107, 131, 289, 318, 102, 320,
+	107, 42, 43, 44, 45, 46, 50, 47, 48, 49,
+	330, 480, 379, 315, 323, 328, 326, 247, 328, 328,
+	328, 331, 332, 333, 340, 300, 478, 476, 472, 265,
+	316, 317, 247, 107, 247, 351, 378, 107, 107, 344,
+	107, 350, 357, 236, 349, 236, 346, 314, 360, 107,
+	354, 430, 431, 367, 335, 307, 337, 338, 247, 13,
+	372, 373, 356, 397, 358, 428, 310, 368, 400, 371,
+	470, 107, 364, 375, 107, 403, 374, 369, 339, 308,
+	380, 54, 308, 330, 247, 312, 309, 299, 297, 107,
+	293, 107, 308, 388, 107, 390, 291, 292, 290, 223,
+	363, 263, 261, 291, 291, 264, 262, 389, 248, 245,
+	230, 107, 402, 376, 247, 107, 399, 102, 222, 398,
+	42, 43, 44, 45, 46, 50, 47, 48, 49, 131,
+	420, 221, 405, 490, 414, 108, 107, 131, 401, 395,
+	329, 482, 419, 417, 422, 131, 131, 432, 475, 381,
+	341, 434, 110, 246, 393, 394, 109, 414, 217, 427,
+	116, 110, 421, 436, 435, 256, 253, 445, 212, 447,
+	256, 404, 449, 209, 188, 188, 188, 188, 188, 114,
+	206, 202, 112, 113, 256, 424, 111, 463, 455, 459,
+	462, 110, 214, 154, 307, 109, 115, 473, 13, 474,
+	467, 469, 464, 416, 229, 360, 131, 414, 471, 479,
+	440, 481, 307, 484, 355, 188, 13, 486, 434, 488,
+	477, 489, 102, 345, 414, 42, 43, 44, 45, 46,
+	50, 47, 48, 49, 460, 188, 446, 188, 426, 383,
+	313, 304, 151, 240, 150, 120, 485, 393, 394, 458,
+	188, 55, 56, 57, 188, 188, 188, 188, 188, 188,
+	188, 7, 188, 188, 188, 188, 188, 188, 188, 457,
+	100, 21, 352, 353, 294, 296, 385, 413, 31, 29,
+	101, 55, 56, 57, 32, 384, 228, 37, 38, 39,
+	68, 415, 83, 444, 42, 43, 44, 45, 46, 50,
+	47, 48, 49, 4, 9, 6, 2, 97, 1, 391,
+	58, 59, 60, 61, 117, 62, 63, 224, 411, 92,
+	410, 409, 347, 27, 25, 295, 94, 188, 188, 95,
+	96, 69, 70, 71, 72, 73, 74, 75, 36, 93,
+	461, 42, 43, 44, 45, 46, 50, 47, 48, 49,
+	80, 23, 22, 20, 78, 79, 19, 58, 59, 60,
+	61, 8, 62, 63, 40, 31, 18, 41, 55, 56,
+	57, 32, 17, 35, 37, 38, 39, 68, 437, 83,
+	361, 42, 43, 44, 45, 46, 50, 47, 48, 49,
+	34, 33, 15, 14, 134, 170, 423, 58, 59, 60,
+	61, 117, 62, 63, 169, 77, 92, 66, 76, 65,
+	91, 53, 81, 94, 3, 98, 95, 96, 69, 70,
+	71, 72, 73, 74, 75, 36, 93, 12, 42, 43,
+	44, 45, 46, 50, 47, 48, 49, 80, 104, 162,
+	0, 78, 79, 0, 0, 0, 0, 0, 0, 0,
+	0, 40, 31, 0, 41, 55, 56, 57, 32, 0,
+	0, 37, 38, 39, 68, 0, 83, 94, 0, 0,
+	0, 0, 69, 70, 71, 72, 73, 74, 75, 119,
+	93, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 80, 468, 92, 0, 78, 79, 0, 0, 0,
+	94, 0, 0, 95, 96, 69, 70, 71, 72, 73,
+	74, 75, 36, 93, 0, 42, 43, 44, 45, 46,
+	50, 47, 48, 49, 80, 68, 159, 83, 78, 79,
+	0, 0, 0, 0, 0, 0, 0, 0, 40, 102,
+	0, 41, 42, 43, 44, 45, 46, 50, 47, 48,
+	49, 0, 0, 0, 92, 0, 0, 68, 0, 83,
+	0, 94, 0, 0, 95, 96, 69, 70, 71, 72,
+	73, 74, 75, 161, 93, 433, 42, 43, 44, 45,
+	46, 50, 47, 48, 49, 80, 92, 0, 0, 78,
+	79, 0, 0, 94, 0, 0, 95, 96, 69, 70,
+	71, 72, 73, 74, 75, 161, 93, 0, 42, 43,
+	44, 45, 46, 50, 47, 48, 49, 80, 0, 0,
+	415, 78, 79, 42, 43, 44, 45, 46, 50, 47,
+	48, 49, 68, 0, 83, 0, 0, 377, 0, 58,
+	59, 60, 61, 117, 62, 63, 167, 0, 0, 42,
+	43, 44, 45, 46, 50, 47, 48, 49, 0, 0,
+	0, 92, 68, 0, 83, 334, 0, 0, 94, 0,
+	0, 95, 96, 69, 70, 71, 72, 73, 74, 75,
+	119, 93, 456, 0, 0, 0, 0, 0, 0, 0,
+	0, 92, 80, 0, 0, 0, 78, 79, 94, 0,
+	0, 95, 96, 69, 70, 71, 72, 73, 74, 75,
+	119, 93, 68, 325, 83, 0, 0, 0, 0, 0,
+	0, 0, 80, 0, 0, 0, 78, 79, 0, 102,
+	0, 0, 42, 43, 44, 45, 46, 50, 47, 48,
+	49, 92, 68, 322, 83, 0, 0, 0, 94, 0,
+	0, 95, 96, 69, 70, 71, 72, 73, 74, 75,
+	119, 93, 406, 0, 0, 0, 0, 0, 0, 0,
+	0, 92, 80, 0, 0, 0, 78, 79, 94, 0,
+	0, 95, 96, 69, 70, 71, 72, 73, 74, 75,
+	119, 93, 68, 235, 83, 0, 0, 0, 0, 0,
+	0, 0, 80, 0, 0, 0, 78, 79, 0, 102,
+	0, 0, 42, 43, 44, 45, 46, 50, 47, 48,
+	49, 92, 68, 0, 83, 0, 0, 217, 94, 0,
+	0, 95, 96, 69, 70, 71, 72, 73, 74, 75,
+	119, 93, 283, 0, 0, 0, 0, 0, 0, 0,
+	0, 92, 80, 0, 0, 0, 78, 79, 94, 0,
+	0, 95, 96, 69, 70, 71, 72, 73, 74, 75,
+	119, 93, 68, 0, 83, 0, 0, 0, 0, 0,
+	0, 0, 80, 0, 0, 0, 78, 79, 211, 102,
+	0, 0, 42, 43, 44, 45, 46, 50, 47, 48,
+	49, 92, 208, 0, 0, 0, 0, 0, 94, 0,
+	0, 95, 96, 69, 70, 71, 72, 73, 74, 75,
+	119, 93, 204, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 80, 0, 0, 102, 78, 79, 42, 43,
+	44, 45, 46, 50, 47, 48, 49, 0, 0, 102,
+	0, 0, 42, 43, 44, 45, 46, 50, 47, 48,
+	49, 0, 0, 0, 0, 0, 0, 0, 0, 102,
+	0, 0, 42, 43, 44, 45, 46, 50, 47, 48,
+	49, 138, 0, 0, 0, 0, 0, 0, 0, 0,
+	139, 140, 141, 142, 143, 144, 145, 146, 147, 148,
+	149, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	135, 136, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 137,
 }
-var yyPact = [...]int{
-
-	-23, 206, 206, -23, -1000, 52, -1000, -1000, -1000, -1000,
-	40, 38, 176, -1000, -1000, -1000, -1000, -1000, -1000, 283,
-	276, -1000, -1000, 414, 291, 414, 205, 204, -1000, -1000,
-	-1000, -1000, -1000, 267, 112, 105, 99, 266, -1000, 113,
-	414, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, 39,
-	127, 414, 98, 86, -1000, -1000, 414, 414, -1000, -1000,
-	21, -1000, 147, 119, 414, -1000, 231, 218, 155, 264,
-	414, 166, 132, -1000, -1000, 396, 414, 414, 37, 366,
-	414, 414, 414, 414, 211, 94, 263, 263, -1000, 414,
-	414, 414, 414, 191, -1000, 414, 414, 414, 414, -1000,
-	26, -1000, -1000, 31, 223, -1000, 414, 293, 27, -1000,
-	-1000, -1000, 304, 289, 414, 152, -1000, -1000, 188, 99,
-	-1000, -1000, 178, -1000, -1000, 113, 170, 127, 127, -1000,
-	336, 25, 262, -1000, 414, 262, 98, 98, 98, 98,
-	-1000, 306, 86, 86, -1000, -1000, -1000, 167, 258, 15,
-	261, 164, -1000, 129, -1000, 292, 302, 414, 250, -1000,
-	414, -1000, 414, -1000, -1000, -1000, 162, -1000, 249, -1000,
-	288, 141, 249, -1000, -1000, 257, -10, -1000, -1000, -1000,
-	215, -1000, -10, 259, 5, -1000, 292, 414, 155, 13,
-	-1000, 3, 144, -1000, -1000, 208, -1000, -1000, -1000, -1000,
-	2, 156, -1000, -1000, -1000, -1000, 0, -1000, -1000, -1000,
-	155, -1000, -1000, -1000, 128, 414, -1000, -1000, -1000, -1000,
-	221, -1000, 260, 87, -1000, 292, 159, 220, -1000, 195,
-	-1000, -1000, -1000, -1000,
+
+var yyPact = [...]int16{
+	-20, 534, -32768, -20, -32768, 121, -32768, -32768, 418, -32768,
+	-32768, 111, 12, 373, -32768, -32768, -32768, -32768, -32768, -32768,
+	-32768, -32768, -32768, -32768, 435, 426, -32768, 423, 436, 94,
+	-32768, 1116, 489, -32768, -32768, -32768, 30, 1116, 132, 127,
+	448, 1116, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768,
+	-32768, -32768, -32768, 134, 1225, 488, 486, 448, -32768, -32768,
+	-32768, -32768, -32768, -32768, 159, 433, -32768, 51, 769, -32768,
+	-32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768,
+	842, -32768, 43, 1116, 109, 186, 102, 145, 162, 172,
+	-32768, -32768, 1116, 1116, 1116, 1116, 1116, -32768, 107, -32768,
+	-32768, -32768, 21, 31, 110, -32768, -32768, 1116, -32768, 420,
+	1165, 419, 1145, 412, 1131, 407, -32768, -32768, 237, 432,
+	1116, 418, 1066, 504, 369, 274, -32768, 356, -32768, 337,
+	-13, 445, 348, 1116, 1116, -32768, -32768, 106, 1036, -32768,
+	-32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768,
+	801, 1116, 495, 1116, 1116, 1116, 352, 396, 351, -45,
+	105, 20, 64, 410, 405, 424, 424, -52, 1116, 343,
+	342, 265, 1116, 1116, 1116, 1116, 1116, 1116, 1116, 418,
+	1116, 1116, 1116, 1116, 1116, 1116, 1116, -32768, 97, -32768,
+	-32768, -32768, -32768, -32768, 103, 397, 1085, 92, -32768, 1116,
+	37, -32768, -32768, 341, -32768, -32768, -32768, 340, -32768, -32768,
+	333, -32768, -32768, 529, 1116, 331, 200, -32768, 326, 261,
+	-32768, -32768, -32768, -32768, -15, 448, -32768, 485, 708, -32768,
+	-32768, 159, -32768, -32768, 329, -32768, -32768, 304, 100, 328,
+	484, 51, 286, 249, 43, 675, 1116, 418, -46, 194,
+	-32768, 986, 93, 956, 380, -32768, 1116, 380, 380, 380,
+	109, -32768, 906, -32768, 47, 1116, 186, 102, 102, 145,
+	145, 145, 145, -32768, 162, 162, 172, 172, -32768, -32768,
+	-32768, -32768, 321, 388, 199, -32768, 277, 465, 92, 87,
+	-32768, 418, -32768, -32768, 448, 527, 1116, 456, -32768, -32768,
+	1116, 448, -32768, -32768, 83, 621, -32768, 54, 1116, -32768,
+	1116, 224, 448, 1116, -32768, 1116, -32768, -32768, 53, 194,
+	448, -32768, -32768, 319, -32768, -32768, 316, 353, -32768, 876,
+	275, 353, 353, 353, -32768, -32768, -32768, 248, -32768, 387,
+	-32768, -32768, 34, 483, -32768, -32768, 181, 220, -52, -32768,
+	-32768, -32768, 448, 1116, 237, 496, 378, -32768, 33, -32768,
+	-32768, -32768, 212, -32768, 301, 1116, 418, -32768, 311, 377,
+	-32768, 448, -32768, -32768, -32768, -32768, 314, -32768, -32768, 1116,
+	-32768, -32768, -32768, 1005, 0, 444, 232, 32, -32768, 237,
+	-32768, 403, -32768, 1116, 208, -32768, 481, 1116, 308, 29,
+	289, -32768, -32768, -32768, -32768, 295, 448, 816, -32768, -32768,
+	-32768, -32768, -32768, 574, -2, 26, -32768, 452, -52, -32768,
+	-32768, -32768, -32768, 222, -32768, -32768, 448, 479, 448, 178,
+	-32768, 448, -32768, -32768, -32768, -32768, -4, 19, 219, 925,
+	-32768, -32768, 1116, -32768, 708, -32768, 448, -32768, 1116, -32768,
+	211, 735, 313, -32768, 1116, 271, 448, 0, 389, -32768,
+	-32768, -32768, 708, -32768, 270, -32768, 1116, 269, 448, 254,
+	379, 231, 448, -32768, 487, -32768, 448, 229, 448, -32768,
+	371, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768,
+	-32768,
 }
-var yyPgo = [...]int{
-
-	0, 387, 385, 384, 311, 4, 2, 9, 16, 382,
-	19, 13, 31, 18, 14, 381, 11, 380, 368, 367,
-	365, 7, 364, 363, 362, 361, 360, 359, 358, 3,
-	12, 0, 357, 40, 1, 15, 355, 8, 10, 6,
-	354, 352, 5, 351, 350, 337, 334, 333, 332, 313,
-	331, 330, 329, 314, 312,
+
+var yyPgo = [...]int16{
+	0, 699, 698, 537, 6, 687, 675, 674, 563, 13,
+	21, 672, 51, 32, 671, 34, 31, 28, 30, 26,
+	38, 29, 25, 44, 670, 341, 669, 668, 667, 665,
+	664, 656, 655, 654, 2, 653, 652, 651, 650, 633,
+	37, 632, 626, 616, 613, 531, 612, 611, 5, 600,
+	8, 33, 17, 22, 0, 7, 585, 81, 9, 19,
+	584, 583, 52, 14, 20, 27, 23, 582, 16, 3,
+	1, 581, 580, 578, 521, 10, 24, 577, 12, 569,
+	568, 566, 565, 564, 553, 546, 545, 536, 529, 509,
 }
-var yyR1 = [...]int{
-
-	0, 47, 47, 48, 48, 3, 3, 4, 2, 2,
-	49, 49, 49, 33, 33, 33, 33, 33, 35, 36,
-	36, 36, 34, 34, 34, 50, 50, 50, 50, 29,
-	29, 30, 30, 28, 28, 5, 5, 7, 7, 9,
-	9, 8, 8, 10, 10, 10, 11, 11, 11, 11,
-	11, 12, 12, 12, 13, 13, 13, 14, 14, 14,
-	15, 16, 16, 16, 17, 17, 17, 17, 17, 17,
-	17, 17, 17, 17, 17, 17, 17, 17, 17, 17,
-	1, 1, 18, 18, 19, 19, 19, 19, 38, 38,
-	37, 39, 39, 20, 20, 20, 21, 21, 21, 21,
-	21, 21, 21, 22, 22, 22, 22, 32, 32, 23,
-	6, 6, 24, 25, 26, 27, 27, 52, 31, 31,
-	53, 51, 54, 51, 41, 41, 40, 40, 43, 43,
-	42, 42, 44, 46, 46, 46, 46, 45,
+
+var yyR1 = [...]int8{
+	0, 80, 80, 81, 81, 7, 7, 8, 6, 6,
+	82, 82, 82, 82, 82, 57, 57, 57, 57, 57,
+	57, 57, 57, 57, 59, 63, 52, 52, 60, 60,
+	60, 60, 60, 61, 61, 61, 61, 61, 62, 62,
+	51, 51, 58, 58, 58, 58, 58, 74, 74, 74,
+	74, 74, 74, 50, 50, 53, 53, 48, 48, 9,
+	9, 12, 12, 33, 33, 33, 33, 33, 33, 33,
+	33, 33, 33, 33, 14, 14, 13, 13, 15, 15,
+	16, 16, 17, 17, 18, 18, 18, 19, 19, 19,
+	19, 19, 19, 20, 20, 20, 21, 21, 21, 22,
+	22, 22, 22, 23, 23, 23, 23, 23, 23, 23,
+	23, 24, 24, 24, 25, 25, 25, 26, 26, 26,
+	26, 26, 26, 26, 26, 26, 26, 26, 26, 26,
+	26, 26, 26, 26, 26, 26, 26, 26, 26, 26,
+	26, 11, 11, 11, 11, 1, 1, 27, 27, 29,
+	29, 32, 32, 28, 28, 28, 28, 28, 28, 28,
+	28, 65, 65, 64, 66, 66, 30, 30, 30, 34,
+	34, 34, 34, 34, 34, 34, 34, 34, 34, 34,
+	35, 35, 35, 35, 56, 56, 36, 79, 79, 78,
+	78, 31, 31, 84, 55, 49, 49, 40, 40, 40,
+	41, 37, 37, 37, 38, 39, 10, 10, 42, 43,
+	43, 44, 44, 46, 46, 46, 77, 77, 76, 47,
+	45, 45, 85, 54, 54, 86, 83, 87, 83, 88,
+	83, 89, 83, 2, 2, 5, 5, 3, 3, 4,
+	4, 4, 4, 4, 4, 4, 68, 68, 67, 67,
+	67, 67, 70, 70, 69, 69, 69, 71, 71, 75,
+	75, 75, 75, 72, 72, 72, 72, 73, 73, 73,
+	73,
 }
-var yyR2 = [...]int{
-
-	0, 2, 2, 0, 1, 1, 2, 3, 1, 3,
-	1, 1, 1, 1, 1, 1, 1, 1, 1, 3,
-	3, 3, 1, 1, 1, 6, 5, 6, 5, 2,
-	4, 1, 3, 1, 2, 1, 3, 1, 3, 1,
-	3, 1, 3, 1, 3, 3, 1, 3, 3, 3,
-	3, 1, 3, 3, 1, 3, 3, 1, 2, 2,
-	1, 1, 1, 1, 4, 4, 3, 4, 3, 3,
-	1, 1, 1, 1, 1, 1, 1, 1, 4, 5,
-	1, 3, 3, 4, 3, 4, 3, 4, 1, 2,
-	3, 2, 3, 0, 1, 3, 2, 1, 1, 1,
-	1, 1, 1, 3, 5, 4, 6, 3, 4, 9,
-	0, 1, 3, 2, 2, 3, 5, 0, 4, 2,
-	0, 7, 0, 6, 0, 2, 1, 3, 1, 2,
-	1, 1, 1, 6, 5, 6, 5, 3,
+
+var yyR2 = [...]int8{
+	0, 1, 2, 0, 1, 1, 2, 3, 1, 3,
+	1, 2, 1, 1, 2, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 1, 4, 1, 3, 3, 3,
+	3, 3, 3, 1, 4, 3, 4, 3, 4, 3,
+	1, 3, 1, 1, 1, 1, 1, 6, 5, 6,
+	5, 9, 8, 2, 4, 1, 3, 1, 2, 1,
+	3, 1, 3, 1, 1, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 1, 3, 1, 3, 1, 3,
+	1, 3, 1, 3, 1, 3, 3, 1, 3, 3,
+	3, 3, 3, 1, 3, 3, 1, 3, 3, 1,
+	3, 3, 3, 1, 2, 2, 2, 2, 2, 4,
+	4, 1, 2, 2, 1, 1, 1, 4, 4, 6,
+	6, 3, 4, 3, 3, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 1, 1, 4, 5, 4, 5,
+	1, 6, 5, 5, 4, 1, 3, 3, 4, 3,
+	4, 3, 5, 3, 4, 3, 4, 3, 4, 3,
+	4, 1, 2, 3, 2, 3, 0, 1, 3, 2,
+	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+	3, 5, 4, 6, 3, 4, 7, 1, 2, 4,
+	3, 1, 3, 0, 2, 0, 1, 1, 1, 1,
+	3, 9, 8, 11, 5, 7, 0, 1, 3, 2,
+	3, 2, 3, 3, 5, 4, 1, 2, 6, 3,
+	3, 5, 0, 4, 2, 0, 8, 0, 7, 0,
+	11, 0, 10, 1, 1, 0, 1, 1, 2, 1,
+	1, 1, 1, 1, 1, 1, 0, 2, 1, 3,
+	1, 3, 1, 2, 1, 1, 1, 1, 2, 6,
+	5, 6, 5, 3, 5, 4, 6, 5, 4, 6,
+	5,
 }
-var yyChk = [...]int{
-
-	-1000, -47, -48, -3, -4, 48, -49, -50, -51, -21,
-	-34, 49, -5, -22, -23, -24, -25, -26, -27, -33,
-	-36, -35, -7, 4, 7, 8, 9, 10, 42, 43,
-	44, 45, 46, 39, -9, -16, -8, -17, -19, -10,
-	11, 33, 34, 35, 36, 37, 38, -18, 50, 47,
-	-11, 13, -12, -13, -14, -15, 30, 40, -49, -4,
-	-2, 39, 39, 39, 18, 17, 15, 15, -5, 39,
-	11, -6, -5, 17, 17, 15, 22, 20, 41, 11,
-	21, 15, 23, 24, -5, -1, -33, -35, 39, 25,
-	26, 27, 28, -20, -7, 29, 30, 31, 32, -14,
-	-16, -14, 17, 41, 11, 17, 20, -41, 19, -7,
-	16, 16, -31, 13, 15, -6, 17, 16, -5, -8,
-	-7, 39, -30, 12, -7, -10, -5, -11, -11, 12,
-	11, 41, -38, -37, 15, -38, -12, -12, -12, -12,
-	14, 18, -13, -13, -14, -14, 39, -29, 12, -34,
-	39, -5, 13, -40, 39, 5, -32, 6, -52, 14,
-	17, 16, 18, 12, 16, 12, -30, 39, -39, -37,
-	15, -5, -39, 14, -7, 12, 18, -31, 17, 39,
-	15, 17, -53, -54, 18, -31, 5, 6, -5, -28,
-	-21, -34, -6, -7, 12, 15, 16, 16, -31, 17,
-	-34, -43, -42, -44, -45, -46, -34, 14, 39, -31,
-	-5, -31, -21, 14, 39, 17, 16, 39, 14, -42,
-	39, -31, -6, 11, 17, 12, -29, 12, -31, 12,
-	-31, 17, -31, 17,
+
+var yyChk = [...]int16{
+	-32768, -80, -81, -7, -8, 77, -82, -74, 87, -83,
+	-34, -58, -5, -9, -35, -36, -40, -41, -42, -43,
+	-44, -45, -46, -47, -57, -60, -59, -61, -63, -3,
+	-12, 4, 10, -37, -38, -39, 64, 13, 14, 15,
+	90, 93, 67, 68, 69, 70, 71, 73, 74, 75,
+	72, -62, -4, -14, -25, 7, 8, 9, 83, 84,
+	85, 86, 88, 89, -13, -26, -28, -15, 16, 57,
+	58, 59, 60, 61, 62, 63, -27, -29, 80, 81,
+	76, -11, -16, 18, -17, -18, -19, -20, -21, -22,
+	-23, -24, 45, 65, 52, 55, 56, -8, -6, 64,
+	-74, -45, 64, 64, -2, 78, 79, 23, 22, 20,
+	16, 20, 16, 20, 16, 20, -4, 87, -9, 64,
+	16, 95, 20, 24, -10, -9, 22, 64, 22, 64,
+	-54, 18, -9, 37, -33, 55, 56, 66, 16, 25,
+	26, 27, 28, 29, 30, 31, 32, 33, 34, 35,
+	16, 16, -54, 36, 20, 50, -9, -57, -50, 17,
+	-58, 64, -1, -63, -57, -59, -62, 64, 51, -30,
+	-32, -12, 49, 38, 39, 40, 41, 42, 43, 82,
+	53, 54, 44, 45, 46, 47, 48, -23, -25, -23,
+	-23, -23, -23, 22, 66, 20, 16, 95, 22, 25,
+	64, -12, 21, -51, 17, -58, 21, -51, 17, 21,
+	-51, 17, 21, -54, 20, -9, -51, 21, -9, -10,
+	-40, 22, 22, 22, -77, 92, -76, 91, -85, 19,
+	22, -13, -12, 64, -53, 17, -12, -10, -58, -9,
+	8, -15, -9, -10, -16, 17, 17, 23, 17, 94,
+	64, 16, 66, 16, -65, -64, 20, -65, -65, -65,
+	-17, 19, 23, 19, 23, 24, -18, -19, -19, -20,
+	-20, -20, -20, -58, -21, -21, -22, -22, -23, -23,
+	-23, 64, -50, 17, -52, 64, -9, -68, 95, 24,
+	17, 23, 17, 17, 5, -56, 6, 17, 40, 21,
+	24, 92, -76, -54, 16, -48, -34, -58, 23, 17,
+	22, 64, 17, 16, 21, 24, -23, -23, -58, 94,
+	-58, -54, 17, -53, 64, 17, -53, -66, -64, 20,
+	-9, -66, -66, -66, 19, -12, 19, -12, -12, 17,
+	-54, 22, 23, 40, 22, 18, -52, -67, 64, -63,
+	-58, -54, 5, 6, -9, 18, -10, -54, -59, 64,
+	-34, 19, 64, -12, -10, 24, 23, -54, -9, -10,
+	64, -58, -54, -54, 17, 17, 20, 21, 21, 24,
+	-54, 22, 64, 16, -86, -87, 40, 23, -54, -9,
+	-54, -79, -78, 11, 12, 21, 64, 22, -9, -58,
+	17, 21, -54, 21, -12, -50, 17, -70, -69, -71,
+	-72, -73, -75, -3, -58, 64, 19, -68, 64, -63,
+	-54, 19, -78, -31, -12, 24, 17, -10, 17, 64,
+	22, 17, -54, 19, -69, -75, -58, 64, 64, 16,
+	18, 24, 23, -55, -84, -54, 17, -54, 24, -54,
+	64, 16, 16, 22, 25, -50, 17, -88, -89, -55,
+	-12, -49, -48, -54, -9, 22, 25, -50, 17, -50,
+	17, -9, 17, -54, -70, 19, 17, -9, 17, -54,
+	17, -54, 22, 22, -54, 19, -54, 22, -54, -54,
+	22,
 }
-var yyDef = [...]int{
-
-	3, -2, 0, 4, 5, 0, 2, 10, 11, 12,
-	0, 0, 0, 97, 98, 99, 100, 101, 102, 22,
-	23, 24, 35, 0, 0, 110, 0, 0, 13, 14,
-	15, 16, 17, -2, 37, 60, 39, 61, 62, 41,
-	0, 70, 71, 72, 73, 74, 75, 76, 77, 0,
-	43, 93, 46, 51, 54, 57, 0, 0, 1, 6,
-	0, 8, 0, 124, 0, 96, 0, 0, 0, 63,
-	110, 0, 111, 113, 114, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, -2, 0,
-	0, 0, 0, 0, 94, 0, 0, 0, 0, 58,
-	60, 59, 7, 0, 0, 115, 0, 0, 0, 36,
-	19, 21, 103, 117, 0, 0, 112, 20, 0, 40,
-	38, 66, 0, 68, 31, 42, 0, 44, 45, 69,
-	0, 0, 84, 88, 0, 86, 47, 48, 49, 50,
-	82, 0, 52, 53, 55, 56, 9, 0, 0, 0,
-	18, 0, -2, 125, 126, 0, 105, 0, 0, 119,
-	110, 65, 0, 67, 64, 78, 0, 81, 85, 89,
-	0, 0, 87, 83, 95, 0, 0, 26, 28, 29,
-	0, 116, 0, 0, 0, 104, 0, 0, 0, 0,
-	33, 0, 0, 32, 79, 0, 91, 90, 25, 27,
-	0, 0, 128, 130, 131, 132, 0, 123, 127, 106,
-	0, 107, 34, 118, 0, 110, 92, 30, 121, 129,
-	0, 108, 0, 0, 137, 0, 0, 0, 109, 0,
-	134, 136, 133, 135,
+
+var yyDef = [...]int16{
+	3, -2, 1, 4, 5, 0, 2, 10, 243, 12,
+	13, 0, 0, 0, 170, 171, 172, 173, 174, 175,
+	176, 177, 178, 179, 42, 43, 44, 45, 46, 236,
+	59, 0, 0, 197, 198, 199, -2, 206, 0, 0,
+	0, 0, 15, 16, 17, 18, 19, 20, 21, 22,
+	23, 33, 237, 61, 111, 0, 0, 0, 239, 240,
+	241, 242, 244, 245, 74, 114, 115, 76, 0, 125,
+	126, 127, 128, 129, 130, 131, 132, 133, 134, 135,
+	0, 140, 78, 166, 80, 82, 84, 87, 93, 96,
+	99, 103, 0, 0, 0, 0, 0, 6, 0, 8,
+	11, 14, 24, 0, 0, 233, 234, 0, 169, 0,
+	0, 0, 0, 0, 0, 0, 238, 243, 0, 116,
+	0, 0, 206, 0, 0, 207, 209, 0, 211, 0,
+	0, 222, 0, 0, 0, 112, 113, 0, 0, 63,
+	64, 65, 66, 67, 68, 69, 70, 71, 72, 73,
+	206, 0, 0, 0, 206, 0, 0, 42, 0, 0,
+	0, -2, 0, 0, 0, 0, 0, -2, 0, 0,
+	0, 167, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 104, 111, 105,
+	106, 107, 108, 7, 0, 0, 0, 0, 220, 0,
+	246, 60, 28, 0, 39, 40, 31, 0, 35, 32,
+	0, 37, 30, 180, 206, 0, 0, 29, 207, 0,
+	200, 208, 210, 212, 213, 0, 216, 0, 0, 224,
+	219, 75, 62, 121, 0, 123, 55, 0, 0, 0,
+	0, 77, 207, 0, 79, 124, 0, 0, 0, 0,
+	53, 0, 0, 0, 157, 161, 0, 153, 155, 159,
+	81, 147, 0, 149, 0, 0, 83, 85, 86, 88,
+	89, 90, 91, 92, 94, 95, 97, 98, 100, 101,
+	102, 9, 0, 0, 0, 26, 0, 0, 0, 0,
+	38, 0, 34, 36, 0, 182, 0, 0, 25, 118,
+	206, 0, 217, 215, 0, 0, 57, 0, 0, 122,
+	206, 0, 0, 0, 117, 206, 109, 110, 0, 0,
+	0, 144, 136, 0, 146, 138, 0, 158, 162, 0,
+	0, 154, 156, 160, 148, 168, 150, 0, 151, 0,
+	48, 50, 0, 0, 221, -2, 0, 247, 248, 250,
+	41, 181, 0, 0, 0, 0, 0, 214, 0, 24,
+	58, 223, 0, 56, 0, 0, 0, 204, 0, 0,
+	54, 0, 143, 142, 137, 139, 0, 164, 163, 0,
+	47, 49, 27, 0, 0, 0, 246, 0, 183, 0,
+	184, 0, 187, 0, 0, 120, 0, 206, 0, 0,
+	0, 119, 141, 165, 152, 0, 0, 0, 252, 254,
+	255, 256, 257, 0, 0, 24, 228, 0, 249, 251,
+	185, 186, 188, 0, 191, 193, 0, 0, 0, 0,
+	205, 0, 52, 226, 253, 258, 0, 24, 0, 0,
+	-2, 193, 0, 190, 195, 218, 0, 202, 0, 51,
+	0, 0, 0, 263, 0, 0, 0, 0, 0, 189,
+	192, 194, 196, 201, 0, 265, 0, 0, 0, 0,
+	0, 0, 0, 268, 0, 232, 0, 0, 0, 270,
+	0, 260, 262, 264, 267, 230, 203, 266, 269, 259,
+	261,
 }
-var yyTok1 = [...]int{
 
+var yyTok1 = [...]int8{
 	1,
 }
-var yyTok2 = [...]int{
 
+var yyTok2 = [...]int8{
 	2, 3, 4, 5, 6, 7, 8, 9, 10, 11,
 	12, 13, 14, 15, 16, 17, 18, 19, 20, 21,
 	22, 23, 24, 25, 26, 27, 28, 29, 30, 31,
 	32, 33, 34, 35, 36, 37, 38, 39, 40, 41,
-	42, 43, 44, 45, 46, 47, 48, 49, 50,
+	42, 43, 44, 45, 46, 47, 48, 49, 50, 51,
+	52, 53, 54, 55, 56, 57, 58, 59, 60, 61,
+	62, 63, 64, 65, 66, 67, 68, 69, 70, 71,
+	72, 73, 74, 75, 76, 77, 78, 79, 80, 81,
+	82, 83, 84, 85, 86, 87, 88, 89, 90, 91,
+	92, 93, 94, 95, 96,
 }
-var yyTok3 = [...]int{
+
+var yyTok3 = [...]int8{
 	0,
 }
 
@@ -399,7 +708,7 @@ func yyNewParser() yyParser {
 	return &yyParserImpl{}
 }
 
-const yyFlag = -1000
+const yyFlag = -32768
 
 func yyTokname(c int) string {
 	if c >= 1 && c-1 < len(yyToknames) {
@@ -438,9 +747,9 @@ func yyErrorMessage(state, lookAhead int) string {
 	expected := make([]int, 0, 4)
 
 	// Look for shiftable tokens.
-	base := yyPact[state]
+	base := int(yyPact[state])
 	for tok := TOKSTART; tok-1 < len(yyToknames); tok++ {
-		if n := base + tok; n >= 0 && n < yyLast && yyChk[yyAct[n]] == tok {
+		if n := base + tok; n >= 0 && n < yyLast && int(yyChk[int(yyAct[n])]) == tok {
 			if len(expected) == cap(expected) {
 				return res
 			}
@@ -450,13 +759,13 @@ func yyErrorMessage(state, lookAhead int) string {
 
 	if yyDef[state] == -2 {
 		i := 0
-		for yyExca[i] != -1 || yyExca[i+1] != state {
+		for yyExca[i] != -1 || int(yyExca[i+1]) != state {
 			i += 2
 		}
 
 		// Look for tokens that we accept or reduce.
 		for i += 2; yyExca[i] >= 0; i += 2 {
-			tok := yyExca[i]
+			tok := int(yyExca[i])
 			if tok < TOKSTART || yyExca[i+1] == 0 {
 				continue
 			}
@@ -487,30 +796,30 @@ func yylex1(lex yyLexer, lval *yySymType) (char, token int) {
 	token = 0
 	char = lex.Lex(lval)
 	if char <= 0 {
-		token = yyTok1[0]
+		token = int(yyTok1[0])
 		goto out
 	}
 	if char < len(yyTok1) {
-		token = yyTok1[char]
+		token = int(yyTok1[char])
 		goto out
 	}
 	if char >= yyPrivate {
 		if char < yyPrivate+len(yyTok2) {
-			token = yyTok2[char-yyPrivate]
+			token = int(yyTok2[char-yyPrivate])
 			goto out
 		}
 	}
 	for i := 0; i < len(yyTok3); i += 2 {
-		token = yyTok3[i+0]
+		token = int(yyTok3[i+0])
 		if token == char {
-			token = yyTok3[i+1]
+			token = int(yyTok3[i+1])
 			goto out
 		}
 	}
 
 out:
 	if token == 0 {
-		token = yyTok2[1] /* unknown char */
+		token = int(yyTok2[1]) /* unknown char */
 	}
 	if yyDebug >= 3 {
 		__yyfmt__.Printf("lex %s(%d)\n", yyTokname(token), uint(char))
@@ -565,7 +874,7 @@ yystack:
 	yyS[yyp].yys = yystate
 
 yynewstate:
-	yyn = yyPact[yystate]
+	yyn = int(yyPact[yystate])
 	if yyn <= yyFlag {
 		goto yydefault /* simple state */
 	}
@@ -576,8 +885,8 @@ yynewstate:
 	if yyn < 0 || yyn >= yyLast {
 		goto yydefault
 	}
-	yyn = yyAct[yyn]
-	if yyChk[yyn] == yytoken { /* valid shift */
+	yyn = int(yyAct[yyn])
+	if int(yyChk[yyn]) == yytoken { /* valid shift */
 		yyrcvr.char = -1
 		yytoken = -1
 		yyVAL = yyrcvr.lval
@@ -590,7 +899,7 @@ yynewstate:
 
 yydefault:
 	/* default state action */
-	yyn = yyDef[yystate]
+	yyn = int(yyDef[yystate])
 	if yyn == -2 {
 		if yyrcvr.char < 0 {
 			yyrcvr.char, yytoken = yylex1(yylex, &yyrcvr.lval)
@@ -599,18 +908,18 @@ yydefault:
 		/* look through exception table */
 		xi := 0
 		for {
-			if yyExca[xi+0] == -1 && yyExca[xi+1] == yystate {
+			if yyExca[xi+0] == -1 && int(yyExca[xi+1]) == yystate {
 				break
 			}
 			xi += 2
 		}
 		for xi += 2; ; xi += 2 {
-			yyn = yyExca[xi+0]
+			yyn = int(yyExca[xi+0])
 			if yyn < 0 || yyn == yytoken {
 				break
 			}
 		}
-		yyn = yyExca[xi+1]
+		yyn = int(yyExca[xi+1])
 		if yyn < 0 {
 			goto ret0
 		}
@@ -632,10 +941,10 @@ yydefault:
 
 			/* find a state where "error" is a legal shift action */
 			for yyp >= 0 {
-				yyn = yyPact[yyS[yyp].yys] + yyErrCode
+				yyn = int(yyPact[yyS[yyp].yys]) + yyErrCode
 				if yyn >= 0 && yyn < yyLast {
-					yystate = yyAct[yyn] /* simulate a shift of "error" */
-					if yyChk[yystate] == yyErrCode {
+					yystate = int(yyAct[yyn]) /* simulate a shift of "error" */
+					if int(yyChk[yystate]) == yyErrCode {
 						goto yystack
 					}
 				}
@@ -671,7 +980,7 @@ yydefault:
 	yypt := yyp
 	_ = yypt // guard against "declared and not used"
 
-	yyp -= yyR2[yyn]
+	yyp -= int(yyR2[yyn])
 	// yyp is now the index of $0. Perform the default action. Iff the
 	// reduced production is ε, $1 is possibly out of range.
 	if yyp+1 >= len(yyS) {
@@ -682,16 +991,16 @@ yydefault:
 	yyVAL = yyS[yyp+1]
 
 	/* consult goto table to find next state */
-	yyn = yyR1[yyn]
-	yyg := yyPgo[yyn]
+	yyn = int(yyR1[yyn])
+	yyg := int(yyPgo[yyn])
 	yyj := yyg + yyS[yyp].yys + 1
 
 	if yyj >= yyLast {
-		yystate = yyAct[yyg]
+		yystate = int(yyAct[yyg])
 	} else {
-		yystate = yyAct[yyj]
-		if yyChk[yystate] != -yyn {
-			yystate = yyAct[yyg]
+		yystate = int(yyAct[yyj])
+		if int(yyChk[yystate]) != -yyn {
+			yystate = int(yyAct[yyg])
 		}
 	}
 	// dummy call; replaced with literal code
@@ -699,598 +1008,1164 @@ yydefault:
 
 	case 3:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line parser.go.y:97
+//line parser.go.y:134
 		{
-			setRequireList(nil)
+			l := yylex.(*Lexer)
+			l.compiler.setRequireList(nil)
 		}
 	case 4:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:101
+//line parser.go.y:139
 		{
-			setRequireList(yyDollar[1].require_list)
+			l := yylex.(*Lexer)
+			l.compiler.setRequireList(yyDollar[1].require_list)
 		}
 	case 6:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line parser.go.y:108
+//line parser.go.y:147
 		{
 			yyVAL.require_list = chainRequireList(yyDollar[1].require_list, yyDollar[2].require_list)
 		}
 	case 7:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:114
+//line parser.go.y:153
 		{
-			yyVAL.require_list = createRequireList(yyDollar[2].package_name)
+			yyVAL.require_list = createRequireList(yyDollar[2].package_name, yyDollar[1].tok.Position())
 		}
 	case 8:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:120
+//line parser.go.y:159
 		{
 			yyVAL.package_name = createPackageName(yyDollar[1].tok.Lit)
 		}
 	case 9:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:124
+//line parser.go.y:163
 		{
 			yyVAL.package_name = chainPackageName(yyDollar[1].package_name, yyDollar[3].tok.Lit)
 		}
-	case 12:
-		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:132
+	case 11:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.go.y:170
 		{
-			l := yylex.(*Lexer)
-			l.compiler.statementList = append(l.compiler.statementList, yyDollar[1].statement)
+			yyDollar[2].function_definition.isPrivate = true
 		}
 	case 13:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:139
+//line parser.go.y:175
 		{
-			yyVAL.type_specifier = createTypeSpecifier(vm.VoidType, yyDollar[1].tok.Position())
+			l := yylex.(*Lexer)
+			l.compiler.statementList = append(l.compiler.statementList, yyDollar[1].statement)
 		}
 	case 14:
-		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:143
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.go.y:181
 		{
-			yyVAL.type_specifier = createTypeSpecifier(vm.BooleanType, yyDollar[1].tok.Position())
+			yyDollar[2].statement.(*Declaration).isPrivate = true
+			l := yylex.(*Lexer)
+			l.compiler.statementList = append(l.compiler.statementList, yyDollar[2].statement)
 		}
 	case 15:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:147
+//line parser.go.y:189
 		{
-			yyVAL.type_specifier = createTypeSpecifier(vm.IntType, yyDollar[1].tok.Position())
+			yyVAL.type_specifier = createTypeSpecifier(vm.VoidType, yyDollar[1].tok.Position())
 		}
 	case 16:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:151
+//line parser.go.y:193
 		{
-			yyVAL.type_specifier = createTypeSpecifier(vm.DoubleType, yyDollar[1].tok.Position())
+			yyVAL.type_specifier = createTypeSpecifier(vm.BooleanType, yyDollar[1].tok.Position())
 		}
 	case 17:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:155
+//line parser.go.y:197
 		{
-			yyVAL.type_specifier = createTypeSpecifier(vm.StringType, yyDollar[1].tok.Position())
+			yyVAL.type_specifier = createTypeSpecifier(vm.IntType, yyDollar[1].tok.Position())
 		}
 	case 18:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:161
+//line parser.go.y:201
 		{
-			yyVAL.type_specifier = createClassTypeSpecifier(yyDollar[1].tok.Lit, yyDollar[1].tok.Position())
+			yyVAL.type_specifier = createTypeSpecifier(vm.DoubleType, yyDollar[1].tok.Position())
 		}
 	case 19:
-		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:167
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:205
 		{
-			yyVAL.type_specifier = createArrayTypeSpecifier(yyDollar[1].type_specifier)
-			yyVAL.type_specifier.SetPosition(yyDollar[1].type_specifier.Position())
+			yyVAL.type_specifier = createTypeSpecifier(vm.CharType, yyDollar[1].tok.Position())
 		}
 	case 20:
-		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:172
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:209
 		{
-			class_type := createClassTypeSpecifier(yyDollar[1].tok.Lit, yyDollar[1].tok.Position())
-			yyVAL.type_specifier = createArrayTypeSpecifier(class_type)
+			yyVAL.type_specifier = createTypeSpecifier(vm.ByteType, yyDollar[1].tok.Position())
 		}
 	case 21:
-		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:177
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:213
 		{
-			yyVAL.type_specifier = createArrayTypeSpecifier(yyDollar[1].type_specifier)
+			yyVAL.type_specifier = createTypeSpecifier(vm.Int32Type, yyDollar[1].tok.Position())
 		}
 	case 22:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:183
+//line parser.go.y:217
 		{
-			yyVAL.type_specifier = yyDollar[1].type_specifier
+			yyVAL.type_specifier = createTypeSpecifier(vm.LongType, yyDollar[1].tok.Position())
 		}
-	case 25:
-		yyDollar = yyS[yypt-6 : yypt+1]
-		//line parser.go.y:191
+	case 23:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:221
+		{
+			yyVAL.type_specifier = createTypeSpecifier(vm.StringType, yyDollar[1].tok.Position())
+		}
+	case 24:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:227
 		{
 			l := yylex.(*Lexer)
-			l.compiler.functionDefine(yyDollar[1].type_specifier, yyDollar[2].tok.Lit, yyDollar[4].parameter_list, yyDollar[6].block)
+			yyVAL.type_specifier = l.compiler.createNamedTypeSpecifier(yyDollar[1].tok.Lit, yyDollar[1].tok.Position())
+		}
+	case 25:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.go.y:234
+		{
+			yyVAL.type_specifier = createGenericTypeSpecifier(yyDollar[1].tok.Lit, yyDollar[3].type_specifier_list, yyDollar[1].tok.Position())
 		}
 	case 26:
-		yyDollar = yyS[yypt-5 : yypt+1]
-		//line parser.go.y:196
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:240
 		{
-			l := yylex.(*Lexer)
-			l.compiler.functionDefine(yyDollar[1].type_specifier, yyDollar[2].tok.Lit, []*Parameter{}, yyDollar[5].block)
+			yyVAL.identifier_list = []string{yyDollar[1].tok.Lit}
 		}
 	case 27:
-		yyDollar = yyS[yypt-6 : yypt+1]
-		//line parser.go.y:201
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:244
 		{
-			l := yylex.(*Lexer)
-			l.compiler.functionDefine(yyDollar[1].type_specifier, yyDollar[2].tok.Lit, yyDollar[4].parameter_list, nil)
+			yyVAL.identifier_list = append(yyDollar[1].identifier_list, yyDollar[3].tok.Lit)
 		}
 	case 28:
-		yyDollar = yyS[yypt-5 : yypt+1]
-		//line parser.go.y:206
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:250
 		{
-			l := yylex.(*Lexer)
-			l.compiler.functionDefine(yyDollar[1].type_specifier, yyDollar[2].tok.Lit, []*Parameter{}, nil)
+			yyVAL.type_specifier = createArrayTypeSpecifier(yyDollar[1].type_specifier)
+			yyVAL.type_specifier.SetPosition(yyDollar[1].type_specifier.Position())
 		}
 	case 29:
-		yyDollar = yyS[yypt-2 : yypt+1]
-		//line parser.go.y:213
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:255
 		{
-			parameter := &Parameter{typeSpecifier: yyDollar[1].type_specifier, name: yyDollar[2].tok.Lit}
-			yyVAL.parameter_list = []*Parameter{parameter}
+			l := yylex.(*Lexer)
+			class_type := l.compiler.createNamedTypeSpecifier(yyDollar[1].tok.Lit, yyDollar[1].tok.Position())
+			yyVAL.type_specifier = createArrayTypeSpecifier(class_type)
 		}
 	case 30:
-		yyDollar = yyS[yypt-4 : yypt+1]
-		//line parser.go.y:218
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:261
 		{
-			yyVAL.parameter_list = append(yyDollar[1].parameter_list, &Parameter{typeSpecifier: yyDollar[3].type_specifier, name: yyDollar[4].tok.Lit})
+			yyVAL.type_specifier = createArrayTypeSpecifier(yyDollar[1].type_specifier)
 		}
 	case 31:
-		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:224
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:265
 		{
-			yyVAL.argument_list = []Expression{yyDollar[1].expression}
+			yyVAL.type_specifier = createArrayTypeSpecifier(yyDollar[1].type_specifier)
 		}
 	case 32:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:228
+//line parser.go.y:269
 		{
-			yyVAL.argument_list = append(yyDollar[1].argument_list, yyDollar[3].expression)
+			yyVAL.type_specifier = createArrayTypeSpecifier(yyDollar[1].type_specifier)
 		}
-	case 33:
-		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:234
+	case 34:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.go.y:277
 		{
-			yyVAL.statement_list = []Statement{yyDollar[1].statement}
+			yyVAL.type_specifier = createFunctionTypeSpecifier(yyDollar[1].type_specifier, yyDollar[3].type_specifier_list)
 		}
-	case 34:
-		yyDollar = yyS[yypt-2 : yypt+1]
-		//line parser.go.y:238
+	case 35:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:281
 		{
-			yyVAL.statement_list = append(yyDollar[1].statement_list, yyDollar[2].statement)
+			yyVAL.type_specifier = createFunctionTypeSpecifier(yyDollar[1].type_specifier, nil)
 		}
 	case 36:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.go.y:285
+		{
+			yyVAL.type_specifier = createFunctionTypeSpecifier(yyDollar[1].type_specifier, yyDollar[3].type_specifier_list)
+		}
+	case 37:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:245
+//line parser.go.y:289
 		{
-			yyVAL.expression = &CommaExpression{left: yyDollar[1].expression, right: yyDollar[3].expression}
-			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+			yyVAL.type_specifier = createFunctionTypeSpecifier(yyDollar[1].type_specifier, nil)
 		}
 	case 38:
-		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:253
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.go.y:295
 		{
-			yyVAL.expression = &AssignExpression{left: yyDollar[1].expression, operand: yyDollar[3].expression}
-			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+			yyVAL.type_specifier = createFunctionTypeSpecifier(yyDollar[1].type_specifier, yyDollar[3].type_specifier_list)
 		}
-	case 40:
+	case 39:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:261
+//line parser.go.y:299
 		{
-			yyVAL.expression = &BinaryExpression{operator: LogicalOrOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
-			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+			yyVAL.type_specifier = createFunctionTypeSpecifier(yyDollar[1].type_specifier, nil)
 		}
-	case 42:
-		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:269
+	case 40:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:305
 		{
-			yyVAL.expression = &BinaryExpression{operator: LogicalAndOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
-			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+			yyVAL.type_specifier_list = []*TypeSpecifier{yyDollar[1].type_specifier}
 		}
-	case 44:
+	case 41:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:277
+//line parser.go.y:309
 		{
-			yyVAL.expression = &BinaryExpression{operator: EqOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
-			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+			yyVAL.type_specifier_list = append(yyDollar[1].type_specifier_list, yyDollar[3].type_specifier)
 		}
-	case 45:
-		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:282
+	case 42:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:315
 		{
-			yyVAL.expression = &BinaryExpression{operator: NeOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
-			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+			yyVAL.type_specifier = yyDollar[1].type_specifier
 		}
 	case 47:
-		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:290
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line parser.go.y:325
 		{
-			yyVAL.expression = &BinaryExpression{operator: GtOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
-			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+			l := yylex.(*Lexer)
+			yyVAL.function_definition = l.compiler.functionDefine(yyDollar[1].type_specifier, yyDollar[2].tok.Lit, yyDollar[4].parameter_list, yyDollar[6].block)
 		}
 	case 48:
-		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:295
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.go.y:330
 		{
-			yyVAL.expression = &BinaryExpression{operator: GeOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
-			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+			l := yylex.(*Lexer)
+			yyVAL.function_definition = l.compiler.functionDefine(yyDollar[1].type_specifier, yyDollar[2].tok.Lit, []*Parameter{}, yyDollar[5].block)
 		}
 	case 49:
-		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:300
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line parser.go.y:335
 		{
-			yyVAL.expression = &BinaryExpression{operator: LtOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
-			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+			l := yylex.(*Lexer)
+			yyVAL.function_definition = l.compiler.functionDefine(yyDollar[1].type_specifier, yyDollar[2].tok.Lit, yyDollar[4].parameter_list, nil)
 		}
 	case 50:
-		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:305
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.go.y:340
 		{
-			yyVAL.expression = &BinaryExpression{operator: LeOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
-			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+			l := yylex.(*Lexer)
+			yyVAL.function_definition = l.compiler.functionDefine(yyDollar[1].type_specifier, yyDollar[2].tok.Lit, []*Parameter{}, nil)
+		}
+	case 51:
+		yyDollar = yyS[yypt-9 : yypt+1]
+//line parser.go.y:345
+		{
+			l := yylex.(*Lexer)
+			yyVAL.function_definition = l.compiler.genericFunctionDefine(yyDollar[1].type_specifier, yyDollar[2].tok.Lit, yyDollar[4].identifier_list, yyDollar[7].parameter_list, yyDollar[9].block)
 		}
 	case 52:
-		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:313
+		yyDollar = yyS[yypt-8 : yypt+1]
+//line parser.go.y:350
 		{
-			yyVAL.expression = &BinaryExpression{operator: AddOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
-			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+			l := yylex.(*Lexer)
+			yyVAL.function_definition = l.compiler.genericFunctionDefine(yyDollar[1].type_specifier, yyDollar[2].tok.Lit, yyDollar[4].identifier_list, []*Parameter{}, yyDollar[8].block)
 		}
 	case 53:
-		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:318
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.go.y:357
 		{
-			yyVAL.expression = &BinaryExpression{operator: SubOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
-			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+			parameter := &Parameter{typeSpecifier: yyDollar[1].type_specifier, name: yyDollar[2].tok.Lit}
+			yyVAL.parameter_list = []*Parameter{parameter}
+		}
+	case 54:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.go.y:362
+		{
+			yyVAL.parameter_list = append(yyDollar[1].parameter_list, &Parameter{typeSpecifier: yyDollar[3].type_specifier, name: yyDollar[4].tok.Lit})
 		}
 	case 55:
-		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:326
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:368
 		{
-			yyVAL.expression = &BinaryExpression{operator: MulOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
-			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+			yyVAL.argument_list = []Expression{yyDollar[1].expression}
 		}
 	case 56:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:331
+//line parser.go.y:372
 		{
-			yyVAL.expression = &BinaryExpression{operator: DivOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
-			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+			yyVAL.argument_list = append(yyDollar[1].argument_list, yyDollar[3].expression)
+		}
+	case 57:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:378
+		{
+			yyVAL.statement_list = []Statement{yyDollar[1].statement}
 		}
 	case 58:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line parser.go.y:339
+//line parser.go.y:382
 		{
-			yyVAL.expression = &MinusExpression{operand: yyDollar[2].expression}
-			yyVAL.expression.SetPosition(yyDollar[1].tok.Position())
+			yyVAL.statement_list = append(yyDollar[1].statement_list, yyDollar[2].statement)
 		}
-	case 59:
-		yyDollar = yyS[yypt-2 : yypt+1]
-		//line parser.go.y:344
+	case 60:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:389
 		{
-			yyVAL.expression = &LogicalNotExpression{operand: yyDollar[2].expression}
-			yyVAL.expression.SetPosition(yyDollar[1].tok.Position())
+			yyVAL.expression = &CommaExpression{left: yyDollar[1].expression, right: yyDollar[3].expression}
+			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+		}
+	case 62:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:397
+		{
+			yyVAL.expression = &AssignExpression{left: yyDollar[1].expression, operator: yyDollar[2].assignment_operator, operand: yyDollar[3].expression}
+			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
 		}
 	case 63:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:356
+//line parser.go.y:404
 		{
-			yyVAL.expression = createIdentifierExpression(yyDollar[1].tok.Lit, yyDollar[1].tok.Position())
+			yyVAL.assignment_operator = NormalAssign
 		}
 	case 64:
-		yyDollar = yyS[yypt-4 : yypt+1]
-		//line parser.go.y:362
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:408
 		{
-			yyVAL.expression = createIndexExpression(yyDollar[1].expression, yyDollar[3].expression, yyDollar[1].expression.Position())
+			yyVAL.assignment_operator = AddAssign
 		}
 	case 65:
-		yyDollar = yyS[yypt-4 : yypt+1]
-		//line parser.go.y:366
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:412
 		{
-			identifier := createIdentifierExpression(yyDollar[1].tok.Lit, yyDollar[1].tok.Position())
-			yyVAL.expression = createIndexExpression(identifier, yyDollar[3].expression, yyDollar[1].tok.Position())
+			yyVAL.assignment_operator = SubAssign
 		}
 	case 66:
-		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:371
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:416
 		{
-			yyVAL.expression = createMemberExpression(yyDollar[1].expression, yyDollar[3].tok.Lit)
+			yyVAL.assignment_operator = MulAssign
 		}
 	case 67:
-		yyDollar = yyS[yypt-4 : yypt+1]
-		//line parser.go.y:375
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:420
 		{
-			yyVAL.expression = &FunctionCallExpression{function: yyDollar[1].expression, argumentList: yyDollar[3].argument_list}
-			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+			yyVAL.assignment_operator = DivAssign
 		}
 	case 68:
-		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:380
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:424
 		{
-			yyVAL.expression = &FunctionCallExpression{function: yyDollar[1].expression, argumentList: []Expression{}}
-			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+			yyVAL.assignment_operator = ModAssign
 		}
 	case 69:
-		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:385
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:428
 		{
-			yyVAL.expression = yyDollar[2].expression
+			yyVAL.assignment_operator = BitAndAssign
 		}
 	case 70:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:389
+//line parser.go.y:432
 		{
-			value, _ := strconv.Atoi(yyDollar[1].tok.Lit)
-			yyVAL.expression = &IntExpression{intValue: value}
-			yyVAL.expression.SetPosition(yyDollar[1].tok.Position())
+			yyVAL.assignment_operator = BitOrAssign
 		}
 	case 71:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:395
+//line parser.go.y:436
 		{
-			value, _ := strconv.ParseFloat(yyDollar[1].tok.Lit, 64)
-			yyVAL.expression = &DoubleExpression{doubleValue: value}
-			yyVAL.expression.SetPosition(yyDollar[1].tok.Position())
+			yyVAL.assignment_operator = BitXorAssign
 		}
 	case 72:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:401
+//line parser.go.y:440
 		{
-			yyVAL.expression = &StringExpression{stringValue: yyDollar[1].tok.Lit}
-			yyVAL.expression.SetPosition(yyDollar[1].tok.Position())
+			yyVAL.assignment_operator = LeftShiftAssign
 		}
 	case 73:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:406
-		{
-			yyVAL.expression = &BooleanExpression{booleanValue: true}
-			yyVAL.expression.SetPosition(yyDollar[1].tok.Position())
-		}
-	case 74:
-		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:411
+//line parser.go.y:444
 		{
-			yyVAL.expression = &BooleanExpression{booleanValue: false}
-			yyVAL.expression.SetPosition(yyDollar[1].tok.Position())
+			yyVAL.assignment_operator = RightShiftAssign
 		}
 	case 75:
-		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:416
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:451
 		{
-			yyVAL.expression = &NullExpression{}
-			yyVAL.expression.SetPosition(yyDollar[1].tok.Position())
+			yyVAL.expression = &BinaryExpression{operator: LogicalOrOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
+			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
 		}
 	case 77:
-		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:422
-		{
-			yyVAL.expression = createThisExpression(yyDollar[1].tok.Position())
-		}
-	case 78:
-		yyDollar = yyS[yypt-4 : yypt+1]
-		//line parser.go.y:426
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:459
 		{
-			yyVAL.expression = createNewExpression(yyDollar[2].class_name, nil, yyDollar[1].tok.Position())
+			yyVAL.expression = &BinaryExpression{operator: LogicalAndOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
+			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
 		}
 	case 79:
-		yyDollar = yyS[yypt-5 : yypt+1]
-		//line parser.go.y:430
-		{
-			yyVAL.expression = createNewExpression(yyDollar[2].class_name, yyDollar[4].argument_list, yyDollar[1].tok.Position())
-		}
-	case 80:
-		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:436
-		{
-			yyVAL.class_name = []string{yyDollar[1].tok.Lit}
-		}
-	case 81:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:440
+//line parser.go.y:467
 		{
-			yyVAL.class_name = append(yyDollar[1].class_name, yyDollar[3].tok.Lit)
+			yyVAL.expression = &BinaryExpression{operator: BitOrOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
+			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
 		}
-	case 82:
+	case 81:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:446
+//line parser.go.y:475
 		{
-			yyVAL.expression = &ArrayLiteralExpression{arrayLiteral: yyDollar[2].expression_list}
-			yyVAL.expression.SetPosition(yyDollar[1].tok.Position())
+			yyVAL.expression = &BinaryExpression{operator: BitXorOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
+			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
 		}
 	case 83:
-		yyDollar = yyS[yypt-4 : yypt+1]
-		//line parser.go.y:451
-		{
-			yyVAL.expression = &ArrayLiteralExpression{arrayLiteral: yyDollar[2].expression_list}
-			yyVAL.expression.SetPosition(yyDollar[1].tok.Position())
-		}
-	case 84:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:458
+//line parser.go.y:483
 		{
-			yyVAL.expression = createBasicArrayCreation(yyDollar[2].type_specifier, yyDollar[3].array_dimension_list, nil, yyDollar[1].tok.Position())
+			yyVAL.expression = &BinaryExpression{operator: BitAndOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
+			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
 		}
 	case 85:
-		yyDollar = yyS[yypt-4 : yypt+1]
-		//line parser.go.y:462
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:491
 		{
-			yyVAL.expression = createBasicArrayCreation(yyDollar[2].type_specifier, yyDollar[3].array_dimension_list, yyDollar[4].array_dimension_list, yyDollar[1].tok.Position())
+			yyVAL.expression = &BinaryExpression{operator: EqOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
+			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
 		}
 	case 86:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:466
+//line parser.go.y:496
 		{
-			yyVAL.expression = createClassArrayCreation(yyDollar[2].type_specifier, yyDollar[3].array_dimension_list, nil, yyDollar[1].tok.Position())
+			yyVAL.expression = &BinaryExpression{operator: NeOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
+			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+		}
+	case 88:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:504
+		{
+			yyVAL.expression = &BinaryExpression{operator: GtOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
+			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+		}
+	case 89:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:509
+		{
+			yyVAL.expression = &BinaryExpression{operator: GeOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
+			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+		}
+	case 90:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:514
+		{
+			yyVAL.expression = &BinaryExpression{operator: LtOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
+			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+		}
+	case 91:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:519
+		{
+			yyVAL.expression = &BinaryExpression{operator: LeOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
+			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+		}
+	case 92:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:524
+		{
+			yyVAL.expression = createInstanceofExpression(yyDollar[1].expression, yyDollar[3].type_specifier, yyDollar[1].expression.Position())
+		}
+	case 94:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:531
+		{
+			yyVAL.expression = &BinaryExpression{operator: LeftShiftOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
+			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+		}
+	case 95:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:536
+		{
+			yyVAL.expression = &BinaryExpression{operator: RightShiftOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
+			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+		}
+	case 97:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:544
+		{
+			yyVAL.expression = &BinaryExpression{operator: AddOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
+			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+		}
+	case 98:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:549
+		{
+			yyVAL.expression = &BinaryExpression{operator: SubOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
+			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+		}
+	case 100:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:557
+		{
+			yyVAL.expression = &BinaryExpression{operator: MulOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
+			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+		}
+	case 101:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:562
+		{
+			yyVAL.expression = &BinaryExpression{operator: DivOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
+			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+		}
+	case 102:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:567
+		{
+			yyVAL.expression = &BinaryExpression{operator: ModOperator, left: yyDollar[1].expression, right: yyDollar[3].expression}
+			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+		}
+	case 104:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.go.y:575
+		{
+			yyVAL.expression = &MinusExpression{operand: yyDollar[2].expression}
+			yyVAL.expression.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 105:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.go.y:580
+		{
+			yyVAL.expression = &LogicalNotExpression{operand: yyDollar[2].expression}
+			yyVAL.expression.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 106:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.go.y:585
+		{
+			yyVAL.expression = &BitNotExpression{operand: yyDollar[2].expression}
+			yyVAL.expression.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 107:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.go.y:590
+		{
+			yyVAL.expression = createIncrementExpression(yyDollar[2].expression, true, true, yyDollar[1].tok.Position())
+		}
+	case 108:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.go.y:594
+		{
+			yyVAL.expression = createIncrementExpression(yyDollar[2].expression, false, true, yyDollar[1].tok.Position())
+		}
+	case 109:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.go.y:598
+		{
+			yyVAL.expression = createDownCastExpression(yyDollar[2].expression, yyDollar[4].expression, yyDollar[1].tok.Position())
+		}
+	case 110:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.go.y:602
+		{
+			yyVAL.expression = createTypeCastExpression(yyDollar[2].type_specifier, yyDollar[4].expression, yyDollar[1].tok.Position())
+		}
+	case 112:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.go.y:609
+		{
+			yyVAL.expression = createIncrementExpression(yyDollar[1].expression, true, false, yyDollar[1].expression.Position())
+		}
+	case 113:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.go.y:613
+		{
+			yyVAL.expression = createIncrementExpression(yyDollar[1].expression, false, false, yyDollar[1].expression.Position())
+		}
+	case 116:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:621
+		{
+			yyVAL.expression = createIdentifierExpression(yyDollar[1].tok.Lit, yyDollar[1].tok.Position())
+		}
+	case 117:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.go.y:627
+		{
+			yyVAL.expression = createIndexExpression(yyDollar[1].expression, yyDollar[3].expression, yyDollar[1].expression.Position())
+		}
+	case 118:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.go.y:631
+		{
+			identifier := createIdentifierExpression(yyDollar[1].tok.Lit, yyDollar[1].tok.Position())
+			yyVAL.expression = createIndexExpression(identifier, yyDollar[3].expression, yyDollar[1].tok.Position())
+		}
+	case 119:
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line parser.go.y:636
+		{
+			yyVAL.expression = createSliceExpression(yyDollar[1].expression, yyDollar[3].expression, yyDollar[5].expression, yyDollar[1].expression.Position())
+		}
+	case 120:
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line parser.go.y:640
+		{
+			identifier := createIdentifierExpression(yyDollar[1].tok.Lit, yyDollar[1].tok.Position())
+			yyVAL.expression = createSliceExpression(identifier, yyDollar[3].expression, yyDollar[5].expression, yyDollar[1].tok.Position())
+		}
+	case 121:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:645
+		{
+			yyVAL.expression = createMemberExpression(yyDollar[1].expression, yyDollar[3].tok.Lit)
+		}
+	case 122:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.go.y:649
+		{
+			yyVAL.expression = &FunctionCallExpression{function: yyDollar[1].expression, argumentList: yyDollar[3].argument_list}
+			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+		}
+	case 123:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:654
+		{
+			yyVAL.expression = &FunctionCallExpression{function: yyDollar[1].expression, argumentList: []Expression{}}
+			yyVAL.expression.SetPosition(yyDollar[1].expression.Position())
+		}
+	case 124:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:659
+		{
+			if identifier, ok := yyDollar[2].expression.(*IdentifierExpression); ok {
+				identifier.parenthesized = true
+			}
+			yyVAL.expression = yyDollar[2].expression
+		}
+	case 125:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:666
+		{
+			value, _ := parseIntLiteral(yyDollar[1].tok.Lit)
+			yyVAL.expression = &IntExpression{intValue: value}
+			yyVAL.expression.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 126:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:672
+		{
+			value, _ := parseDoubleLiteral(yyDollar[1].tok.Lit)
+			yyVAL.expression = &DoubleExpression{doubleValue: value}
+			yyVAL.expression.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 127:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:678
+		{
+			yyVAL.expression = createCharLiteralExpression(yyDollar[1].tok)
+		}
+	case 128:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:682
+		{
+			yyVAL.expression = &StringExpression{stringValue: yyDollar[1].tok.Lit}
+			yyVAL.expression.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 129:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:687
+		{
+			yyVAL.expression = &BooleanExpression{booleanValue: true}
+			yyVAL.expression.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 130:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:692
+		{
+			yyVAL.expression = &BooleanExpression{booleanValue: false}
+			yyVAL.expression.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 131:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:697
+		{
+			yyVAL.expression = &NullExpression{}
+			yyVAL.expression.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 134:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:704
+		{
+			yyVAL.expression = createThisExpression(yyDollar[1].tok.Position())
+		}
+	case 135:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:708
+		{
+			yyVAL.expression = createSuperExpression(yyDollar[1].tok.Position())
+		}
+	case 136:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.go.y:712
+		{
+			yyVAL.expression = createNewExpression(yyDollar[2].class_name, nil, yyDollar[1].tok.Position())
+		}
+	case 137:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.go.y:716
+		{
+			yyVAL.expression = createNewExpression(yyDollar[2].class_name, yyDollar[4].argument_list, yyDollar[1].tok.Position())
+		}
+	case 138:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.go.y:720
+		{
+			yyVAL.expression = createGenericNewExpression(yyDollar[2].type_specifier, nil, yyDollar[1].tok.Position())
+		}
+	case 139:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.go.y:724
+		{
+			yyVAL.expression = createGenericNewExpression(yyDollar[2].type_specifier, yyDollar[4].argument_list, yyDollar[1].tok.Position())
+		}
+	case 141:
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line parser.go.y:731
+		{
+			yyVAL.expression = createLambdaExpression(yyDollar[5].type_specifier, yyDollar[2].parameter_list, yyDollar[6].block, yyDollar[1].tok.Position())
+		}
+	case 142:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.go.y:735
+		{
+			yyVAL.expression = createLambdaExpression(yyDollar[4].type_specifier, []*Parameter{}, yyDollar[5].block, yyDollar[1].tok.Position())
+		}
+	case 143:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.go.y:739
+		{
+			typ := createTypeSpecifier(vm.VoidType, yyDollar[4].tok.Position())
+			yyVAL.expression = createLambdaExpression(typ, yyDollar[2].parameter_list, yyDollar[5].block, yyDollar[1].tok.Position())
+		}
+	case 144:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.go.y:744
+		{
+			typ := createTypeSpecifier(vm.VoidType, yyDollar[3].tok.Position())
+			yyVAL.expression = createLambdaExpression(typ, []*Parameter{}, yyDollar[4].block, yyDollar[1].tok.Position())
+		}
+	case 145:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:751
+		{
+			yyVAL.class_name = []string{yyDollar[1].tok.Lit}
+		}
+	case 146:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:755
+		{
+			yyVAL.class_name = append(yyDollar[1].class_name, yyDollar[3].tok.Lit)
+		}
+	case 147:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:761
+		{
+			yyVAL.expression = &ArrayLiteralExpression{arrayLiteral: yyDollar[2].expression_list}
+			yyVAL.expression.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 148:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.go.y:766
+		{
+			yyVAL.expression = &ArrayLiteralExpression{arrayLiteral: yyDollar[2].expression_list}
+			yyVAL.expression.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 149:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:773
+		{
+			yyVAL.expression = createMapLiteralExpression(yyDollar[2].expression_list, yyDollar[1].tok.Position())
+		}
+	case 150:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.go.y:777
+		{
+			yyVAL.expression = createMapLiteralExpression(yyDollar[2].expression_list, yyDollar[1].tok.Position())
+		}
+	case 151:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:784
+		{
+			yyVAL.expression_list = []Expression{yyDollar[1].expression, yyDollar[3].expression}
+		}
+	case 152:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.go.y:788
+		{
+			yyVAL.expression_list = append(yyDollar[1].expression_list, yyDollar[3].expression, yyDollar[5].expression)
+		}
+	case 153:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:794
+		{
+			yyVAL.expression = createBasicArrayCreation(yyDollar[2].type_specifier, yyDollar[3].array_dimension_list, nil, yyDollar[1].tok.Position())
 		}
-	case 87:
+	case 154:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line parser.go.y:470
+//line parser.go.y:798
+		{
+			yyVAL.expression = createBasicArrayCreation(yyDollar[2].type_specifier, yyDollar[3].array_dimension_list, yyDollar[4].array_dimension_list, yyDollar[1].tok.Position())
+		}
+	case 155:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:802
+		{
+			yyVAL.expression = createClassArrayCreation(yyDollar[2].type_specifier, yyDollar[3].array_dimension_list, nil, yyDollar[1].tok.Position())
+		}
+	case 156:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.go.y:806
 		{
 			yyVAL.expression = createClassArrayCreation(yyDollar[2].type_specifier, yyDollar[3].array_dimension_list, yyDollar[4].array_dimension_list, yyDollar[1].tok.Position())
 		}
-	case 88:
+	case 157:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:810
+		{
+			yyVAL.expression = createClassArrayCreation(yyDollar[2].type_specifier, yyDollar[3].array_dimension_list, nil, yyDollar[1].tok.Position())
+		}
+	case 158:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.go.y:814
+		{
+			yyVAL.expression = createClassArrayCreation(yyDollar[2].type_specifier, yyDollar[3].array_dimension_list, yyDollar[4].array_dimension_list, yyDollar[1].tok.Position())
+		}
+	case 159:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:819
+		{
+			yyVAL.expression = createBasicArrayCreation(yyDollar[2].type_specifier, yyDollar[3].array_dimension_list, nil, yyDollar[1].tok.Position())
+		}
+	case 160:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.go.y:823
+		{
+			yyVAL.expression = createBasicArrayCreation(yyDollar[2].type_specifier, yyDollar[3].array_dimension_list, yyDollar[4].array_dimension_list, yyDollar[1].tok.Position())
+		}
+	case 161:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:476
+//line parser.go.y:829
 		{
 			yyVAL.array_dimension_list = []*ArrayDimension{yyDollar[1].array_dimension}
 		}
-	case 89:
+	case 162:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line parser.go.y:480
+//line parser.go.y:833
 		{
 			yyVAL.array_dimension_list = append(yyDollar[1].array_dimension_list, yyDollar[2].array_dimension)
 		}
-	case 90:
+	case 163:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:486
+//line parser.go.y:839
 		{
 			yyVAL.array_dimension = &ArrayDimension{expression: yyDollar[2].expression}
 		}
-	case 91:
+	case 164:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line parser.go.y:492
+//line parser.go.y:845
 		{
 			yyVAL.array_dimension_list = []*ArrayDimension{&ArrayDimension{}}
 		}
-	case 92:
+	case 165:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:496
+//line parser.go.y:849
 		{
 			yyVAL.array_dimension_list = append(yyDollar[1].array_dimension_list, &ArrayDimension{})
 		}
-	case 93:
+	case 166:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line parser.go.y:502
+//line parser.go.y:855
 		{
 			yyVAL.expression_list = nil
 		}
-	case 94:
+	case 167:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:506
+//line parser.go.y:859
 		{
 			yyVAL.expression_list = []Expression{yyDollar[1].expression}
 		}
-	case 95:
+	case 168:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:510
+//line parser.go.y:863
 		{
 			yyVAL.expression_list = append(yyDollar[1].expression_list, yyDollar[3].expression)
 		}
-	case 96:
+	case 169:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line parser.go.y:516
+//line parser.go.y:869
 		{
 			yyVAL.statement = &ExpressionStatement{expression: yyDollar[1].expression}
 			yyVAL.statement.SetPosition(yyDollar[1].expression.Position())
 		}
-	case 103:
+	case 180:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:529
+//line parser.go.y:886
 		{
 			yyVAL.statement = &IfStatement{condition: yyDollar[2].expression, thenBlock: yyDollar[3].block, elifList: []*Elif{}, elseBlock: nil}
 			yyVAL.statement.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 104:
+	case 181:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line parser.go.y:534
+//line parser.go.y:891
 		{
 			yyVAL.statement = &IfStatement{condition: yyDollar[2].expression, thenBlock: yyDollar[3].block, elifList: []*Elif{}, elseBlock: yyDollar[5].block}
 			yyVAL.statement.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 105:
+	case 182:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line parser.go.y:539
+//line parser.go.y:896
 		{
 			yyVAL.statement = &IfStatement{condition: yyDollar[2].expression, thenBlock: yyDollar[3].block, elifList: yyDollar[4].elif_list, elseBlock: nil}
 			yyVAL.statement.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 106:
+	case 183:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line parser.go.y:544
+//line parser.go.y:901
 		{
 			yyVAL.statement = &IfStatement{condition: yyDollar[2].expression, thenBlock: yyDollar[3].block, elifList: yyDollar[4].elif_list, elseBlock: yyDollar[6].block}
 			yyVAL.statement.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 107:
+	case 184:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:551
+//line parser.go.y:908
 		{
 			yyVAL.elif_list = []*Elif{&Elif{condition: yyDollar[2].expression, block: yyDollar[3].block}}
 		}
-	case 108:
+	case 185:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line parser.go.y:555
+//line parser.go.y:912
 		{
 			yyVAL.elif_list = append(yyDollar[1].elif_list, &Elif{condition: yyDollar[3].expression, block: yyDollar[4].block})
 		}
-	case 109:
+	case 186:
+		yyDollar = yyS[yypt-7 : yypt+1]
+//line parser.go.y:918
+		{
+			yyVAL.statement = createSwitchStatement(yyDollar[3].expression, yyDollar[6].case_list, yyDollar[1].tok.Position())
+		}
+	case 187:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:924
+		{
+			yyVAL.case_list = []*CaseClause{yyDollar[1].case_clause}
+		}
+	case 188:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.go.y:928
+		{
+			yyVAL.case_list = append(yyDollar[1].case_list, yyDollar[2].case_clause)
+		}
+	case 189:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.go.y:934
+		{
+			yyVAL.case_clause = createCaseClause(yyDollar[2].expression_list, yyDollar[4].block, yyDollar[1].tok.Position())
+		}
+	case 190:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:938
+		{
+			yyVAL.case_clause = createCaseClause(nil, yyDollar[3].block, yyDollar[1].tok.Position())
+		}
+	case 191:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:944
+		{
+			yyVAL.expression_list = []Expression{yyDollar[1].expression}
+		}
+	case 192:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:948
+		{
+			yyVAL.expression_list = append(yyDollar[1].expression_list, yyDollar[3].expression)
+		}
+	case 193:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line parser.go.y:954
+		{
+			l := yylex.(*Lexer)
+			l.compiler.currentBlock = &Block{outerBlock: l.compiler.currentBlock}
+			yyVAL.block = l.compiler.currentBlock
+		}
+	case 194:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.go.y:960
+		{
+			currentBlock := yyDollar[1].block
+			currentBlock.statementList = yyDollar[2].statement_list
+
+			l := yylex.(*Lexer)
+
+			yyVAL.block = currentBlock
+			l.compiler.currentBlock = currentBlock.outerBlock
+		}
+	case 195:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line parser.go.y:972
+		{
+			yyVAL.statement_list = nil
+		}
+	case 200:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:984
+		{
+			yyVAL.statement = createLabeledStatement(yyDollar[1].tok.Lit, yyDollar[3].statement, yyDollar[1].tok.Position())
+		}
+	case 201:
 		yyDollar = yyS[yypt-9 : yypt+1]
-		//line parser.go.y:561
+//line parser.go.y:990
 		{
 			yyVAL.statement = &ForStatement{init: yyDollar[3].expression, condition: yyDollar[5].expression, post: yyDollar[7].expression, block: yyDollar[9].block}
 			yyVAL.statement.SetPosition(yyDollar[1].tok.Position())
 			yyDollar[9].block.parent = &StatementBlockInfo{statement: yyVAL.statement}
 		}
-	case 110:
+	case 202:
+		yyDollar = yyS[yypt-8 : yypt+1]
+//line parser.go.y:996
+		{
+			decl := createForEachDeclaration(yyDollar[3].type_specifier, yyDollar[4].tok.Lit)
+			yyVAL.statement = createForEachStatement([]*Declaration{decl}, yyDollar[6].expression, yyDollar[8].block, yyDollar[1].tok.Position())
+		}
+	case 203:
+		yyDollar = yyS[yypt-11 : yypt+1]
+//line parser.go.y:1001
+		{
+			declList := []*Declaration{createForEachDeclaration(yyDollar[3].type_specifier, yyDollar[4].tok.Lit), createForEachDeclaration(yyDollar[6].type_specifier, yyDollar[7].tok.Lit)}
+			yyVAL.statement = createForEachStatement(declList, yyDollar[9].expression, yyDollar[11].block, yyDollar[1].tok.Position())
+		}
+	case 204:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.go.y:1008
+		{
+			yyVAL.statement = &WhileStatement{condition: yyDollar[3].expression, block: yyDollar[5].block}
+			yyVAL.statement.SetPosition(yyDollar[1].tok.Position())
+			yyDollar[5].block.parent = &StatementBlockInfo{statement: yyVAL.statement}
+		}
+	case 205:
+		yyDollar = yyS[yypt-7 : yypt+1]
+//line parser.go.y:1016
+		{
+			yyVAL.statement = &DoWhileStatement{block: yyDollar[2].block, condition: yyDollar[5].expression}
+			yyVAL.statement.SetPosition(yyDollar[1].tok.Position())
+			yyDollar[2].block.parent = &StatementBlockInfo{statement: yyVAL.statement}
+		}
+	case 206:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line parser.go.y:569
+//line parser.go.y:1024
 		{
 			yyVAL.expression = nil
 		}
-	case 112:
+	case 208:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:576
+//line parser.go.y:1031
 		{
 			yyVAL.statement = &ReturnStatement{returnValue: yyDollar[2].expression}
 			yyVAL.statement.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 113:
+	case 209:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line parser.go.y:583
+//line parser.go.y:1038
 		{
 			yyVAL.statement = &BreakStatement{}
 			yyVAL.statement.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 114:
+	case 210:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:1043
+		{
+			yyVAL.statement = &BreakStatement{label: yyDollar[2].tok.Lit}
+			yyVAL.statement.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 211:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line parser.go.y:590
+//line parser.go.y:1050
 		{
 			yyVAL.statement = &ContinueStatement{}
 			yyVAL.statement.SetPosition(yyDollar[1].tok.Position())
 		}
-	case 115:
+	case 212:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:1055
+		{
+			yyVAL.statement = &ContinueStatement{label: yyDollar[2].tok.Lit}
+			yyVAL.statement.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 213:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:597
+//line parser.go.y:1062
+		{
+			yyVAL.statement = createTryStatement(yyDollar[2].block, yyDollar[3].catch_list, nil, yyDollar[1].tok.Position())
+		}
+	case 214:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.go.y:1066
+		{
+			yyVAL.statement = createTryStatement(yyDollar[2].block, yyDollar[3].catch_list, yyDollar[5].block, yyDollar[1].tok.Position())
+		}
+	case 215:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.go.y:1070
+		{
+			yyVAL.statement = createTryStatement(yyDollar[2].block, nil, yyDollar[4].block, yyDollar[1].tok.Position())
+		}
+	case 216:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:1076
+		{
+			yyVAL.catch_list = []*CatchClause{yyDollar[1].catch_clause}
+		}
+	case 217:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.go.y:1080
+		{
+			yyVAL.catch_list = append(yyDollar[1].catch_list, yyDollar[2].catch_clause)
+		}
+	case 218:
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line parser.go.y:1086
+		{
+			yyVAL.catch_clause = createCatchClause(yyDollar[3].type_specifier, yyDollar[4].tok.Lit, yyDollar[6].block, yyDollar[1].tok.Position())
+		}
+	case 219:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:1092
+		{
+			yyVAL.statement = &ThrowStatement{exception: yyDollar[2].expression}
+			yyVAL.statement.SetPosition(yyDollar[1].tok.Position())
+		}
+	case 220:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:1099
 		{
 			yyVAL.statement = &Declaration{typeSpecifier: yyDollar[1].type_specifier, name: yyDollar[2].tok.Lit, variableIndex: -1}
 			yyVAL.statement.SetPosition(yyDollar[1].type_specifier.Position())
 		}
-	case 116:
+	case 221:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line parser.go.y:602
+//line parser.go.y:1104
 		{
 			yyVAL.statement = &Declaration{typeSpecifier: yyDollar[1].type_specifier, name: yyDollar[2].tok.Lit, initializer: yyDollar[4].expression, variableIndex: -1}
 			yyVAL.statement.SetPosition(yyDollar[1].type_specifier.Position())
 		}
-	case 117:
+	case 222:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:609
+//line parser.go.y:1111
 		{
 			l := yylex.(*Lexer)
 			l.compiler.currentBlock = &Block{outerBlock: l.compiler.currentBlock}
 			yyVAL.block = l.compiler.currentBlock
 		}
-	case 118:
+	case 223:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line parser.go.y:615
+//line parser.go.y:1117
 		{
 			currentBlock := yyDollar[2].block
 			currentBlock.statementList = yyDollar[3].statement_list
@@ -1300,102 +2175,262 @@ yydefault:
 			yyVAL.block = l.compiler.currentBlock
 			l.compiler.currentBlock = currentBlock.outerBlock
 		}
-	case 119:
+	case 224:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line parser.go.y:625
+//line parser.go.y:1127
 		{
 			l := yylex.(*Lexer)
 			yyVAL.block = &Block{outerBlock: l.compiler.currentBlock}
 		}
-	case 120:
-		yyDollar = yyS[yypt-4 : yypt+1]
-		//line parser.go.y:632
+	case 225:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.go.y:1134
 		{
-			startClassDefine(yyDollar[2].tok.Lit, yyDollar[3].extends_list, yyDollar[1].tok.Position())
+			l := yylex.(*Lexer)
+			l.compiler.startClassDefine(yyDollar[1].modifier_list, yyDollar[2].tok.Tok == INTERFACE_T, yyDollar[3].tok.Lit, yyDollar[4].extends_list, yyDollar[2].tok.Position())
 		}
-	case 121:
+	case 226:
+		yyDollar = yyS[yypt-8 : yypt+1]
+//line parser.go.y:1139
+		{
+			l := yylex.(*Lexer)
+			l.compiler.endClassDefine(yyDollar[7].member_declaration)
+		}
+	case 227:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.go.y:1144
+		{
+			l := yylex.(*Lexer)
+			l.compiler.startClassDefine(yyDollar[1].modifier_list, yyDollar[2].tok.Tok == INTERFACE_T, yyDollar[3].tok.Lit, yyDollar[4].extends_list, yyDollar[2].tok.Position())
+		}
+	case 228:
 		yyDollar = yyS[yypt-7 : yypt+1]
-		//line parser.go.y:636
+//line parser.go.y:1149
+		{
+			l := yylex.(*Lexer)
+			l.compiler.endClassDefine(nil)
+		}
+	case 229:
+		yyDollar = yyS[yypt-8 : yypt+1]
+//line parser.go.y:1154
 		{
-			endClassDefine(yyDollar[6].member_declaration)
+			l := yylex.(*Lexer)
+			l.compiler.startGenericClassDefine(yyDollar[1].modifier_list, yyDollar[2].tok.Tok == INTERFACE_T, yyDollar[3].tok.Lit, yyDollar[5].identifier_list, yyDollar[7].extends_list, yyDollar[2].tok.Position())
 		}
-	case 122:
-		yyDollar = yyS[yypt-4 : yypt+1]
-		//line parser.go.y:640
+	case 230:
+		yyDollar = yyS[yypt-11 : yypt+1]
+//line parser.go.y:1159
 		{
-			startClassDefine(yyDollar[2].tok.Lit, yyDollar[3].extends_list, yyDollar[1].tok.Position())
+			l := yylex.(*Lexer)
+			l.compiler.endGenericClassDefine(yyDollar[10].member_declaration)
 		}
-	case 123:
-		yyDollar = yyS[yypt-6 : yypt+1]
-		//line parser.go.y:644
+	case 231:
+		yyDollar = yyS[yypt-8 : yypt+1]
+//line parser.go.y:1164
 		{
-			endClassDefine(nil)
+			l := yylex.(*Lexer)
+			l.compiler.startGenericClassDefine(yyDollar[1].modifier_list, yyDollar[2].tok.Tok == INTERFACE_T, yyDollar[3].tok.Lit, yyDollar[5].identifier_list, yyDollar[7].extends_list, yyDollar[2].tok.Position())
 		}
-	case 124:
+	case 232:
+		yyDollar = yyS[yypt-10 : yypt+1]
+//line parser.go.y:1169
+		{
+			l := yylex.(*Lexer)
+			l.compiler.endGenericClassDefine(nil)
+		}
+	case 235:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line parser.go.y:650
+//line parser.go.y:1180
+		{
+			yyVAL.modifier_list = nil
+		}
+	case 238:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.go.y:1188
+		{
+			yyVAL.modifier_list = chainClassOrMemberModifier(yyDollar[1].modifier_list, yyDollar[2].modifier_list)
+		}
+	case 239:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:1194
+		{
+			yyVAL.modifier_list = createClassOrMemberModifier(AbstractModifier, yyDollar[1].tok.Position())
+		}
+	case 240:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:1198
+		{
+			yyVAL.modifier_list = createClassOrMemberModifier(VirtualModifier, yyDollar[1].tok.Position())
+		}
+	case 241:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:1202
+		{
+			yyVAL.modifier_list = createClassOrMemberModifier(OverrideModifier, yyDollar[1].tok.Position())
+		}
+	case 242:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:1206
+		{
+			yyVAL.modifier_list = createClassOrMemberModifier(PublicModifier, yyDollar[1].tok.Position())
+		}
+	case 243:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:1210
+		{
+			yyVAL.modifier_list = createClassOrMemberModifier(PrivateModifier, yyDollar[1].tok.Position())
+		}
+	case 244:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:1214
+		{
+			yyVAL.modifier_list = createClassOrMemberModifier(ProtectedModifier, yyDollar[1].tok.Position())
+		}
+	case 245:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:1218
+		{
+			yyVAL.modifier_list = createClassOrMemberModifier(StaticModifier, yyDollar[1].tok.Position())
+		}
+	case 246:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line parser.go.y:1224
 		{
 			yyVAL.extends_list = nil
 		}
-	case 125:
+	case 247:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line parser.go.y:654
+//line parser.go.y:1228
 		{
 			yyVAL.extends_list = yyDollar[2].extends_list
 		}
-	case 126:
+	case 248:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:660
+//line parser.go.y:1234
 		{
 			yyVAL.extends_list = createExtendList(yyDollar[1].tok.Lit)
 		}
-	case 127:
+	case 249:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:664
+//line parser.go.y:1238
 		{
 			yyVAL.extends_list = chainExtendList(yyDollar[1].extends_list, yyDollar[3].tok.Lit)
 		}
-	case 129:
+	case 250:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.go.y:1242
+		{
+			yyVAL.extends_list = createGenericExtendList(yyDollar[1].type_specifier)
+		}
+	case 251:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.go.y:1246
+		{
+			yyVAL.extends_list = chainGenericExtendList(yyDollar[1].extends_list, yyDollar[3].type_specifier)
+		}
+	case 253:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line parser.go.y:671
+//line parser.go.y:1253
 		{
 			yyVAL.member_declaration = chainMemberDeclaration(yyDollar[1].member_declaration, yyDollar[2].member_declaration)
 		}
-	case 132:
+	case 257:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line parser.go.y:681
+//line parser.go.y:1264
+		{
+			l := yylex.(*Lexer)
+			yyVAL.member_declaration = l.compiler.createMethodMember(nil, yyDollar[1].function_definition, yyDollar[1].function_definition.typeSpecifier.Position())
+		}
+	case 258:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.go.y:1269
 		{
-			yyVAL.member_declaration = createMethodMember(yyDollar[1].function_definition, yyDollar[1].function_definition.typeSpecifier.Position())
+			l := yylex.(*Lexer)
+			yyVAL.member_declaration = l.compiler.createMethodMember(yyDollar[1].modifier_list, yyDollar[2].function_definition, yyDollar[2].function_definition.typeSpecifier.Position())
 		}
-	case 133:
+	case 259:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line parser.go.y:687
+//line parser.go.y:1276
 		{
-			yyVAL.function_definition = methodFunctionDefine(yyDollar[1].type_specifier, yyDollar[2].tok.Lit, yyDollar[4].parameter_list, yyDollar[6].block)
+			l := yylex.(*Lexer)
+			yyVAL.function_definition = l.compiler.methodFunctionDefine(yyDollar[1].type_specifier, yyDollar[2].tok.Lit, yyDollar[4].parameter_list, yyDollar[6].block)
 		}
-	case 134:
+	case 260:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line parser.go.y:691
+//line parser.go.y:1281
 		{
-			yyVAL.function_definition = methodFunctionDefine(yyDollar[1].type_specifier, yyDollar[2].tok.Lit, nil, yyDollar[5].block)
+			l := yylex.(*Lexer)
+			yyVAL.function_definition = l.compiler.methodFunctionDefine(yyDollar[1].type_specifier, yyDollar[2].tok.Lit, nil, yyDollar[5].block)
 		}
-	case 135:
+	case 261:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line parser.go.y:695
+//line parser.go.y:1286
 		{
-			yyVAL.function_definition = methodFunctionDefine(yyDollar[1].type_specifier, yyDollar[2].tok.Lit, yyDollar[4].parameter_list, nil)
+			l := yylex.(*Lexer)
+			yyVAL.function_definition = l.compiler.methodFunctionDefine(yyDollar[1].type_specifier, yyDollar[2].tok.Lit, yyDollar[4].parameter_list, nil)
 		}
-	case 136:
+	case 262:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line parser.go.y:699
+//line parser.go.y:1291
 		{
-			yyVAL.function_definition = methodFunctionDefine(yyDollar[1].type_specifier, yyDollar[2].tok.Lit, nil, nil)
+			l := yylex.(*Lexer)
+			yyVAL.function_definition = l.compiler.methodFunctionDefine(yyDollar[1].type_specifier, yyDollar[2].tok.Lit, nil, nil)
 		}
-	case 137:
+	case 263:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line parser.go.y:705
+//line parser.go.y:1298
+		{
+			l := yylex.(*Lexer)
+			yyVAL.member_declaration = l.compiler.createFieldMember(nil, yyDollar[1].type_specifier, yyDollar[2].tok.Lit, nil, yyDollar[1].type_specifier.Position())
+		}
+	case 264:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.go.y:1303
+		{
+			l := yylex.(*Lexer)
+			yyVAL.member_declaration = l.compiler.createFieldMember(nil, yyDollar[1].type_specifier, yyDollar[2].tok.Lit, yyDollar[4].expression, yyDollar[1].type_specifier.Position())
+		}
+	case 265:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.go.y:1308
+		{
+			l := yylex.(*Lexer)
+			yyVAL.member_declaration = l.compiler.createFieldMember(yyDollar[1].modifier_list, yyDollar[2].type_specifier, yyDollar[3].tok.Lit, nil, yyDollar[2].type_specifier.Position())
+		}
+	case 266:
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line parser.go.y:1313
+		{
+			l := yylex.(*Lexer)
+			yyVAL.member_declaration = l.compiler.createFieldMember(yyDollar[1].modifier_list, yyDollar[2].type_specifier, yyDollar[3].tok.Lit, yyDollar[5].expression, yyDollar[2].type_specifier.Position())
+		}
+	case 267:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.go.y:1320
+		{
+			l := yylex.(*Lexer)
+			yyVAL.member_declaration = l.compiler.createConstructorMember(nil, yyDollar[1].tok.Lit, yyDollar[3].parameter_list, yyDollar[5].block, yyDollar[1].tok.Position())
+		}
+	case 268:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.go.y:1325
 		{
-			yyVAL.member_declaration = createFieldMember(yyDollar[1].type_specifier, yyDollar[2].tok.Lit, yyDollar[1].type_specifier.Position())
+			l := yylex.(*Lexer)
+			yyVAL.member_declaration = l.compiler.createConstructorMember(nil, yyDollar[1].tok.Lit, nil, yyDollar[4].block, yyDollar[1].tok.Position())
+		}
+	case 269:
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line parser.go.y:1330
+		{
+			l := yylex.(*Lexer)
+			yyVAL.member_declaration = l.compiler.createConstructorMember(yyDollar[1].modifier_list, yyDollar[2].tok.Lit, yyDollar[4].parameter_list, yyDollar[6].block, yyDollar[2].tok.Position())
+		}
+	case 270:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.go.y:1335
+		{
+			l := yylex.(*Lexer)
+			yyVAL.member_declaration = l.compiler.createConstructorMember(yyDollar[1].modifier_list, yyDollar[2].tok.Lit, nil, yyDollar[5].block, yyDollar[2].tok.Position())
 		}
 	}
 	goto yystack /* stack new state and value */
@@ -3,7 +3,6 @@ package compiler
 
 import (
     "github.com/lth-go/gogogogo/vm"
-    "strconv"
 )
 %}
 
@@ -22,6 +21,8 @@ import (
 
     basic_type_specifier *TypeSpecifier
     type_specifier       *TypeSpecifier
+    type_specifier_list  []*TypeSpecifier
+    identifier_list      []string
 
     array_dimension      *ArrayDimension
     array_dimension_list []*ArrayDimension
@@ -34,72 +35,110 @@ import (
     function_definition  *FunctionDefinition
 
     class_name           []string
+    modifier_list        *ClassOrMemberModifierList
+
+    catch_clause         *CatchClause
+    catch_list           []*CatchClause
+
+    case_clause          *CaseClause
+    case_list            []*CaseClause
+
+    assignment_operator  AssignmentOperatorKind
 
     tok                  Token
 }
 
-%token<tok> IF ELSE ELIF FOR RETURN_T BREAK CONTINUE
+%token<tok> IF ELSE ELIF FOR WHILE DO_T SWITCH CASE DEFAULT_T RETURN_T BREAK CONTINUE
         LP RP LC RC LB RB
         SEMICOLON COMMA COLON
-        ASSIGN_T
+        ASSIGN_T ADD_ASSIGN_T SUB_ASSIGN_T MUL_ASSIGN_T DIV_ASSIGN_T MOD_ASSIGN_T
+        BIT_AND_ASSIGN_T BIT_OR_ASSIGN_T BIT_XOR_ASSIGN_T LEFT_SHIFT_ASSIGN_T RIGHT_SHIFT_ASSIGN_T
         LOGICAL_AND LOGICAL_OR
         EQ NE GT GE LT LE
-        ADD SUB MUL DIV
-        INT_LITERAL DOUBLE_LITERAL STRING_LITERAL TRUE_T FALSE_T
+        ADD SUB MUL DIV MOD
+        BIT_AND BIT_OR BIT_XOR BIT_NOT LEFT_SHIFT RIGHT_SHIFT
+        INCREMENT DECREMENT
+        INT_LITERAL DOUBLE_LITERAL CHAR_LITERAL STRING_LITERAL TRUE_T FALSE_T
         NULL_T
         IDENTIFIER
         EXCLAMATION DOT
-        VOID_T BOOLEAN_T INT_T DOUBLE_T STRING_T
+        VOID_T BOOLEAN_T INT_T DOUBLE_T CHAR_T STRING_T BYTE_T INT32_T LONG_T
         NEW
         REQUIRE
-        CLASS_T THIS_T
+        CLASS_T INTERFACE_T THIS_T SUPER_T INSTANCEOF
+        ABSTRACT_T VIRTUAL_T OVERRIDE_T PUBLIC_T PRIVATE_T PROTECTED_T STATIC_T
+        TRY CATCH FINALLY THROW
+        ARROW TYPE_LT
 
 %type   <class_name> class_name
+%type   <tok> class_or_interface
+%type   <modifier_list> class_or_member_modifier_list class_or_member_modifier class_modifier_opt
 %type   <package_name> package_name
 %type   <require_list> require_list require_declaration
 
-%type <expression> expression expression_opt
+%type <expression> expression expression_opt lambda_expression
       assignment_expression
       logical_and_expression logical_or_expression
-      equality_expression relational_expression
+      inclusive_or_expression exclusive_or_expression and_expression
+      equality_expression relational_expression shift_expression
       additive_expression multiplicative_expression
       unary_expression postfix_expression primary_expression primary_no_new_array
-      array_literal array_creation
-%type   <expression_list> expression_list
+      array_literal array_creation map_literal
+%type   <expression_list> expression_list case_value_list map_entry_list
+%type   <assignment_operator> assignment_operator
 
 %type <statement> statement
-      if_statement for_statement
+      if_statement switch_statement for_statement while_statement do_while_statement
+      loop_statement labeled_statement
       return_statement break_statement continue_statement
       declaration_statement
-%type <statement_list> statement_list
+      try_statement throw_statement
+%type <statement_list> statement_list case_statement_list
 %type <parameter_list> parameter_list
+%type <type_specifier_list> type_list
+%type <identifier_list> type_parameter_list
 %type <argument_list> argument_list
-%type <block> block
+%type <block> block case_block
 %type <elif_list> elif_list
 
 %type <type_specifier> basic_type_specifier type_specifier class_type_specifier array_type_specifier
+      function_type_specifier basic_function_type_specifier generic_type_specifier
 
 %type <array_dimension> dimension_expression
 %type <array_dimension_list> dimension_expression_list dimension_list
 
 %type   <extends_list> extends_list extends
-%type   <member_declaration> member_declaration member_declaration_list method_member field_member
-%type   <function_definition> method_function_definition
+%type   <member_declaration> member_declaration member_declaration_list method_member field_member constructor_member
+%type   <function_definition> function_definition method_function_definition
+
+%type   <catch_clause> catch_clause
+%type   <catch_list> catch_list
+
+%type   <case_clause> case_clause
+%type   <case_list> case_list
+
+// (a)之后的token既可能是向下转型的操作数, 也可能属于括号表达式之外
+// {, -, ++, --, (时作为括号表达式, (a)(b)在修正时根据a是否是类名区分向下转型和函数调用
+%nonassoc LP
+%nonassoc LC SUB INCREMENT DECREMENT
+%nonassoc PAREN_EXPRESSION
 
 %%
 
 translation_unit
-        : initial_declaration definition_or_statement
+        : initial_declaration
         | translation_unit definition_or_statement
         ;
 initial_declaration
         : /* empty */
         {
-            setRequireList(nil)
+            l := yylex.(*Lexer)
+            l.compiler.setRequireList(nil)
         }
         | require_list
         {
-            setRequireList($1)
+            l := yylex.(*Lexer)
+            l.compiler.setRequireList($1)
         }
         ;
 require_list
@@ -112,7 +151,7 @@ require_list
 require_declaration
         : REQUIRE package_name SEMICOLON
         {
-            $$ = createRequireList($2)
+            $$ = createRequireList($2, $1.Position())
         }
         ;
 package_name
@@ -127,12 +166,23 @@ package_name
         ;
 definition_or_statement
         : function_definition
+        | PRIVATE_T function_definition
+        {
+            $2.isPrivate = true
+        }
         | class_definition
         | statement
         {
             l := yylex.(*Lexer)
             l.compiler.statementList = append(l.compiler.statementList, $1)
         }
+        /* private全局变量不能从包外访问 */
+        | PRIVATE_T declaration_statement
+        {
+            $2.(*Declaration).isPrivate = true
+            l := yylex.(*Lexer)
+            l.compiler.statementList = append(l.compiler.statementList, $2)
+        }
         ;
 basic_type_specifier
         : VOID_T
@@ -151,6 +201,22 @@ basic_type_specifier
         {
             $$ = createTypeSpecifier(vm.DoubleType, $1.Position())
         }
+        | CHAR_T
+        {
+            $$ = createTypeSpecifier(vm.CharType, $1.Position())
+        }
+        | BYTE_T
+        {
+            $$ = createTypeSpecifier(vm.ByteType, $1.Position())
+        }
+        | INT32_T
+        {
+            $$ = createTypeSpecifier(vm.Int32Type, $1.Position())
+        }
+        | LONG_T
+        {
+            $$ = createTypeSpecifier(vm.LongType, $1.Position())
+        }
         | STRING_T
         {
             $$ = createTypeSpecifier(vm.StringType, $1.Position())
@@ -159,7 +225,24 @@ basic_type_specifier
 class_type_specifier
         : IDENTIFIER
         {
-            $$ = createClassTypeSpecifier($1.Lit, $1.Position())
+            l := yylex.(*Lexer)
+            $$ = l.compiler.createNamedTypeSpecifier($1.Lit, $1.Position())
+        }
+        ;
+generic_type_specifier
+        : IDENTIFIER TYPE_LT type_list GT
+        {
+            $$ = createGenericTypeSpecifier($1.Lit, $3, $1.Position())
+        }
+        ;
+type_parameter_list
+        : IDENTIFIER
+        {
+            $$ = []string{$1.Lit}
+        }
+        | type_parameter_list COMMA IDENTIFIER
+        {
+            $$ = append($1, $3.Lit)
         }
         ;
 array_type_specifier
@@ -170,13 +253,62 @@ array_type_specifier
         }
         | IDENTIFIER LB RB
         {
-            class_type := createClassTypeSpecifier($1.Lit, $1.Position())
+            l := yylex.(*Lexer)
+            class_type := l.compiler.createNamedTypeSpecifier($1.Lit, $1.Position())
             $$ = createArrayTypeSpecifier(class_type)
         }
+        | generic_type_specifier LB RB
+        {
+            $$ = createArrayTypeSpecifier($1)
+        }
         | array_type_specifier LB RB
         {
             $$ = createArrayTypeSpecifier($1)
         }
+        | function_type_specifier LB RB
+        {
+            $$ = createArrayTypeSpecifier($1)
+        }
+        ;
+/* 返回类的函数类型与函数调用冲突, 暂不支持 */
+function_type_specifier
+        : basic_function_type_specifier
+        | array_type_specifier LP type_list RP
+        {
+            $$ = createFunctionTypeSpecifier($1, $3)
+        }
+        | array_type_specifier LP RP
+        {
+            $$ = createFunctionTypeSpecifier($1, nil)
+        }
+        | function_type_specifier LP type_list RP
+        {
+            $$ = createFunctionTypeSpecifier($1, $3)
+        }
+        | function_type_specifier LP RP
+        {
+            $$ = createFunctionTypeSpecifier($1, nil)
+        }
+        ;
+basic_function_type_specifier
+        : basic_type_specifier LP type_list RP
+        {
+            $$ = createFunctionTypeSpecifier($1, $3)
+        }
+        | basic_type_specifier LP RP
+        {
+            $$ = createFunctionTypeSpecifier($1, nil)
+        }
+        ;
+type_list
+        : type_specifier
+        {
+            $$ = []*TypeSpecifier{$1}
+        }
+        | type_list COMMA type_specifier
+        {
+            $$ = append($1, $3)
+        }
         ;
 type_specifier
         : basic_type_specifier
@@ -185,27 +317,39 @@ type_specifier
         }
         | array_type_specifier
         | class_type_specifier
+        | function_type_specifier
+        | generic_type_specifier
         ;
 function_definition
         : type_specifier IDENTIFIER LP parameter_list RP block
         {
             l := yylex.(*Lexer)
-            l.compiler.functionDefine($1, $2.Lit, $4, $6)
+            $$ = l.compiler.functionDefine($1, $2.Lit, $4, $6)
         }
         | type_specifier IDENTIFIER LP RP block
         {
             l := yylex.(*Lexer)
-            l.compiler.functionDefine($1, $2.Lit, []*Parameter{}, $5)
+            $$ = l.compiler.functionDefine($1, $2.Lit, []*Parameter{}, $5)
         }
         | type_specifier IDENTIFIER LP parameter_list RP SEMICOLON
         {
             l := yylex.(*Lexer)
-            l.compiler.functionDefine($1, $2.Lit, $4, nil)
+            $$ = l.compiler.functionDefine($1, $2.Lit, $4, nil)
         }
         | type_specifier IDENTIFIER LP RP SEMICOLON
         {
             l := yylex.(*Lexer)
-            l.compiler.functionDefine($1, $2.Lit, []*Parameter{}, nil)
+            $$ = l.compiler.functionDefine($1, $2.Lit, []*Parameter{}, nil)
+        }
+        | type_specifier IDENTIFIER TYPE_LT type_parameter_list GT LP parameter_list RP block
+        {
+            l := yylex.(*Lexer)
+            $$ = l.compiler.genericFunctionDefine($1, $2.Lit, $4, $7, $9)
+        }
+        | type_specifier IDENTIFIER TYPE_LT type_parameter_list GT LP RP block
+        {
+            l := yylex.(*Lexer)
+            $$ = l.compiler.genericFunctionDefine($1, $2.Lit, $4, []*Parameter{}, $8)
         }
         ;
 parameter_list
@@ -249,12 +393,58 @@ expression
         ;
 assignment_expression
         : logical_or_expression
-        | primary_expression ASSIGN_T assignment_expression
+        | primary_expression assignment_operator assignment_expression
         {
-            $$ = &AssignExpression{left: $1, operand: $3}
+            $$ = &AssignExpression{left: $1, operator: $2, operand: $3}
             $$.SetPosition($1.Position())
         }
         ;
+assignment_operator
+        : ASSIGN_T
+        {
+            $$ = NormalAssign
+        }
+        | ADD_ASSIGN_T
+        {
+            $$ = AddAssign
+        }
+        | SUB_ASSIGN_T
+        {
+            $$ = SubAssign
+        }
+        | MUL_ASSIGN_T
+        {
+            $$ = MulAssign
+        }
+        | DIV_ASSIGN_T
+        {
+            $$ = DivAssign
+        }
+        | MOD_ASSIGN_T
+        {
+            $$ = ModAssign
+        }
+        | BIT_AND_ASSIGN_T
+        {
+            $$ = BitAndAssign
+        }
+        | BIT_OR_ASSIGN_T
+        {
+            $$ = BitOrAssign
+        }
+        | BIT_XOR_ASSIGN_T
+        {
+            $$ = BitXorAssign
+        }
+        | LEFT_SHIFT_ASSIGN_T
+        {
+            $$ = LeftShiftAssign
+        }
+        | RIGHT_SHIFT_ASSIGN_T
+        {
+            $$ = RightShiftAssign
+        }
+        ;
 logical_or_expression
         : logical_and_expression
         | logical_or_expression LOGICAL_OR logical_and_expression
@@ -264,13 +454,37 @@ logical_or_expression
         }
         ;
 logical_and_expression
-        : equality_expression
-        | logical_and_expression LOGICAL_AND equality_expression
+        : inclusive_or_expression
+        | logical_and_expression LOGICAL_AND inclusive_or_expression
         {
             $$ = &BinaryExpression{operator: LogicalAndOperator, left: $1, right: $3}
             $$.SetPosition($1.Position())
         }
         ;
+inclusive_or_expression
+        : exclusive_or_expression
+        | inclusive_or_expression BIT_OR exclusive_or_expression
+        {
+            $$ = &BinaryExpression{operator: BitOrOperator, left: $1, right: $3}
+            $$.SetPosition($1.Position())
+        }
+        ;
+exclusive_or_expression
+        : and_expression
+        | exclusive_or_expression BIT_XOR and_expression
+        {
+            $$ = &BinaryExpression{operator: BitXorOperator, left: $1, right: $3}
+            $$.SetPosition($1.Position())
+        }
+        ;
+and_expression
+        : equality_expression
+        | and_expression BIT_AND equality_expression
+        {
+            $$ = &BinaryExpression{operator: BitAndOperator, left: $1, right: $3}
+            $$.SetPosition($1.Position())
+        }
+        ;
 equality_expression
         : relational_expression
         | equality_expression EQ relational_expression
@@ -285,27 +499,44 @@ equality_expression
         }
         ;
 relational_expression
-        : additive_expression
-        | relational_expression GT additive_expression
+        : shift_expression
+        | relational_expression GT shift_expression
         {
             $$ = &BinaryExpression{operator: GtOperator, left: $1, right: $3}
             $$.SetPosition($1.Position())
         }
-        | relational_expression GE additive_expression
+        | relational_expression GE shift_expression
         {
             $$ = &BinaryExpression{operator: GeOperator, left: $1, right: $3}
             $$.SetPosition($1.Position())
         }
-        | relational_expression LT additive_expression
+        | relational_expression LT shift_expression
         {
             $$ = &BinaryExpression{operator: LtOperator, left: $1, right: $3}
             $$.SetPosition($1.Position())
         }
-        | relational_expression LE additive_expression
+        | relational_expression LE shift_expression
         {
             $$ = &BinaryExpression{operator: LeOperator, left: $1, right: $3}
             $$.SetPosition($1.Position())
         }
+        | relational_expression INSTANCEOF type_specifier
+        {
+            $$ = createInstanceofExpression($1, $3, $1.Position())
+        }
+        ;
+shift_expression
+        : additive_expression
+        | shift_expression LEFT_SHIFT additive_expression
+        {
+            $$ = &BinaryExpression{operator: LeftShiftOperator, left: $1, right: $3}
+            $$.SetPosition($1.Position())
+        }
+        | shift_expression RIGHT_SHIFT additive_expression
+        {
+            $$ = &BinaryExpression{operator: RightShiftOperator, left: $1, right: $3}
+            $$.SetPosition($1.Position())
+        }
         ;
 additive_expression
         : multiplicative_expression
@@ -332,6 +563,11 @@ multiplicative_expression
             $$ = &BinaryExpression{operator: DivOperator, left: $1, right: $3}
             $$.SetPosition($1.Position())
         }
+        | multiplicative_expression MOD unary_expression
+        {
+            $$ = &BinaryExpression{operator: ModOperator, left: $1, right: $3}
+            $$.SetPosition($1.Position())
+        }
         ;
 unary_expression
         : postfix_expression
@@ -345,9 +581,38 @@ unary_expression
             $$ = &LogicalNotExpression{operand: $2}
             $$.SetPosition($1.Position())
         }
+        | BIT_NOT unary_expression
+        {
+            $$ = &BitNotExpression{operand: $2}
+            $$.SetPosition($1.Position())
+        }
+        | INCREMENT unary_expression
+        {
+            $$ = createIncrementExpression($2, true, true, $1.Position())
+        }
+        | DECREMENT unary_expression
+        {
+            $$ = createIncrementExpression($2, false, true, $1.Position())
+        }
+        | LP expression RP unary_expression
+        {
+            $$ = createDownCastExpression($2, $4, $1.Position())
+        }
+        | LP basic_type_specifier RP unary_expression
+        {
+            $$ = createTypeCastExpression($2, $4, $1.Position())
+        }
         ;
 postfix_expression
         : primary_expression
+        | primary_expression INCREMENT
+        {
+            $$ = createIncrementExpression($1, true, false, $1.Position())
+        }
+        | primary_expression DECREMENT
+        {
+            $$ = createIncrementExpression($1, false, false, $1.Position())
+        }
         ;
 primary_expression
         : primary_no_new_array
@@ -367,6 +632,15 @@ primary_no_new_array
             identifier := createIdentifierExpression($1.Lit, $1.Position());
             $$ = createIndexExpression(identifier, $3, $1.Position())
         }
+        | primary_no_new_array LB expression_opt COLON expression_opt RB
+        {
+            $$ = createSliceExpression($1, $3, $5, $1.Position())
+        }
+        | IDENTIFIER LB expression_opt COLON expression_opt RB
+        {
+            identifier := createIdentifierExpression($1.Lit, $1.Position());
+            $$ = createSliceExpression(identifier, $3, $5, $1.Position())
+        }
         | primary_expression DOT IDENTIFIER
         {
             $$ = createMemberExpression($1, $3.Lit)
@@ -381,22 +655,29 @@ primary_no_new_array
             $$ = &FunctionCallExpression{function: $1, argumentList: []Expression{}}
             $$.SetPosition($1.Position())
         }
-        | LP expression RP
+        | LP expression RP %prec PAREN_EXPRESSION
         {
+            if identifier, ok := $2.(*IdentifierExpression); ok {
+                identifier.parenthesized = true
+            }
             $$ = $2
         }
         | INT_LITERAL
         {
-            value, _ := strconv.Atoi($1.Lit)
+            value, _ := parseIntLiteral($1.Lit)
             $$ = &IntExpression{intValue: value}
             $$.SetPosition($1.Position())
         }
         | DOUBLE_LITERAL
         {
-            value, _ := strconv.ParseFloat($1.Lit, 64)
+            value, _ := parseDoubleLiteral($1.Lit)
             $$ = &DoubleExpression{doubleValue: value}
             $$.SetPosition($1.Position())
         }
+        | CHAR_LITERAL
+        {
+            $$ = createCharLiteralExpression($1)
+        }
         | STRING_LITERAL
         {
             $$ = &StringExpression{stringValue: $1.Lit}
@@ -418,10 +699,15 @@ primary_no_new_array
             $$.SetPosition($1.Position())
         }
         | array_literal
+        | map_literal
         | THIS_T
         {
             $$ = createThisExpression($1.Position())
         }
+        | SUPER_T
+        {
+            $$ = createSuperExpression($1.Position())
+        }
         | NEW class_name LP RP
         {
             $$ = createNewExpression($2, nil, $1.Position())
@@ -430,6 +716,35 @@ primary_no_new_array
         {
             $$ = createNewExpression($2, $4, $1.Position())
         }
+        | NEW generic_type_specifier LP RP
+        {
+            $$ = createGenericNewExpression($2, nil, $1.Position())
+        }
+        | NEW generic_type_specifier LP argument_list RP
+        {
+            $$ = createGenericNewExpression($2, $4, $1.Position())
+        }
+        | lambda_expression
+        ;
+lambda_expression
+        : LP parameter_list RP ARROW type_specifier block
+        {
+            $$ = createLambdaExpression($5, $2, $6, $1.Position())
+        }
+        | LP RP ARROW type_specifier block
+        {
+            $$ = createLambdaExpression($4, []*Parameter{}, $5, $1.Position())
+        }
+        | LP parameter_list RP ARROW block
+        {
+            typ := createTypeSpecifier(vm.VoidType, $4.Position())
+            $$ = createLambdaExpression(typ, $2, $5, $1.Position())
+        }
+        | LP RP ARROW block
+        {
+            typ := createTypeSpecifier(vm.VoidType, $3.Position())
+            $$ = createLambdaExpression(typ, []*Parameter{}, $4, $1.Position())
+        }
         ;
 class_name
         : IDENTIFIER
@@ -453,6 +768,27 @@ array_literal
             $$.SetPosition($1.Position())
         }
         ;
+map_literal
+        : LC map_entry_list RC
+        {
+            $$ = createMapLiteralExpression($2, $1.Position())
+        }
+        | LC map_entry_list COMMA RC
+        {
+            $$ = createMapLiteralExpression($2, $1.Position())
+        }
+        ;
+/* 键和值交替排列 */
+map_entry_list
+        : assignment_expression COLON assignment_expression
+        {
+            $$ = []Expression{$1, $3}
+        }
+        | map_entry_list COMMA assignment_expression COLON assignment_expression
+        {
+            $$ = append($1, $3, $5)
+        }
+        ;
 array_creation
         : NEW basic_type_specifier dimension_expression_list
         {
@@ -470,6 +806,23 @@ array_creation
         {
             $$ = createClassArrayCreation($2, $3, $4, $1.Position())
         }
+        | NEW generic_type_specifier dimension_expression_list
+        {
+            $$ = createClassArrayCreation($2, $3, nil, $1.Position())
+        }
+        | NEW generic_type_specifier dimension_expression_list dimension_list
+        {
+            $$ = createClassArrayCreation($2, $3, $4, $1.Position())
+        }
+        /* new Foo[3]与数组类型冲突, 只支持返回基本类型的函数 */
+        | NEW basic_function_type_specifier dimension_expression_list
+        {
+            $$ = createBasicArrayCreation($2, $3, nil, $1.Position())
+        }
+        | NEW basic_function_type_specifier dimension_expression_list dimension_list
+        {
+            $$ = createBasicArrayCreation($2, $3, $4, $1.Position())
+        }
         ;
 dimension_expression_list
         : dimension_expression
@@ -518,11 +871,15 @@ statement
             $$.SetPosition($1.Position())
         }
         | if_statement
-        | for_statement
+        | switch_statement
+        | loop_statement
+        | labeled_statement
         | return_statement
         | break_statement
         | continue_statement
         | declaration_statement
+        | try_statement
+        | throw_statement
         ;
 if_statement
         : IF expression block
@@ -556,6 +913,78 @@ elif_list
             $$ = append($1, &Elif{condition: $3, block: $4})
         }
         ;
+switch_statement
+        : SWITCH LP expression RP LC case_list RC
+        {
+            $$ = createSwitchStatement($3, $6, $1.Position())
+        }
+        ;
+case_list
+        : case_clause
+        {
+            $$ = []*CaseClause{$1}
+        }
+        | case_list case_clause
+        {
+            $$ = append($1, $2)
+        }
+        ;
+case_clause
+        : CASE case_value_list COLON case_block
+        {
+            $$ = createCaseClause($2, $4, $1.Position())
+        }
+        | DEFAULT_T COLON case_block
+        {
+            $$ = createCaseClause(nil, $3, $1.Position())
+        }
+        ;
+case_value_list
+        : assignment_expression
+        {
+            $$ = []Expression{$1}
+        }
+        | case_value_list COMMA assignment_expression
+        {
+            $$ = append($1, $3)
+        }
+        ;
+case_block
+        :
+        {
+            l := yylex.(*Lexer)
+            l.compiler.currentBlock = &Block{outerBlock: l.compiler.currentBlock}
+            $<block>$ = l.compiler.currentBlock
+        }
+          case_statement_list
+        {
+            currentBlock := $<block>1
+            currentBlock.statementList = $2
+
+            l := yylex.(*Lexer)
+
+            $$ = currentBlock
+            l.compiler.currentBlock = currentBlock.outerBlock
+        }
+        ;
+case_statement_list
+        : /* empty */
+        {
+            $$ = nil
+        }
+        | statement_list
+        ;
+loop_statement
+        : for_statement
+        | while_statement
+        | do_while_statement
+        ;
+labeled_statement
+        : IDENTIFIER COLON loop_statement
+        {
+            $$ = createLabeledStatement($1.Lit, $3, $1.Position())
+        }
+        ;
 for_statement
         : FOR LP expression_opt SEMICOLON expression_opt SEMICOLON expression_opt RP block
         {
@@ -563,6 +992,32 @@ for_statement
             $$.SetPosition($1.Position())
             $9.parent = &StatementBlockInfo{statement: $$}
         }
+        | FOR LP type_specifier IDENTIFIER COLON expression RP block
+        {
+            decl := createForEachDeclaration($3, $4.Lit)
+            $$ = createForEachStatement([]*Declaration{decl}, $6, $8, $1.Position())
+        }
+        | FOR LP type_specifier IDENTIFIER COMMA type_specifier IDENTIFIER COLON expression RP block
+        {
+            declList := []*Declaration{createForEachDeclaration($3, $4.Lit), createForEachDeclaration($6, $7.Lit)}
+            $$ = createForEachStatement(declList, $9, $11, $1.Position())
+        }
+        ;
+while_statement
+        : WHILE LP expression RP block
+        {
+            $$ = &WhileStatement{condition: $3, block: $5}
+            $$.SetPosition($1.Position())
+            $5.parent = &StatementBlockInfo{statement: $$}
+        }
+        ;
+do_while_statement
+        : DO_T block WHILE LP expression RP SEMICOLON
+        {
+            $$ = &DoWhileStatement{block: $2, condition: $5}
+            $$.SetPosition($1.Position())
+            $2.parent = &StatementBlockInfo{statement: $$}
+        }
         ;
 expression_opt
         :
@@ -584,6 +1039,11 @@ break_statement
             $$ = &BreakStatement{}
             $$.SetPosition($1.Position())
         }
+        | BREAK IDENTIFIER SEMICOLON
+        {
+            $$ = &BreakStatement{label: $2.Lit}
+            $$.SetPosition($1.Position())
+        }
         ;
 continue_statement
         : CONTINUE SEMICOLON
@@ -591,6 +1051,48 @@ continue_statement
             $$ = &ContinueStatement{}
             $$.SetPosition($1.Position())
         }
+        | CONTINUE IDENTIFIER SEMICOLON
+        {
+            $$ = &ContinueStatement{label: $2.Lit}
+            $$.SetPosition($1.Position())
+        }
+        ;
+try_statement
+        : TRY block catch_list
+        {
+            $$ = createTryStatement($2, $3, nil, $1.Position())
+        }
+        | TRY block catch_list FINALLY block
+        {
+            $$ = createTryStatement($2, $3, $5, $1.Position())
+        }
+        | TRY block FINALLY block
+        {
+            $$ = createTryStatement($2, nil, $4, $1.Position())
+        }
+        ;
+catch_list
+        : catch_clause
+        {
+            $$ = []*CatchClause{$1}
+        }
+        | catch_list catch_clause
+        {
+            $$ = append($1, $2)
+        }
+        ;
+catch_clause
+        : CATCH LP class_type_specifier IDENTIFIER RP block
+        {
+            $$ = createCatchClause($3, $4.Lit, $6, $1.Position())
+        }
+        ;
+throw_statement
+        : THROW expression SEMICOLON
+        {
+            $$ = &ThrowStatement{exception: $2}
+            $$.SetPosition($1.Position())
+        }
         ;
 declaration_statement
         : type_specifier IDENTIFIER SEMICOLON
@@ -628,21 +1130,93 @@ block
         }
         ;
 class_definition
-        : CLASS_T IDENTIFIER extends LC
+        : class_modifier_opt class_or_interface IDENTIFIER extends LC
         {
-            startClassDefine($2.Lit, $3, $1.Position())
+            l := yylex.(*Lexer)
+            l.compiler.startClassDefine($1, $2.Tok == INTERFACE_T, $3.Lit, $4, $2.Position())
+        }
+          member_declaration_list RC
+        {
+            l := yylex.(*Lexer)
+            l.compiler.endClassDefine($7)
+        }
+        | class_modifier_opt class_or_interface IDENTIFIER extends LC
+        {
+            l := yylex.(*Lexer)
+            l.compiler.startClassDefine($1, $2.Tok == INTERFACE_T, $3.Lit, $4, $2.Position())
+        }
+          RC
+        {
+            l := yylex.(*Lexer)
+            l.compiler.endClassDefine(nil)
+        }
+        | class_modifier_opt class_or_interface IDENTIFIER TYPE_LT type_parameter_list GT extends LC
+        {
+            l := yylex.(*Lexer)
+            l.compiler.startGenericClassDefine($1, $2.Tok == INTERFACE_T, $3.Lit, $5, $7, $2.Position())
         }
           member_declaration_list RC
         {
-            endClassDefine($6)
+            l := yylex.(*Lexer)
+            l.compiler.endGenericClassDefine($10)
         }
-        | CLASS_T IDENTIFIER extends LC
+        | class_modifier_opt class_or_interface IDENTIFIER TYPE_LT type_parameter_list GT extends LC
         {
-            startClassDefine($2.Lit, $3, $1.Position())
+            l := yylex.(*Lexer)
+            l.compiler.startGenericClassDefine($1, $2.Tok == INTERFACE_T, $3.Lit, $5, $7, $2.Position())
         }
           RC
         {
-            endClassDefine(nil)
+            l := yylex.(*Lexer)
+            l.compiler.endGenericClassDefine(nil)
+        }
+        ;
+class_or_interface
+        : CLASS_T
+        | INTERFACE_T
+        ;
+class_modifier_opt
+        : /* empty */
+        {
+            $$ = nil
+        }
+        | class_or_member_modifier_list
+        ;
+class_or_member_modifier_list
+        : class_or_member_modifier
+        | class_or_member_modifier_list class_or_member_modifier
+        {
+            $$ = chainClassOrMemberModifier($1, $2)
+        }
+        ;
+class_or_member_modifier
+        : ABSTRACT_T
+        {
+            $$ = createClassOrMemberModifier(AbstractModifier, $1.Position())
+        }
+        | VIRTUAL_T
+        {
+            $$ = createClassOrMemberModifier(VirtualModifier, $1.Position())
+        }
+        | OVERRIDE_T
+        {
+            $$ = createClassOrMemberModifier(OverrideModifier, $1.Position())
+        }
+        | PUBLIC_T
+        {
+            $$ = createClassOrMemberModifier(PublicModifier, $1.Position())
+        }
+        | PRIVATE_T
+        {
+            $$ = createClassOrMemberModifier(PrivateModifier, $1.Position())
+        }
+        | PROTECTED_T
+        {
+            $$ = createClassOrMemberModifier(ProtectedModifier, $1.Position())
+        }
+        | STATIC_T
+        {
+            $$ = createClassOrMemberModifier(StaticModifier, $1.Position())
         }
         ;
 extends
@@ -664,6 +1238,14 @@ extends_list
         {
             $$ = chainExtendList($1, $3.Lit)
         }
+        | generic_type_specifier
+        {
+            $$ = createGenericExtendList($1)
+        }
+        | extends_list COMMA generic_type_specifier
+        {
+            $$ = chainGenericExtendList($1, $3)
+        }
         ;
 member_declaration_list
         : member_declaration
@@ -675,35 +1257,84 @@ member_declaration_list
 member_declaration
         : method_member
         | field_member
+        | constructor_member
         ;
 method_member
         : method_function_definition
         {
-            $$ = createMethodMember($1, $1.typeSpecifier.Position())
+            l := yylex.(*Lexer)
+            $$ = l.compiler.createMethodMember(nil, $1, $1.typeSpecifier.Position())
+        }
+        | class_or_member_modifier_list method_function_definition
+        {
+            l := yylex.(*Lexer)
+            $$ = l.compiler.createMethodMember($1, $2, $2.typeSpecifier.Position())
         }
         ;
 method_function_definition
         : type_specifier IDENTIFIER LP parameter_list RP block
         {
-            $$ = methodFunctionDefine($1, $2.Lit, $4, $6);
+            l := yylex.(*Lexer)
+            $$ = l.compiler.methodFunctionDefine($1, $2.Lit, $4, $6);
         }
         | type_specifier IDENTIFIER LP RP block
         {
-            $$ = methodFunctionDefine($1, $2.Lit, nil, $5);
+            l := yylex.(*Lexer)
+            $$ = l.compiler.methodFunctionDefine($1, $2.Lit, nil, $5);
         }
         | type_specifier IDENTIFIER LP parameter_list RP SEMICOLON
         {
-            $$ = methodFunctionDefine($1, $2.Lit, $4, nil);
+            l := yylex.(*Lexer)
+            $$ = l.compiler.methodFunctionDefine($1, $2.Lit, $4, nil);
         }
         | type_specifier IDENTIFIER LP RP SEMICOLON
         {
-            $$ = methodFunctionDefine($1, $2.Lit, nil, nil);
+            l := yylex.(*Lexer)
+            $$ = l.compiler.methodFunctionDefine($1, $2.Lit, nil, nil);
         }
         ;
 field_member
         : type_specifier IDENTIFIER SEMICOLON
         {
-            $$ = createFieldMember($1, $2.Lit, $1.Position())
+            l := yylex.(*Lexer)
+            $$ = l.compiler.createFieldMember(nil, $1, $2.Lit, nil, $1.Position())
+        }
+        | type_specifier IDENTIFIER ASSIGN_T expression SEMICOLON
+        {
+            l := yylex.(*Lexer)
+            $$ = l.compiler.createFieldMember(nil, $1, $2.Lit, $4, $1.Position())
+        }
+        | class_or_member_modifier_list type_specifier IDENTIFIER SEMICOLON
+        {
+            l := yylex.(*Lexer)
+            $$ = l.compiler.createFieldMember($1, $2, $3.Lit, nil, $2.Position())
+        }
+        | class_or_member_modifier_list type_specifier IDENTIFIER ASSIGN_T expression SEMICOLON
+        {
+            l := yylex.(*Lexer)
+            $$ = l.compiler.createFieldMember($1, $2, $3.Lit, $5, $2.Position())
+        }
+        ;
+constructor_member
+        : IDENTIFIER LP parameter_list RP block
+        {
+            l := yylex.(*Lexer)
+            $$ = l.compiler.createConstructorMember(nil, $1.Lit, $3, $5, $1.Position())
+        }
+        | IDENTIFIER LP RP block
+        {
+            l := yylex.(*Lexer)
+            $$ = l.compiler.createConstructorMember(nil, $1.Lit, nil, $4, $1.Position())
+        }
+        | class_or_member_modifier_list IDENTIFIER LP parameter_list RP block
+        {
+            l := yylex.(*Lexer)
+            $$ = l.compiler.createConstructorMember($1, $2.Lit, $4, $6, $2.Position())
+        }
+        | class_or_member_modifier_list IDENTIFIER LP RP block
+        {
+            l := yylex.(*Lexer)
+            $$ = l.compiler.createConstructorMember($1, $2.Lit, nil, $5, $2.Position())
         }
         ;
 %%
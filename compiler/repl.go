@@ -0,0 +1,223 @@
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/lth-go/gogogogo/vm"
+)
+
+// ==============================
+// 交互模式
+// ==============================
+
+// 交互模式下输入的文件名
+const replPath = "<stdin>"
+
+// Session 交互模式的编译会话
+// 每段输入都在同一个compiler上增量编译, 之前定义的全局变量, 函数和类在之后的输入中可见
+type Session struct {
+	compiler *Compiler
+}
+
+// NewSession 创建交互模式的编译会话
+func NewSession(opts Options) *Session {
+	ctx := &compileContext{options: opts.withDefault()}
+
+	c := newCompiler(ctx)
+	c.interactive = true
+
+	return &Session{compiler: c}
+}
+
+// Compile 编译一段输入
+// 返回的exeList只包含本次新增的exe, 需要加载到同一个虚拟机中执行
+// 最后一条语句是有值的表达式时hasResult为true, 执行结束后表达式的值转换为字符串留在栈上
+// 编译失败时丢弃本段输入中的所有定义, 会话可以继续使用
+func (s *Session) Compile(source string) (exeList *vm.ExecutableList, hasResult bool, err error) {
+	c := s.compiler
+	ctx := c.ctx
+
+	ctx.diagnosticList = nil
+	ctx.fatal = false
+
+	snapshot := c.takeSnapshot()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("编译器内部错误: %v", r)
+		}
+		if err != nil {
+			c.rollback(snapshot)
+			exeList = nil
+			hasResult = false
+			return
+		}
+
+		c.funcStart = len(c.funcList)
+		c.classStart = len(c.classDefinitionList)
+	}()
+
+	c.addLexerBySource(replPath, source)
+	c.statementList = []Statement{}
+
+	exeList = vm.NewExecutableList()
+	exeList.TopLevel = c.compile(exeList, false)
+
+	if len(ctx.diagnosticList) > 0 {
+		return nil, false, DiagnosticList(ctx.diagnosticList)
+	}
+	if exeList.TopLevel == nil {
+		return nil, false, errNoTopLevel
+	}
+
+	if len(c.statementList) > 0 {
+		result, ok := c.statementList[len(c.statementList)-1].(*resultStatement)
+		hasResult = ok && result.hasResult
+	}
+
+	return exeList, hasResult, nil
+}
+
+// 编译前的状态, 编译失败时恢复
+type compilerSnapshot struct {
+	funcCount        int
+	declarationCount int
+	classCount       int
+	vmFunctionCount  int
+	vmClassCount     int
+	requiredCount    int
+	compilerCount    int
+	instanceCount    int
+}
+
+func (c *Compiler) takeSnapshot() *compilerSnapshot {
+	return &compilerSnapshot{
+		funcCount:        len(c.funcList),
+		declarationCount: len(c.declarationList),
+		classCount:       len(c.classDefinitionList),
+		vmFunctionCount:  len(c.vmFunctionList),
+		vmClassCount:     len(c.vmClassList),
+		requiredCount:    len(c.requiredList),
+		compilerCount:    len(c.ctx.compilerList),
+		instanceCount:    len(c.ctx.instanceList),
+	}
+}
+
+func (c *Compiler) rollback(snapshot *compilerSnapshot) {
+	c.funcList = c.funcList[:snapshot.funcCount]
+	c.declarationList = c.declarationList[:snapshot.declarationCount]
+	c.classDefinitionList = c.classDefinitionList[:snapshot.classCount]
+	c.vmFunctionList = c.vmFunctionList[:snapshot.vmFunctionCount]
+	c.vmClassList = c.vmClassList[:snapshot.vmClassCount]
+	c.requiredList = c.requiredList[:snapshot.requiredCount]
+	c.ctx.compilerList = c.ctx.compilerList[:snapshot.compilerCount]
+	c.ctx.instanceList = c.ctx.instanceList[:snapshot.instanceCount]
+
+	c.statementList = []Statement{}
+	c.currentBlock = nil
+	c.currentClassDefinition = nil
+}
+
+// 将最后一条表达式语句替换为resultStatement
+func (c *Compiler) addResultStatement() {
+	if len(c.statementList) == 0 {
+		return
+	}
+
+	last := len(c.statementList) - 1
+
+	stmt, ok := c.statementList[last].(*ExpressionStatement)
+	if !ok {
+		return
+	}
+
+	// 赋值不输出
+	if _, ok := stmt.expression.(*AssignExpression); ok {
+		return
+	}
+
+	c.statementList[last] = &resultStatement{expression: stmt.expression}
+}
+
+// ==============================
+// resultStatement
+// ==============================
+
+// 交互模式下输入的最后一条表达式语句, 值转换为字符串后留在栈上
+type resultStatement struct {
+	StatementImpl
+	expression Expression
+
+	// void类型的表达式没有值
+	hasResult bool
+	// 不能转换为字符串的值, 输出类型名
+	typeName string
+}
+
+func (stmt *resultStatement) show(indent int) {
+	printWithIndent("ResultStmt", indent)
+
+	subIndent := indent + 2
+
+	stmt.expression.show(subIndent)
+}
+
+func (stmt *resultStatement) fix(c *Compiler, currentBlock *Block, fd *FunctionDefinition) {
+	stmt.expression = stmt.expression.fix(c, currentBlock)
+
+	typ := stmt.expression.typeS()
+
+	switch {
+	case isVoid(typ) && len(typ.deriveList) == 0:
+		return
+	case isMethodValue(stmt.expression):
+		// 方法名
+		compileError(stmt.expression.Position(), FUNCTION_IDENTIFIER_ERR, getExpressionName(stmt.expression))
+	case len(typ.deriveList) > 0, isClass(typ):
+		stmt.typeName = getTypeName(typ)
+	case typ.basicType == vm.NullType:
+		stmt.typeName = "null"
+	case isBoolean(typ), isInteger(typ), isDouble(typ), isChar(typ):
+		stmt.expression = createToStringCast(stmt.expression)
+	}
+
+	stmt.hasResult = true
+}
+
+// 非static方法不能作为值使用
+func isMethodValue(expr Expression) bool {
+	memberExpr, ok := expr.(*MemberExpression)
+	if !ok {
+		return false
+	}
+	member, ok := memberExpr.memberDeclaration.(*MethodMember)
+	return ok && !member.isStatic
+}
+
+func getExpressionName(expr Expression) string {
+	switch e := expr.(type) {
+	case *IdentifierExpression:
+		return e.name
+	case *MemberExpression:
+		return e.memberName
+	}
+	return ""
+}
+
+func (stmt *resultStatement) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	expr := stmt.expression
+
+	expr.generate(exe, currentBlock, ob)
+
+	if !stmt.hasResult {
+		ob.generateCode(expr.Position(), vm.VM_POP)
+		return
+	}
+
+	if stmt.typeName != "" {
+		ob.generateCode(expr.Position(), vm.VM_POP)
+
+		cpIdx := exe.AddConstantPool(vm.NewConstantString(stmt.typeName))
+		ob.generateCode(expr.Position(), vm.VM_PUSH_STRING, cpIdx)
+	}
+}
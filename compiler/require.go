@@ -3,6 +3,9 @@ package compiler
 import (
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/lth-go/gogogogo/vm"
 )
 
 const (
@@ -21,12 +24,44 @@ func createRequire(packageNameList []string) *Require {
 	}
 }
 
-func createRequireList(packageNameList []string) []*Require {
+func createRequireList(packageNameList []string, pos Position) []*Require {
 	req := createRequire(packageNameList)
+	req.SetPosition(pos)
 
 	return []*Require{req}
 }
 
+// 默认导入的包
+func addDefaultPackage(requireList []*Require) []*Require {
+	for _, require := range requireList {
+		if require.isDefaultPackage() {
+			return requireList
+		}
+	}
+
+	req := createRequire(strings.Split(defaultPackage, "."))
+
+	return append([]*Require{req}, requireList...)
+}
+
+func (r *Require) isDefaultPackage() bool {
+	return r.getPackageName() == defaultPackage
+}
+
+// 没有源文件, 只有原生函数的包
+func (r *Require) isNativePackage(searchBasePath string) bool {
+	if !vm.IsNativePackage(r.getPackageName()) {
+		return false
+	}
+
+	_, err := os.Stat(filepath.Join(searchBasePath, r.getRelativePath()))
+	return err != nil
+}
+
+func (r *Require) getPackageName() string {
+	return strings.Join(r.packageNameList, ".")
+}
+
 func chainRequireList(requireList1, requireList2 []*Require) []*Require {
 	return append(requireList1, requireList2...)
 }
@@ -38,14 +73,7 @@ func (r *Require) getRelativePath() string {
 	return path
 }
 
-func (r *Require) getFullPath() string {
-	// TODO 暂时写死, 方便测试
-	searchBasePath := os.Getenv("REQUIRE_SEARCH_PATH")
-	if searchBasePath == "" {
-	   searchBasePath = "."
-	}
-	// searchBasePath := "/home/lth/toy/gogogogo/test"
-
+func (r *Require) getFullPath(searchBasePath string) string {
 	relativePath := r.getRelativePath()
 
 	fullPath := filepath.Join(searchBasePath, relativePath)
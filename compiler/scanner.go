@@ -4,7 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"os"
+	"strconv"
 	"strings"
 	"unicode"
 )
@@ -18,42 +18,75 @@ const (
 
 // opName is correction of operation names.
 var opName = map[string]int{
-	"if":       IF,
-	"else":     ELSE,
-	"elif":     ELIF,
-	"for":      FOR,
-	"return":   RETURN_T,
-	"break":    BREAK,
-	"continue": CONTINUE,
-	"true":     TRUE_T,
-	"false":    FALSE_T,
-	"void":     VOID_T,
-	"boolean":  BOOLEAN_T,
-	"int":      INT_T,
-	"double":   DOUBLE_T,
-	"string":   STRING_T,
-	"null":     NULL_T,
-	"new":      NEW,
-	"require":  REQUIRE,
-	"class":    CLASS_T,
-	"this":     THIS_T,
-	"(":        LP,
-	")":        RP,
-	"[":        LB,
-	"]":        RB,
-	"{":        LC,
-	"}":        RC,
-	";":        SEMICOLON,
-	":":        COLON,
-	",":        COMMA,
-	"+":        ADD,
-	"-":        SUB,
-	"*":        MUL,
-	"/":        DIV,
-	"!":        EXCLAMATION,
-	".":        DOT,
+	"if":         IF,
+	"else":       ELSE,
+	"elif":       ELIF,
+	"for":        FOR,
+	"while":      WHILE,
+	"do":         DO_T,
+	"switch":     SWITCH,
+	"case":       CASE,
+	"default":    DEFAULT_T,
+	"return":     RETURN_T,
+	"break":      BREAK,
+	"continue":   CONTINUE,
+	"true":       TRUE_T,
+	"false":      FALSE_T,
+	"void":       VOID_T,
+	"boolean":    BOOLEAN_T,
+	"int":        INT_T,
+	"double":     DOUBLE_T,
+	"char":       CHAR_T,
+	"byte":       BYTE_T,
+	"int32":      INT32_T,
+	"long":       LONG_T,
+	"string":     STRING_T,
+	"null":       NULL_T,
+	"new":        NEW,
+	"require":    REQUIRE,
+	"class":      CLASS_T,
+	"interface":  INTERFACE_T,
+	"abstract":   ABSTRACT_T,
+	"virtual":    VIRTUAL_T,
+	"override":   OVERRIDE_T,
+	"public":     PUBLIC_T,
+	"private":    PRIVATE_T,
+	"protected":  PROTECTED_T,
+	"static":     STATIC_T,
+	"this":       THIS_T,
+	"super":      SUPER_T,
+	"instanceof": INSTANCEOF,
+	"try":        TRY,
+	"catch":      CATCH,
+	"finally":    FINALLY,
+	"throw":      THROW,
+	"(":          LP,
+	")":          RP,
+	"[":          LB,
+	"]":          RB,
+	"{":          LC,
+	"}":          RC,
+	";":          SEMICOLON,
+	":":          COLON,
+	",":          COMMA,
+	"+":          ADD,
+	"-":          SUB,
+	"*":          MUL,
+	"/":          DIV,
+	"%":          MOD,
+	"*=":         MUL_ASSIGN_T,
+	"/=":         DIV_ASSIGN_T,
+	"%=":         MOD_ASSIGN_T,
+	"^":          BIT_XOR,
+	"^=":         BIT_XOR_ASSIGN_T,
+	"~":          BIT_NOT,
+	"!":          EXCLAMATION,
+	".":          DOT,
 }
 
+// 没有结束的字符字面量, 不影响语法解析, 在修正时报告
+var errUnterminatedChar = errors.New("unterminated character literal")
+
 // Scanner stores informations for lexer.
 type Scanner struct {
 	src      []rune
@@ -62,19 +95,18 @@ type Scanner struct {
 	line     int
 }
 
-func newScannerByFilePath(path string) *Scanner {
-	_, err := os.Stat(path)
-	if err != nil {
-		panic("文件不存在")
-	}
-
+func newScannerByFilePath(path string) (*Scanner, error) {
 	buf, err := ioutil.ReadFile(path)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	scanner := &Scanner{src: []rune(string(buf))}
 
-	return scanner
+	return scanner, nil
+}
+
+func newScannerBySource(source string) *Scanner {
+	return &Scanner{src: []rune(source)}
 }
 
 // Scan analyses token, and decide identify or literals.
@@ -113,10 +145,13 @@ retry:
 		if err != nil {
 			return
 		}
+	// 字符
 	case ch == '\'':
-		tok = STRING_LITERAL
+		// 字符的个数在修正时检查
+		tok = CHAR_LITERAL
 		lit, err = s.scanString('\'')
 		if err != nil {
+			err = errUnterminatedChar
 			return
 		}
 	default:
@@ -160,6 +195,17 @@ retry:
 			case '=':
 				tok = GE
 				lit = ">="
+			case '>':
+				s.next()
+				switch s.peek() {
+				case '=':
+					tok = RIGHT_SHIFT_ASSIGN_T
+					lit = ">>="
+				default:
+					s.back()
+					tok = RIGHT_SHIFT
+					lit = ">>"
+				}
 			default:
 				s.back()
 				tok = GT
@@ -171,6 +217,17 @@ retry:
 			case '=':
 				tok = LE
 				lit = "<="
+			case '<':
+				s.next()
+				switch s.peek() {
+				case '=':
+					tok = LEFT_SHIFT_ASSIGN_T
+					lit = "<<="
+				default:
+					s.back()
+					tok = LEFT_SHIFT
+					lit = "<<"
+				}
 			default:
 				s.back()
 				tok = LT
@@ -182,11 +239,13 @@ retry:
 			case '|':
 				tok = LOGICAL_OR
 				lit = "||"
+			case '=':
+				tok = BIT_OR_ASSIGN_T
+				lit = "|="
 			default:
 				s.back()
-				err = fmt.Errorf(`syntax Error "%s"`, string(ch))
-				tok = int(ch)
-				lit = string(ch)
+				tok = BIT_OR
+				lit = "|"
 			}
 		case '&':
 			s.next()
@@ -194,13 +253,57 @@ retry:
 			case '&':
 				tok = LOGICAL_AND
 				lit = "&&"
+			case '=':
+				tok = BIT_AND_ASSIGN_T
+				lit = "&="
 			default:
 				s.back()
-				err = fmt.Errorf(`syntax Error "%s"`, string(ch))
-				tok = int(ch)
+				tok = BIT_AND
+				lit = "&"
+			}
+		case '+':
+			s.next()
+			switch s.peek() {
+			case '+':
+				tok = INCREMENT
+				lit = "++"
+			case '=':
+				tok = ADD_ASSIGN_T
+				lit = "+="
+			default:
+				s.back()
+				tok = ADD
+				lit = "+"
+			}
+		case '-':
+			s.next()
+			switch s.peek() {
+			case '-':
+				tok = DECREMENT
+				lit = "--"
+			case '=':
+				tok = SUB_ASSIGN_T
+				lit = "-="
+			case '>':
+				tok = ARROW
+				lit = "->"
+			default:
+				s.back()
+				tok = SUB
+				lit = "-"
+			}
+		case '*', '/', '%', '^':
+			s.next()
+			switch s.peek() {
+			case '=':
+				tok = opName[string(ch)+"="]
+				lit = string(ch) + "="
+			default:
+				s.back()
+				tok = opName[string(ch)]
 				lit = string(ch)
 			}
-		case '(', ')', '[', ']', '{', '}', ':', ';', ',', '+', '-', '*', '/', '.':
+		case '(', ')', '[', ']', '{', '}', ':', ';', ',', '~', '.':
 			tok = opName[string(ch)]
 			lit = string(ch)
 		default:
@@ -228,6 +331,18 @@ func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || ('a' <= ch && ch <= 'f') || ('A' <= ch && ch <= 'F')
+}
+
+func isOctalDigit(ch rune) bool {
+	return '0' <= ch && ch <= '7'
+}
+
+func isBinaryDigit(ch rune) bool {
+	return ch == '0' || ch == '1'
+}
+
 // isEOL returns true if the rune is at end-of-line or end-of-file.
 func isEOL(ch rune) bool {
 	return ch == '\n' || ch == -1
@@ -303,21 +418,89 @@ func (s *Scanner) scanIdentifier() (string, error) {
 }
 
 // scanNumber returns number begining at current position.
+// 整数可以用0x, 0o, 0b前缀表示十六, 八, 二进制, 数字之间可以用下划线分隔, eg, 0xff, 1_000_000
 func (s *Scanner) scanNumber() (string, error) {
 	var ret []rune
 	ch := s.peek()
 	ret = append(ret, ch)
 	s.next()
 
-	for isDigit(s.peek()) || s.peek() == '.' {
+	isNumberDigit := isDigit
+	hasPrefix := false
+	if ch == '0' {
+		hasPrefix = true
+		switch s.peek() {
+		case 'x', 'X':
+			isNumberDigit = isHexDigit
+		case 'o', 'O':
+			isNumberDigit = isOctalDigit
+		case 'b', 'B':
+			isNumberDigit = isBinaryDigit
+		default:
+			hasPrefix = false
+		}
+		if hasPrefix {
+			ret = append(ret, s.peek())
+			s.next()
+		}
+	}
+
+	for isNumberDigit(s.peek()) || s.peek() == '_' || (!hasPrefix && s.peek() == '.') {
 		ret = append(ret, s.peek())
 		s.next()
 	}
 
+	if isDigit(s.peek()) {
+		return "", errors.New("invalid digit in numeric literal")
+	}
 	if isLetter(s.peek()) {
 		return "", errors.New("identifier starts immediately after numeric literal")
 	}
-	return string(ret), nil
+
+	lit := string(ret)
+	if strings.Contains(lit, "__") || strings.HasSuffix(lit, "_") ||
+		strings.Contains(lit, "_.") || strings.Contains(lit, "._") {
+		return "", errors.New("'_' must separate successive digits")
+	}
+
+	var err error
+	if strings.Contains(lit, ".") {
+		_, err = parseDoubleLiteral(lit)
+	} else {
+		_, err = parseIntLiteral(lit)
+	}
+	if err != nil {
+		return "", fmt.Errorf("invalid numeric literal %s", lit)
+	}
+
+	return lit, nil
+}
+
+// 去掉下划线后按前缀确定进制
+func parseIntLiteral(lit string) (int, error) {
+	str := strings.Replace(lit, "_", "", -1)
+
+	base := 10
+	if len(str) > 2 && str[0] == '0' {
+		switch str[1] {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
+	}
+	if base != 10 {
+		str = str[2:]
+	}
+
+	value, err := strconv.ParseInt(str, base, 64)
+	return int(value), err
+}
+
+func parseDoubleLiteral(lit string) (float64, error) {
+	return strconv.ParseFloat(strings.Replace(lit, "_", "", -1), 64)
 }
 
 // scanString returns string starting at current position.
@@ -13,7 +13,7 @@ type Statement interface {
 	// Pos接口
 	Pos
 
-	fix(*Block, *FunctionDefinition)
+	fix(*Compiler, *Block, *FunctionDefinition)
 	generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf)
 
 	show(indent int)
@@ -41,16 +41,22 @@ func (stmt *ExpressionStatement) show(indent int) {
 	stmt.expression.show(subIndent)
 }
 
-func (stmt *ExpressionStatement) fix(currentBlock *Block, fd *FunctionDefinition) {
-	stmt.expression = stmt.expression.fix(currentBlock)
+func (stmt *ExpressionStatement) fix(c *Compiler, currentBlock *Block, fd *FunctionDefinition) {
+	stmt.expression = stmt.expression.fix(c, currentBlock)
 }
 
 func (stmt *ExpressionStatement) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
-	expr := stmt.expression
-	switch assignExpr := expr.(type) {
+	generateExpressionWithoutValue(exe, currentBlock, stmt.expression, ob)
+}
+
+// 生成表达式, 不在栈上留下结果
+func generateExpressionWithoutValue(exe *vm.Executable, currentBlock *Block, expr Expression, ob *OpCodeBuf) {
+	switch e := expr.(type) {
 	case *AssignExpression:
 		// TODO
-		assignExpr.generateEx(exe, currentBlock, ob, true)
+		e.generateEx(exe, currentBlock, ob, true)
+	case *IncrementExpression:
+		e.generateEx(exe, currentBlock, ob, true)
 	default:
 		expr.generate(exe, currentBlock, ob)
 		ob.generateCode(expr.Position(), vm.VM_POP)
@@ -98,28 +104,28 @@ func (stmt *IfStatement) show(indent int) {
 	}
 }
 
-func (stmt *IfStatement) fix(currentBlock *Block, fd *FunctionDefinition) {
+func (stmt *IfStatement) fix(c *Compiler, currentBlock *Block, fd *FunctionDefinition) {
 
-	stmt.condition = stmt.condition.fix(currentBlock)
+	stmt.condition = stmt.condition.fix(c, currentBlock)
 
 	if !isBoolean(stmt.condition.typeS()) {
 		compileError(stmt.condition.Position(), IF_CONDITION_NOT_BOOLEAN_ERR)
 	}
 
 	if stmt.thenBlock != nil {
-		fixStatementList(stmt.thenBlock, stmt.thenBlock.statementList, fd)
+		fixStatementList(c, stmt.thenBlock, stmt.thenBlock.statementList, fd)
 	}
 
 	for _, elif := range stmt.elifList {
-		elif.condition = elif.condition.fix(currentBlock)
+		elif.condition = elif.condition.fix(c, currentBlock)
 
 		if elif.block != nil {
-			fixStatementList(elif.block, elif.block.statementList, fd)
+			fixStatementList(c, elif.block, elif.block.statementList, fd)
 		}
 	}
 
 	if stmt.elseBlock != nil {
-		fixStatementList(stmt.elseBlock, stmt.elseBlock.statementList, fd)
+		fixStatementList(c, stmt.elseBlock, stmt.elseBlock.statementList, fd)
 	}
 }
 
@@ -168,6 +174,85 @@ func (stmt *IfStatement) generate(exe *vm.Executable, currentBlock *Block, ob *O
 	ob.setLabel(endLabel)
 }
 
+// ==============================
+// 循环语句
+// ==============================
+
+// 可以被break和continue跳出的循环语句
+type loopStatement interface {
+	Statement
+
+	// 循环的标签, 没有标签时为空
+	loopLabel() string
+	setLoopLabel(label string)
+}
+
+// 带标签的循环语句
+func createLabeledStatement(label string, stmt Statement, pos Position) Statement {
+	loop := stmt.(loopStatement)
+	loop.setLoopLabel(label)
+	loop.SetPosition(pos)
+
+	return loop
+}
+
+// 外层的循环不能使用相同的标签
+func checkLoopLabel(loop loopStatement, currentBlock *Block) {
+	if loop.loopLabel() == "" {
+		return
+	}
+
+	for block := currentBlock; block != nil && !block.isFunctionBlock(); block = block.outerBlock {
+		parent, ok := block.parent.(*StatementBlockInfo)
+		if !ok {
+			continue
+		}
+		outer, ok := parent.statement.(loopStatement)
+		if ok && outer.loopLabel() == loop.loopLabel() {
+			compileError(loop.Position(), LABEL_MULTIPLE_DEFINE_ERR, loop.loopLabel())
+		}
+	}
+}
+
+// 向外查找break和continue的目标循环, 返回循环体
+// 不带标签的break也可以跳出switch, 此时返回case的语句块
+func searchLoopBlock(currentBlock *Block, label string, pos Position, errorNumber int) *Block {
+	for block := currentBlock; block != nil && !block.isFunctionBlock(); block = block.outerBlock {
+		parent, ok := block.parent.(*StatementBlockInfo)
+		if !ok {
+			continue
+		}
+		loop, ok := parent.statement.(loopStatement)
+		if ok && (label == "" || loop.loopLabel() == label) {
+			return block
+		}
+		_, ok = parent.statement.(*SwitchStatement)
+		if ok && label == "" && errorNumber == BREAK_OUT_OF_LOOP_ERR {
+			return block
+		}
+	}
+
+	if label != "" {
+		compileError(pos, LABEL_NOT_FOUND_ERR, label)
+	}
+	compileError(pos, errorNumber)
+
+	return nil
+}
+
+// 跳出到目标循环, 途经的try语句先执行finally
+func generateLeaveBlock(currentBlock *Block, target *Block, pos Position, ob *OpCodeBuf) {
+	for block := currentBlock; block != target; block = block.outerBlock {
+		parent, ok := block.parent.(*StatementBlockInfo)
+		if !ok {
+			continue
+		}
+		if tryStmt, ok := parent.statement.(*TryStatement); ok {
+			tryStmt.generateGoFinally(block, pos, ob)
+		}
+	}
+}
+
 // ==============================
 // ForStatement
 // ==============================
@@ -176,12 +261,17 @@ func (stmt *IfStatement) generate(exe *vm.Executable, currentBlock *Block, ob *O
 type ForStatement struct {
 	StatementImpl
 
+	label string
+
 	init      Expression
 	condition Expression
 	post      Expression
 	block     *Block
 }
 
+func (stmt *ForStatement) loopLabel() string          { return stmt.label }
+func (stmt *ForStatement) setLoopLabel(label string) { stmt.label = label }
+
 func (stmt *ForStatement) show(indent int) {
 	printWithIndent("ForStmt", indent)
 	subIndent := indent + 2
@@ -201,13 +291,15 @@ func (stmt *ForStatement) show(indent int) {
 	}
 }
 
-func (stmt *ForStatement) fix(currentBlock *Block, fd *FunctionDefinition) {
+func (stmt *ForStatement) fix(c *Compiler, currentBlock *Block, fd *FunctionDefinition) {
+	checkLoopLabel(stmt, currentBlock)
+
 	if stmt.init != nil {
-		stmt.init = stmt.init.fix(currentBlock)
+		stmt.init = stmt.init.fix(c, currentBlock)
 	}
 
 	if stmt.condition != nil {
-		stmt.condition = stmt.condition.fix(currentBlock)
+		stmt.condition = stmt.condition.fix(c, currentBlock)
 
 		if !isBoolean(stmt.condition.typeS()) {
 			compileError(stmt.condition.Position(), FOR_CONDITION_NOT_BOOLEAN_ERR)
@@ -215,57 +307,369 @@ func (stmt *ForStatement) fix(currentBlock *Block, fd *FunctionDefinition) {
 	}
 
 	if stmt.post != nil {
-		stmt.post = stmt.post.fix(currentBlock)
+		stmt.post = stmt.post.fix(c, currentBlock)
 	}
 
 	if stmt.block != nil {
-		fixStatementList(stmt.block, stmt.block.statementList, fd)
+		fixStatementList(c, stmt.block, stmt.block.statementList, fd)
 	}
 }
 func (stmt *ForStatement) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
 
 	if stmt.init != nil {
-		stmt.init.generate(exe, currentBlock, ob)
+		generateExpressionWithoutValue(exe, currentBlock, stmt.init, ob)
 	}
 
 	// 获取循环地址
 	loopLabel := ob.getLabel()
+	continueLabel := ob.getLabel()
+	breakLabel := ob.getLabel()
 
 	// 设置循环地址
 	ob.setLabel(loopLabel)
 
 	if stmt.condition != nil {
 		stmt.condition.generate(exe, currentBlock, ob)
-	}
-
-	label := ob.getLabel()
-
-	if stmt.condition != nil {
-		// 如果条件为否,跳转到break, label = parent.breakLabel
-		ob.generateCode(stmt.Position(), vm.VM_JUMP_IF_FALSE, label)
+		// 如果条件为否,跳转到break
+		ob.generateCode(stmt.Position(), vm.VM_JUMP_IF_FALSE, breakLabel)
 	}
 
 	if stmt.block != nil {
 		parent := stmt.block.parent.(*StatementBlockInfo)
-		// 获取break,continue地址
-		parent.breakLabel = label
-		parent.continueLabel = label
+		// 设置break,continue地址
+		parent.breakLabel = breakLabel
+		parent.continueLabel = continueLabel
 
 		generateStatementList(exe, stmt.block, stmt.block.statementList, ob)
 	}
 
-	// 如果有continue,直接跳过block,从这里执行, label = parent.continueLabel
-	ob.setLabel(label)
+	// continue跳过剩余的循环体,从这里执行
+	ob.setLabel(continueLabel)
 
 	if stmt.post != nil {
-		stmt.post.generate(exe, currentBlock, ob)
+		generateExpressionWithoutValue(exe, currentBlock, stmt.post, ob)
 	}
 
 	// 跳回到循环开头
 	ob.generateCode(stmt.Position(), vm.VM_JUMP, loopLabel)
 
-	// 设置结束标签, label = parent.breakLabel
-	ob.setLabel(label)
+	// 设置结束标签
+	ob.setLabel(breakLabel)
+}
+
+// ==============================
+// ForEachStatement
+// ==============================
+
+// ForEachStatement 遍历数组, 字符串和map
+// eg, for (int x : arr), for (int i, int x : arr), for (string k, int v : m)
+// 按下标循环, 字符串先拆分为字符数组, map先取出所有的键
+type ForEachStatement struct {
+	StatementImpl
+
+	label string
+
+	// 一个或两个循环变量
+	declarationList []*Declaration
+	collection      Expression
+	block           *Block
+	isString        bool
+
+	// 编译器生成的变量, 保存遍历的数组(map), 下标和map的键
+	collectionDeclaration *Declaration
+	indexDeclaration      *Declaration
+	keysDeclaration       *Declaration
+
+	// 每次循环赋给循环变量的值
+	valueList []Expression
+
+	// map当前的键, 循环中被删除的键跳过
+	keyExpression Expression
+}
+
+func (stmt *ForEachStatement) loopLabel() string          { return stmt.label }
+func (stmt *ForEachStatement) setLoopLabel(label string) { stmt.label = label }
+
+func (stmt *ForEachStatement) show(indent int) {
+	printWithIndent("ForEachStmt", indent)
+	subIndent := indent + 2
+
+	stmt.collection.show(subIndent)
+	stmt.block.show(subIndent)
+}
+
+func (stmt *ForEachStatement) fix(c *Compiler, currentBlock *Block, fd *FunctionDefinition) {
+	checkLoopLabel(stmt, currentBlock)
+
+	stmt.collection = stmt.collection.fix(c, currentBlock)
+	collectionType := stmt.collection.typeS()
+
+	pos := stmt.Position()
+	intType := createTypeSpecifier(vm.IntType, pos)
+
+	// 循环变量在循环体中声明
+	for _, decl := range stmt.declarationList {
+		decl.fix(c, stmt.block, fd)
+	}
+
+	stmt.indexDeclaration = addHiddenDeclaration(c, fd, intType, pos)
+	index := createDeclarationExpression(stmt.indexDeclaration, pos)
+
+	switch {
+	case isMap(collectionType):
+		keyType := collectionType.deriveList[0].(*MapDerive).keyType
+		stmt.collectionDeclaration = addHiddenDeclaration(c, fd, collectionType, pos)
+		stmt.keysDeclaration = addHiddenDeclaration(c, fd, createArrayTypeSpecifier(cloneTypeSpecifier(keyType)), pos)
+
+		// 第一个变量为键, 第二个为值
+		keys := createDeclarationExpression(stmt.keysDeclaration, pos)
+		stmt.keyExpression = createIndexExpression(keys, index, pos).fix(c, currentBlock)
+		stmt.valueList = append(stmt.valueList, createIndexExpression(keys, index, pos))
+		if len(stmt.declarationList) == 2 {
+			key := createIndexExpression(keys, index, pos)
+			m := createDeclarationExpression(stmt.collectionDeclaration, pos)
+			stmt.valueList = append(stmt.valueList, createIndexExpression(m, key, pos))
+		}
+	case isArray(collectionType), isString(collectionType) && len(collectionType.deriveList) == 0:
+		arrayType := collectionType
+		if !isArray(collectionType) {
+			// 字符串拆分为char数组
+			stmt.isString = true
+			arrayType = createArrayTypeSpecifier(createTypeSpecifier(vm.CharType, pos))
+		}
+		stmt.collectionDeclaration = addHiddenDeclaration(c, fd, arrayType, pos)
+
+		// 两个变量时第一个为下标
+		if len(stmt.declarationList) == 2 {
+			stmt.valueList = append(stmt.valueList, index)
+		}
+		array := createDeclarationExpression(stmt.collectionDeclaration, pos)
+		stmt.valueList = append(stmt.valueList, createIndexExpression(array, index, pos))
+	default:
+		compileError(stmt.collection.Position(), FOREACH_TYPE_ERR, getTypeName(collectionType))
+	}
+
+	for i, decl := range stmt.declarationList {
+		stmt.valueList[i] = stmt.valueList[i].fix(c, stmt.block)
+		stmt.valueList[i] = createAssignCast(stmt.valueList[i], decl.typeSpecifier)
+	}
+
+	fixStatementList(c, stmt.block, stmt.block.statementList, fd)
+}
+
+func (stmt *ForEachStatement) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	pos := stmt.Position()
+
+	stmt.collection.generate(exe, currentBlock, ob)
+	if stmt.isString {
+		ob.generateCode(pos, vm.VM_STRING_TO_CHARS)
+	}
+	generatePopToIdentifier(stmt.collectionDeclaration, currentBlock, pos, ob)
+
+	// 按下标遍历的数组, map为键的数组
+	arrayDeclaration := stmt.collectionDeclaration
+	if stmt.keysDeclaration != nil {
+		createDeclarationExpression(stmt.collectionDeclaration, pos).generate(exe, currentBlock, ob)
+		ob.generateCode(pos, vm.VM_MAP_KEYS_INT+getOpcodeTypeOffset(getElementType(stmt.keysDeclaration.typeSpecifier)))
+		generatePopToIdentifier(stmt.keysDeclaration, currentBlock, pos, ob)
+		arrayDeclaration = stmt.keysDeclaration
+	}
+
+	ob.generateCode(pos, vm.VM_PUSH_INT_1BYTE, 0)
+	generatePopToIdentifier(stmt.indexDeclaration, currentBlock, pos, ob)
+
+	loopLabel := ob.getLabel()
+	continueLabel := ob.getLabel()
+	breakLabel := ob.getLabel()
+
+	// 每次循环重新获取长度
+	ob.setLabel(loopLabel)
+	createDeclarationExpression(stmt.indexDeclaration, pos).generate(exe, currentBlock, ob)
+	createDeclarationExpression(arrayDeclaration, pos).generate(exe, currentBlock, ob)
+	ob.generateCode(pos, vm.VM_ARRAY_LENGTH)
+	ob.generateCode(pos, vm.VM_LT_INT)
+	ob.generateCode(pos, vm.VM_JUMP_IF_FALSE, breakLabel)
+
+	if stmt.keyExpression != nil {
+		createDeclarationExpression(stmt.collectionDeclaration, pos).generate(exe, currentBlock, ob)
+		stmt.keyExpression.generate(exe, currentBlock, ob)
+		ob.generateCode(pos, vm.VM_MAP_CONTAINS)
+		ob.generateCode(pos, vm.VM_JUMP_IF_FALSE, continueLabel)
+	}
+
+	// 被lambda捕获的循环变量每次循环创建新的cell
+	for i, decl := range stmt.declarationList {
+		stmt.valueList[i].generate(exe, stmt.block, ob)
+		generateInitializeIdentifier(decl, stmt.block, pos, ob)
+	}
+
+	parent := stmt.block.parent.(*StatementBlockInfo)
+	parent.breakLabel = breakLabel
+	parent.continueLabel = continueLabel
+
+	generateStatementList(exe, stmt.block, stmt.block.statementList, ob)
+
+	ob.setLabel(continueLabel)
+	createDeclarationExpression(stmt.indexDeclaration, pos).generate(exe, currentBlock, ob)
+	ob.generateCode(pos, vm.VM_INCREMENT)
+	generatePopToIdentifier(stmt.indexDeclaration, currentBlock, pos, ob)
+
+	ob.generateCode(pos, vm.VM_JUMP, loopLabel)
+
+	ob.setLabel(breakLabel)
+}
+
+func createForEachDeclaration(typ *TypeSpecifier, name string) *Declaration {
+	decl := &Declaration{typeSpecifier: typ, name: name, variableIndex: -1}
+	decl.SetPosition(typ.Position())
+	return decl
+}
+
+func createForEachStatement(declarationList []*Declaration, collection Expression, block *Block, pos Position) *ForEachStatement {
+	stmt := &ForEachStatement{
+		declarationList: declarationList,
+		collection:      collection,
+		block:           block,
+	}
+	stmt.SetPosition(pos)
+	block.parent = &StatementBlockInfo{statement: stmt}
+
+	return stmt
+}
+
+// 编译器生成的变量, 没有名字, 不能在脚本中访问
+func addHiddenDeclaration(c *Compiler, fd *FunctionDefinition, typ *TypeSpecifier, pos Position) *Declaration {
+	decl := &Declaration{typeSpecifier: typ, variableIndex: -1}
+	decl.SetPosition(pos)
+	typ.fix(c)
+
+	if fd != nil {
+		decl.isLocal = true
+		fd.addLocalVariable(decl)
+	} else {
+		decl.isLocal = false
+		c.declarationList = append(c.declarationList, decl)
+	}
+
+	return decl
+}
+
+// ==============================
+// WhileStatement
+// ==============================
+
+// WhileStatement while语句
+type WhileStatement struct {
+	StatementImpl
+
+	label string
+
+	condition Expression
+	block     *Block
+}
+
+func (stmt *WhileStatement) loopLabel() string          { return stmt.label }
+func (stmt *WhileStatement) setLoopLabel(label string) { stmt.label = label }
+
+func (stmt *WhileStatement) show(indent int) {
+	printWithIndent("WhileStmt", indent)
+	subIndent := indent + 2
+
+	stmt.condition.show(subIndent)
+	stmt.block.show(subIndent)
+}
+
+func (stmt *WhileStatement) fix(c *Compiler, currentBlock *Block, fd *FunctionDefinition) {
+	checkLoopLabel(stmt, currentBlock)
+
+	stmt.condition = stmt.condition.fix(c, currentBlock)
+
+	if !isBoolean(stmt.condition.typeS()) {
+		compileError(stmt.condition.Position(), WHILE_CONDITION_NOT_BOOLEAN_ERR)
+	}
+
+	fixStatementList(c, stmt.block, stmt.block.statementList, fd)
+}
+
+func (stmt *WhileStatement) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	loopLabel := ob.getLabel()
+	breakLabel := ob.getLabel()
+
+	// continue直接回到条件判断
+	ob.setLabel(loopLabel)
+
+	stmt.condition.generate(exe, currentBlock, ob)
+	ob.generateCode(stmt.Position(), vm.VM_JUMP_IF_FALSE, breakLabel)
+
+	parent := stmt.block.parent.(*StatementBlockInfo)
+	parent.breakLabel = breakLabel
+	parent.continueLabel = loopLabel
+
+	generateStatementList(exe, stmt.block, stmt.block.statementList, ob)
+
+	ob.generateCode(stmt.Position(), vm.VM_JUMP, loopLabel)
+
+	ob.setLabel(breakLabel)
+}
+
+// ==============================
+// DoWhileStatement
+// ==============================
+
+// DoWhileStatement do while语句, 循环体至少执行一次
+type DoWhileStatement struct {
+	StatementImpl
+
+	label string
+
+	block     *Block
+	condition Expression
+}
+
+func (stmt *DoWhileStatement) loopLabel() string          { return stmt.label }
+func (stmt *DoWhileStatement) setLoopLabel(label string) { stmt.label = label }
+
+func (stmt *DoWhileStatement) show(indent int) {
+	printWithIndent("DoWhileStmt", indent)
+	subIndent := indent + 2
+
+	stmt.block.show(subIndent)
+	stmt.condition.show(subIndent)
+}
+
+func (stmt *DoWhileStatement) fix(c *Compiler, currentBlock *Block, fd *FunctionDefinition) {
+	checkLoopLabel(stmt, currentBlock)
+
+	fixStatementList(c, stmt.block, stmt.block.statementList, fd)
+
+	// 条件在循环体之外, 不能使用循环体中声明的变量
+	stmt.condition = stmt.condition.fix(c, currentBlock)
+
+	if !isBoolean(stmt.condition.typeS()) {
+		compileError(stmt.condition.Position(), DO_WHILE_CONDITION_NOT_BOOLEAN_ERR)
+	}
+}
+
+func (stmt *DoWhileStatement) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	loopLabel := ob.getLabel()
+	continueLabel := ob.getLabel()
+	breakLabel := ob.getLabel()
+
+	ob.setLabel(loopLabel)
+
+	parent := stmt.block.parent.(*StatementBlockInfo)
+	parent.breakLabel = breakLabel
+	parent.continueLabel = continueLabel
+
+	generateStatementList(exe, stmt.block, stmt.block.statementList, ob)
+
+	// continue跳到条件判断
+	ob.setLabel(continueLabel)
+
+	stmt.condition.generate(exe, currentBlock, ob)
+	ob.generateCode(stmt.Position(), vm.VM_JUMP_IF_TRUE, loopLabel)
+
+	ob.setLabel(breakLabel)
 }
 
 // ==============================
@@ -287,7 +691,7 @@ func (stmt *ReturnStatement) show(indent int) {
 	stmt.returnValue.show(subIndent)
 }
 
-func (stmt *ReturnStatement) fix(currentBlock *Block, fd *FunctionDefinition) {
+func (stmt *ReturnStatement) fix(c *Compiler, currentBlock *Block, fd *FunctionDefinition) {
 
 	fdType := fd.typeS()
 
@@ -297,7 +701,7 @@ func (stmt *ReturnStatement) fix(currentBlock *Block, fd *FunctionDefinition) {
 			compileError(stmt.Position(), RETURN_IN_VOID_FUNCTION_ERR)
 		}
 
-		stmt.returnValue = stmt.returnValue.fix(currentBlock)
+		stmt.returnValue = fixAssignOperand(c, currentBlock, stmt.returnValue, fdType)
 
 		// 类型转换
 		stmt.returnValue = createAssignCast(stmt.returnValue, fdType)
@@ -305,32 +709,36 @@ func (stmt *ReturnStatement) fix(currentBlock *Block, fd *FunctionDefinition) {
 		return
 	}
 
-	// return value == nil
+	stmt.returnValue = createDefaultValueExpression(fdType, stmt.Position())
+}
 
+// 各类型的默认值
+func createDefaultValueExpression(typ *TypeSpecifier, pos Position) Expression {
 	// 衍生类型
-	if fdType.deriveList != nil {
-		if !fdType.isArrayDerive() {
-			panic("TODO")
-		}
-		stmt.returnValue = createNullExpression(stmt.Position())
-
-		return
+	if typ.deriveList != nil {
+		return createNullExpression(pos)
 	}
 
 	// 基础类型
-	switch fdType.basicType {
+	switch typ.basicType {
 	case vm.VoidType:
-		stmt.returnValue = createIntExpression(stmt.Position())
+		return createIntExpression(pos)
 	case vm.BooleanType:
-		stmt.returnValue = createBooleanExpression(stmt.Position())
+		return createBooleanExpression(pos)
 	case vm.IntType:
-		stmt.returnValue = createIntExpression(stmt.Position())
+		return createIntExpression(pos)
+	case vm.ByteType, vm.Int32Type, vm.LongType:
+		expr := createIntExpression(pos)
+		expr.setType(createTypeSpecifier(typ.basicType, pos))
+		return expr
 	case vm.DoubleType:
-		stmt.returnValue = createDoubleExpression(stmt.Position())
+		return createDoubleExpression(pos)
+	case vm.CharType:
+		return createCharExpression(pos)
 	case vm.StringType:
-		stmt.returnValue = createStringExpression(stmt.Position())
+		return createStringExpression(pos)
 	case vm.ClassType:
-		stmt.returnValue = createNullExpression(stmt.Position())
+		return createNullExpression(pos)
 	case vm.NullType:
 		fallthrough
 	default:
@@ -345,6 +753,17 @@ func (stmt *ReturnStatement) generate(exe *vm.Executable, currentBlock *Block, o
 
 	stmt.returnValue.generate(exe, currentBlock, ob)
 
+	// 返回前执行外层的finally
+	for block := currentBlock; block != nil && !block.isFunctionBlock(); block = block.outerBlock {
+		parent, ok := block.parent.(*StatementBlockInfo)
+		if !ok {
+			continue
+		}
+		if tryStmt, ok := parent.statement.(*TryStatement); ok {
+			tryStmt.generateGoFinally(block, stmt.Position(), ob)
+		}
+	}
+
 	ob.generateCode(stmt.Position(), vm.VM_RETURN)
 }
 
@@ -355,26 +774,27 @@ func (stmt *ReturnStatement) generate(exe *vm.Executable, currentBlock *Block, o
 // BreakStatement break 语句
 type BreakStatement struct {
 	StatementImpl
+
+	// 跳出的循环的标签, 为空时跳出最内层的循环
+	label string
+
+	// 跳出的循环的循环体
+	target *Block
 }
 
 func (stmt *BreakStatement) show(indent int) {
 	printWithIndent("BreakStmt", indent)
 }
 
-func (stmt *BreakStatement) fix(currentBlock *Block, fd *FunctionDefinition) {}
+func (stmt *BreakStatement) fix(c *Compiler, currentBlock *Block, fd *FunctionDefinition) {
+	stmt.target = searchLoopBlock(currentBlock, stmt.label, stmt.Position(), BREAK_OUT_OF_LOOP_ERR)
+}
 
 func (stmt *BreakStatement) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
-	// 向外寻找,直到找到for的block
-	for block := currentBlock; block != nil; block = block.outerBlock {
-		switch block.parent.(type) {
-		case *StatementBlockInfo:
-			ob.generateCode(stmt.Position(), vm.VM_JUMP, block.parent.(*StatementBlockInfo).breakLabel)
-			return
-		default:
-			continue
-		}
-	}
-	compileError(stmt.Position(), LABEL_NOT_FOUND_ERR)
+	generateLeaveBlock(currentBlock, stmt.target, stmt.Position(), ob)
+
+	parent := stmt.target.parent.(*StatementBlockInfo)
+	ob.generateCode(stmt.Position(), vm.VM_JUMP, parent.breakLabel)
 }
 
 // ==============================
@@ -384,27 +804,244 @@ func (stmt *BreakStatement) generate(exe *vm.Executable, currentBlock *Block, ob
 // ContinueStatement continue 语句
 type ContinueStatement struct {
 	StatementImpl
+
+	// 继续的循环的标签, 为空时继续最内层的循环
+	label string
+
+	// 继续的循环的循环体
+	target *Block
 }
 
 func (stmt *ContinueStatement) show(indent int) {
 	printWithIndent("ContinueStmt", indent)
 }
 
-func (stmt *ContinueStatement) fix(currentBlock *Block, fd *FunctionDefinition) {}
+func (stmt *ContinueStatement) fix(c *Compiler, currentBlock *Block, fd *FunctionDefinition) {
+	stmt.target = searchLoopBlock(currentBlock, stmt.label, stmt.Position(), CONTINUE_OUT_OF_LOOP_ERR)
+}
 
 func (stmt *ContinueStatement) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
-	// 向外寻找,直到找到for的block
-	for block := currentBlock; block != nil; block = block.outerBlock {
-		switch block.parent.(type) {
-		case *StatementBlockInfo:
-			ob.generateCode(stmt.Position(), vm.VM_JUMP, block.parent.(*StatementBlockInfo).continueLabel)
-			return
-		default:
-			continue
+	generateLeaveBlock(currentBlock, stmt.target, stmt.Position(), ob)
+
+	parent := stmt.target.parent.(*StatementBlockInfo)
+	ob.generateCode(stmt.Position(), vm.VM_JUMP, parent.continueLabel)
+}
+
+// ==============================
+// TryStatement
+// ==============================
+
+// TryStatement try语句
+type TryStatement struct {
+	StatementImpl
+
+	tryBlock     *Block
+	catchList    []*CatchClause
+	finallyBlock *Block
+
+	// finally的入口
+	finallyLabel int
+}
+
+func (stmt *TryStatement) show(indent int) {
+	printWithIndent("TryStmt", indent)
+	subIndent := indent + 2
+
+	stmt.tryBlock.show(subIndent)
+
+	for _, catchClause := range stmt.catchList {
+		printWithIndent("Catch", subIndent)
+		catchClause.block.show(subIndent + 2)
+	}
+
+	if stmt.finallyBlock != nil {
+		printWithIndent("Finally", subIndent)
+		stmt.finallyBlock.show(subIndent + 2)
+	}
+}
+
+func (stmt *TryStatement) fix(c *Compiler, currentBlock *Block, fd *FunctionDefinition) {
+	fixStatementList(c, stmt.tryBlock, stmt.tryBlock.statementList, fd)
+
+	for _, catchClause := range stmt.catchList {
+		catchClause.fix(c, fd)
+	}
+
+	if stmt.finallyBlock != nil {
+		fixStatementList(c, stmt.finallyBlock, stmt.finallyBlock.statementList, fd)
+	}
+}
+
+func (stmt *TryStatement) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	try := &vm.Try{
+		CatchList:      []*vm.Catch{},
+		FinallyStartPc: -1,
+		FinallyEndPc:   -1,
+	}
+
+	if stmt.finallyBlock != nil {
+		stmt.finallyLabel = ob.getLabel()
+	}
+	endLabel := ob.getLabel()
+
+	try.StartPc = ob.getLabel()
+	ob.setLabel(try.StartPc)
+
+	generateStatementList(exe, stmt.tryBlock, stmt.tryBlock.statementList, ob)
+
+	try.EndPc = ob.getLabel()
+	ob.setLabel(try.EndPc)
+
+	stmt.generateLeave(endLabel, ob)
+
+	for _, catchClause := range stmt.catchList {
+		catch := &vm.Catch{
+			ClassIndex: catchClause.typeSpecifier.classRef.classIndex,
 		}
+
+		catch.StartPc = ob.getLabel()
+		ob.setLabel(catch.StartPc)
+
+		// 栈顶为异常对象
+		generateInitializeIdentifier(catchClause.variableDeclaration, catchClause.block, catchClause.Position(), ob)
+		generateStatementList(exe, catchClause.block, catchClause.block.statementList, ob)
+
+		catch.EndPc = ob.getLabel()
+		ob.setLabel(catch.EndPc)
+
+		stmt.generateLeave(endLabel, ob)
+
+		try.CatchList = append(try.CatchList, catch)
+	}
+
+	if stmt.finallyBlock != nil {
+		try.FinallyStartPc = stmt.finallyLabel
+		ob.setLabel(try.FinallyStartPc)
+
+		generateStatementList(exe, stmt.finallyBlock, stmt.finallyBlock.statementList, ob)
+		ob.generateCode(stmt.Position(), vm.VM_FINALLY_END)
+
+		try.FinallyEndPc = ob.getLabel()
+		ob.setLabel(try.FinallyEndPc)
+	}
+
+	ob.setLabel(endLabel)
+
+	// 内层的try先加入异常表
+	ob.tryList = append(ob.tryList, try)
+}
+
+// try或catch正常结束, 执行finally后跳到结尾
+func (stmt *TryStatement) generateLeave(endLabel int, ob *OpCodeBuf) {
+	if stmt.finallyBlock != nil {
+		ob.generateCode(stmt.Position(), vm.VM_GO_FINALLY, stmt.finallyLabel)
+	}
+	ob.generateCode(stmt.Position(), vm.VM_JUMP, endLabel)
+}
+
+// 从block中跳出try语句时, 需要先执行finally
+func (stmt *TryStatement) generateGoFinally(block *Block, pos Position, ob *OpCodeBuf) {
+	if stmt.finallyBlock == nil || block == stmt.finallyBlock {
+		return
+	}
+	ob.generateCode(pos, vm.VM_GO_FINALLY, stmt.finallyLabel)
+}
+
+func createTryStatement(tryBlock *Block, catchList []*CatchClause, finallyBlock *Block, pos Position) *TryStatement {
+	stmt := &TryStatement{
+		tryBlock:     tryBlock,
+		catchList:    catchList,
+		finallyBlock: finallyBlock,
+	}
+	stmt.SetPosition(pos)
+
+	tryBlock.parent = &StatementBlockInfo{statement: stmt}
+
+	for _, catchClause := range catchList {
+		catchClause.block.parent = &StatementBlockInfo{statement: stmt}
+	}
+
+	if finallyBlock != nil {
+		finallyBlock.parent = &StatementBlockInfo{statement: stmt}
+	}
+
+	return stmt
+}
+
+//
+// CatchClause
+//
+type CatchClause struct {
+	PosImpl
+
+	typeSpecifier *TypeSpecifier
+	variableName  string
+	block         *Block
+
+	variableDeclaration *Declaration
+}
+
+func (catchClause *CatchClause) fix(c *Compiler, fd *FunctionDefinition) {
+	catchClause.typeSpecifier.fix(c)
+
+	if !isExceptionClass(catchClause.typeSpecifier) {
+		compileError(catchClause.Position(), EXCEPTION_CLASS_IS_NOT_EXCEPTION_ERR, catchClause.typeSpecifier.classRef.identifier)
+	}
+
+	// 异常变量只在catch块中有效
+	decl := &Declaration{
+		typeSpecifier: catchClause.typeSpecifier,
+		name:          catchClause.variableName,
+		variableIndex: -1,
 	}
-	compileError(stmt.Position(), LABEL_NOT_FOUND_ERR)
+	decl.SetPosition(catchClause.Position())
+	decl.fix(c, catchClause.block, fd)
 
+	catchClause.variableDeclaration = decl
+
+	fixStatementList(c, catchClause.block, catchClause.block.statementList, fd)
+}
+
+func createCatchClause(typ *TypeSpecifier, variableName string, block *Block, pos Position) *CatchClause {
+	catchClause := &CatchClause{
+		typeSpecifier: typ,
+		variableName:  variableName,
+		block:         block,
+	}
+	catchClause.SetPosition(pos)
+
+	return catchClause
+}
+
+// ==============================
+// ThrowStatement
+// ==============================
+
+// ThrowStatement throw语句
+type ThrowStatement struct {
+	StatementImpl
+
+	exception Expression
+}
+
+func (stmt *ThrowStatement) show(indent int) {
+	printWithIndent("ThrowStmt", indent)
+	subIndent := indent + 2
+
+	stmt.exception.show(subIndent)
+}
+
+func (stmt *ThrowStatement) fix(c *Compiler, currentBlock *Block, fd *FunctionDefinition) {
+	stmt.exception = stmt.exception.fix(c, currentBlock)
+
+	if !isExceptionClass(stmt.exception.typeS()) {
+		compileError(stmt.Position(), THROW_TYPE_IS_NOT_EXCEPTION_ERR)
+	}
+}
+
+func (stmt *ThrowStatement) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	stmt.exception.generate(exe, currentBlock, ob)
+	ob.generateCode(stmt.Position(), vm.VM_THROW)
 }
 
 // ==============================
@@ -423,6 +1060,17 @@ type Declaration struct {
 	variableIndex int
 
 	isLocal bool
+
+	// 被lambda捕获, 栈上保存的是cell
+	isCaptured bool
+
+	// 顶层块中声明的全局变量, 每次执行声明都是新的变量
+	isBlockScoped bool
+
+	// 全局变量所属的包, 其他包中通过包名和变量名访问
+	packageName string
+	// private全局变量只能在包内访问
+	isPrivate bool
 }
 
 func (stmt *Declaration) show(indent int) {
@@ -434,23 +1082,29 @@ func (stmt *Declaration) show(indent int) {
 	}
 }
 
-func (stmt *Declaration) fix(currentBlock *Block, fd *FunctionDefinition) {
-	currentBlock.addDeclaration(stmt, fd, stmt.Position())
+func (stmt *Declaration) fix(c *Compiler, currentBlock *Block, fd *FunctionDefinition) {
+	currentBlock.addDeclaration(c, stmt, fd, stmt.Position())
 
-	stmt.typeSpecifier.fix()
+	stmt.typeSpecifier.fix(c)
 
 	// 类型转换
 	if stmt.initializer != nil {
-		stmt.initializer = stmt.initializer.fix(currentBlock)
+		stmt.initializer = fixAssignOperand(c, currentBlock, stmt.initializer, stmt.typeSpecifier)
 		stmt.initializer = createAssignCast(stmt.initializer, stmt.typeSpecifier)
 	}
 }
 
 func (stmt *Declaration) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	// 先创建cell再计算初始值, lambda可以递归引用自身
+	if stmt.isCell() {
+		createDefaultValueExpression(stmt.typeSpecifier, stmt.Position()).generate(exe, currentBlock, ob)
+		generateInitializeIdentifier(stmt, currentBlock, stmt.Position(), ob)
+	}
+
 	if stmt.initializer == nil {
 		return
 	}
 
 	stmt.initializer.generate(exe, currentBlock, ob)
-	generatePopToIdentifier(stmt, stmt.Position(), ob)
+	generatePopToIdentifier(stmt, currentBlock, stmt.Position(), ob)
 }
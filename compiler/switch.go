@@ -0,0 +1,320 @@
+package compiler
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/lth-go/gogogogo/vm"
+)
+
+// ==============================
+// SwitchStatement
+// ==============================
+
+// switch的表达式类型
+type switchKind int
+
+const (
+	intSwitch switchKind = iota
+	stringSwitch
+	classSwitch
+)
+
+// SwitchStatement switch语句, 执行完匹配的case后跳出, 不会继续执行下一个case
+type SwitchStatement struct {
+	StatementImpl
+
+	expression Expression
+	caseList   []*CaseClause
+
+	kind          switchKind
+	defaultClause *CaseClause
+}
+
+func (stmt *SwitchStatement) show(indent int) {
+	printWithIndent("SwitchStmt", indent)
+	subIndent := indent + 2
+
+	stmt.expression.show(subIndent)
+
+	for _, caseClause := range stmt.caseList {
+		caseClause.block.show(subIndent)
+	}
+}
+
+func (stmt *SwitchStatement) fix(c *Compiler, currentBlock *Block, fd *FunctionDefinition) {
+	stmt.expression = stmt.expression.fix(c, currentBlock)
+
+	typ := stmt.expression.typeS()
+
+	switch {
+	case len(typ.deriveList) > 0:
+		compileError(stmt.expression.Position(), SWITCH_EXPRESSION_TYPE_ERR, getTypeName(typ))
+	case isInteger(typ), isChar(typ):
+		stmt.kind = intSwitch
+	case isString(typ):
+		stmt.kind = stringSwitch
+	case isClass(typ):
+		stmt.kind = classSwitch
+	default:
+		compileError(stmt.expression.Position(), SWITCH_EXPRESSION_TYPE_ERR, getTypeName(typ))
+	}
+
+	// 用于检查重复的case
+	caseValueMap := map[string]bool{}
+
+	for _, caseClause := range stmt.caseList {
+		if caseClause.isDefault {
+			if stmt.defaultClause != nil {
+				compileError(caseClause.Position(), DEFAULT_MULTIPLE_DEFINE_ERR)
+			}
+			stmt.defaultClause = caseClause
+		}
+
+		for i, value := range caseClause.valueList {
+			var key string
+
+			switch stmt.kind {
+			case intSwitch:
+				intValue := fixIntCaseValue(c, currentBlock, value, typ)
+				caseClause.intValueList = append(caseClause.intValueList, intValue)
+				key = strconv.Itoa(intValue)
+				if isChar(typ) {
+					key = strconv.QuoteRune(rune(intValue))
+				}
+			case stringSwitch:
+				stringValue := fixStringCaseValue(c, currentBlock, value)
+				caseClause.stringValueList = append(caseClause.stringValueList, stringValue)
+				key = strconv.Quote(stringValue)
+			case classSwitch:
+				cd, classIndex := fixClassCaseValue(c, value, typ.classRef.classDefinition)
+				caseClause.classIndexList = append(caseClause.classIndexList, classIndex)
+				key = cd.name
+			}
+
+			if caseValueMap[key] {
+				compileError(caseClause.valueList[i].Position(), CASE_DUPLICATE_ERR, key)
+			}
+			caseValueMap[key] = true
+		}
+
+		fixStatementList(c, caseClause.block, caseClause.block.statementList, fd)
+	}
+}
+
+// case的值必须是整数或char常量, 与switch的表达式类型相同, 常量表达式在fix时已经合并
+func fixIntCaseValue(c *Compiler, currentBlock *Block, value Expression, typ *TypeSpecifier) int {
+	value = value.fix(c, currentBlock)
+
+	// 整数常量转换为switch的类型
+	if isInteger(value.typeS()) && isInteger(typ) {
+		value = createAssignCast(value, typ)
+	}
+
+	if value.typeS().basicType != typ.basicType || len(value.typeS().deriveList) > 0 {
+		compileError(value.Position(), CASE_TYPE_MISMATCH_ERR)
+	}
+
+	switch e := value.(type) {
+	case *IntExpression:
+		return e.intValue
+	case *CharExpression:
+		return int(e.charValue)
+	}
+
+	compileError(value.Position(), CASE_NOT_CONSTANT_ERR)
+	return 0
+}
+
+func fixStringCaseValue(c *Compiler, currentBlock *Block, value Expression) string {
+	value = value.fix(c, currentBlock)
+
+	if !isString(value.typeS()) {
+		compileError(value.Position(), CASE_TYPE_MISMATCH_ERR)
+	}
+
+	stringExpr, ok := value.(*StringExpression)
+	if !ok {
+		compileError(value.Position(), CASE_NOT_CONSTANT_ERR)
+	}
+
+	return stringExpr.stringValue
+}
+
+// 类作为case时, 匹配该类及其子类的实例
+func fixClassCaseValue(c *Compiler, value Expression, switchClass *ClassDefinition) (*ClassDefinition, int) {
+	identifier, ok := value.(*IdentifierExpression)
+	if !ok {
+		compileError(value.Position(), CASE_NOT_CLASS_ERR)
+	}
+
+	cd := c.searchClass(identifier.name)
+	if cd == nil {
+		compileError(value.Position(), CLASS_NOT_FOUND_ERR, identifier.name)
+	}
+
+	if !cd.isSubClassOf(switchClass) && !cd.isInterface && !switchClass.isInterface {
+		compileError(value.Position(), CASE_TYPE_MISMATCH_ERR)
+	}
+
+	return cd, cd.addToCompiler(c)
+}
+
+func (stmt *SwitchStatement) generate(exe *vm.Executable, currentBlock *Block, ob *OpCodeBuf) {
+	breakLabel := ob.getLabel()
+
+	for _, caseClause := range stmt.caseList {
+		caseClause.label = ob.getLabel()
+
+		parent := caseClause.block.parent.(*StatementBlockInfo)
+		parent.breakLabel = breakLabel
+	}
+
+	// 没有default时跳出switch
+	defaultLabel := breakLabel
+	if stmt.defaultClause != nil {
+		defaultLabel = stmt.defaultClause.label
+	}
+
+	stmt.expression.generate(exe, currentBlock, ob)
+
+	switch stmt.kind {
+	case intSwitch:
+		stmt.generateIntSwitch(defaultLabel, ob)
+	case stringSwitch:
+		stmt.generateStringSwitch(defaultLabel, ob)
+	case classSwitch:
+		stmt.generateClassSwitch(defaultLabel, ob)
+	}
+
+	for _, caseClause := range stmt.caseList {
+		ob.setLabel(caseClause.label)
+
+		// 类的case跳转时栈上保留着switch的值
+		if stmt.kind == classSwitch && !caseClause.isDefault {
+			ob.generateCode(caseClause.Position(), vm.VM_POP)
+		}
+
+		generateStatementList(exe, caseClause.block, caseClause.block.statementList, ob)
+
+		ob.generateCode(caseClause.Position(), vm.VM_JUMP, breakLabel)
+	}
+
+	ob.setLabel(breakLabel)
+}
+
+// case值比较密集时使用tableswitch, 按下标直接跳转, 否则使用lookupswitch二分查找
+func (stmt *SwitchStatement) generateIntSwitch(defaultLabel int, ob *OpCodeBuf) {
+	labelMap := map[int]int{}
+	keyList := []int{}
+
+	for _, caseClause := range stmt.caseList {
+		for _, value := range caseClause.intValueList {
+			labelMap[value] = caseClause.label
+			keyList = append(keyList, value)
+		}
+	}
+
+	sort.Ints(keyList)
+
+	table := &vm.SwitchTable{DefaultPc: defaultLabel}
+
+	if isDenseCase(keyList) {
+		table.Low = keyList[0]
+		for i := 0; i <= keyList[len(keyList)-1]-table.Low; i++ {
+			label, ok := labelMap[table.Low+i]
+			if !ok {
+				label = defaultLabel
+			}
+			table.AddressList = append(table.AddressList, label)
+		}
+		ob.generateCode(stmt.Position(), vm.VM_TABLESWITCH, ob.addSwitchTable(table))
+		return
+	}
+
+	for _, value := range keyList {
+		table.KeyList = append(table.KeyList, value)
+		table.AddressList = append(table.AddressList, labelMap[value])
+	}
+	ob.generateCode(stmt.Position(), vm.VM_LOOKUPSWITCH, ob.addSwitchTable(table))
+}
+
+// 跳转表中至少一半是case的值
+func isDenseCase(keyList []int) bool {
+	if len(keyList) == 0 {
+		return false
+	}
+
+	span := keyList[len(keyList)-1] - keyList[0]
+
+	return span >= 0 && span < len(keyList)*2
+}
+
+func (stmt *SwitchStatement) generateStringSwitch(defaultLabel int, ob *OpCodeBuf) {
+	table := &vm.SwitchTable{DefaultPc: defaultLabel}
+
+	for _, caseClause := range stmt.caseList {
+		for _, value := range caseClause.stringValueList {
+			table.StringKeyList = append(table.StringKeyList, value)
+			table.AddressList = append(table.AddressList, caseClause.label)
+		}
+	}
+
+	ob.generateCode(stmt.Position(), vm.VM_LOOKUPSWITCH_STRING, ob.addSwitchTable(table))
+}
+
+// 按顺序逐个判断instanceof, 先匹配的case优先
+func (stmt *SwitchStatement) generateClassSwitch(defaultLabel int, ob *OpCodeBuf) {
+	for _, caseClause := range stmt.caseList {
+		for _, classIndex := range caseClause.classIndexList {
+			ob.generateCode(caseClause.Position(), vm.VM_DUPLICATE)
+			ob.generateCode(caseClause.Position(), vm.VM_INSTANCEOF, classIndex)
+			ob.generateCode(caseClause.Position(), vm.VM_JUMP_IF_TRUE, caseClause.label)
+		}
+	}
+
+	ob.generateCode(stmt.Position(), vm.VM_POP)
+	ob.generateCode(stmt.Position(), vm.VM_JUMP, defaultLabel)
+}
+
+func createSwitchStatement(expression Expression, caseList []*CaseClause, pos Position) *SwitchStatement {
+	stmt := &SwitchStatement{
+		expression: expression,
+		caseList:   caseList,
+	}
+	stmt.SetPosition(pos)
+
+	for _, caseClause := range caseList {
+		caseClause.block.parent = &StatementBlockInfo{statement: stmt}
+	}
+
+	return stmt
+}
+
+// CaseClause
+type CaseClause struct {
+	PosImpl
+
+	// default没有值
+	isDefault bool
+	valueList []Expression
+	block     *Block
+
+	// fix之后的case值
+	intValueList    []int
+	stringValueList []string
+	classIndexList  []int
+
+	label int
+}
+
+func createCaseClause(valueList []Expression, block *Block, pos Position) *CaseClause {
+	caseClause := &CaseClause{
+		isDefault: valueList == nil,
+		valueList: valueList,
+		block:     block,
+	}
+	caseClause.SetPosition(pos)
+
+	return caseClause
+}
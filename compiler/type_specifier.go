@@ -16,6 +16,11 @@ type FunctionDerive struct {
 
 type ArrayDerive struct{}
 
+// map<K, V>, 所在的类型为V
+type MapDerive struct {
+	keyType *TypeSpecifier
+}
+
 //
 // TypeSpecifier
 //
@@ -37,29 +42,43 @@ type TypeSpecifier struct {
 
 	// 派生类型
 	deriveList []TypeDerive
+
+	// 泛型类的类型参数, 修正时实例化, eg, List<int>
+	typeArgumentList []*TypeSpecifier
 }
 
-func (t *TypeSpecifier) fix() {
+func (t *TypeSpecifier) fix(c *Compiler) {
 
 	for _, deriveIfs := range t.deriveList {
-		derive, ok := deriveIfs.(*FunctionDerive)
-		if ok {
+		switch derive := deriveIfs.(type) {
+		case *FunctionDerive:
 			for _, parameter := range derive.parameterList {
-				parameter.typeSpecifier.fix()
+				parameter.typeSpecifier.fix(c)
+			}
+		case *MapDerive:
+			derive.keyType.fix(c)
+			if !isMapKeyType(derive.keyType) {
+				compileError(t.Position(), MAP_KEY_TYPE_ERR, getTypeName(derive.keyType))
 			}
 		}
 	}
 
 	if t.basicType == vm.ClassType && t.classRef.classDefinition == nil {
 
-		cd := searchClass(t.classRef.identifier)
+		var cd *ClassDefinition
+		if t.typeArgumentList != nil {
+			cd = c.instantiateClass(t)
+		} else {
+			cd = c.searchClass(t.classRef.identifier)
+		}
 		if cd == nil {
 			compileError(t.Position(), TYPE_NAME_NOT_FOUND_ERR, t.classRef.identifier)
 			return
 		}
 
+		t.classRef.identifier = cd.name
 		t.classRef.classDefinition = cd
-		t.classRef.classIndex = cd.addToCurrentCompiler()
+		t.classRef.classIndex = cd.addToCompiler(c)
 		return
 	}
 }
@@ -86,16 +105,39 @@ func createClassTypeSpecifier(identifier string, pos Position) *TypeSpecifier {
 	return typ
 }
 
+// 派生类型从外到内排列, 后声明的派生在最外层
+// eg, int(int)[]为[ArrayDerive, FunctionDerive]
 func createArrayTypeSpecifier(typ *TypeSpecifier) *TypeSpecifier {
-	typ.appendDerive(&ArrayDerive{})
+	typ.prependDerive(&ArrayDerive{})
 	return typ
 }
 
-func (t *TypeSpecifier) appendDerive(derive TypeDerive) {
-	if t.deriveList == nil {
-		t.deriveList = []TypeDerive{}
+// 函数类型, eg, int(int, int)
+func createFunctionTypeSpecifier(typ *TypeSpecifier, typeList []*TypeSpecifier) *TypeSpecifier {
+	parameterList := []*Parameter{}
+	for _, paramType := range typeList {
+		parameterList = append(parameterList, &Parameter{typeSpecifier: paramType})
 	}
-	t.deriveList = append(t.deriveList, derive)
+	typ.prependDerive(&FunctionDerive{parameterList: parameterList})
+	return typ
+}
+
+// map类型, eg, map<string, int>
+func createMapTypeSpecifier(keyType *TypeSpecifier, valueType *TypeSpecifier) *TypeSpecifier {
+	valueType.prependDerive(&MapDerive{keyType: keyType})
+	return valueType
+}
+
+// map的键只能是可以比较的基本类型
+func isMapKeyType(t *TypeSpecifier) bool {
+	if len(t.deriveList) != 0 {
+		return false
+	}
+	return isInteger(t) || isDouble(t) || isBoolean(t) || isChar(t) || isString(t)
+}
+
+func (t *TypeSpecifier) prependDerive(derive TypeDerive) {
+	t.deriveList = append([]TypeDerive{derive}, t.deriveList...)
 }
 
 func (t *TypeSpecifier) isArrayDerive() bool {
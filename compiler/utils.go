@@ -23,10 +23,43 @@ func isVoid(t *TypeSpecifier) bool    { return t.basicType == vm.VoidType }
 func isBoolean(t *TypeSpecifier) bool { return t.basicType == vm.BooleanType }
 func isInt(t *TypeSpecifier) bool     { return t.basicType == vm.IntType }
 func isDouble(t *TypeSpecifier) bool  { return t.basicType == vm.DoubleType }
+func isChar(t *TypeSpecifier) bool    { return t.basicType == vm.CharType }
+func isByte(t *TypeSpecifier) bool    { return t.basicType == vm.ByteType }
+func isInt32(t *TypeSpecifier) bool   { return t.basicType == vm.Int32Type }
+func isLong(t *TypeSpecifier) bool    { return t.basicType == vm.LongType }
 func isString(t *TypeSpecifier) bool  { return t.basicType == vm.StringType }
 func isClass(t *TypeSpecifier) bool   { return t.basicType == vm.ClassType }
 func isModule(t *TypeSpecifier) bool  { return t.basicType == vm.ModuleType }
-func isObject(t *TypeSpecifier) bool  { return isString(t) || isArray(t) }
+func isObject(t *TypeSpecifier) bool  { return isString(t) || isArray(t) || isClass(t) || isFunction(t) || isMap(t) }
+
+// byte, int32, int和long, 在虚拟机中都保存为int
+func isInteger(t *TypeSpecifier) bool { return isByte(t) || isInt32(t) || isInt(t) || isLong(t) }
+
+// 整数类型的位数, 转换为位数更少的类型时可能丢失数据
+func getIntegerBits(t *TypeSpecifier) int {
+	switch t.basicType {
+	case vm.ByteType:
+		return 8
+	case vm.Int32Type:
+		return 32
+	}
+	return 64
+}
+
+// 是否是Exception或其子类
+func isExceptionClass(t *TypeSpecifier) bool {
+	if !isClass(t) || t.deriveList != nil {
+		return false
+	}
+
+	for cd := t.classRef.classDefinition; cd != nil; cd = cd.superClass {
+		if cd.name == "Exception" && cd.getPackageName() == defaultPackage {
+			return true
+		}
+	}
+	return false
+}
+
 func isArray(t *TypeSpecifier) bool {
 	if t.deriveList == nil || len(t.deriveList) == 0 {
 		return false
@@ -36,15 +69,51 @@ func isArray(t *TypeSpecifier) bool {
 	return ok
 }
 
+// 函数类型, 值为函数或lambda
+func isFunction(t *TypeSpecifier) bool {
+	if len(t.deriveList) == 0 {
+		return false
+	}
+	_, ok := t.deriveList[0].(*FunctionDerive)
+	return ok
+}
+
+// map<K, V>
+func isMap(t *TypeSpecifier) bool {
+	if len(t.deriveList) == 0 {
+		return false
+	}
+	_, ok := t.deriveList[0].(*MapDerive)
+	return ok
+}
+
+// 原生函数中的任意类型的数组
+func isAnyArray(t *TypeSpecifier) bool {
+	return t.basicType == vm.BaseType && isArray(t)
+}
+
 func getTypeName(typ *TypeSpecifier) string {
-	typeName := getBasicTypeName(typ.basicType)
+	var typeName string
 
-	for _, derive := range typ.deriveList {
-		switch derive.(type) {
+	if isClass(typ) {
+		typeName = typ.classRef.identifier
+	} else {
+		typeName = getBasicTypeName(typ.basicType)
+	}
+
+	// 从最内层的派生开始拼接
+	for i := len(typ.deriveList) - 1; i >= 0; i-- {
+		switch derive := typ.deriveList[i].(type) {
 		case *FunctionDerive:
-			panic("TODO:derive_tag, func")
+			typeNameList := []string{}
+			for _, param := range derive.parameterList {
+				typeNameList = append(typeNameList, getTypeName(param.typeSpecifier))
+			}
+			typeName = typeName + "(" + strings.Join(typeNameList, ", ") + ")"
 		case *ArrayDerive:
 			typeName = typeName + "[]"
+		case *MapDerive:
+			typeName = "map<" + getTypeName(derive.keyType) + "," + typeName + ">"
 		default:
 			print("=====\n", typ.Position().Line)
 			panic("TODO:derive_tag")
@@ -62,10 +131,20 @@ func getBasicTypeName(typ vm.BasicType) string {
 		return "int"
 	case vm.DoubleType:
 		return "double"
+	case vm.CharType:
+		return "char"
+	case vm.ByteType:
+		return "byte"
+	case vm.Int32Type:
+		return "int32"
+	case vm.LongType:
+		return "long"
 	case vm.StringType:
 		return "string"
 	case vm.NullType:
 		return "null"
+	case vm.VoidType:
+		return "void"
 	default:
 		panic(fmt.Sprintf("bad case. type..%d\n", typ))
 	}
@@ -73,16 +152,14 @@ func getBasicTypeName(typ vm.BasicType) string {
 
 func getOpcodeTypeOffset(typ *TypeSpecifier) byte {
 
+	// 数组和函数都是引用
 	if typ.deriveList != nil && len(typ.deriveList) != 0 {
-		if !typ.isArrayDerive() {
-			panic("TODO")
-		}
 		return 2
 	}
 	switch typ.basicType {
 	case vm.VoidType:
 		panic("basic type is void")
-	case vm.BooleanType, vm.IntType:
+	case vm.BooleanType, vm.IntType, vm.CharType, vm.ByteType, vm.Int32Type, vm.LongType:
 		return byte(0)
 	case vm.DoubleType:
 		return byte(1)
@@ -93,7 +170,6 @@ func getOpcodeTypeOffset(typ *TypeSpecifier) byte {
 	default:
 		panic("basic type")
 	}
-	return byte(0)
 }
 
 func get2ByteInt(b []byte) int {
@@ -131,7 +207,17 @@ func compareType(typ1 *TypeSpecifier, typ2 *TypeSpecifier) bool {
 		case *FunctionDerive:
 			switch d2 := derive2.(type) {
 			case *FunctionDerive:
-				if !compareParameter(d1.parameterList, d2.parameterList) {
+				// 函数类型不区分形参名
+				if !compareParameterType(d1.parameterList, d2.parameterList) {
+					return false
+				}
+			default:
+				return false
+			}
+		case *MapDerive:
+			switch d2 := derive2.(type) {
+			case *MapDerive:
+				if !compareType(d1.keyType, d2.keyType) {
 					return false
 				}
 			default:
@@ -144,20 +230,24 @@ func compareType(typ1 *TypeSpecifier, typ2 *TypeSpecifier) bool {
 	return true
 }
 
-func compareParameter(paramList1, paramList2 []*Parameter) bool {
-	length1 := len(paramList1)
-	length2 := len(paramList2)
-	if length1 != length2 {
+// 类型完全一致, 类类型还要求是同一个类
+func isSameType(typ1 *TypeSpecifier, typ2 *TypeSpecifier) bool {
+	if !compareType(typ1, typ2) {
 		return false
 	}
+	if isClass(typ1) && typ1.classRef.classDefinition != typ2.classRef.classDefinition {
+		return false
+	}
+	return true
+}
 
-	for i := length1; i < length1; i++ {
-		param1 := paramList1[i]
-		param2 := paramList2[i]
-		if param1.name != param2.name {
-			return false
-		}
-		if !compareType(param1.typeSpecifier, param2.typeSpecifier) {
+// 参数类型完全一致, 不比较参数名
+func compareParameterType(paramList1, paramList2 []*Parameter) bool {
+	if len(paramList1) != len(paramList2) {
+		return false
+	}
+	for i, param := range paramList1 {
+		if !isSameType(param.typeSpecifier, paramList2[i].typeSpecifier) {
 			return false
 		}
 	}
@@ -167,7 +257,7 @@ func compareParameter(paramList1, paramList2 []*Parameter) bool {
 //
 // search
 //
-func searchDeclaration(name string, currentBlock *Block) *Declaration {
+func (c *Compiler) searchDeclaration(name string, currentBlock *Block) *Declaration {
 
 	// 从局部作用域查找
 	for block := currentBlock; block != nil; block = block.outerBlock {
@@ -179,9 +269,22 @@ func searchDeclaration(name string, currentBlock *Block) *Declaration {
 	}
 
 	// 从全局作用域查找
-	compiler := getCurrentCompiler()
-	for _, declaration := range compiler.declarationList {
-		if declaration.name == name {
+	if declaration := c.searchGlobalDeclaration(name); declaration != nil {
+		return declaration
+	}
+
+	// 导入的compiler查找, private变量只能在包内访问
+	for _, required := range c.requiredList {
+		for _, declaration := range required.declarationList {
+			if declaration.name == name && !declaration.isPrivate && !declaration.isBlockScoped {
+				return declaration
+			}
+		}
+	}
+
+	// 泛型实例中可以使用定义泛型的包的private变量
+	if owner := c.getInstanceOwner(); owner != nil {
+		if declaration := owner.searchGlobalDeclaration(name); declaration != nil && !declaration.isBlockScoped {
 			return declaration
 		}
 	}
@@ -189,32 +292,62 @@ func searchDeclaration(name string, currentBlock *Block) *Declaration {
 	return nil
 }
 
-func searchFunction(name string) *FunctionDefinition {
-	compiler := getCurrentCompiler()
+func (c *Compiler) searchGlobalDeclaration(name string) *Declaration {
+	for _, declaration := range c.declarationList {
+		if declaration.name == name {
+			return declaration
+		}
+	}
+	return nil
+}
 
-	// 当前compiler查找
-	for _, pos := range compiler.funcList {
-		if pos.name == name {
+func (c *Compiler) searchFunction(name string) *FunctionDefinition {
+	// 当前compiler查找, 方法只能通过类或对象访问
+	for _, pos := range c.funcList {
+		if pos.name == name && pos.classDefinition == nil {
 			return pos
 		}
 	}
 
-	// 导入的compiler查找
-	for _, required := range compiler.requiredList {
+	// 导入的compiler查找, private函数只能在包内访问
+	for _, required := range c.requiredList {
 		for _, fd := range required.funcList {
+			if fd.name == name && fd.classDefinition == nil && !fd.isPrivate {
+				return fd
+			}
+		}
+	}
+
+	// 泛型实例中可以调用定义泛型的包的private函数
+	if owner := c.getInstanceOwner(); owner != nil {
+		for _, fd := range owner.funcList {
 			if fd.name == name && fd.classDefinition == nil {
 				return fd
 			}
 		}
 	}
 
-	return nil
+	// 不属于任何包的原生函数
+	return c.searchNativeFunction("", name)
 }
 
-func searchModule(name string) *Module {
-	compiler := getCurrentCompiler()
+// 在导入的包中查找方法对应的函数定义, 如构造方法
+func (c *Compiler) searchRequiredMethod(packageName string, vmFuncName string) *FunctionDefinition {
+	for _, required := range c.requiredList {
+		for _, fd := range required.funcList {
+			if fd.classDefinition == nil || fd.getPackageName() != packageName {
+				continue
+			}
+			if fd.getVmFuncName() == vmFuncName {
+				return fd
+			}
+		}
+	}
+	return nil
+}
 
-	for _, requiredCompiler := range compiler.requiredList {
+func (c *Compiler) searchModule(name string) *Module {
+	for _, requiredCompiler := range c.requiredList {
 		// 暂无处理重名
 		lastName := requiredCompiler.packageNameList[len(requiredCompiler.packageNameList)-1]
 		if name == lastName {
@@ -229,17 +362,15 @@ func searchModule(name string) *Module {
 }
 
 // 根据名字在当前compiler, 及required里搜索类定义
-func searchClass(identifier string) *ClassDefinition {
-
-	compiler := getCurrentCompiler()
+func (c *Compiler) searchClass(identifier string) *ClassDefinition {
 
-	for _, cd := range compiler.classDefinitionList {
+	for _, cd := range c.classDefinitionList {
 		if cd.name == identifier {
 			return cd
 		}
 	}
 
-	for _, requiredCompiler := range compiler.requiredList {
+	for _, requiredCompiler := range c.requiredList {
 		for _, cd := range requiredCompiler.classDefinitionList {
 			if cd.name == identifier {
 				return cd
@@ -250,15 +381,15 @@ func searchClass(identifier string) *ClassDefinition {
 	return nil
 }
 
-func searchClassAndAdd(pos Position, name string, classIndexP *int) *ClassDefinition {
+func (c *Compiler) searchClassAndAdd(pos Position, name string, classIndexP *int) *ClassDefinition {
 
-	cd := searchClass(name)
+	cd := c.searchClass(name)
 
 	if cd == nil {
 		compileError(pos, CLASS_NOT_FOUND_ERR, name)
 	}
 
-	*classIndexP = cd.addToCurrentCompiler()
+	*classIndexP = cd.addToCompiler(c)
 
 	return cd
 }
@@ -1,29 +1,125 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/lth-go/gogogogo/compiler"
 	"github.com/lth-go/gogogogo/vm"
 )
 
+var (
+	// 只编译, 输出字节码文件
+	compileOnly = flag.Bool("c", false, "只编译, 生成字节码文件")
+	// 字节码文件路径
+	outputPath = flag.String("o", "", "字节码文件的输出路径, 默认与源文件同名")
+	// 输出反汇编结果, 不执行
+	disasm = flag.Bool("disasm", false, "输出反汇编结果, 不执行")
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "用法:")
+	fmt.Fprintln(os.Stderr, "  gogogogo file.4g [args]   编译并执行, 之后的参数由io.args()获取")
+	fmt.Fprintln(os.Stderr, "  gogogogo file.4gc [args]  执行字节码文件")
+	fmt.Fprintln(os.Stderr, "  gogogogo -c [-o out.4gc] file.4g")
+	fmt.Fprintln(os.Stderr, "                            编译为字节码文件, 包含导入的包, 执行时不需要源文件")
+	fmt.Fprintln(os.Stderr, "                            导入的包总是从源文件编译, 不会读取已有的.4gc")
+	fmt.Fprintln(os.Stderr, "  gogogogo -disasm file.4g|file.4gc")
+	fmt.Fprintln(os.Stderr, "                            输出反汇编结果")
+	fmt.Fprintln(os.Stderr, "  gogogogo repl             交互模式")
+	flag.PrintDefaults()
+}
+
 func main() {
-	if len(os.Args) != 2 {
-		panic("参数错误")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
 	}
-	filename := os.Args[1]
+	filename := flag.Arg(0)
 
-	_, err := os.Stat(filename)
+	if filename == "repl" {
+		runRepl(os.Stdin, os.Stdout)
+		return
+	}
+
+	exeList, err := loadExecutableList(filename)
 	if err != nil {
-		panic("文件不存在")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 
-	exeList := compiler.CompileFile(filename)
+	if *disasm {
+		exeList.Disassemble(os.Stdout)
+		return
+	}
+
+	if *compileOnly {
+		err = writeBytecode(exeList, getOutputPath(filename))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	// 创建虚拟机
 	VM := vm.NewVirtualMachine()
 
+	VM.SetArgs(flag.Args()[1:])
 	VM.SetExecutableList(exeList)
 
-	VM.Execute()
+	err = VM.Execute()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "运行错误")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// 源文件需要编译, 字节码文件直接读取
+func loadExecutableList(filename string) (*vm.ExecutableList, error) {
+	if filepath.Ext(filename) != vm.BytecodeSuffix {
+		return compiler.CompileFile(filename)
+	}
+
+	if *compileOnly {
+		return nil, fmt.Errorf("%s已经是字节码文件", filename)
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	exeList := vm.NewExecutableList()
+
+	err = exeList.Unmarshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+
+	return exeList, nil
+}
+
+func getOutputPath(filename string) string {
+	if *outputPath != "" {
+		return *outputPath
+	}
+	return strings.TrimSuffix(filename, filepath.Ext(filename)) + vm.BytecodeSuffix
+}
+
+func writeBytecode(exeList *vm.ExecutableList, path string) error {
+	data, err := exeList.Marshal()
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
 }
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lth-go/gogogogo/compiler"
+	"github.com/lth-go/gogogogo/vm"
+)
+
+const (
+	// 输入提示符
+	replPrompt = ">>> "
+	// 多行输入未结束时的提示符
+	replContinuePrompt = "... "
+)
+
+// 交互模式, 逐段编译并执行输入
+// 括号未闭合时继续读取下一行
+func runRepl(in io.Reader, out io.Writer) {
+	session := compiler.NewSession(compiler.Options{})
+	VM := vm.NewVirtualMachine()
+
+	scanner := bufio.NewScanner(in)
+	source := ""
+
+	fmt.Fprint(out, replPrompt)
+
+	for scanner.Scan() {
+		source += scanner.Text() + "\n"
+
+		if isIncompleteInput(source) {
+			fmt.Fprint(out, replContinuePrompt)
+			continue
+		}
+
+		if strings.TrimSpace(source) != "" {
+			evalInput(session, VM, source, out)
+		}
+
+		source = ""
+		fmt.Fprint(out, replPrompt)
+	}
+
+	fmt.Fprintln(out)
+}
+
+// 编译并执行一段输入, 出错时只输出错误信息
+func evalInput(session *compiler.Session, VM *vm.VirtualMachine, source string, out io.Writer) {
+	// 单行表达式可以省略分号
+	trimmed := strings.TrimSpace(source)
+	if !strings.HasSuffix(trimmed, ";") && !strings.HasSuffix(trimmed, "}") {
+		source = trimmed + ";\n"
+	}
+
+	exeList, hasResult, err := session.Compile(source)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return
+	}
+
+	result, err := VM.Eval(exeList, hasResult)
+	if err != nil {
+		fmt.Fprintln(out, "运行错误")
+		fmt.Fprintln(out, err)
+		return
+	}
+
+	if hasResult {
+		fmt.Fprintln(out, result)
+	}
+}
+
+// 大括号或小括号没有闭合, 忽略字符串, 字符字面量和注释中的括号
+func isIncompleteInput(source string) bool {
+	depth := 0
+	// 所在字面量的引号, 不在字面量中时为0
+	var quote byte
+	inComment := false
+
+	for i := 0; i < len(source); i++ {
+		ch := source[i]
+
+		switch {
+		case inComment:
+			if ch == '\n' {
+				inComment = false
+			}
+		case quote != 0:
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			} else if ch == '\n' && quote == '\'' {
+				// 字符字面量不能跨行, 未闭合时交给编译器报错
+				quote = 0
+			}
+		case ch == '#':
+			inComment = true
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '{' || ch == '(':
+			depth++
+		case ch == '}' || ch == ')':
+			depth--
+		}
+	}
+
+	return depth > 0
+}
@@ -14,6 +14,14 @@ const (
 	IntType
 	// DoubleType 浮点
 	DoubleType
+	// CharType 字符, 值为Unicode码点
+	CharType
+	// ByteType 无符号8位整数
+	ByteType
+	// Int32Type 有符号32位整数
+	Int32Type
+	// LongType 有符号64位整数
+	LongType
 	// StringType 字符串类型
 	StringType
 	// Null
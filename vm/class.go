@@ -20,9 +20,14 @@ type ExecClass struct {
 	superClass *ExecClass
 	// 虚表
 	classTable *VTable
+	// 接口表, 包括父类实现的接口
+	interfaceTable []*ITable
 
 	// 所有字段类型表
 	fieldTypeList []*TypeSpecifier
+
+	// 静态字段, 由实现该类的exe初始化
+	static *Static
 }
 
 // 虚表
@@ -31,6 +36,13 @@ type VTable struct {
 	table     []*VTableItem
 }
 
+// 接口表, 接口方法按接口中的声明顺序排列
+type ITable struct {
+	packageName string
+	name        string
+	table       []*VTableItem
+}
+
 // 虚表字段
 type VTableItem struct {
 	name  string
@@ -0,0 +1,465 @@
+package vm
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ==============================
+// 反汇编
+// ==============================
+//
+// 字节码中的下标是exe内的下标, 需要在SetExecutableList之前调用
+
+// Disassemble 输出所有exe的反汇编结果
+func (exeList *ExecutableList) Disassemble(w io.Writer) {
+	for _, exe := range exeList.List {
+		exe.Disassemble(w)
+	}
+}
+
+// Disassemble 输出常量池, 顶层代码和所有函数的反汇编结果
+func (exe *Executable) Disassemble(w io.Writer) {
+	packageName := exe.PackageName
+	if packageName == "" {
+		packageName = "<main>"
+	}
+	fmt.Fprintf(w, "== package %s (%s) ==\n", packageName, exe.Path)
+
+	if exe.ConstantPool.Length() > 0 {
+		fmt.Fprintln(w, "-- constant pool --")
+		for i := 0; i < exe.ConstantPool.Length(); i++ {
+			fmt.Fprintf(w, "  #%-4d %s\n", i, constantString(exe.ConstantPool.pool[i]))
+		}
+	}
+
+	if len(exe.GlobalVariableList) > 0 {
+		fmt.Fprintln(w, "-- global variables --")
+		for i, v := range exe.GlobalVariableList {
+			fmt.Fprintf(w, "  %-5d %s %s\n", i, typeSpecifierString(v.typeSpecifier), v.name)
+		}
+	}
+
+	for _, class := range exe.ClassDefinitionList {
+		if class.IsImplemented {
+			disassembleClass(w, class)
+		}
+	}
+
+	fmt.Fprintln(w, "-- top level --")
+	d := &disassembler{exe: exe, w: w}
+	d.disassemble(exe.CodeList, exe.LineNumberList, exe.TryList, exe.SwitchTableList)
+
+	for _, f := range exe.FunctionList {
+		if !f.IsImplemented {
+			continue
+		}
+		fmt.Fprintf(w, "-- function %s --\n", functionSignature(f))
+
+		d := &disassembler{exe: exe, function: f, w: w}
+		d.disassemble(f.CodeList, f.LineNumberList, f.TryList, f.SwitchTableList)
+	}
+
+	fmt.Fprintln(w)
+}
+
+func disassembleClass(w io.Writer, class *Class) {
+	switch {
+	case class.IsInterface:
+		fmt.Fprintf(w, "-- interface %s", class.Name)
+	case class.IsAbstract:
+		fmt.Fprintf(w, "-- abstract class %s", class.Name)
+	default:
+		fmt.Fprintf(w, "-- class %s", class.Name)
+	}
+
+	extendList := []string{}
+	if class.SuperClass != nil {
+		extendList = append(extendList, class.SuperClass.Name)
+	}
+	for _, iface := range class.InterfaceList {
+		extendList = append(extendList, iface.Name)
+	}
+	if len(extendList) > 0 {
+		fmt.Fprintf(w, " : %s", strings.Join(extendList, ", "))
+	}
+	fmt.Fprintln(w, " --")
+
+	for _, field := range class.FieldList {
+		fmt.Fprintf(w, "  field  %s %s\n", typeSpecifierString(field.Typ), field.Name)
+	}
+	for _, field := range class.StaticFieldList {
+		fmt.Fprintf(w, "  static %s %s\n", typeSpecifierString(field.Typ), field.Name)
+	}
+	for _, method := range class.MethodList {
+		if method.IsAbstract {
+			fmt.Fprintf(w, "  method %s (abstract)\n", method.Name)
+		} else {
+			fmt.Fprintf(w, "  method %s\n", method.Name)
+		}
+	}
+}
+
+//
+// disassembler
+//
+type disassembler struct {
+	exe *Executable
+	// 顶层代码为nil
+	function *Function
+
+	w io.Writer
+
+	// 跳转地址对应的标签
+	labelMap map[int]string
+}
+
+func (d *disassembler) disassemble(codeList []byte, lineNumberList []*LineNumber, tryList []*Try, switchTableList []*SwitchTable) {
+	d.collectLabels(codeList, switchTableList)
+
+	lineIndex := 0
+
+	for pc := 0; pc < len(codeList); {
+		for lineIndex < len(lineNumberList) && lineNumberList[lineIndex].StartPc <= pc {
+			fmt.Fprintf(d.w, "  ; line %d\n", lineNumberList[lineIndex].LineNumber)
+			lineIndex++
+		}
+
+		if label, ok := d.labelMap[pc]; ok {
+			fmt.Fprintf(d.w, "%s:\n", label)
+		}
+
+		var text string
+		text, pc = d.instruction(codeList, pc)
+
+		fmt.Fprintln(d.w, text)
+	}
+
+	d.showTryList(tryList)
+	d.showSwitchTableList(switchTableList)
+}
+
+// 为所有跳转目标分配标签, 按地址排序
+func (d *disassembler) collectLabels(codeList []byte, switchTableList []*SwitchTable) {
+	addressList := []int{}
+	found := map[int]bool{}
+
+	addAddress := func(address int) {
+		if !found[address] {
+			found[address] = true
+			addressList = append(addressList, address)
+		}
+	}
+
+	for pc := 0; pc < len(codeList); pc = nextPc(codeList, pc) {
+		if !isJumpOpcode(codeList[pc]) || pc+3 > len(codeList) {
+			continue
+		}
+		addAddress(get2ByteInt(codeList[pc+1:]))
+	}
+
+	for _, table := range switchTableList {
+		for _, address := range table.AddressList {
+			addAddress(address)
+		}
+		addAddress(table.DefaultPc)
+	}
+
+	sort.Ints(addressList)
+
+	d.labelMap = map[int]string{}
+	for i, address := range addressList {
+		d.labelMap[address] = "L" + strconv.Itoa(i)
+	}
+}
+
+// 返回一条指令的文本和下一条指令的位置
+func (d *disassembler) instruction(codeList []byte, pc int) (string, int) {
+	code := codeList[pc]
+
+	if int(code) >= len(OpcodeInfo) {
+		return fmt.Sprintf("  %04d  <unknown opcode %d>", pc, code), pc + 1
+	}
+
+	info := OpcodeInfo[code]
+
+	operandList := []string{}
+	valueList := []int{}
+
+	next := pc + 1
+	for _, param := range []byte(info.Parameter) {
+		switch param {
+		case 'b':
+			if next+1 > len(codeList) {
+				return fmt.Sprintf("  %04d  %s <truncated>", pc, info.Mnemonic), len(codeList)
+			}
+			valueList = append(valueList, int(codeList[next]))
+			next++
+		case 's', 'p':
+			if next+2 > len(codeList) {
+				return fmt.Sprintf("  %04d  %s <truncated>", pc, info.Mnemonic), len(codeList)
+			}
+			valueList = append(valueList, get2ByteInt(codeList[next:]))
+			next += 2
+		default:
+			return fmt.Sprintf("  %04d  %s <unknown parameter %q>", pc, info.Mnemonic, param), len(codeList)
+		}
+	}
+
+	for i, param := range []byte(info.Parameter) {
+		operandList = append(operandList, d.operand(code, param, valueList[i]))
+	}
+
+	text := fmt.Sprintf("  %04d  %-26s %s", pc, info.Mnemonic, strings.Join(operandList, ", "))
+
+	if comment := d.comment(code, valueList); comment != "" {
+		text = fmt.Sprintf("%-50s ; %s", text, comment)
+	}
+
+	return strings.TrimRight(text, " "), next
+}
+
+func (d *disassembler) operand(code byte, param byte, value int) string {
+	switch {
+	case isJumpOpcode(code):
+		if label, ok := d.labelMap[value]; ok {
+			return label
+		}
+	case param == 'p':
+		return "#" + strconv.Itoa(value)
+	}
+	return strconv.Itoa(value)
+}
+
+// 指令的附加说明, 常量值和变量名等
+func (d *disassembler) comment(code byte, valueList []int) string {
+	exe := d.exe
+
+	switch code {
+	case VM_PUSH_INT, VM_PUSH_DOUBLE, VM_PUSH_STRING:
+		if valueList[0] < exe.ConstantPool.Length() {
+			return constantString(exe.ConstantPool.pool[valueList[0]])
+		}
+	case VM_PUSH_STACK_INT, VM_PUSH_STACK_DOUBLE, VM_PUSH_STACK_OBJECT,
+		VM_POP_STACK_INT, VM_POP_STACK_DOUBLE, VM_POP_STACK_OBJECT:
+		return d.localVariableName(valueList[0])
+	case VM_PUSH_STATIC_INT, VM_PUSH_STATIC_DOUBLE, VM_PUSH_STATIC_OBJECT,
+		VM_POP_STATIC_INT, VM_POP_STATIC_DOUBLE, VM_POP_STATIC_OBJECT:
+		if valueList[0] < len(exe.GlobalVariableList) {
+			return exe.GlobalVariableList[valueList[0]].name
+		}
+	case VM_PUSH_PACKAGE_STATIC_INT, VM_PUSH_PACKAGE_STATIC_DOUBLE, VM_PUSH_PACKAGE_STATIC_OBJECT,
+		VM_POP_PACKAGE_STATIC_INT, VM_POP_PACKAGE_STATIC_DOUBLE, VM_POP_PACKAGE_STATIC_OBJECT:
+		if valueList[0] < len(exe.ImportedVariableList) {
+			v := exe.ImportedVariableList[valueList[0]]
+			return qualifiedName(v.PackageName, v.Name)
+		}
+	case VM_PUSH_CLASS_STATIC_INT, VM_PUSH_CLASS_STATIC_DOUBLE, VM_PUSH_CLASS_STATIC_OBJECT,
+		VM_POP_CLASS_STATIC_INT, VM_POP_CLASS_STATIC_DOUBLE, VM_POP_CLASS_STATIC_OBJECT:
+		if valueList[0] < len(exe.ClassDefinitionList) {
+			class := exe.ClassDefinitionList[valueList[0]]
+			if valueList[1] < len(class.StaticFieldList) {
+				return qualifiedName(class.PackageName, class.Name+"."+class.StaticFieldList[valueList[1]].Name)
+			}
+		}
+	case VM_PUSH_FUNCTION, VM_NEW_CLOSURE:
+		if valueList[0] < len(exe.FunctionList) {
+			f := exe.FunctionList[valueList[0]]
+			return qualifiedName(f.PackageName, f.Name)
+		}
+	case VM_NEW, VM_UP_CAST, VM_DOWN_CAST, VM_INSTANCEOF, VM_SUPER, VM_PUSH_INTERFACE_METHOD:
+		if valueList[0] < len(exe.ClassDefinitionList) {
+			class := exe.ClassDefinitionList[valueList[0]]
+			return qualifiedName(class.PackageName, class.Name)
+		}
+	case VM_NEW_ARRAY:
+		if valueList[1] < len(exe.TypeSpecifierList) {
+			return typeSpecifierString(exe.TypeSpecifierList[valueList[1]])
+		}
+	}
+
+	return ""
+}
+
+// 栈上的位置依次为形参, this, 局部变量
+func (d *disassembler) localVariableName(index int) string {
+	f := d.function
+	if f == nil {
+		return ""
+	}
+
+	if index < len(f.ParameterList) {
+		return f.ParameterList[index].Name
+	}
+	index -= len(f.ParameterList)
+
+	if f.IsMethod {
+		if index == 0 {
+			return "this"
+		}
+		index--
+	}
+
+	if index < len(f.LocalVariableList) {
+		return f.LocalVariableList[index].Name
+	}
+	return ""
+}
+
+func (d *disassembler) showTryList(tryList []*Try) {
+	addressString := func(address int) string {
+		if label, ok := d.labelMap[address]; ok {
+			return fmt.Sprintf("%04d(%s)", address, label)
+		}
+		return fmt.Sprintf("%04d", address)
+	}
+
+	for _, try := range tryList {
+		fmt.Fprintf(d.w, "  try [%04d, %04d)\n", try.StartPc, try.EndPc)
+
+		for _, catch := range try.CatchList {
+			className := strconv.Itoa(catch.ClassIndex)
+			if catch.ClassIndex < len(d.exe.ClassDefinitionList) {
+				className = d.exe.ClassDefinitionList[catch.ClassIndex].Name
+			}
+			fmt.Fprintf(d.w, "    catch %s [%04d, %04d)\n", className, catch.StartPc, catch.EndPc)
+		}
+
+		if try.FinallyStartPc >= 0 {
+			fmt.Fprintf(d.w, "    finally %s\n", addressString(try.FinallyStartPc))
+		}
+	}
+}
+
+func (d *disassembler) showSwitchTableList(switchTableList []*SwitchTable) {
+	for i, table := range switchTableList {
+		fmt.Fprintf(d.w, "  switch table %d\n", i)
+
+		for j, address := range table.AddressList {
+			var key string
+			switch {
+			case j < len(table.StringKeyList):
+				key = strconv.Quote(table.StringKeyList[j])
+			case j < len(table.KeyList):
+				key = strconv.Itoa(table.KeyList[j])
+			default:
+				key = strconv.Itoa(table.Low + j)
+			}
+			fmt.Fprintf(d.w, "    %-8s %s\n", key, d.labelMap[address])
+		}
+
+		fmt.Fprintf(d.w, "    %-8s %s\n", "default", d.labelMap[table.DefaultPc])
+	}
+}
+
+// ==============================
+// utils
+// ==============================
+
+func isJumpOpcode(code byte) bool {
+	switch code {
+	case VM_JUMP, VM_JUMP_IF_TRUE, VM_JUMP_IF_FALSE, VM_GO_FINALLY:
+		return true
+	}
+	return false
+}
+
+// 下一条指令的位置
+func nextPc(codeList []byte, pc int) int {
+	code := codeList[pc]
+	if int(code) >= len(OpcodeInfo) {
+		return pc + 1
+	}
+
+	next := pc + 1
+	for _, param := range []byte(OpcodeInfo[code].Parameter) {
+		switch param {
+		case 'b':
+			next++
+		case 's', 'p':
+			next += 2
+		default:
+			return len(codeList)
+		}
+	}
+	return next
+}
+
+func qualifiedName(packageName string, name string) string {
+	if packageName == "" {
+		return name
+	}
+	return packageName + "." + name
+}
+
+func constantString(c Constant) string {
+	switch constant := c.(type) {
+	case *ConstantInt:
+		return fmt.Sprintf("int %d", constant.intValue)
+	case *ConstantDouble:
+		return fmt.Sprintf("double %v", constant.doubleValue)
+	case *ConstantString:
+		return fmt.Sprintf("string %q", constant.stringValue)
+	}
+	return "?"
+}
+
+var basicTypeNameList = []string{
+	BooleanType: "boolean",
+	IntType:     "int",
+	DoubleType:  "double",
+	CharType:    "char",
+	ByteType:    "byte",
+	Int32Type:   "int32",
+	LongType:    "long",
+	StringType:  "string",
+	NullType:    "null",
+	VoidType:    "void",
+	ClassType:   "class",
+	BaseType:    "base",
+	ModuleType:  "module",
+}
+
+func typeSpecifierString(typ *TypeSpecifier) string {
+	if typ == nil {
+		return "?"
+	}
+
+	name := "?"
+	if int(typ.BasicType) < len(basicTypeNameList) {
+		name = basicTypeNameList[typ.BasicType]
+	}
+
+	for _, deriveIfs := range typ.DeriveList {
+		switch derive := deriveIfs.(type) {
+		case *ArrayDerive:
+			name += "[]"
+		case *FunctionDerive:
+			name += "(" + parameterListString(derive.ParameterList) + ")"
+		case *MapDerive:
+			name = "map<" + typeSpecifierString(derive.KeyType) + "," + name + ">"
+		}
+	}
+
+	return name
+}
+
+func parameterListString(list []*LocalVariable) string {
+	paramList := []string{}
+	for _, param := range list {
+		paramList = append(paramList, typeSpecifierString(param.TypeSpecifier)+" "+param.Name)
+	}
+	return strings.Join(paramList, ", ")
+}
+
+func functionSignature(f *Function) string {
+	// 重载的构造方法名中已经带有参数类型, 只输出一次参数列表
+	name := f.Name
+	if index := strings.Index(name, "("); index >= 0 {
+		name = name[:index]
+	}
+	return fmt.Sprintf("%s %s(%s)", typeSpecifierString(f.TypeSpecifier), qualifiedName(f.PackageName, name), parameterListString(f.ParameterList))
+}
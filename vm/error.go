@@ -1,9 +1,9 @@
 package vm
 
 import (
-	//"fmt"
 	"fmt"
-	"os"
+	"regexp"
+	"strings"
 )
 
 const (
@@ -19,6 +19,10 @@ const (
     CLASS_NOT_FOUND_ERR
     CLASS_CAST_ERR
     DYNAMIC_LOAD_WITHOUT_PACKAGE_ERR
+    UNCAUGHT_EXCEPTION_ERR
+    ILLEGAL_ARGUMENT_ERR
+    NUMBER_FORMAT_ERR
+    VARIABLE_NOT_FOUND_ERR
 )
 
 var errMessageList []string = []string{
@@ -32,8 +36,12 @@ var errMessageList []string = []string{
 	"加载文件时发生错误($(status))。",
 	"重复定义了类$(package)#$(name)。",
 	"没有找到类$(name)。",
-	"对象的类型为$(org)。,不能向下转型为$(target)。",
+	"对象的类型为$(org), 不能向下转型为$(target)。",
 	"由于函数$(name)没有指定包，不能动态加载。",
+	"未捕获的异常$(class_name): $(message)",
+	"函数$(name)的参数不正确($(reason))。",
+	"不能将\"$(str)\"转换为数字。",
+	"没有找到包$(package)中的全局变量$(name)。",
 }
 
 var errMessageMap = map[int]string{
@@ -45,20 +53,119 @@ var errMessageMap = map[int]string{
 //NULL_POINTER_ERR:             "引用了null。",
 }
 
+// 虚拟机运行时错误
+type vmErrorInfo struct {
+	errorNumber int
+	args        []interface{}
+}
+
+func (e *vmErrorInfo) Error() string {
+	return FormatMessage(errMessageList[e.errorNumber], e.args...)
+}
+
+// 可被脚本捕获的运行时错误, 以及对应的异常类
+var errExceptionClassMap = map[int]string{
+	INDEX_OUT_OF_BOUNDS_ERR: "ArrayIndexOutOfBoundsException",
+	DIVISION_BY_ZERO_ERR:    "DivisionByZeroException",
+	NULL_POINTER_ERR:        "NullPointerException",
+	CLASS_CAST_ERR:          "ClassCastException",
+	ILLEGAL_ARGUMENT_ERR:    "IllegalArgumentException",
+	NUMBER_FORMAT_ERR:       "NumberFormatException",
+}
+
+// 执行中的错误会被转换为异常对象, 由execute分发
 func vmError(errorNumber int, a ...interface{}) {
-	//vm := getVirtualMachine()
-
-	//exe := vm.currentExecutable.executable
-	//functionList := vm.currentFunction
-	//pc := vm.pc
-
-	fmt.Println("运行错误")
-	//fmt.Printf("Line: %d\n", getLineNumberByPc(exe, functionList, pc))
-	println(errorNumber)
-	println(errMessageList[errorNumber])
-	panic("TODO")
-	//fmt.Printf(errMessageMap[errorNumber], a...)
-	os.Exit(1)
+	panic(&vmErrorInfo{errorNumber: errorNumber, args: a})
+}
+
+// ==============================
+// RuntimeError
+// ==============================
+
+// RuntimeError 未被捕获的运行时错误
+type RuntimeError struct {
+	// 错误码, 对应INDEX_OUT_OF_BOUNDS_ERR等常量
+	Code    int
+	Message string
+	// 发生错误时的调用栈, 从发生错误的函数开始
+	StackTrace []*StackFrame
+}
+
+func (e *RuntimeError) Error() string {
+	lineList := []string{e.Message}
+	for _, frame := range e.StackTrace {
+		lineList = append(lineList, "\tat "+frame.String())
+	}
+	return strings.Join(lineList, "\n")
+}
+
+// StackFrame 调用栈中的一层
+type StackFrame struct {
+	PackageName string
+	// 函数名, 方法为"类名#方法名", 顶层代码为空
+	FunctionName string
+	Line         int
+}
+
+func (frame *StackFrame) String() string {
+	name := frame.FunctionName
+	if name == "" {
+		name = "<top-level>"
+	}
+	if frame.PackageName != "" {
+		name = frame.PackageName + "." + name
+	}
+	return fmt.Sprintf("%s (line %d)", name, frame.Line)
+}
+
+// 获取调用栈, 沿着栈上的CallInfo向外查找
+func (vm *VirtualMachine) getStackTrace(function *GFunction, exe *Executable, pc int, base int) []*StackFrame {
+	stackTrace := []*StackFrame{}
+
+	for {
+		frame := &StackFrame{
+			PackageName: exe.PackageName,
+			Line:        getLineNumberByPc(exe, function, pc),
+		}
+		stackTrace = append(stackTrace, frame)
+
+		if function == nil {
+			return stackTrace
+		}
+
+		f := exe.FunctionList[function.Index]
+		frame.FunctionName = f.Name
+
+		callInfo, ok := vm.stack.stack[base+getArgumentCount(f)].(*CallInfo)
+		if !ok || callInfo.callerAddress == callFromNative {
+			return stackTrace
+		}
+
+		function = callInfo.caller
+		pc = callInfo.callerAddress
+		base = callInfo.base
+
+		if function == nil {
+			exe = vm.runningTopLevel.executable
+		} else {
+			exe = function.Executable.executable
+		}
+	}
+}
+
+// 错误信息中的$(name)占位符
+var placeholderRegexp = regexp.MustCompile(`\$\([a-z_]+\)`)
+
+// FormatMessage 按顺序将参数填入占位符, 编译器和虚拟机的错误信息共用
+func FormatMessage(format string, a ...interface{}) string {
+	i := 0
+	return placeholderRegexp.ReplaceAllStringFunc(format, func(placeholder string) string {
+		if i >= len(a) {
+			return placeholder
+		}
+		i++
+		return fmt.Sprint(a[i-1])
+	})
 }
 
 func getLineNumberByPc(exe *Executable, function *GFunction, pc int) int {
@@ -0,0 +1,194 @@
+package vm
+
+// 异常类所在的包
+const exceptionPackageName = "gogogogo.lang"
+
+// Exception类中message字段的下标
+const exceptionMessageFieldIndex = 0
+
+// 被抛出的异常, 通过panic传递到execute
+type thrownException struct {
+	exception *ObjectRef
+}
+
+func throwException(exception *ObjectRef) {
+	panic(&thrownException{exception: exception})
+}
+
+// 将执行中recover到的值转换为异常对象
+func (vm *VirtualMachine) recoverException(r interface{}, function *GFunction, exe *Executable, pc int, base int) *ObjectRef {
+	var exception *ObjectRef
+	var runtimeError *RuntimeError
+
+	switch e := r.(type) {
+	case *thrownException:
+		exception = e.exception
+		if getRuntimeError(exception) != nil {
+			// finally结束后重新抛出
+			return exception
+		}
+		runtimeError = &RuntimeError{
+			Code:    UNCAUGHT_EXCEPTION_ERR,
+			Message: FormatMessage(errMessageList[UNCAUGHT_EXCEPTION_ERR], exception.vTable.execClass.name, getExceptionMessage(exception)),
+		}
+	case *vmErrorInfo:
+		runtimeError = &RuntimeError{
+			Code:    e.errorNumber,
+			Message: e.Error(),
+		}
+		runtimeError.StackTrace = vm.getStackTrace(function, exe, pc, base)
+
+		className, ok := errExceptionClassMap[e.errorNumber]
+		if !ok {
+			panic(runtimeError)
+		}
+		exception = vm.createException(className, runtimeError.Message)
+		if exception == nil {
+			panic(runtimeError)
+		}
+	default:
+		panic(r)
+	}
+
+	if runtimeError.StackTrace == nil {
+		runtimeError.StackTrace = vm.getStackTrace(function, exe, pc, base)
+	}
+	exception.data.(*ObjectClassObject).runtimeError = runtimeError
+
+	return exception
+}
+
+func getRuntimeError(exception *ObjectRef) *RuntimeError {
+	return exception.data.(*ObjectClassObject).runtimeError
+}
+
+// 创建异常对象, 没有加载异常类时返回nil
+func (vm *VirtualMachine) createException(className string, message string) *ObjectRef {
+	classIndex := vm.searchClassIndex(exceptionPackageName, className)
+	if classIndex < 0 {
+		return nil
+	}
+
+	messageRef := vm.createStringObject(message)
+
+	ref := vm.createClassObject(classIndex)
+	ref.data.(*ObjectClassObject).writeObject(exceptionMessageFieldIndex, messageRef)
+
+	return ref
+}
+
+// 原生函数中抛出异常, 可以被脚本捕获
+func (vm *VirtualMachine) throwNativeException(className string, message string) {
+	exception := vm.createException(className, message)
+	if exception == nil {
+		vmError(UNCAUGHT_EXCEPTION_ERR, className, message)
+	}
+	throwException(exception)
+}
+
+// 从当前函数开始, 沿调用链查找能处理异常的try语句
+func (vm *VirtualMachine) dispatchException(exception *ObjectRef, funcP **GFunction, codeP *[]byte, pcP *int, baseP *int, eeP **ExecutableEntry, exeP **Executable) bool {
+	pc := *pcP
+
+	for {
+		var tryList []*Try
+		var sp int
+
+		if *funcP == nil {
+			tryList = (*exeP).TryList
+			sp = 0
+		} else {
+			f := (*exeP).FunctionList[(*funcP).Index]
+			tryList = f.TryList
+			sp = *baseP + getArgumentCount(f) + 1 + len(f.LocalVariableList)
+		}
+
+		handlerPc, ok := searchExceptionHandler(*exeP, tryList, exception, pc)
+		if ok {
+			// 丢弃表达式的中间结果, 压入异常对象
+			vm.stack.stackPointer = sp
+			vm.stack.setObject(0, exception)
+			vm.stack.stackPointer++
+
+			*pcP = handlerPc
+			return true
+		}
+
+		if *funcP == nil {
+			return false
+		}
+
+		doReturn(vm, funcP, codeP, pcP, baseP, eeP, exeP)
+
+		// 调用者中发生异常的位置是invoke指令
+		pc = *pcP - 1
+	}
+}
+
+func searchExceptionHandler(exe *Executable, tryList []*Try, exception *ObjectRef, pc int) (int, bool) {
+	for _, try := range tryList {
+		if pc >= try.StartPc && pc < try.EndPc {
+			for _, catch := range try.CatchList {
+				if isInstanceOf(exception, exe.ClassDefinitionList[catch.ClassIndex]) {
+					return catch.StartPc, true
+				}
+			}
+		} else if !isInCatch(try, pc) {
+			continue
+		}
+
+		// 没有匹配的catch, 先执行finally, 由finally_end重新抛出
+		if try.FinallyStartPc >= 0 {
+			return try.FinallyStartPc, true
+		}
+	}
+
+	return 0, false
+}
+
+func isInCatch(try *Try, pc int) bool {
+	for _, catch := range try.CatchList {
+		if pc >= catch.StartPc && pc < catch.EndPc {
+			return true
+		}
+	}
+	return false
+}
+
+// 判断对象是否是类或其子类的实例
+func isInstanceOf(obj *ObjectRef, class *Class) bool {
+	for ec := obj.vTable.execClass; ec != nil; ec = ec.superClass {
+		if ec.name == class.Name && ec.packageName == class.PackageName {
+			return true
+		}
+	}
+	if class.IsInterface {
+		return searchInterfaceTable(obj.vTable.execClass, class) != nil
+	}
+	return false
+}
+
+// 查找类实现的接口, 没有实现时返回nil
+func searchInterfaceTable(ec *ExecClass, iface *Class) *ITable {
+	for _, iTable := range ec.interfaceTable {
+		if iTable.name == iface.Name && iTable.packageName == iface.PackageName {
+			return iTable
+		}
+	}
+	return nil
+}
+
+func getExceptionMessage(exception *ObjectRef) string {
+	obj := exception.data.(*ObjectClassObject)
+	message := obj.getObject(exceptionMessageFieldIndex)
+
+	if message.data == nil {
+		return "null"
+	}
+	return message.data.(*ObjectString).stringValue
+}
+
+// 没有被捕获的异常, 由Execute作为error返回
+func (vm *VirtualMachine) uncaughtException(exception *ObjectRef) {
+	panic(getRuntimeError(exception))
+}
@@ -1,7 +1,8 @@
 package vm
 
 import (
-	"fmt"
+	"os"
+	"sort"
 )
 
 //
@@ -24,6 +25,9 @@ type Executable struct {
 	// 仅保存名称和类型
 	GlobalVariableList []*Variable
 
+	// 引用的其他包的全局变量
+	ImportedVariableList []*ImportedVariable
+
 	// 函数列表
 	FunctionList []*Function
 
@@ -39,6 +43,30 @@ type Executable struct {
 	// 行号对应表
 	// 保存字节码和与之对应的源代码的行号
 	LineNumberList []*LineNumber
+
+	// 顶层代码的异常处理表
+	TryList []*Try
+
+	// 顶层代码的switch跳转表
+	SwitchTableList []*SwitchTable
+}
+
+// 加载到虚拟机时的副本, 虚拟机会改写字节码中的下标
+// 只复制加载时修改的部分, 其余与原exe共享
+func (exe *Executable) copyForLoad() *Executable {
+	newExe := *exe
+	newExe.CodeList = append([]byte{}, exe.CodeList...)
+	newExe.SwitchTableList = copySwitchTableList(exe.SwitchTableList)
+
+	newExe.FunctionList = make([]*Function, len(exe.FunctionList))
+	for i, f := range exe.FunctionList {
+		newFunc := *f
+		newFunc.CodeList = append([]byte{}, f.CodeList...)
+		newFunc.SwitchTableList = copySwitchTableList(f.SwitchTableList)
+		newExe.FunctionList[i] = &newFunc
+	}
+
+	return &newExe
 }
 
 func NewExecutable() *Executable {
@@ -48,6 +76,8 @@ func NewExecutable() *Executable {
 		FunctionList:        []*Function{},
 		CodeList:            []byte{},
 		LineNumberList:      []*LineNumber{},
+		TryList:             []*Try{},
+		SwitchTableList:     []*SwitchTable{},
 		TypeSpecifierList:   []*TypeSpecifier{},
 		ClassDefinitionList: []*Class{},
 	}
@@ -55,25 +85,9 @@ func NewExecutable() *Executable {
 	return exe
 }
 
+// ShowCode 输出反汇编结果
 func (exe *Executable) ShowCode() {
-	for i := 0; i < len(exe.CodeList); {
-		code := exe.CodeList[i]
-		info := OpcodeInfo[int(code)]
-		paramList := []byte(info.Parameter)
-
-		fmt.Println(info.Mnemonic)
-		for _, param := range paramList {
-			switch param {
-			case 'b':
-				i += 1
-			case 's', 'p':
-				i += 2
-			default:
-				panic("TODO")
-			}
-		}
-		i += 1
-	}
+	exe.Disassemble(os.Stdout)
 }
 
 func (exe *Executable) AddConstantPool(cp Constant) int {
@@ -88,6 +102,18 @@ type ExecutableEntry struct {
 	executable *Executable
 
 	static *Static
+
+	// 引用的其他包的全局变量, 与ImportedVariableList对应
+	importedList []*importedStatic
+
+	// 被导入的包的顶层代码只执行一次
+	initialized bool
+}
+
+// 其他包的全局变量所在的位置
+type importedStatic struct {
+	static *Static
+	index  int
 }
 
 //
@@ -188,6 +214,11 @@ type FunctionDerive struct {
 type ArrayDerive struct {
 }
 
+// map<K, V>, 所在的类型为V
+type MapDerive struct {
+	KeyType *TypeSpecifier
+}
+
 type TypeSpecifier struct {
 	BasicType  BasicType
 	DeriveList []TypeDerive
@@ -229,6 +260,12 @@ func NewVmVariable(name string, typeSpecifier *TypeSpecifier) *Variable {
 	}
 }
 
+// ImportedVariable 其他包的全局变量, 加载时按包名和变量名查找
+type ImportedVariable struct {
+	PackageName string
+	Name        string
+}
+
 // ==============================
 // 函数
 // ==============================
@@ -252,6 +289,10 @@ type Function struct {
 	CodeList []byte
 	// 行号对应表
 	LineNumberList []*LineNumber
+	// 异常处理表
+	TryList []*Try
+	// switch跳转表
+	SwitchTableList []*SwitchTable
 }
 
 type LocalVariable struct {
@@ -259,27 +300,6 @@ type LocalVariable struct {
 	TypeSpecifier *TypeSpecifier
 }
 
-func (f *Function) ShowCode() {
-	for i := 0; i < len(f.CodeList); {
-		code := f.CodeList[i]
-		info := OpcodeInfo[int(code)]
-		paramList := []byte(info.Parameter)
-
-		fmt.Println(info.Mnemonic)
-		for _, param := range paramList {
-			switch param {
-			case 'b':
-				i += 1
-			case 's', 'p':
-				i += 2
-			default:
-				panic("TODO")
-			}
-		}
-		i += 1
-	}
-}
-
 // ==============================
 // 行号对应表
 // ==============================
@@ -295,6 +315,109 @@ type LineNumber struct {
 	PcCount int
 }
 
+// ==============================
+// 异常处理表
+// ==============================
+
+// Try 对应一个try语句, 内层的try排在外层之前
+type Try struct {
+	// try块的字节码范围, [StartPc, EndPc)
+	StartPc int
+	EndPc   int
+
+	CatchList []*Catch
+
+	// finally块的字节码范围, 没有finally时为-1
+	FinallyStartPc int
+	FinallyEndPc   int
+}
+
+// Catch 对应一个catch子句
+type Catch struct {
+	// 捕获的异常类, exe中的类下标
+	ClassIndex int
+
+	// catch块的字节码范围
+	StartPc int
+	EndPc   int
+}
+
+// ==============================
+// switch跳转表
+// ==============================
+
+// SwitchTable 对应一个switch语句, 由tableswitch等指令的操作数指定
+type SwitchTable struct {
+	// tableswitch中AddressList[0]对应的case值
+	Low int
+	// lookupswitch的case值, 从小到大排列
+	KeyList []int
+	// lookupswitch_string的case值
+	StringKeyList []string
+
+	// 跳转地址, tableswitch中按case值排列, 空缺的值跳转到default
+	// 其余与KeyList或StringKeyList一一对应
+	AddressList []int
+
+	// 没有匹配的case时的跳转地址
+	DefaultPc int
+
+	// 加载时由StringKeyList生成
+	stringMap map[string]int
+}
+
+// 当前执行的代码中的跳转表, gFunc为nil时是顶层代码
+func getSwitchTable(exe *Executable, gFunc *GFunction, index int) *SwitchTable {
+	if gFunc == nil {
+		return exe.SwitchTableList[index]
+	}
+	return exe.FunctionList[gFunc.Index].SwitchTableList[index]
+}
+
+// 整数case的跳转地址
+func (table *SwitchTable) searchInt(value int, isTable bool) int {
+	if isTable {
+		index := value - table.Low
+		if index < 0 || index >= len(table.AddressList) {
+			return table.DefaultPc
+		}
+		return table.AddressList[index]
+	}
+
+	index := sort.SearchInts(table.KeyList, value)
+	if index < len(table.KeyList) && table.KeyList[index] == value {
+		return table.AddressList[index]
+	}
+	return table.DefaultPc
+}
+
+// 字符串case的跳转地址
+func (table *SwitchTable) searchString(value string) int {
+	if pc, ok := table.stringMap[value]; ok {
+		return pc
+	}
+	return table.DefaultPc
+}
+
+// 复制跳转表并生成字符串case的map
+func copySwitchTableList(list []*SwitchTable) []*SwitchTable {
+	newList := make([]*SwitchTable, len(list))
+	for i, table := range list {
+		newTable := *table
+		newList[i] = &newTable
+
+		if len(table.StringKeyList) == 0 {
+			continue
+		}
+		newTable.stringMap = make(map[string]int, len(table.StringKeyList))
+		for j, key := range table.StringKeyList {
+			newTable.stringMap[key] = table.AddressList[j]
+		}
+	}
+
+	return newList
+}
+
 // ==============================
 // Class
 // ==============================
@@ -303,10 +426,17 @@ type Class struct {
 	Name        string
 
 	IsImplemented bool
+	IsAbstract    bool
+	IsInterface   bool
 	SuperClass    *ClassIdentifier
+	// 直接实现的接口, 父类实现的接口不包括在内
+	InterfaceList []*ClassIdentifier
 
 	FieldList  []*Field
 	MethodList []*Method
+
+	// 静态字段, 存放在类中, 不属于对象
+	StaticFieldList []*StaticField
 }
 
 type ClassIdentifier struct {
@@ -318,6 +448,17 @@ type Field struct {
 	Name string
 	Typ  *TypeSpecifier
 }
+
+// 静态字段, 初始值不是常量时由顶层代码赋值
+type StaticField struct {
+	Name string
+	Typ  *TypeSpecifier
+	// 初始值在常量池中的下标, 没有初始值或初始值不是常量时为-1
+	ConstantIndex int
+}
+
 type Method struct {
 	Name string
+	// abstract方法没有对应的函数
+	IsAbstract bool
 }
@@ -22,6 +22,9 @@ type NativeFunction struct {
 
 	proc     NativeFunctionProc
 	argCount int
+
+	// 编译器使用的签名
+	signature *Function
 }
 
 func (f *NativeFunction) getName() string { return f.Name }
@@ -22,7 +22,8 @@ func (vm *VirtualMachine) checkGC() {
 //
 func mark(ref *ObjectRef) {
 	obj := ref.data
-	if obj == nil {
+	// 已经标记过的对象不再遍历, 避免循环引用
+	if obj == nil || obj.isMarked() {
 		return
 	}
 	obj.setMark(true)
@@ -40,6 +41,21 @@ func mark(ref *ObjectRef) {
 				mark(o.fieldList[i].(*ObjectRef))
 			}
 		}
+	case *ObjectClosure:
+		for _, subObj := range o.environment {
+			mark(subObj)
+		}
+	case *ObjectMap:
+		// 键只有基本类型和字符串, 不需要标记
+		for _, value := range o.valueMap {
+			if value.isPointer() {
+				mark(value.(*ObjectRef))
+			}
+		}
+	case *ObjectCell:
+		if o.value.isPointer() {
+			mark(o.value.(*ObjectRef))
+		}
 	}
 }
 
@@ -57,8 +73,21 @@ func (vm *VirtualMachine) markObjects() {
 	}
 
 	for _, ee := range vm.executableEntryList {
-		for i, variable := range ee.static.variableList {
-			if isReferenceType(ee.executable.GlobalVariableList[i].typeSpecifier) {
+		// 顶层块中被捕获的变量保存的是cell, 按值判断而不是按声明类型
+		for _, variable := range ee.static.variableList {
+			if ref, ok := variable.(*ObjectRef); ok {
+				mark(ref)
+			}
+		}
+	}
+
+	// 类的静态字段
+	for _, ec := range vm.classList {
+		if ec.static == nil {
+			continue
+		}
+		for i, variable := range ec.static.variableList {
+			if isReferenceType(ec.vmClass.StaticFieldList[i].Typ) {
 				mark(variable.(*ObjectRef))
 			}
 		}
@@ -85,6 +114,15 @@ func (vm *VirtualMachine) disposeObject(obj Object) {
 		o.doubleArray = nil
 	case *ObjectArrayObject:
 		o.objectArray = nil
+	case *ObjectClassObject:
+		o.fieldList = nil
+	case *ObjectClosure:
+		o.environment = nil
+	case *ObjectMap:
+		o.keyList = nil
+		o.valueMap = nil
+	case *ObjectCell:
+		o.value = nil
 	default:
 		panic("TODO")
 	}
@@ -171,6 +209,18 @@ func (vm *VirtualMachine) createArrayObject(size int) *ObjectRef {
 	return ref
 }
 
+//
+// map object
+//
+func (vm *VirtualMachine) createMap() *ObjectRef {
+	obj := &ObjectMap{valueMap: map[interface{}]Value{}}
+	vm.addObject(obj)
+
+	ref := &ObjectRef{data: obj}
+
+	return ref
+}
+
 //
 // class object
 //
@@ -182,7 +232,7 @@ func (vm *VirtualMachine) createClassObject(classIndex int) *ObjectRef {
 
 	obj.fieldList = []Value{}
 	for _, typ := range execClass.fieldTypeList {
-		obj.fieldList = append(obj.fieldList, initializeValue(typ))
+		obj.fieldList = append(obj.fieldList, vm.initializeValue(typ))
 	}
 
 	ref := &ObjectRef{
@@ -193,12 +243,39 @@ func (vm *VirtualMachine) createClassObject(classIndex int) *ObjectRef {
 	return ref
 }
 
+//
+// closure object
+//
+// 捕获的变量位于栈顶
+func (vm *VirtualMachine) createClosure(functionIndex int, count int) *ObjectRef {
+	obj := &ObjectClosure{functionIndex: functionIndex}
+	vm.addObject(obj)
+
+	obj.environment = make([]*ObjectRef, count)
+	for i := 0; i < count; i++ {
+		obj.environment[i] = vm.stack.getObject(-count + i)
+	}
+
+	ref := &ObjectRef{data: obj}
+
+	return ref
+}
+
+func (vm *VirtualMachine) createCell(value Value) *ObjectRef {
+	obj := &ObjectCell{value: value}
+	vm.addObject(obj)
+
+	ref := &ObjectRef{data: obj}
+
+	return ref
+}
+
 // utils
 
 // 判断是否是引用类型
 func isReferenceType(typ *TypeSpecifier) bool {
-	// 字符串, 类, 数组
-	if ((typ.BasicType == StringType || typ.BasicType == ClassType) && len(typ.DeriveList) == 0) || (typ.isArrayDerive()) {
+	// 字符串, 类, 数组, 函数
+	if typ.BasicType == StringType || typ.BasicType == ClassType || len(typ.DeriveList) > 0 {
 		return true
 	}
 	return false
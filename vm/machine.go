@@ -1,12 +1,15 @@
 package vm
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"math"
+	"os"
 )
 
 var functionNotFound = -1
 var callFromNative = -1
-var vmNullObjectRef = &ObjectRef{}
 
 //
 // 虚拟机
@@ -35,6 +38,17 @@ type VirtualMachine struct {
 
 	// 顶层exe
 	topLevel *ExecutableEntry
+	// 正在执行顶层代码的exe, 先执行被导入的包, 最后是topLevel
+	runningTopLevel *ExecutableEntry
+
+	// null引用
+	nullObjectRef *ObjectRef
+
+	// 原生函数使用的标准输入输出
+	stdin  *bufio.Reader
+	stdout io.Writer
+	// 脚本的命令行参数
+	args []string
 }
 
 func NewVirtualMachine() *VirtualMachine {
@@ -43,38 +57,54 @@ func NewVirtualMachine() *VirtualMachine {
 		heap:              NewHeap(),
 		functionList:      []ExecFunction{},
 		currentExecutable: nil,
+		nullObjectRef:     &ObjectRef{},
+		stdin:             bufio.NewReader(os.Stdin),
+		stdout:            os.Stdout,
+		args:              []string{},
 	}
-	setVirtualMachine(vm)
 
 	vm.AddNativeFunctions()
 
 	return vm
 }
 
-// 设置全局vm
-var StVirtualMachine *VirtualMachine
+//////////////////////////////
+// 虚拟机初始化操作
+//////////////////////////////
 
-func setVirtualMachine(vm *VirtualMachine) {
-	StVirtualMachine = vm
+// SetArgs 设置脚本中io.args()返回的命令行参数
+func (vm *VirtualMachine) SetArgs(args []string) {
+	vm.args = args
 }
-func getVirtualMachine() *VirtualMachine {
-	return StVirtualMachine
+
+// SetStdin 设置io.readLine读取的输入
+func (vm *VirtualMachine) SetStdin(in io.Reader) {
+	vm.stdin = bufio.NewReader(in)
 }
 
-//////////////////////////////
-// 虚拟机初始化操作
-//////////////////////////////
+// SetStdout 设置print输出的位置
+func (vm *VirtualMachine) SetStdout(out io.Writer) {
+	vm.stdout = out
+}
 
 // 添加executableList
 func (vm *VirtualMachine) SetExecutableList(exeList *ExecutableList) {
+	start := len(vm.executableEntryList)
+
 	for _, exe := range exeList.List {
 		vm.addExecutable(exe, exe == exeList.TopLevel)
 	}
 
+	// 所有的包都加载后才能查找其他包的全局变量
+	for _, ee := range vm.executableEntryList[start:] {
+		vm.linkImportedVariables(ee)
+	}
 }
 
 // 添加单个exe到vm
+// 加载时会改写字节码, 使用副本, 编译结果可以同时加载到多个虚拟机中
 func (vm *VirtualMachine) addExecutable(exe *Executable, isTopLevel bool) {
+	exe = exe.copyForLoad()
 
 	newEntry := &ExecutableEntry{executable: exe}
 
@@ -90,17 +120,90 @@ func (vm *VirtualMachine) addExecutable(exe *Executable, isTopLevel bool) {
 		vm.convertCode(exe, f.CodeList, f)
 	}
 
-	addStaticVariables(newEntry, exe)
+	vm.addStaticVariables(newEntry, exe, isTopLevel)
+
+	vm.addClassStaticFields(newEntry)
 
 	if isTopLevel {
 		vm.topLevel = newEntry
 	}
 }
 
-func addStaticVariables(entry *ExecutableEntry, exe *Executable) {
-	entry.static = NewStatic()
-	for _, value := range exe.GlobalVariableList {
-		entry.static.append(initializeValue(value.typeSpecifier))
+// 交互模式下所有的顶层exe共享全局变量, 只初始化新增的变量
+func (vm *VirtualMachine) addStaticVariables(entry *ExecutableEntry, exe *Executable, isTopLevel bool) {
+	if isTopLevel && vm.topLevel != nil {
+		entry.static = vm.topLevel.static
+	} else {
+		entry.static = NewStatic()
+	}
+
+	for i := len(entry.static.variableList); i < len(exe.GlobalVariableList); i++ {
+		entry.static.append(vm.initializeValue(exe.GlobalVariableList[i].typeSpecifier))
+	}
+}
+
+// 其他包的全局变量保存在定义它的包的static中
+func (vm *VirtualMachine) linkImportedVariables(ee *ExecutableEntry) {
+	ee.importedList = nil
+
+	for _, v := range ee.executable.ImportedVariableList {
+		imported := vm.searchPackageVariable(v.PackageName, v.Name)
+		if imported == nil {
+			vmError(VARIABLE_NOT_FOUND_ERR, v.PackageName, v.Name)
+		}
+		ee.importedList = append(ee.importedList, imported)
+	}
+}
+
+func (vm *VirtualMachine) searchPackageVariable(packageName string, name string) *importedStatic {
+	for _, ee := range vm.executableEntryList {
+		exe := ee.executable
+		if !exe.IsRequired || exe.PackageName != packageName {
+			continue
+		}
+		for i, v := range exe.GlobalVariableList {
+			if v.name == name {
+				return &importedStatic{static: ee.static, index: i}
+			}
+		}
+	}
+	return nil
+}
+
+// 初始化本exe实现的类的静态字段, 导入的类已经由实现它的exe初始化
+func (vm *VirtualMachine) addClassStaticFields(ee *ExecutableEntry) {
+	exe := ee.executable
+
+	for _, exeClass := range exe.ClassDefinitionList {
+		if !exeClass.IsImplemented {
+			continue
+		}
+
+		execClass := vm.classList[vm.searchClass(exeClass.PackageName, exeClass.Name)]
+		execClass.static = NewStatic()
+
+		for _, field := range exeClass.StaticFieldList {
+			execClass.static.append(vm.initializeStaticField(exe, field))
+		}
+	}
+}
+
+func (vm *VirtualMachine) initializeStaticField(exe *Executable, field *StaticField) Value {
+	if field.ConstantIndex < 0 {
+		return vm.initializeValue(field.Typ)
+	}
+
+	cp := &exe.ConstantPool
+
+	switch field.Typ.BasicType {
+	case BooleanType, IntType, CharType, ByteType, Int32Type, LongType:
+		return NewIntValue(cp.getInt(field.ConstantIndex))
+	case DoubleType:
+		return NewDoubleValue(cp.getDouble(field.ConstantIndex))
+	case StringType:
+		return vm.createStringObject(cp.getString(field.ConstantIndex))
+	default:
+		panic("TODO")
 	}
 }
 
@@ -108,31 +211,30 @@ func (vm *VirtualMachine) addFunctions(ee *ExecutableEntry) {
 	exe := ee.executable
 
 	for _, exeFunc := range exe.FunctionList {
+		// 未实现的函数由其他exe或原生函数提供
+		if !exeFunc.IsImplemented {
+			continue
+		}
 		for _, vmFunc := range vm.functionList {
-			// TODO 实现默认函数后去除
-			if !exeFunc.IsImplemented {
-				continue
-			}
 			if vmFunc.getName() == exeFunc.Name && vmFunc.getPackageName() == exeFunc.PackageName {
 				vmError(FUNCTION_MULTIPLE_DEFINE_ERR, vmFunc.getPackageName(), vmFunc.getName())
 			}
 		}
 	}
 
-	destIdx := len(vm.functionList)
-
 	for srcIdx, exeFunc := range exe.FunctionList {
-		vmFunc := &GFunction{}
-
-		vm.functionList = append(vm.functionList, vmFunc)
-
-		vmFunc.PackageName = exeFunc.PackageName
-		vmFunc.Name = exeFunc.Name
+		if !exeFunc.IsImplemented {
+			continue
+		}
 
-		vm.functionList[destIdx].(*GFunction).Executable = ee
-		vm.functionList[destIdx].(*GFunction).Index = srcIdx
+		vmFunc := &GFunction{
+			PackageName: exeFunc.PackageName,
+			Name:        exeFunc.Name,
+			Executable:  ee,
+			Index:       srcIdx,
+		}
 
-		destIdx++
+		vm.functionList = append(vm.functionList, vmFunc)
 	}
 }
 
@@ -221,8 +323,39 @@ func addMethods(vm *VirtualMachine, exe *Executable, src *Class, dest *ExecClass
 		execClass: dest,
 	}
 	addMethod(vm, exe, src, vTable)
-	// TODO
 	dest.classTable = vTable
+
+	dest.interfaceTable = nil
+	for pos := src; ; {
+		for _, ifaceID := range pos.InterfaceList {
+			iface := searchClassFromExecutable(exe, ifaceID.Name)
+			dest.interfaceTable = append(dest.interfaceTable, createITable(iface, vTable))
+		}
+		if pos.SuperClass == nil {
+			break
+		}
+		pos = searchClassFromExecutable(exe, pos.SuperClass.Name)
+	}
+}
+
+// 按方法名从虚表中取出接口方法
+func createITable(iface *Class, vTable *VTable) *ITable {
+	iTable := &ITable{
+		packageName: iface.PackageName,
+		name:        iface.Name,
+		table:       make([]*VTableItem, len(iface.MethodList)),
+	}
+
+	for i, method := range iface.MethodList {
+		iTable.table[i] = &VTableItem{name: method.Name, index: functionNotFound}
+		for _, item := range vTable.table {
+			if item.name == method.Name {
+				iTable.table[i] = item
+				break
+			}
+		}
+	}
+	return iTable
 }
 
 func addMethod(vm *VirtualMachine, exe *Executable, pos *Class, vTable *VTable) int {
@@ -242,13 +375,14 @@ func addMethod(vm *VirtualMachine, exe *Executable, pos *Class, vTable *VTable)
 		for j = 0; j < superMethodCount; j++ {
 			// 如果有与父类相同的方法名, 覆盖之
 			if method.Name == vTable.table[j].name {
-				vTable.table[j] = setVTable(vm, pos, method, false)
+				// 保留方法名, 孙类还需要按名称覆盖
+				vTable.table[j] = setVTable(vm, pos, method)
 				break
 			}
 		}
 		// 新增方法
 		if j == superMethodCount {
-			vTable.table = append(vTable.table, setVTable(vm, pos, method, true))
+			vTable.table = append(vTable.table, setVTable(vm, pos, method))
 			methodCount++
 		}
 	}
@@ -256,11 +390,13 @@ func addMethod(vm *VirtualMachine, exe *Executable, pos *Class, vTable *VTable)
 	return methodCount
 }
 
-func setVTable(vm *VirtualMachine, classP *Class, src *Method, setName bool) *VTableItem {
-	dest := &VTableItem{}
+func setVTable(vm *VirtualMachine, classP *Class, src *Method) *VTableItem {
+	dest := &VTableItem{name: src.Name}
 
-	if setName {
-		dest.name = src.Name
+	// 抽象方法没有实现, 由子类覆盖
+	if src.IsAbstract {
+		dest.index = functionNotFound
+		return dest
 	}
 
 	funcName := createMethodFunctionName(classP.Name, src.Name)
@@ -323,14 +459,91 @@ func searchClass(vm *VirtualMachine, packageName string, name string) int {
 //
 // 虚拟机执行入口
 //
-func (vm *VirtualMachine) Execute() {
-	vm.currentExecutable = vm.topLevel
+func (vm *VirtualMachine) Execute() (err error) {
+	// 未捕获的异常
+	defer func() {
+		if r := recover(); r != nil {
+			runtimeError, ok := r.(*RuntimeError)
+			if !ok {
+				panic(r)
+			}
+			err = runtimeError
+		}
+	}()
+
+	// 被导入的包按依赖顺序加载, 先执行它们的顶层代码初始化全局变量
+	for _, ee := range vm.executableEntryList {
+		if ee.initialized || !ee.executable.IsRequired {
+			continue
+		}
+		ee.initialized = true
+		vm.executeTopLevel(ee)
+	}
+
+	vm.executeTopLevel(vm.topLevel)
+
+	return nil
+}
+
+func (vm *VirtualMachine) executeTopLevel(ee *ExecutableEntry) {
+	vm.runningTopLevel = ee
+	vm.currentExecutable = ee
 	vm.currentFunction = nil
 	vm.pc = 0
 
-	vm.stack.expand(vm.topLevel.executable.CodeList)
+	vm.stack.expand(ee.executable.CodeList)
+
+	vm.execute(nil, ee.executable.CodeList)
+}
+
+// Eval 交互模式下加载一段输入编译出的exe并执行
+// hasResult为true时, 顶层代码执行结束后栈顶是表达式的值转换成的字符串, 作为结果返回
+func (vm *VirtualMachine) Eval(exeList *ExecutableList, hasResult bool) (result string, err error) {
+	err = vm.load(exeList)
+	if err != nil {
+		return "", err
+	}
+
+	// 丢弃上次执行出错时残留在栈上的值
+	vm.stack.stackPointer = 0
+
+	err = vm.Execute()
+	if err != nil || !hasResult {
+		return "", err
+	}
+
+	vm.stack.stackPointer--
+	obj := vm.stack.getObjectI(vm.stack.stackPointer).data
+	if obj == nil {
+		return "null", nil
+	}
+
+	return obj.(*ObjectString).stringValue, nil
+}
+
+// 加载exe, 出错时撤销已加载的部分
+func (vm *VirtualMachine) load(exeList *ExecutableList) (err error) {
+	functionCount := len(vm.functionList)
+	classCount := len(vm.classList)
+	entryCount := len(vm.executableEntryList)
+
+	defer func() {
+		if r := recover(); r != nil {
+			info, ok := r.(*vmErrorInfo)
+			if !ok {
+				panic(r)
+			}
+			vm.functionList = vm.functionList[:functionCount]
+			vm.classList = vm.classList[:classCount]
+			vm.executableEntryList = vm.executableEntryList[:entryCount]
+
+			err = &RuntimeError{Code: info.errorNumber, Message: info.Error()}
+		}
+	}()
+
+	vm.SetExecutableList(exeList)
 
-	vm.execute(nil, vm.topLevel.executable.CodeList)
+	return nil
 }
 
 func (vm *VirtualMachine) execute(gFunc *GFunction, codeList []byte) Value {
@@ -342,500 +555,936 @@ func (vm *VirtualMachine) execute(gFunc *GFunction, codeList []byte) Value {
 	ee := vm.currentExecutable
 	exe := vm.currentExecutable.executable
 
-	for pc := vm.pc; pc < len(codeList); {
-		static := ee.static
+	pc := vm.pc
 
-		switch codeList[pc] {
-		case VM_PUSH_INT_1BYTE:
-			stack.setInt(0, int(codeList[pc+1]))
-			vm.stack.stackPointer++
-			pc += 2
-		case VM_PUSH_INT_2BYTE:
-			index := get2ByteInt(codeList[pc+1:])
-			stack.setInt(0, index)
-			vm.stack.stackPointer++
-			pc += 3
-		case VM_PUSH_INT:
-			index := get2ByteInt(codeList[pc+1:])
-			stack.setInt(0, exe.ConstantPool.getInt(index))
-			vm.stack.stackPointer++
-			pc += 3
-		case VM_PUSH_DOUBLE_0:
-			stack.setDouble(0, 0.0)
-			vm.stack.stackPointer++
-			pc++
-		case VM_PUSH_DOUBLE_1:
-			stack.setDouble(0, 1.0)
-			vm.stack.stackPointer++
-			pc++
-		case VM_PUSH_DOUBLE:
-			index := get2ByteInt(codeList[pc+1:])
-			stack.setDouble(0, exe.ConstantPool.getDouble(index))
-			vm.stack.stackPointer++
-			pc += 3
-		case VM_PUSH_STRING:
-			index := get2ByteInt(codeList[pc+1:])
-			stack.setObject(0, vm.createStringObject(exe.ConstantPool.getString(index)))
-			vm.stack.stackPointer++
-			pc += 3
-		case VM_PUSH_NULL:
-			stack.setObject(0, vmNullObjectRef)
-			vm.stack.stackPointer++
-			pc++
-		case VM_PUSH_STACK_INT:
-			index := get2ByteInt(codeList[pc+1:])
-			stack.setInt(0, stack.getIntI(base+index))
-			vm.stack.stackPointer++
-			pc += 3
-		case VM_PUSH_STACK_DOUBLE:
-			index := get2ByteInt(codeList[pc+1:])
-			stack.setDouble(0, stack.getDoubleI(base+index))
-			vm.stack.stackPointer++
-			pc += 3
-		case VM_PUSH_STACK_OBJECT:
-			index := get2ByteInt(codeList[pc+1:])
-			stack.setObject(0, stack.getObjectI(base+index))
-			vm.stack.stackPointer++
-			pc += 3
-		case VM_POP_STACK_INT:
-			index := get2ByteInt(codeList[pc+1:])
-			stack.setIntI(base+index, stack.getInt(-1))
-			vm.stack.stackPointer--
-			pc += 3
-		case VM_POP_STACK_DOUBLE:
-			index := get2ByteInt(codeList[pc+1:])
-			stack.setDoubleI(base+index, stack.getDouble(-1))
-			vm.stack.stackPointer--
-			pc += 3
-		case VM_POP_STACK_OBJECT:
-			index := get2ByteInt(codeList[pc+1:])
-			stack.setObjectI(base+index, stack.getObject(-1))
-			vm.stack.stackPointer--
-			pc += 3
-		case VM_PUSH_STATIC_INT:
-			index := get2ByteInt(codeList[pc+1:])
-			stack.setInt(0, static.getInt(index))
-			vm.stack.stackPointer++
-			pc += 3
-		case VM_PUSH_STATIC_DOUBLE:
-			index := get2ByteInt(codeList[pc+1:])
-			stack.setDouble(0, static.getDouble(index))
-			vm.stack.stackPointer++
-			pc += 3
-		case VM_PUSH_STATIC_OBJECT:
-			index := get2ByteInt(codeList[pc+1:])
-			stack.setObject(0, static.getObject(index))
-			vm.stack.stackPointer++
-			pc += 3
-		case VM_POP_STATIC_INT:
-			index := get2ByteInt(codeList[pc+1:])
-			static.setInt(index, stack.getInt(-1))
-			vm.stack.stackPointer--
-			pc += 3
-		case VM_POP_STATIC_DOUBLE:
-			index := get2ByteInt(codeList[pc+1:])
-			static.setDouble(index, stack.getDouble(-1))
-			vm.stack.stackPointer--
-			pc += 3
-		case VM_POP_STATIC_OBJECT:
-			index := get2ByteInt(codeList[pc+1:])
-			static.setObject(index, stack.getObject(-1))
-			vm.stack.stackPointer--
-			pc += 3
-		case VM_PUSH_ARRAY_INT:
-			array := stack.getArrayInt(-2)
-			index := stack.getInt(-1)
+	// 执行字节码, 发生异常时返回异常对象
+	run := func() (exception *ObjectRef) {
+		defer func() {
+			if r := recover(); r != nil {
+				exception = vm.recoverException(r, gFunc, exe, pc, base)
+			}
+		}()
 
-			vm.restorePc(ee, gFunc, pc)
-			intValue := array.getInt(index)
+		for pc < len(codeList) {
+			static := ee.static
 
-			stack.setInt(-2, intValue)
-			vm.stack.stackPointer--
-			pc++
-		case VM_PUSH_ARRAY_DOUBLE:
-			array := stack.getArrayDouble(-2)
-			index := stack.getInt(-1)
+			switch codeList[pc] {
+			case VM_PUSH_INT_1BYTE:
+				stack.setInt(0, int(codeList[pc+1]))
+				vm.stack.stackPointer++
+				pc += 2
+			case VM_PUSH_INT_2BYTE:
+				index := get2ByteInt(codeList[pc+1:])
+				stack.setInt(0, index)
+				vm.stack.stackPointer++
+				pc += 3
+			case VM_PUSH_INT:
+				index := get2ByteInt(codeList[pc+1:])
+				stack.setInt(0, exe.ConstantPool.getInt(index))
+				vm.stack.stackPointer++
+				pc += 3
+			case VM_PUSH_DOUBLE_0:
+				stack.setDouble(0, 0.0)
+				vm.stack.stackPointer++
+				pc++
+			case VM_PUSH_DOUBLE_1:
+				stack.setDouble(0, 1.0)
+				vm.stack.stackPointer++
+				pc++
+			case VM_PUSH_DOUBLE:
+				index := get2ByteInt(codeList[pc+1:])
+				stack.setDouble(0, exe.ConstantPool.getDouble(index))
+				vm.stack.stackPointer++
+				pc += 3
+			case VM_PUSH_STRING:
+				index := get2ByteInt(codeList[pc+1:])
+				stack.setObject(0, vm.createStringObject(exe.ConstantPool.getString(index)))
+				vm.stack.stackPointer++
+				pc += 3
+			case VM_PUSH_NULL:
+				stack.setObject(0, vm.nullObjectRef)
+				vm.stack.stackPointer++
+				pc++
+			case VM_PUSH_STACK_INT:
+				index := get2ByteInt(codeList[pc+1:])
+				stack.setInt(0, stack.getIntI(base+index))
+				vm.stack.stackPointer++
+				pc += 3
+			case VM_PUSH_STACK_DOUBLE:
+				index := get2ByteInt(codeList[pc+1:])
+				stack.setDouble(0, stack.getDoubleI(base+index))
+				vm.stack.stackPointer++
+				pc += 3
+			case VM_PUSH_STACK_OBJECT:
+				index := get2ByteInt(codeList[pc+1:])
+				stack.setObject(0, stack.getObjectI(base+index))
+				vm.stack.stackPointer++
+				pc += 3
+			case VM_POP_STACK_INT:
+				index := get2ByteInt(codeList[pc+1:])
+				stack.setIntI(base+index, stack.getInt(-1))
+				vm.stack.stackPointer--
+				pc += 3
+			case VM_POP_STACK_DOUBLE:
+				index := get2ByteInt(codeList[pc+1:])
+				stack.setDoubleI(base+index, stack.getDouble(-1))
+				vm.stack.stackPointer--
+				pc += 3
+			case VM_POP_STACK_OBJECT:
+				index := get2ByteInt(codeList[pc+1:])
+				stack.setObjectI(base+index, stack.getObject(-1))
+				vm.stack.stackPointer--
+				pc += 3
+			case VM_PUSH_STATIC_INT:
+				index := get2ByteInt(codeList[pc+1:])
+				stack.setInt(0, static.getInt(index))
+				vm.stack.stackPointer++
+				pc += 3
+			case VM_PUSH_STATIC_DOUBLE:
+				index := get2ByteInt(codeList[pc+1:])
+				stack.setDouble(0, static.getDouble(index))
+				vm.stack.stackPointer++
+				pc += 3
+			case VM_PUSH_STATIC_OBJECT:
+				index := get2ByteInt(codeList[pc+1:])
+				stack.setObject(0, static.getObject(index))
+				vm.stack.stackPointer++
+				pc += 3
+			case VM_POP_STATIC_INT:
+				index := get2ByteInt(codeList[pc+1:])
+				static.setInt(index, stack.getInt(-1))
+				vm.stack.stackPointer--
+				pc += 3
+			case VM_POP_STATIC_DOUBLE:
+				index := get2ByteInt(codeList[pc+1:])
+				static.setDouble(index, stack.getDouble(-1))
+				vm.stack.stackPointer--
+				pc += 3
+			case VM_POP_STATIC_OBJECT:
+				index := get2ByteInt(codeList[pc+1:])
+				static.setObject(index, stack.getObject(-1))
+				vm.stack.stackPointer--
+				pc += 3
+			case VM_PUSH_ARRAY_INT:
+				array := stack.getArrayInt(-2)
+				index := stack.getInt(-1)
 
-			vm.restorePc(ee, gFunc, pc)
-			doubleValue := array.getDouble(index)
+				vm.restorePc(ee, gFunc, pc)
+				intValue := array.getInt(index)
 
-			stack.setDouble(-2, doubleValue)
-			vm.stack.stackPointer--
-			pc++
-		case VM_PUSH_ARRAY_OBJECT:
-			array := stack.getArrayObject(-2)
-			index := stack.getInt(-1)
+				stack.setInt(-2, intValue)
+				vm.stack.stackPointer--
+				pc++
+			case VM_PUSH_ARRAY_DOUBLE:
+				array := stack.getArrayDouble(-2)
+				index := stack.getInt(-1)
 
-			vm.restorePc(ee, gFunc, pc)
-			object := array.getObject(index)
+				vm.restorePc(ee, gFunc, pc)
+				doubleValue := array.getDouble(index)
 
-			stack.setObject(-2, object)
-			vm.stack.stackPointer--
-			pc++
-		case VM_POP_ARRAY_INT:
-			value := stack.getInt(-3)
-			array := stack.getArrayInt(-2)
-			index := stack.getInt(-1)
-
-			vm.restorePc(ee, gFunc, pc)
-			array.setInt(index, value)
-			vm.stack.stackPointer -= 3
-			pc++
-		case VM_POP_ARRAY_DOUBLE:
-			value := stack.getDouble(-3)
-			array := stack.getArrayDouble(-2)
-			index := stack.getInt(-1)
-
-			vm.restorePc(ee, gFunc, pc)
-			array.setDouble(index, value)
-			vm.stack.stackPointer -= 3
-			pc++
-		case VM_POP_ARRAY_OBJECT:
-			value := stack.getObject(-3)
-			array := stack.getArrayObject(-2)
-			index := stack.getInt(-1)
-
-			vm.restorePc(ee, gFunc, pc)
-			array.setObject(index, value)
-			vm.stack.stackPointer -= 3
-			pc++
-		case VM_PUSH_FIELD_INT:
-			// TODO 丑
-			obj := stack.getClassObject(-1)
-			index := get2ByteInt(codeList[pc+1:])
-
-			checkNullPointer(stack.getObject(-1))
-			stack.setInt(-1, obj.getInt(index))
-			pc += 3
-		case VM_PUSH_FIELD_DOUBLE:
-			obj := stack.getClassObject(-1)
-			index := get2ByteInt(codeList[pc+1:])
-
-			checkNullPointer(stack.getObject(-1))
-			stack.setDouble(-1, obj.getDouble(index))
-			pc += 3
-		case VM_PUSH_FIELD_OBJECT:
-			obj := stack.getClassObject(-1)
-			index := get2ByteInt(codeList[pc+1:])
-
-			checkNullPointer(stack.getObject(-1))
-			stack.setObject(-1, obj.getObject(index))
-			pc += 3
-		case VM_POP_FIELD_INT:
-			obj := stack.getClassObject(-1)
-			index := get2ByteInt(codeList[pc+1:])
-
-			checkNullPointer(stack.getObject(-1))
-			obj.writeInt(index, stack.getInt(-2))
-			stack.stackPointer -= 2
-			pc += 3
-		case VM_POP_FIELD_DOUBLE:
-			obj := stack.getClassObject(-1)
-			index := get2ByteInt(codeList[pc+1:])
-
-			checkNullPointer(stack.getObject(-1))
-			obj.writeDouble(index, stack.getDouble(-2))
-			stack.stackPointer -= 2
-			pc += 3
-		case VM_POP_FIELD_OBJECT:
-			obj := stack.getClassObject(-1)
-			index := get2ByteInt(codeList[pc+1:])
-
-			checkNullPointer(stack.getObject(-1))
-			obj.writeObject(index, stack.getObject(-2))
-			stack.stackPointer -= 2
-			pc += 3
-		case VM_ADD_INT:
-			stack.setInt(-2, stack.getInt(-2)+stack.getInt(-1))
-			vm.stack.stackPointer--
-			pc++
-		case VM_ADD_DOUBLE:
-			stack.setDouble(-2, stack.getDouble(-2)+stack.getDouble(-1))
-			vm.stack.stackPointer--
-			pc++
-		case VM_ADD_STRING:
-			stack.setObject(-2, vm.chainStringObject(stack.getObject(-2), stack.getObject(-1)))
-			vm.stack.stackPointer--
-			pc++
-		case VM_SUB_INT:
-			stack.setInt(-2, stack.getInt(-2)-stack.getInt(-1))
-			vm.stack.stackPointer--
-			pc++
-		case VM_SUB_DOUBLE:
-			stack.setDouble(-2, stack.getDouble(-2)-stack.getDouble(-1))
-			vm.stack.stackPointer--
-			pc++
-		case VM_MUL_INT:
-			stack.setInt(-2, stack.getInt(-2)*stack.getInt(-1))
-			vm.stack.stackPointer--
-			pc++
-		case VM_MUL_DOUBLE:
-			stack.setDouble(-2, stack.getDouble(-2)*stack.getDouble(-1))
-			vm.stack.stackPointer--
-			pc++
-		case VM_DIV_INT:
-			if stack.getInt(-1) == 0 {
-				vmError(DIVISION_BY_ZERO_ERR)
-			}
-			stack.setInt(-2, stack.getInt(-2)/stack.getInt(-1))
-			vm.stack.stackPointer--
-			pc++
-		case VM_DIV_DOUBLE:
-			stack.setDouble(-2, stack.getDouble(-2)/stack.getDouble(-1))
-			vm.stack.stackPointer--
-			pc++
-		case VM_MINUS_INT:
-			stack.setInt(-1, -stack.getInt(-1))
-			pc++
-		case VM_MINUS_DOUBLE:
-			stack.setDouble(-1, -stack.getDouble(-1))
-			pc++
-		case VM_CAST_INT_TO_DOUBLE:
-			stack.setDouble(-1, float64(stack.getInt(-1)))
-			pc++
-		case VM_CAST_DOUBLE_TO_INT:
-			stack.setInt(-1, int(stack.getDouble(-1)))
-			pc++
-		case VM_CAST_BOOLEAN_TO_STRING:
-			if stack.getInt(-1) != 0 {
-				stack.setObject(-1, vm.createStringObject("true"))
-			} else {
-				stack.setObject(-1, vm.createStringObject("false"))
-			}
-			pc++
-		case VM_CAST_INT_TO_STRING:
-			// TODO 啥意思
-			vm.restorePc(ee, gFunc, pc)
-			buf := fmt.Sprintf("%d", stack.getInt(-1))
-			stack.setObject(-1, vm.createStringObject(buf))
-			pc++
-		case VM_CAST_DOUBLE_TO_STRING:
-			// TODO 啥意思
-			vm.restorePc(ee, gFunc, pc)
-			buf := fmt.Sprintf("%f", stack.getDouble(-1))
-			stack.setObject(-1, vm.createStringObject(buf))
-			pc++
-		case VM_EQ_INT:
-			stack.setInt(-2, boolToInt(stack.getInt(-2) == stack.getInt(-1)))
-			vm.stack.stackPointer--
-			pc++
-		case VM_EQ_DOUBLE:
-			stack.setInt(-2, boolToInt(stack.getDouble(-2) == stack.getDouble(-1)))
-			vm.stack.stackPointer--
-			pc++
-		case VM_EQ_OBJECT:
-			stack.setInt(-2, boolToInt(stack.getObject(-2).data == stack.getObject(-1).data))
-			vm.stack.stackPointer--
-			pc++
-		case VM_EQ_STRING:
-			stack.setInt(-2, boolToInt(!(stack.getString(-2) == stack.getString(-1))))
-			vm.stack.stackPointer--
-			pc++
-		case VM_GT_INT:
-			stack.setInt(-2, boolToInt(stack.getInt(-2) > stack.getInt(-1)))
-			vm.stack.stackPointer--
-			pc++
-		case VM_GT_DOUBLE:
-			stack.setInt(-2, boolToInt(stack.getDouble(-2) > stack.getDouble(-1)))
-			vm.stack.stackPointer--
-			pc++
-		case VM_GT_STRING:
-			stack.setInt(-2, boolToInt(stack.getString(-2) > stack.getString(-1)))
-			vm.stack.stackPointer--
-			pc++
-		case VM_GE_INT:
-			stack.setInt(-2, boolToInt(stack.getInt(-2) >= stack.getInt(-1)))
-			vm.stack.stackPointer--
-			pc++
-		case VM_GE_DOUBLE:
-			stack.setInt(-2, boolToInt(stack.getDouble(-2) >= stack.getDouble(-1)))
-			vm.stack.stackPointer--
-			pc++
-		case VM_GE_STRING:
-			stack.setInt(-2, boolToInt(stack.getString(-2) >= stack.getString(-1)))
-			vm.stack.stackPointer--
-			pc++
-		case VM_LT_INT:
-			stack.setInt(-2, boolToInt(stack.getInt(-2) < stack.getInt(-1)))
-			vm.stack.stackPointer--
-			pc++
-		case VM_LT_DOUBLE:
-			stack.setInt(-2, boolToInt(stack.getDouble(-2) < stack.getDouble(-1)))
-			vm.stack.stackPointer--
-			pc++
-		case VM_LT_STRING:
-			stack.setInt(-2, boolToInt(stack.getString(-2) < stack.getString(-1)))
-			vm.stack.stackPointer--
-			pc++
-		case VM_LE_INT:
-			stack.setInt(-2, boolToInt(stack.getInt(-2) <= stack.getInt(-1)))
-			vm.stack.stackPointer--
-			pc++
-		case VM_LE_DOUBLE:
-			stack.setInt(-2, boolToInt(stack.getDouble(-2) <= stack.getDouble(-1)))
-			vm.stack.stackPointer--
-			pc++
-		case VM_LE_STRING:
-			stack.setInt(-2, boolToInt(stack.getString(-2) <= stack.getString(-1)))
-			vm.stack.stackPointer--
-			pc++
-		case VM_NE_INT:
-			stack.setInt(-2, boolToInt(stack.getInt(-2) != stack.getInt(-1)))
-			vm.stack.stackPointer--
-			pc++
-		case VM_NE_DOUBLE:
-			stack.setInt(-2, boolToInt(stack.getDouble(-2) != stack.getDouble(-1)))
-			vm.stack.stackPointer--
-			pc++
-		case VM_NE_OBJECT:
-			stack.setInt(-2, boolToInt(stack.getObject(-2).data != stack.getObject(-1).data))
-			vm.stack.stackPointer--
-			pc++
-		case VM_NE_STRING:
-			stack.setInt(-2, boolToInt(stack.getString(-2) != stack.getString(-1)))
-			vm.stack.stackPointer--
-			pc++
-		case VM_LOGICAL_AND:
-			stack.setInt(-2, boolToInt(intToBool(stack.getInt(-2)) && intToBool(stack.getInt(-1))))
-			vm.stack.stackPointer--
-			pc++
-		case VM_LOGICAL_OR:
-			stack.setInt(-2, boolToInt(intToBool(stack.getInt(-2)) || intToBool(stack.getInt(-1))))
-			vm.stack.stackPointer--
-			pc++
-		case VM_LOGICAL_NOT:
-			stack.setInt(-1, boolToInt(!intToBool(stack.getInt(-1))))
-			pc++
-		case VM_POP:
-			vm.stack.stackPointer--
-			pc++
-		case VM_DUPLICATE:
-			// TODO
-			stack.stack[vm.stack.stackPointer] = stack.stack[vm.stack.stackPointer-1]
-			stack.stack[vm.stack.stackPointer].setPointer(stack.stack[vm.stack.stackPointer-1].isPointer())
-			vm.stack.stackPointer++
-			pc++
-		case VM_DUPLICATE_OFFSET:
-			offset := get2ByteInt(codeList[pc+1:])
-			stack.stack[vm.stack.stackPointer] = stack.stack[vm.stack.stackPointer-1-offset]
-			vm.stack.stackPointer++
-			pc += 3
-		case VM_JUMP:
-			index := get2ByteInt(codeList[pc+1:])
-			pc = index
-		case VM_JUMP_IF_TRUE:
-			if intToBool(stack.getInt(-1)) {
+				stack.setDouble(-2, doubleValue)
+				vm.stack.stackPointer--
+				pc++
+			case VM_PUSH_ARRAY_OBJECT:
+				array := stack.getArrayObject(-2)
+				index := stack.getInt(-1)
+
+				vm.restorePc(ee, gFunc, pc)
+				object := array.getObject(index)
+
+				stack.setObject(-2, object)
+				vm.stack.stackPointer--
+				pc++
+			case VM_POP_ARRAY_INT:
+				value := stack.getInt(-3)
+				array := stack.getArrayInt(-2)
+				index := stack.getInt(-1)
+
+				vm.restorePc(ee, gFunc, pc)
+				array.setInt(index, value)
+				vm.stack.stackPointer -= 3
+				pc++
+			case VM_POP_ARRAY_DOUBLE:
+				value := stack.getDouble(-3)
+				array := stack.getArrayDouble(-2)
+				index := stack.getInt(-1)
+
+				vm.restorePc(ee, gFunc, pc)
+				array.setDouble(index, value)
+				vm.stack.stackPointer -= 3
+				pc++
+			case VM_POP_ARRAY_OBJECT:
+				value := stack.getObject(-3)
+				array := stack.getArrayObject(-2)
+				index := stack.getInt(-1)
+
+				vm.restorePc(ee, gFunc, pc)
+				array.setObject(index, value)
+				vm.stack.stackPointer -= 3
+				pc++
+			case VM_ARRAY_LENGTH:
+				vm.restorePc(ee, gFunc, pc)
+				array := stack.getNotNullObject(-1).data.(ObjectArray)
+				stack.setInt(-1, array.getArraySize())
+				pc++
+			case VM_ARRAY_SLICE:
+				vm.restorePc(ee, gFunc, pc)
+				array := stack.getNotNullObject(-3).data.(ObjectArray)
+				slice := vm.createArraySlice(array, stack.getInt(-2), stack.getInt(-1))
+				stack.setObject(-3, slice)
+				vm.stack.stackPointer -= 2
+				pc++
+			case VM_ARRAY_APPEND_INT:
+				vm.restorePc(ee, gFunc, pc)
+				stack.getArrayInt(-2).appendInt(stack.getInt(-1))
+				vm.stack.stackPointer--
+				pc++
+			case VM_ARRAY_APPEND_DOUBLE:
+				vm.restorePc(ee, gFunc, pc)
+				stack.getArrayDouble(-2).appendDouble(stack.getDouble(-1))
+				vm.stack.stackPointer--
+				pc++
+			case VM_ARRAY_APPEND_OBJECT:
+				vm.restorePc(ee, gFunc, pc)
+				stack.getArrayObject(-2).appendObject(stack.getObject(-1))
+				vm.stack.stackPointer--
+				pc++
+			case VM_ARRAY_INSERT_INT:
+				vm.restorePc(ee, gFunc, pc)
+				stack.getArrayInt(-3).insertInt(stack.getInt(-2), stack.getInt(-1))
+				// 与void函数相同, 留下一个值
+				stack.setInt(-3, 0)
+				vm.stack.stackPointer -= 2
+				pc++
+			case VM_ARRAY_INSERT_DOUBLE:
+				vm.restorePc(ee, gFunc, pc)
+				stack.getArrayDouble(-3).insertDouble(stack.getInt(-2), stack.getDouble(-1))
+				stack.setInt(-3, 0)
+				vm.stack.stackPointer -= 2
+				pc++
+			case VM_ARRAY_INSERT_OBJECT:
+				vm.restorePc(ee, gFunc, pc)
+				stack.getArrayObject(-3).insertObject(stack.getInt(-2), stack.getObject(-1))
+				stack.setInt(-3, 0)
+				vm.stack.stackPointer -= 2
+				pc++
+			case VM_ARRAY_REMOVE_INT:
+				vm.restorePc(ee, gFunc, pc)
+				intValue := stack.getArrayInt(-2).removeInt(stack.getInt(-1))
+				stack.setInt(-2, intValue)
+				vm.stack.stackPointer--
+				pc++
+			case VM_ARRAY_REMOVE_DOUBLE:
+				vm.restorePc(ee, gFunc, pc)
+				doubleValue := stack.getArrayDouble(-2).removeDouble(stack.getInt(-1))
+				stack.setDouble(-2, doubleValue)
+				vm.stack.stackPointer--
+				pc++
+			case VM_ARRAY_REMOVE_OBJECT:
+				vm.restorePc(ee, gFunc, pc)
+				object := stack.getArrayObject(-2).removeObject(stack.getInt(-1))
+				if object == nil {
+					object = vm.nullObjectRef
+				}
+				stack.setObject(-2, object)
+				vm.stack.stackPointer--
+				pc++
+			case VM_STRING_LENGTH:
+				vm.restorePc(ee, gFunc, pc)
+				stack.setInt(-1, stack.getStringObject(-1).length())
+				pc++
+			case VM_STRING_TO_CHARS:
+				vm.restorePc(ee, gFunc, pc)
+				array := vm.createStringChars(stack.getStringObject(-1))
+				stack.setObject(-1, array)
+				pc++
+			case VM_PUSH_STRING_CHAR:
+				str := stack.getStringObject(-2)
+				index := stack.getInt(-1)
+
+				vm.restorePc(ee, gFunc, pc)
+				stack.setInt(-2, int(str.getChar(index)))
+				vm.stack.stackPointer--
+				pc++
+			case VM_PUSH_MAP_INT:
+				vm.restorePc(ee, gFunc, pc)
+				value, ok := stack.getMap(-2).get(stack.getMapKey(-1))
+
+				// 不存在的键返回默认值
+				intValue := 0
+				if ok {
+					intValue = value.(*IntValue).intValue
+				}
+				stack.setInt(-2, intValue)
+				vm.stack.stackPointer--
+				pc++
+			case VM_PUSH_MAP_DOUBLE:
+				vm.restorePc(ee, gFunc, pc)
+				value, ok := stack.getMap(-2).get(stack.getMapKey(-1))
+
+				doubleValue := 0.0
+				if ok {
+					doubleValue = value.(*DoubleValue).doubleValue
+				}
+				stack.setDouble(-2, doubleValue)
+				vm.stack.stackPointer--
+				pc++
+			case VM_PUSH_MAP_OBJECT:
+				vm.restorePc(ee, gFunc, pc)
+				value, ok := stack.getMap(-2).get(stack.getMapKey(-1))
+
+				object := vm.nullObjectRef
+				if ok {
+					object = value.(*ObjectRef)
+				}
+				stack.setObject(-2, object)
+				vm.stack.stackPointer--
+				pc++
+			case VM_POP_MAP_INT:
+				vm.restorePc(ee, gFunc, pc)
+				stack.getMap(-2).set(stack.getMapKey(-1), NewIntValue(stack.getInt(-3)))
+				vm.stack.stackPointer -= 3
+				pc++
+			case VM_POP_MAP_DOUBLE:
+				vm.restorePc(ee, gFunc, pc)
+				stack.getMap(-2).set(stack.getMapKey(-1), NewDoubleValue(stack.getDouble(-3)))
+				vm.stack.stackPointer -= 3
+				pc++
+			case VM_POP_MAP_OBJECT:
+				vm.restorePc(ee, gFunc, pc)
+				stack.getMap(-2).set(stack.getMapKey(-1), stack.getObject(-3))
+				vm.stack.stackPointer -= 3
+				pc++
+			case VM_MAP_SIZE:
+				vm.restorePc(ee, gFunc, pc)
+				stack.setInt(-1, len(stack.getMap(-1).keyList))
+				pc++
+			case VM_MAP_CONTAINS:
+				vm.restorePc(ee, gFunc, pc)
+				_, ok := stack.getMap(-2).get(stack.getMapKey(-1))
+				stack.setInt(-2, boolToInt(ok))
+				vm.stack.stackPointer--
+				pc++
+			case VM_MAP_DELETE:
+				vm.restorePc(ee, gFunc, pc)
+				ok := stack.getMap(-2).delete(stack.getMapKey(-1))
+				stack.setInt(-2, boolToInt(ok))
+				vm.stack.stackPointer--
+				pc++
+			case VM_MAP_KEYS_INT, VM_MAP_KEYS_DOUBLE, VM_MAP_KEYS_OBJECT:
+				vm.restorePc(ee, gFunc, pc)
+				array := vm.createMapKeys(stack.getMap(-1), codeList[pc])
+				stack.setObject(-1, array)
+				pc++
+			case VM_MAP_VALUES_INT, VM_MAP_VALUES_DOUBLE, VM_MAP_VALUES_OBJECT:
+				vm.restorePc(ee, gFunc, pc)
+				array := vm.createMapValues(stack.getMap(-1), codeList[pc])
+				stack.setObject(-1, array)
+				pc++
+			case VM_PUSH_FIELD_INT:
+				// TODO 丑
+				obj := stack.getClassObject(-1)
 				index := get2ByteInt(codeList[pc+1:])
-				pc = index
-			} else {
+
+				checkNullPointer(stack.getObject(-1))
+				stack.setInt(-1, obj.getInt(index))
 				pc += 3
-			}
-			vm.stack.stackPointer--
-		case VM_JUMP_IF_FALSE:
-			if !intToBool(stack.getInt(-1)) {
+			case VM_PUSH_FIELD_DOUBLE:
+				obj := stack.getClassObject(-1)
 				index := get2ByteInt(codeList[pc+1:])
-				pc = index
-			} else {
+
+				checkNullPointer(stack.getObject(-1))
+				stack.setDouble(-1, obj.getDouble(index))
 				pc += 3
-			}
-			vm.stack.stackPointer--
-		case VM_PUSH_FUNCTION:
-			value := get2ByteInt(codeList[pc+1:])
-			stack.setInt(0, value)
-			vm.stack.stackPointer++
-			pc += 3
-		case VM_PUSH_METHOD:
-			obj := stack.getObject(-1)
-			index := get2ByteInt(codeList[pc+1:])
+			case VM_PUSH_FIELD_OBJECT:
+				obj := stack.getClassObject(-1)
+				index := get2ByteInt(codeList[pc+1:])
 
-			checkNullPointer(obj)
+				checkNullPointer(stack.getObject(-1))
+				stack.setObject(-1, obj.getObject(index))
+				pc += 3
+			case VM_POP_FIELD_INT:
+				obj := stack.getClassObject(-1)
+				index := get2ByteInt(codeList[pc+1:])
 
-			stack.setInt(0, obj.vTable.table[index].index)
-			vm.stack.stackPointer++
-			pc += 3
-		case VM_INVOKE:
-			funcIdx := stack.getInt(-1)
-			switch f := vm.functionList[funcIdx].(type) {
-			case *NativeFunction:
+				checkNullPointer(stack.getObject(-1))
+				obj.writeInt(index, stack.getInt(-2))
+				stack.stackPointer -= 2
+				pc += 3
+			case VM_POP_FIELD_DOUBLE:
+				obj := stack.getClassObject(-1)
+				index := get2ByteInt(codeList[pc+1:])
+
+				checkNullPointer(stack.getObject(-1))
+				obj.writeDouble(index, stack.getDouble(-2))
+				stack.stackPointer -= 2
+				pc += 3
+			case VM_POP_FIELD_OBJECT:
+				obj := stack.getClassObject(-1)
+				index := get2ByteInt(codeList[pc+1:])
+
+				checkNullPointer(stack.getObject(-1))
+				obj.writeObject(index, stack.getObject(-2))
+				stack.stackPointer -= 2
+				pc += 3
+			case VM_PUSH_PACKAGE_STATIC_INT:
+				imported := ee.importedList[get2ByteInt(codeList[pc+1:])]
+				stack.setInt(0, imported.static.getInt(imported.index))
+				vm.stack.stackPointer++
+				pc += 3
+			case VM_PUSH_PACKAGE_STATIC_DOUBLE:
+				imported := ee.importedList[get2ByteInt(codeList[pc+1:])]
+				stack.setDouble(0, imported.static.getDouble(imported.index))
+				vm.stack.stackPointer++
+				pc += 3
+			case VM_PUSH_PACKAGE_STATIC_OBJECT:
+				imported := ee.importedList[get2ByteInt(codeList[pc+1:])]
+				stack.setObject(0, imported.static.getObject(imported.index))
+				vm.stack.stackPointer++
+				pc += 3
+			case VM_POP_PACKAGE_STATIC_INT:
+				imported := ee.importedList[get2ByteInt(codeList[pc+1:])]
+				imported.static.setInt(imported.index, stack.getInt(-1))
+				vm.stack.stackPointer--
+				pc += 3
+			case VM_POP_PACKAGE_STATIC_DOUBLE:
+				imported := ee.importedList[get2ByteInt(codeList[pc+1:])]
+				imported.static.setDouble(imported.index, stack.getDouble(-1))
+				vm.stack.stackPointer--
+				pc += 3
+			case VM_POP_PACKAGE_STATIC_OBJECT:
+				imported := ee.importedList[get2ByteInt(codeList[pc+1:])]
+				imported.static.setObject(imported.index, stack.getObject(-1))
+				vm.stack.stackPointer--
+				pc += 3
+			case VM_PUSH_CLASS_STATIC_INT:
+				// 参数为类下标和静态字段下标
+				classStatic := vm.classList[get2ByteInt(codeList[pc+1:])].static
+				index := get2ByteInt(codeList[pc+3:])
+				stack.setInt(0, classStatic.getInt(index))
+				vm.stack.stackPointer++
+				pc += 5
+			case VM_PUSH_CLASS_STATIC_DOUBLE:
+				classStatic := vm.classList[get2ByteInt(codeList[pc+1:])].static
+				index := get2ByteInt(codeList[pc+3:])
+				stack.setDouble(0, classStatic.getDouble(index))
+				vm.stack.stackPointer++
+				pc += 5
+			case VM_PUSH_CLASS_STATIC_OBJECT:
+				classStatic := vm.classList[get2ByteInt(codeList[pc+1:])].static
+				index := get2ByteInt(codeList[pc+3:])
+				stack.setObject(0, classStatic.getObject(index))
+				vm.stack.stackPointer++
+				pc += 5
+			case VM_POP_CLASS_STATIC_INT:
+				classStatic := vm.classList[get2ByteInt(codeList[pc+1:])].static
+				index := get2ByteInt(codeList[pc+3:])
+				classStatic.setInt(index, stack.getInt(-1))
+				vm.stack.stackPointer--
+				pc += 5
+			case VM_POP_CLASS_STATIC_DOUBLE:
+				classStatic := vm.classList[get2ByteInt(codeList[pc+1:])].static
+				index := get2ByteInt(codeList[pc+3:])
+				classStatic.setDouble(index, stack.getDouble(-1))
+				vm.stack.stackPointer--
+				pc += 5
+			case VM_POP_CLASS_STATIC_OBJECT:
+				classStatic := vm.classList[get2ByteInt(codeList[pc+1:])].static
+				index := get2ByteInt(codeList[pc+3:])
+				classStatic.setObject(index, stack.getObject(-1))
+				vm.stack.stackPointer--
+				pc += 5
+			case VM_NEW_CELL_INT, VM_NEW_CELL_DOUBLE, VM_NEW_CELL_OBJECT:
+				// 将栈顶的值移到堆上
+				vm.restorePc(ee, gFunc, pc)
+				cell := vm.createCell(stack.stack[stack.stackPointer-1])
+				stack.setObject(-1, cell)
+				pc++
+			case VM_PUSH_CELL_INT:
+				cell := stack.getObject(-1).data.(*ObjectCell)
+				stack.setInt(-1, cell.value.(*IntValue).intValue)
+				pc++
+			case VM_PUSH_CELL_DOUBLE:
+				cell := stack.getObject(-1).data.(*ObjectCell)
+				stack.setDouble(-1, cell.value.(*DoubleValue).doubleValue)
+				pc++
+			case VM_PUSH_CELL_OBJECT:
+				cell := stack.getObject(-1).data.(*ObjectCell)
+				stack.setObject(-1, cell.value.(*ObjectRef))
+				pc++
+			case VM_POP_CELL_INT:
+				cell := stack.getObject(-1).data.(*ObjectCell)
+				cell.value = NewIntValue(stack.getInt(-2))
+				stack.stackPointer -= 2
+				pc++
+			case VM_POP_CELL_DOUBLE:
+				cell := stack.getObject(-1).data.(*ObjectCell)
+				cell.value = NewDoubleValue(stack.getDouble(-2))
+				stack.stackPointer -= 2
+				pc++
+			case VM_POP_CELL_OBJECT:
+				cell := stack.getObject(-1).data.(*ObjectCell)
+				cell.value = stack.getObject(-2)
+				stack.stackPointer -= 2
+				pc++
+			case VM_PUSH_ENV:
+				// 栈顶为当前lambda的closure
+				closure := stack.getObject(-1).data.(*ObjectClosure)
+				stack.setObject(-1, closure.environment[get2ByteInt(codeList[pc+1:])])
+				pc += 3
+			case VM_ADD_INT:
+				stack.setInt(-2, stack.getInt(-2)+stack.getInt(-1))
+				vm.stack.stackPointer--
+				pc++
+			case VM_ADD_DOUBLE:
+				stack.setDouble(-2, stack.getDouble(-2)+stack.getDouble(-1))
+				vm.stack.stackPointer--
+				pc++
+			case VM_ADD_STRING:
+				stack.setObject(-2, vm.chainStringObject(stack.getObject(-2), stack.getObject(-1)))
+				vm.stack.stackPointer--
+				pc++
+			case VM_SUB_INT:
+				stack.setInt(-2, stack.getInt(-2)-stack.getInt(-1))
+				vm.stack.stackPointer--
+				pc++
+			case VM_SUB_DOUBLE:
+				stack.setDouble(-2, stack.getDouble(-2)-stack.getDouble(-1))
+				vm.stack.stackPointer--
+				pc++
+			case VM_MUL_INT:
+				stack.setInt(-2, stack.getInt(-2)*stack.getInt(-1))
+				vm.stack.stackPointer--
+				pc++
+			case VM_MUL_DOUBLE:
+				stack.setDouble(-2, stack.getDouble(-2)*stack.getDouble(-1))
+				vm.stack.stackPointer--
+				pc++
+			case VM_DIV_INT:
+				if stack.getInt(-1) == 0 {
+					vmError(DIVISION_BY_ZERO_ERR)
+				}
+				stack.setInt(-2, stack.getInt(-2)/stack.getInt(-1))
+				vm.stack.stackPointer--
+				pc++
+			case VM_DIV_DOUBLE:
+				stack.setDouble(-2, stack.getDouble(-2)/stack.getDouble(-1))
+				vm.stack.stackPointer--
+				pc++
+			case VM_MOD_INT:
+				if stack.getInt(-1) == 0 {
+					vmError(DIVISION_BY_ZERO_ERR)
+				}
+				stack.setInt(-2, stack.getInt(-2)%stack.getInt(-1))
+				vm.stack.stackPointer--
+				pc++
+			case VM_MOD_DOUBLE:
+				stack.setDouble(-2, math.Mod(stack.getDouble(-2), stack.getDouble(-1)))
+				vm.stack.stackPointer--
+				pc++
+			case VM_MINUS_INT:
+				stack.setInt(-1, -stack.getInt(-1))
+				pc++
+			case VM_MINUS_DOUBLE:
+				stack.setDouble(-1, -stack.getDouble(-1))
+				pc++
+			case VM_INCREMENT:
+				stack.setInt(-1, stack.getInt(-1)+1)
+				pc++
+			case VM_DECREMENT:
+				stack.setInt(-1, stack.getInt(-1)-1)
+				pc++
+			case VM_BIT_AND:
+				stack.setInt(-2, stack.getInt(-2)&stack.getInt(-1))
+				vm.stack.stackPointer--
+				pc++
+			case VM_BIT_OR:
+				stack.setInt(-2, stack.getInt(-2)|stack.getInt(-1))
+				vm.stack.stackPointer--
+				pc++
+			case VM_BIT_XOR:
+				stack.setInt(-2, stack.getInt(-2)^stack.getInt(-1))
+				vm.stack.stackPointer--
+				pc++
+			case VM_BIT_NOT:
+				stack.setInt(-1, ^stack.getInt(-1))
+				pc++
+			case VM_LEFT_SHIFT:
+				stack.setInt(-2, stack.getInt(-2)<<shiftCount(stack.getInt(-1)))
+				vm.stack.stackPointer--
+				pc++
+			case VM_RIGHT_SHIFT:
+				stack.setInt(-2, stack.getInt(-2)>>shiftCount(stack.getInt(-1)))
+				vm.stack.stackPointer--
+				pc++
+			case VM_CAST_INT_TO_DOUBLE:
+				stack.setDouble(-1, float64(stack.getInt(-1)))
+				pc++
+			case VM_CAST_DOUBLE_TO_INT:
+				stack.setInt(-1, int(stack.getDouble(-1)))
+				pc++
+			case VM_CAST_BOOLEAN_TO_STRING:
+				if stack.getInt(-1) != 0 {
+					stack.setObject(-1, vm.createStringObject("true"))
+				} else {
+					stack.setObject(-1, vm.createStringObject("false"))
+				}
+				pc++
+			case VM_CAST_INT_TO_STRING:
+				// TODO 啥意思
+				vm.restorePc(ee, gFunc, pc)
+				buf := fmt.Sprintf("%d", stack.getInt(-1))
+				stack.setObject(-1, vm.createStringObject(buf))
+				pc++
+			case VM_CAST_DOUBLE_TO_STRING:
+				// TODO 啥意思
+				vm.restorePc(ee, gFunc, pc)
+				buf := fmt.Sprintf("%f", stack.getDouble(-1))
+				stack.setObject(-1, vm.createStringObject(buf))
+				pc++
+			case VM_CAST_CHAR_TO_STRING:
 				vm.restorePc(ee, gFunc, pc)
-				vm.invokeNativeFunction(f, &vm.stack.stackPointer)
+				stack.setObject(-1, vm.createStringObject(string(rune(stack.getInt(-1)))))
+				pc++
+			case VM_CAST_INT_TO_BYTE:
+				// 截断为低8位, 溢出时回绕
+				stack.setInt(-1, int(uint8(stack.getInt(-1))))
+				pc++
+			case VM_CAST_INT_TO_INT32:
+				stack.setInt(-1, int(int32(stack.getInt(-1))))
+				pc++
+			case VM_EQ_INT:
+				stack.setInt(-2, boolToInt(stack.getInt(-2) == stack.getInt(-1)))
+				vm.stack.stackPointer--
+				pc++
+			case VM_EQ_DOUBLE:
+				stack.setInt(-2, boolToInt(stack.getDouble(-2) == stack.getDouble(-1)))
+				vm.stack.stackPointer--
+				pc++
+			case VM_EQ_OBJECT:
+				stack.setInt(-2, boolToInt(stack.getObject(-2).data == stack.getObject(-1).data))
+				vm.stack.stackPointer--
+				pc++
+			case VM_EQ_STRING:
+				stack.setInt(-2, boolToInt(stack.getString(-2) == stack.getString(-1)))
+				vm.stack.stackPointer--
+				pc++
+			case VM_GT_INT:
+				stack.setInt(-2, boolToInt(stack.getInt(-2) > stack.getInt(-1)))
+				vm.stack.stackPointer--
+				pc++
+			case VM_GT_DOUBLE:
+				stack.setInt(-2, boolToInt(stack.getDouble(-2) > stack.getDouble(-1)))
+				vm.stack.stackPointer--
+				pc++
+			case VM_GT_STRING:
+				stack.setInt(-2, boolToInt(stack.getString(-2) > stack.getString(-1)))
+				vm.stack.stackPointer--
+				pc++
+			case VM_GE_INT:
+				stack.setInt(-2, boolToInt(stack.getInt(-2) >= stack.getInt(-1)))
+				vm.stack.stackPointer--
+				pc++
+			case VM_GE_DOUBLE:
+				stack.setInt(-2, boolToInt(stack.getDouble(-2) >= stack.getDouble(-1)))
+				vm.stack.stackPointer--
+				pc++
+			case VM_GE_STRING:
+				stack.setInt(-2, boolToInt(stack.getString(-2) >= stack.getString(-1)))
+				vm.stack.stackPointer--
+				pc++
+			case VM_LT_INT:
+				stack.setInt(-2, boolToInt(stack.getInt(-2) < stack.getInt(-1)))
+				vm.stack.stackPointer--
+				pc++
+			case VM_LT_DOUBLE:
+				stack.setInt(-2, boolToInt(stack.getDouble(-2) < stack.getDouble(-1)))
+				vm.stack.stackPointer--
+				pc++
+			case VM_LT_STRING:
+				stack.setInt(-2, boolToInt(stack.getString(-2) < stack.getString(-1)))
+				vm.stack.stackPointer--
+				pc++
+			case VM_LE_INT:
+				stack.setInt(-2, boolToInt(stack.getInt(-2) <= stack.getInt(-1)))
+				vm.stack.stackPointer--
+				pc++
+			case VM_LE_DOUBLE:
+				stack.setInt(-2, boolToInt(stack.getDouble(-2) <= stack.getDouble(-1)))
+				vm.stack.stackPointer--
+				pc++
+			case VM_LE_STRING:
+				stack.setInt(-2, boolToInt(stack.getString(-2) <= stack.getString(-1)))
+				vm.stack.stackPointer--
+				pc++
+			case VM_NE_INT:
+				stack.setInt(-2, boolToInt(stack.getInt(-2) != stack.getInt(-1)))
+				vm.stack.stackPointer--
+				pc++
+			case VM_NE_DOUBLE:
+				stack.setInt(-2, boolToInt(stack.getDouble(-2) != stack.getDouble(-1)))
+				vm.stack.stackPointer--
+				pc++
+			case VM_NE_OBJECT:
+				stack.setInt(-2, boolToInt(stack.getObject(-2).data != stack.getObject(-1).data))
+				vm.stack.stackPointer--
+				pc++
+			case VM_NE_STRING:
+				stack.setInt(-2, boolToInt(stack.getString(-2) != stack.getString(-1)))
+				vm.stack.stackPointer--
+				pc++
+			case VM_LOGICAL_AND:
+				stack.setInt(-2, boolToInt(intToBool(stack.getInt(-2)) && intToBool(stack.getInt(-1))))
+				vm.stack.stackPointer--
+				pc++
+			case VM_LOGICAL_OR:
+				stack.setInt(-2, boolToInt(intToBool(stack.getInt(-2)) || intToBool(stack.getInt(-1))))
+				vm.stack.stackPointer--
+				pc++
+			case VM_LOGICAL_NOT:
+				stack.setInt(-1, boolToInt(!intToBool(stack.getInt(-1))))
 				pc++
-			case *GFunction:
-				vm.invokeGFunction(&gFunc, f, &codeList, &pc, &vm.stack.stackPointer, &base, &ee, &exe)
+			case VM_POP:
+				vm.stack.stackPointer--
+				pc++
+			case VM_DUPLICATE:
+				// TODO
+				stack.stack[vm.stack.stackPointer] = stack.stack[vm.stack.stackPointer-1]
+				stack.stack[vm.stack.stackPointer].setPointer(stack.stack[vm.stack.stackPointer-1].isPointer())
+				vm.stack.stackPointer++
+				pc++
+			case VM_DUPLICATE_OFFSET:
+				offset := get2ByteInt(codeList[pc+1:])
+				stack.stack[vm.stack.stackPointer] = stack.stack[vm.stack.stackPointer-1-offset]
+				vm.stack.stackPointer++
+				pc += 3
+			case VM_ROTATE:
+				count := get2ByteInt(codeList[pc+1:])
+				sp := vm.stack.stackPointer
+				top := stack.stack[sp-1]
+				copy(stack.stack[sp-count:sp], stack.stack[sp-1-count:sp-1])
+				stack.stack[sp-1-count] = top
+				pc += 3
+			case VM_JUMP:
+				index := get2ByteInt(codeList[pc+1:])
+				pc = index
+			case VM_JUMP_IF_TRUE:
+				if intToBool(stack.getInt(-1)) {
+					index := get2ByteInt(codeList[pc+1:])
+					pc = index
+				} else {
+					pc += 3
+				}
+				vm.stack.stackPointer--
+			case VM_JUMP_IF_FALSE:
+				if !intToBool(stack.getInt(-1)) {
+					index := get2ByteInt(codeList[pc+1:])
+					pc = index
+				} else {
+					pc += 3
+				}
+				vm.stack.stackPointer--
+			case VM_TABLESWITCH, VM_LOOKUPSWITCH:
+				table := getSwitchTable(exe, gFunc, get2ByteInt(codeList[pc+1:]))
+				pc = table.searchInt(stack.getInt(-1), codeList[pc] == VM_TABLESWITCH)
+				vm.stack.stackPointer--
+			case VM_LOOKUPSWITCH_STRING:
+				table := getSwitchTable(exe, gFunc, get2ByteInt(codeList[pc+1:]))
+				str := stack.getObject(-1)
+				if str.data == nil {
+					pc = table.DefaultPc
+				} else {
+					pc = table.searchString(str.data.(*ObjectString).stringValue)
+				}
+				vm.stack.stackPointer--
+			case VM_PUSH_FUNCTION:
+				value := get2ByteInt(codeList[pc+1:])
+				stack.setInt(0, value)
+				vm.stack.stackPointer++
+				pc += 3
+			case VM_PUSH_METHOD:
+				obj := stack.getObject(-1)
+				index := get2ByteInt(codeList[pc+1:])
+
+				checkNullPointer(obj)
+
+				stack.setInt(0, obj.vTable.table[index].index)
+				vm.stack.stackPointer++
+				pc += 3
+			case VM_PUSH_INTERFACE_METHOD:
+				// 参数为接口下标和接口内的方法下标, 从对象的接口表中获取方法
+				iface := exe.ClassDefinitionList[get2ByteInt(codeList[pc+1:])]
+				index := get2ByteInt(codeList[pc+3:])
+				obj := stack.getObject(-1)
+
+				checkNullPointer(obj)
+
+				iTable := searchInterfaceTable(obj.vTable.execClass, iface)
+				if iTable == nil {
+					vmError(CLASS_CAST_ERR, obj.vTable.execClass.name, iface.Name)
+				}
+
+				stack.setInt(0, iTable.table[index].index)
+				vm.stack.stackPointer++
+				pc += 5
+			case VM_SUPER:
+				// 参数为父类下标和方法下标, 从父类的虚表中获取方法
+				class := vm.classList[get2ByteInt(codeList[pc+1:])]
+				index := get2ByteInt(codeList[pc+3:])
+
+				stack.setInt(0, class.classTable.table[index].index)
+				vm.stack.stackPointer++
+				pc += 5
+			case VM_INVOKE_CLOSURE:
+				// lambda以closure作为this, 其余函数不需要closure
+				closure := stack.getObject(-1)
+				checkNullPointer(closure)
+
+				funcIdx := closure.data.(*ObjectClosure).functionIndex
+				if vm.isLambda(funcIdx) {
+					stack.setInt(0, funcIdx)
+					vm.stack.stackPointer++
+				} else {
+					stack.setInt(-1, funcIdx)
+				}
+				fallthrough
+			case VM_INVOKE:
+				funcIdx := stack.getInt(-1)
+				switch f := vm.functionList[funcIdx].(type) {
+				case *NativeFunction:
+					vm.restorePc(ee, gFunc, pc)
+					vm.invokeNativeFunction(f, &vm.stack.stackPointer)
+					pc++
+				case *GFunction:
+					vm.invokeGFunction(&gFunc, f, &codeList, &pc, &vm.stack.stackPointer, &base, &ee, &exe)
+				default:
+					panic("TODO")
+				}
+			case VM_RETURN:
+				if vm.returnFunction(&gFunc, &codeList, &pc, &base, &ee, &exe) {
+					ret = stack.stack[stack.stackPointer-1]
+					return nil
+				}
+			case VM_NEW:
+				classIndex := get2ByteInt(codeList[pc+1:])
+				stack.setObject(0, vm.createClassObject(classIndex))
+				vm.stack.stackPointer++
+				pc += 3
+			case VM_NEW_ARRAY:
+				dim := int(codeList[pc+1])
+				typ := exe.TypeSpecifierList[get2ByteInt(codeList[pc+2:])]
+
+				vm.restorePc(ee, gFunc, pc)
+				array := vm.createArray(dim, typ)
+				vm.stack.stackPointer -= dim
+
+				stack.setObject(0, array)
+				vm.stack.stackPointer++
+				pc += 4
+			case VM_NEW_ARRAY_LITERAL_INT:
+				size := get2ByteInt(codeList[pc+1:])
+
+				vm.restorePc(ee, gFunc, pc)
+				array := vm.createArrayLiteralInt(size)
+				vm.stack.stackPointer -= size
+				stack.setObject(0, array)
+				vm.stack.stackPointer++
+				pc += 3
+			case VM_NEW_ARRAY_LITERAL_DOUBLE:
+				size := get2ByteInt(codeList[pc+1:])
+
+				vm.restorePc(ee, gFunc, pc)
+				array := vm.createArrayLiteralDouble(size)
+				vm.stack.stackPointer -= size
+				stack.setObject(0, array)
+				vm.stack.stackPointer++
+				pc += 3
+			case VM_NEW_ARRAY_LITERAL_OBJECT:
+				size := get2ByteInt(codeList[pc+1:])
+
+				vm.restorePc(ee, gFunc, pc)
+				array := vm.createArrayLiteralObject(size)
+				vm.stack.stackPointer -= size
+				stack.setObject(0, array)
+				vm.stack.stackPointer++
+				pc += 3
+			case VM_NEW_MAP_LITERAL:
+				size := get2ByteInt(codeList[pc+1:])
+
+				vm.restorePc(ee, gFunc, pc)
+				m := vm.createMapLiteral(size)
+				vm.stack.stackPointer -= size * 2
+				stack.setObject(0, m)
+				vm.stack.stackPointer++
+				pc += 3
+			case VM_NEW_CLOSURE:
+				// 参数为函数下标和捕获的变量数量
+				funcIdx := get2ByteInt(codeList[pc+1:])
+				count := get2ByteInt(codeList[pc+3:])
+
+				vm.restorePc(ee, gFunc, pc)
+				closure := vm.createClosure(funcIdx, count)
+				vm.stack.stackPointer -= count
+				stack.setObject(0, closure)
+				vm.stack.stackPointer++
+				pc += 5
+			case VM_DOWN_CAST:
+				class := exe.ClassDefinitionList[get2ByteInt(codeList[pc+1:])]
+				obj := stack.getObject(-1)
+				if obj.data != nil && !isInstanceOf(obj, class) {
+					vmError(CLASS_CAST_ERR, obj.vTable.execClass.name, class.Name)
+				}
+				pc += 3
+			case VM_INSTANCEOF:
+				class := exe.ClassDefinitionList[get2ByteInt(codeList[pc+1:])]
+				obj := stack.getObject(-1)
+				stack.setInt(-1, boolToInt(obj.data != nil && isInstanceOf(obj, class)))
+				pc += 3
+			case VM_THROW:
+				exception := stack.getObject(-1)
+				checkNullPointer(exception)
+				throwException(exception)
+			case VM_GO_FINALLY:
+				// 保存返回地址后跳转到finally
+				stack.setInt(0, pc+3)
+				vm.stack.stackPointer++
+				pc = get2ByteInt(codeList[pc+1:])
+			case VM_FINALLY_END:
+				// 栈顶为返回地址, 或者是需要重新抛出的异常
+				vm.stack.stackPointer--
+				switch v := stack.stack[vm.stack.stackPointer].(type) {
+				case *IntValue:
+					pc = v.intValue
+				case *ObjectRef:
+					throwException(v)
+				default:
+					panic("TODO")
+				}
 			default:
 				panic("TODO")
 			}
-		case VM_RETURN:
-			if vm.returnFunction(&gFunc, &codeList, &pc, &base, &ee, &exe) {
-				ret = stack.stack[stack.stackPointer-1]
-				// TODO goto
-				return ret
-			}
-		case VM_NEW:
-			classIndex := get2ByteInt(codeList[pc+1:])
-			stack.setObject(0, vm.createClassObject(classIndex))
-			vm.stack.stackPointer++
-			pc += 3
-		case VM_NEW_ARRAY:
-			dim := int(codeList[pc+1])
-			typ := exe.TypeSpecifierList[get2ByteInt(codeList[pc+2:])]
+		}
+		return nil
+	}
 
-			vm.restorePc(ee, gFunc, pc)
-			array := vm.createArray(dim, typ)
-			vm.stack.stackPointer -= dim
+	for {
+		exception := run()
+		if exception == nil {
+			return ret
+		}
 
-			stack.setObject(0, array)
-			vm.stack.stackPointer++
-			pc += 4
-		case VM_NEW_ARRAY_LITERAL_INT:
-			size := get2ByteInt(codeList[pc+1:])
-
-			vm.restorePc(ee, gFunc, pc)
-			array := vm.createArrayLiteralInt(size)
-			vm.stack.stackPointer -= size
-			stack.setObject(0, array)
-			vm.stack.stackPointer++
-			pc += 3
-		case VM_NEW_ARRAY_LITERAL_DOUBLE:
-			size := get2ByteInt(codeList[pc+1:])
-
-			vm.restorePc(ee, gFunc, pc)
-			array := vm.createArrayLiteralDouble(size)
-			vm.stack.stackPointer -= size
-			stack.setObject(0, array)
-			vm.stack.stackPointer++
-			pc += 3
-		case VM_NEW_ARRAY_LITERAL_OBJECT:
-			size := get2ByteInt(codeList[pc+1:])
-
-			vm.restorePc(ee, gFunc, pc)
-			array := vm.createArrayLiteralObject(size)
-			vm.stack.stackPointer -= size
-			stack.setObject(0, array)
-			vm.stack.stackPointer++
-			pc += 3
-		default:
-			panic("TODO")
+		if !vm.dispatchException(exception, &gFunc, &codeList, &pc, &base, &ee, &exe) {
+			vm.uncaughtException(exception)
 		}
 	}
-	return ret
 }
 
 func (vm *VirtualMachine) initializeLocalVariables(f *Function, fromSp int) {
-	var i, spIdx int
+	for i, v := range f.LocalVariableList {
+		value := vm.initializeValue(v.TypeSpecifier)
+		value.setPointer(isReferenceType(v.TypeSpecifier))
 
-	for i = 0; i < len(f.LocalVariableList); i++ {
-		vm.stack.stack[i].setPointer(false)
-	}
-
-	spIdx = fromSp
-	for i = 0; i < len(f.LocalVariableList); i++ {
-		vm.stack.stack[spIdx] = initializeValue(f.LocalVariableList[i].TypeSpecifier)
-
-		if f.LocalVariableList[i].TypeSpecifier.BasicType == StringType {
-			vm.stack.stack[i].setPointer(true)
-		}
-		spIdx++
+		vm.stack.stack[fromSp+i] = value
 	}
 }
 
@@ -872,12 +1521,21 @@ func (vm *VirtualMachine) convertCode(exe *Executable, codeList []byte, f *Funct
 			}
 			set2ByteInt(codeList[i+1:], destIdx)
 
-		case VM_PUSH_FUNCTION:
+		case VM_PUSH_FUNCTION, VM_NEW_CLOSURE:
 
 			idxInExe := get2ByteInt(codeList[i+1:])
 			funcIdx := vm.searchFunction(exe.FunctionList[idxInExe].PackageName, exe.FunctionList[idxInExe].Name)
+			if funcIdx == functionNotFound {
+				// 原生函数不属于任何包
+				funcIdx = vm.searchFunction("", exe.FunctionList[idxInExe].Name)
+			}
+			if funcIdx == functionNotFound {
+				vmError(FUNCTION_NOT_FOUND_ERR, exe.FunctionList[idxInExe].Name)
+			}
 			set2ByteInt(codeList[i+1:], funcIdx)
-		case VM_NEW:
+		case VM_NEW, VM_SUPER,
+			VM_PUSH_CLASS_STATIC_INT, VM_PUSH_CLASS_STATIC_DOUBLE, VM_PUSH_CLASS_STATIC_OBJECT,
+			VM_POP_CLASS_STATIC_INT, VM_POP_CLASS_STATIC_DOUBLE, VM_POP_CLASS_STATIC_OBJECT:
 			idxInExe := get2ByteInt(codeList[i+1:])
 			classIdx := vm.searchClass(exe.ClassDefinitionList[idxInExe].PackageName, exe.ClassDefinitionList[idxInExe].Name)
 			set2ByteInt(codeList[i+1:], classIdx)
@@ -909,21 +1567,36 @@ func (vm *VirtualMachine) searchFunction(packageName, name string) int {
 }
 
 func (vm *VirtualMachine) searchClass(packageName, name string) int {
+	classIndex := vm.searchClassIndex(packageName, name)
+	if classIndex < 0 {
+		vmError(CLASS_NOT_FOUND_ERR, name)
+	}
+
+	return classIndex
+}
 
+// 查找类, 没有找到时返回-1
+func (vm *VirtualMachine) searchClassIndex(packageName, name string) int {
 	for i, class := range vm.classList {
 		if class.packageName == packageName && class.name == name {
 			return i
 		}
 	}
-
-	vmError(CLASS_NOT_FOUND_ERR, name)
-
-	return 0
+	return -1
 }
 
 //
 // 函数相关
 //
+// lambda编译为以closure作为this的方法
+func (vm *VirtualMachine) isLambda(funcIdx int) bool {
+	f, ok := vm.functionList[funcIdx].(*GFunction)
+	if !ok {
+		return false
+	}
+	return f.Executable.executable.FunctionList[f.Index].IsMethod
+}
+
 // 执行原生函数
 func (vm *VirtualMachine) invokeNativeFunction(f *NativeFunction, spP *int) {
 
@@ -1002,10 +1675,7 @@ func doReturn(vm *VirtualMachine, funcP **GFunction, codeP *[]byte, pcP *int, ba
 
 	calleeP := (*exeP).FunctionList[(*funcP).Index]
 
-	argCount := len(calleeP.ParameterList)
-	if calleeP.IsMethod {
-		argCount++ /* for this */
-	}
+	argCount := getArgumentCount(calleeP)
 	callInfo := vm.stack.stack[*baseP+argCount].(*CallInfo)
 
 	if callInfo.caller != nil {
@@ -1014,9 +1684,9 @@ func doReturn(vm *VirtualMachine, funcP **GFunction, codeP *[]byte, pcP *int, ba
 		callerP := (*exeP).FunctionList[callInfo.caller.Index]
 		*codeP = callerP.CodeList
 	} else {
-		*eeP = vm.topLevel
-		*exeP = vm.topLevel.executable
-		*codeP = vm.topLevel.executable.CodeList
+		*eeP = vm.runningTopLevel
+		*exeP = vm.runningTopLevel.executable
+		*codeP = vm.runningTopLevel.executable.CodeList
 	}
 	*funcP = callInfo.caller
 
@@ -1027,6 +1697,15 @@ func doReturn(vm *VirtualMachine, funcP **GFunction, codeP *[]byte, pcP *int, ba
 	return callInfo.callerAddress == callFromNative
 }
 
+// 栈上参数的数量, 方法包括this
+func getArgumentCount(f *Function) int {
+	argCount := len(f.ParameterList)
+	if f.IsMethod {
+		argCount++ /* for this */
+	}
+	return argCount
+}
+
 func (vm *VirtualMachine) createArraySub(dim int, dimIndex int, typ *TypeSpecifier) *ObjectRef {
 	var ret *ObjectRef
 
@@ -1036,7 +1715,7 @@ func (vm *VirtualMachine) createArraySub(dim int, dimIndex int, typ *TypeSpecifi
 		switch typ.BasicType {
 		case VoidType:
 			panic("TODO")
-		case BooleanType, IntType:
+		case BooleanType, IntType, CharType, ByteType, Int32Type, LongType:
 			ret = vm.createArrayInt(size)
 		case DoubleType:
 			ret = vm.createArrayDouble(size)
@@ -1099,6 +1778,123 @@ func (vm *VirtualMachine) createArrayLiteralObject(size int) *ObjectRef {
 	return array
 }
 
+// 切片与原数组共享存储, 包含begin, 不包含end
+// 容量限制为切片的长度, 追加元素时重新分配, 不会覆盖原数组
+func (vm *VirtualMachine) createArraySlice(array ObjectArray, begin int, end int) *ObjectRef {
+	arraySize := array.getArraySize()
+	if begin < 0 || begin > arraySize {
+		vmError(INDEX_OUT_OF_BOUNDS_ERR, arraySize, begin)
+	}
+	if end < begin || end > arraySize {
+		vmError(INDEX_OUT_OF_BOUNDS_ERR, arraySize, end)
+	}
+
+	var obj Object
+	switch a := array.(type) {
+	case *ObjectArrayInt:
+		obj = &ObjectArrayInt{intArray: a.intArray[begin:end:end]}
+	case *ObjectArrayDouble:
+		obj = &ObjectArrayDouble{doubleArray: a.doubleArray[begin:end:end]}
+	case *ObjectArrayObject:
+		obj = &ObjectArrayObject{objectArray: a.objectArray[begin:end:end]}
+	}
+	vm.addObject(obj)
+
+	return &ObjectRef{data: obj}
+}
+
+// 按字符拆分字符串, 返回char数组
+func (vm *VirtualMachine) createStringChars(str *ObjectString) *ObjectRef {
+	runeList := str.getRuneList()
+	ret := vm.createArrayInt(len(runeList))
+
+	intArray := ret.data.(*ObjectArrayInt).intArray
+	for i, r := range runeList {
+		intArray[i] = int(r)
+	}
+
+	return ret
+}
+
+// 键和值交替位于栈顶, 相同的键以后面的为准
+func (vm *VirtualMachine) createMapLiteral(size int) *ObjectRef {
+	ref := vm.createMap()
+	m := ref.data.(*ObjectMap)
+
+	for i := 0; i < size; i++ {
+		key := vm.stack.getMapKey(-size*2 + i*2)
+
+		var value Value
+		switch v := vm.stack.stack[vm.stack.stackPointer-size*2+i*2+1].(type) {
+		case *IntValue:
+			value = NewIntValue(v.intValue)
+		case *DoubleValue:
+			value = NewDoubleValue(v.doubleValue)
+		default:
+			value = v
+		}
+		m.set(key, value)
+	}
+
+	return ref
+}
+
+// 按插入的顺序返回所有的键
+func (vm *VirtualMachine) createMapKeys(m *ObjectMap, code byte) *ObjectRef {
+	var ret *ObjectRef
+
+	switch code {
+	case VM_MAP_KEYS_INT:
+		ret = vm.createArrayInt(len(m.keyList))
+		for i, key := range m.keyList {
+			ret.data.(*ObjectArrayInt).intArray[i] = key.(int)
+		}
+	case VM_MAP_KEYS_DOUBLE:
+		ret = vm.createArrayDouble(len(m.keyList))
+		for i, key := range m.keyList {
+			ret.data.(*ObjectArrayDouble).doubleArray[i] = key.(float64)
+		}
+	case VM_MAP_KEYS_OBJECT:
+		ret = vm.createArrayObject(len(m.keyList))
+
+		// 创建字符串时可能触发gc, 先将数组压栈
+		vm.stack.setObject(0, ret)
+		vm.stack.stackPointer++
+		for i, key := range m.keyList {
+			str := vm.createStringObject(key.(string))
+			ret.data.(*ObjectArrayObject).objectArray[i] = str
+		}
+		vm.stack.stackPointer--
+	}
+
+	return ret
+}
+
+// 按键的顺序返回所有的值
+func (vm *VirtualMachine) createMapValues(m *ObjectMap, code byte) *ObjectRef {
+	var ret *ObjectRef
+
+	switch code {
+	case VM_MAP_VALUES_INT:
+		ret = vm.createArrayInt(len(m.keyList))
+		for i, key := range m.keyList {
+			ret.data.(*ObjectArrayInt).intArray[i] = m.valueMap[key].(*IntValue).intValue
+		}
+	case VM_MAP_VALUES_DOUBLE:
+		ret = vm.createArrayDouble(len(m.keyList))
+		for i, key := range m.keyList {
+			ret.data.(*ObjectArrayDouble).doubleArray[i] = m.valueMap[key].(*DoubleValue).doubleValue
+		}
+	case VM_MAP_VALUES_OBJECT:
+		ret = vm.createArrayObject(len(m.keyList))
+		for i, key := range m.keyList {
+			ret.data.(*ObjectArrayObject).objectArray[i] = m.valueMap[key].(*ObjectRef)
+		}
+	}
+
+	return ret
+}
+
 // TODO 待研究
 func (vm *VirtualMachine) restorePc(ee *ExecutableEntry, function *GFunction, pc int) {
 	vm.currentExecutable = ee
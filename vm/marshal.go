@@ -0,0 +1,689 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ==============================
+// 字节码文件(.4gc)
+// ==============================
+//
+// 文件头: magic(4字节) + 版本(2字节) + 内容类型(1字节)
+// 之后按字段顺序写入, 整数为varint, 字符串和字节列表先写长度
+//
+// SetExecutableList会改写字节码中的下标, 序列化需要在此之前进行
+
+// 字节码文件后缀
+const BytecodeSuffix = ".4gc"
+
+// 格式变化时增加版本号
+const bytecodeVersion = 14
+
+var bytecodeMagic = []byte{'4', 'G', 'C', 0}
+
+// 文件内容类型
+const (
+	bytecodeKindExecutable byte = iota + 1
+	bytecodeKindExecutableList
+)
+
+// 常量池元素类型
+const (
+	constantKindInt byte = iota + 1
+	constantKindDouble
+	constantKindString
+)
+
+// 类型派生
+const (
+	deriveKindFunction byte = iota + 1
+	deriveKindArray
+	deriveKindMap
+)
+
+// ErrBadBytecode 字节码文件格式错误
+var ErrBadBytecode = errors.New("字节码文件格式错误")
+
+// Marshal 序列化为字节码文件
+func (exe *Executable) Marshal() ([]byte, error) {
+	w := newBytecodeWriter(bytecodeKindExecutable)
+	w.writeExecutable(exe)
+
+	return w.bytes(), nil
+}
+
+// Unmarshal 从字节码文件读取
+func (exe *Executable) Unmarshal(data []byte) (err error) {
+	r, err := newBytecodeReader(data, bytecodeKindExecutable)
+	if err != nil {
+		return err
+	}
+	defer r.recover(&err)
+
+	*exe = *r.readExecutable()
+	r.checkEOF()
+
+	return nil
+}
+
+// Marshal 序列化为字节码文件, 包括所有依赖的包
+func (exeList *ExecutableList) Marshal() ([]byte, error) {
+	w := newBytecodeWriter(bytecodeKindExecutableList)
+
+	topLevelIndex := -1
+
+	w.writeInt(len(exeList.List))
+	for i, exe := range exeList.List {
+		if exe == exeList.TopLevel {
+			topLevelIndex = i
+		}
+		w.writeExecutable(exe)
+	}
+	w.writeInt(topLevelIndex)
+
+	return w.bytes(), nil
+}
+
+// Unmarshal 从字节码文件读取
+func (exeList *ExecutableList) Unmarshal(data []byte) (err error) {
+	r, err := newBytecodeReader(data, bytecodeKindExecutableList)
+	if err != nil {
+		return err
+	}
+	defer r.recover(&err)
+
+	list := make([]*Executable, r.readLength())
+	for i := range list {
+		list[i] = r.readExecutable()
+	}
+
+	var topLevel *Executable
+
+	topLevelIndex := r.readInt()
+	if topLevelIndex >= len(list) {
+		r.fail()
+	}
+	if topLevelIndex >= 0 {
+		topLevel = list[topLevelIndex]
+	}
+
+	r.checkEOF()
+
+	exeList.List = list
+	exeList.TopLevel = topLevel
+
+	return nil
+}
+
+// ==============================
+// bytecodeWriter
+// ==============================
+
+type bytecodeWriter struct {
+	buf bytes.Buffer
+}
+
+func newBytecodeWriter(kind byte) *bytecodeWriter {
+	w := &bytecodeWriter{}
+
+	w.buf.Write(bytecodeMagic)
+
+	version := make([]byte, 2)
+	binary.BigEndian.PutUint16(version, bytecodeVersion)
+	w.buf.Write(version)
+
+	w.buf.WriteByte(kind)
+
+	return w
+}
+
+func (w *bytecodeWriter) bytes() []byte {
+	return w.buf.Bytes()
+}
+
+func (w *bytecodeWriter) writeInt(value int) {
+	b := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutVarint(b, int64(value))
+	w.buf.Write(b[:n])
+}
+
+func (w *bytecodeWriter) writeBool(value bool) {
+	w.buf.WriteByte(byte(boolToInt(value)))
+}
+
+func (w *bytecodeWriter) writeDouble(value float64) {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, math.Float64bits(value))
+	w.buf.Write(b)
+}
+
+func (w *bytecodeWriter) writeString(value string) {
+	w.writeInt(len(value))
+	w.buf.WriteString(value)
+}
+
+func (w *bytecodeWriter) writeByteList(value []byte) {
+	w.writeInt(len(value))
+	w.buf.Write(value)
+}
+
+func (w *bytecodeWriter) writeExecutable(exe *Executable) {
+	w.writeString(exe.PackageName)
+	w.writeBool(exe.IsRequired)
+	w.writeString(exe.Path)
+
+	w.writeConstantPool(&exe.ConstantPool)
+
+	w.writeInt(len(exe.GlobalVariableList))
+	for _, v := range exe.GlobalVariableList {
+		w.writeString(v.name)
+		w.writeTypeSpecifier(v.typeSpecifier)
+	}
+
+	w.writeInt(len(exe.ImportedVariableList))
+	for _, v := range exe.ImportedVariableList {
+		w.writeString(v.PackageName)
+		w.writeString(v.Name)
+	}
+
+	w.writeInt(len(exe.FunctionList))
+	for _, f := range exe.FunctionList {
+		w.writeFunction(f)
+	}
+
+	w.writeInt(len(exe.TypeSpecifierList))
+	for _, typ := range exe.TypeSpecifierList {
+		w.writeTypeSpecifier(typ)
+	}
+
+	w.writeByteList(exe.CodeList)
+
+	w.writeInt(len(exe.ClassDefinitionList))
+	for _, class := range exe.ClassDefinitionList {
+		w.writeClass(class)
+	}
+
+	w.writeLineNumberList(exe.LineNumberList)
+	w.writeTryList(exe.TryList)
+	w.writeSwitchTableList(exe.SwitchTableList)
+}
+
+func (w *bytecodeWriter) writeConstantPool(cp *ConstantPool) {
+	w.writeInt(len(cp.pool))
+
+	for _, c := range cp.pool {
+		switch constant := c.(type) {
+		case *ConstantInt:
+			w.buf.WriteByte(constantKindInt)
+			w.writeInt(constant.intValue)
+		case *ConstantDouble:
+			w.buf.WriteByte(constantKindDouble)
+			w.writeDouble(constant.doubleValue)
+		case *ConstantString:
+			w.buf.WriteByte(constantKindString)
+			w.writeString(constant.stringValue)
+		default:
+			panic("TODO")
+		}
+	}
+}
+
+func (w *bytecodeWriter) writeTypeSpecifier(typ *TypeSpecifier) {
+	w.writeBool(typ != nil)
+	if typ == nil {
+		return
+	}
+
+	w.writeInt(int(typ.BasicType))
+
+	w.writeInt(len(typ.DeriveList))
+	for _, deriveIfs := range typ.DeriveList {
+		switch derive := deriveIfs.(type) {
+		case *FunctionDerive:
+			w.buf.WriteByte(deriveKindFunction)
+			w.writeLocalVariableList(derive.ParameterList)
+		case *ArrayDerive:
+			w.buf.WriteByte(deriveKindArray)
+		case *MapDerive:
+			w.buf.WriteByte(deriveKindMap)
+			w.writeTypeSpecifier(derive.KeyType)
+		default:
+			panic("TODO")
+		}
+	}
+}
+
+func (w *bytecodeWriter) writeLocalVariableList(list []*LocalVariable) {
+	w.writeInt(len(list))
+	for _, v := range list {
+		w.writeString(v.Name)
+		w.writeTypeSpecifier(v.TypeSpecifier)
+	}
+}
+
+func (w *bytecodeWriter) writeFunction(f *Function) {
+	w.writeTypeSpecifier(f.TypeSpecifier)
+	w.writeString(f.PackageName)
+	w.writeString(f.Name)
+	w.writeLocalVariableList(f.ParameterList)
+	w.writeBool(f.IsImplemented)
+	w.writeBool(f.IsMethod)
+	w.writeLocalVariableList(f.LocalVariableList)
+	w.writeByteList(f.CodeList)
+	w.writeLineNumberList(f.LineNumberList)
+	w.writeTryList(f.TryList)
+	w.writeSwitchTableList(f.SwitchTableList)
+}
+
+func (w *bytecodeWriter) writeClass(class *Class) {
+	w.writeString(class.PackageName)
+	w.writeString(class.Name)
+	w.writeBool(class.IsImplemented)
+	w.writeBool(class.IsAbstract)
+	w.writeBool(class.IsInterface)
+
+	w.writeBool(class.SuperClass != nil)
+	if class.SuperClass != nil {
+		w.writeString(class.SuperClass.PackageName)
+		w.writeString(class.SuperClass.Name)
+	}
+
+	w.writeInt(len(class.InterfaceList))
+	for _, iface := range class.InterfaceList {
+		w.writeString(iface.PackageName)
+		w.writeString(iface.Name)
+	}
+
+	w.writeInt(len(class.FieldList))
+	for _, field := range class.FieldList {
+		w.writeString(field.Name)
+		w.writeTypeSpecifier(field.Typ)
+	}
+
+	w.writeInt(len(class.MethodList))
+	for _, method := range class.MethodList {
+		w.writeString(method.Name)
+		w.writeBool(method.IsAbstract)
+	}
+
+	w.writeInt(len(class.StaticFieldList))
+	for _, field := range class.StaticFieldList {
+		w.writeString(field.Name)
+		w.writeTypeSpecifier(field.Typ)
+		w.writeInt(field.ConstantIndex)
+	}
+}
+
+func (w *bytecodeWriter) writeLineNumberList(list []*LineNumber) {
+	w.writeInt(len(list))
+	for _, line := range list {
+		w.writeInt(line.LineNumber)
+		w.writeInt(line.StartPc)
+		w.writeInt(line.PcCount)
+	}
+}
+
+func (w *bytecodeWriter) writeTryList(list []*Try) {
+	w.writeInt(len(list))
+	for _, try := range list {
+		w.writeInt(try.StartPc)
+		w.writeInt(try.EndPc)
+
+		w.writeInt(len(try.CatchList))
+		for _, catch := range try.CatchList {
+			w.writeInt(catch.ClassIndex)
+			w.writeInt(catch.StartPc)
+			w.writeInt(catch.EndPc)
+		}
+
+		w.writeInt(try.FinallyStartPc)
+		w.writeInt(try.FinallyEndPc)
+	}
+}
+
+func (w *bytecodeWriter) writeSwitchTableList(list []*SwitchTable) {
+	w.writeInt(len(list))
+	for _, table := range list {
+		w.writeInt(table.Low)
+
+		w.writeInt(len(table.KeyList))
+		for _, key := range table.KeyList {
+			w.writeInt(key)
+		}
+
+		w.writeInt(len(table.StringKeyList))
+		for _, key := range table.StringKeyList {
+			w.writeString(key)
+		}
+
+		w.writeInt(len(table.AddressList))
+		for _, address := range table.AddressList {
+			w.writeInt(address)
+		}
+
+		w.writeInt(table.DefaultPc)
+	}
+}
+
+// ==============================
+// bytecodeReader
+// ==============================
+
+// 读取失败, 由Unmarshal转换为error
+type bytecodeReadError struct{}
+
+type bytecodeReader struct {
+	data   []byte
+	offset int
+}
+
+func newBytecodeReader(data []byte, kind byte) (*bytecodeReader, error) {
+	headerSize := len(bytecodeMagic) + 3
+
+	if len(data) < headerSize || !bytes.Equal(data[:len(bytecodeMagic)], bytecodeMagic) {
+		return nil, ErrBadBytecode
+	}
+
+	version := binary.BigEndian.Uint16(data[len(bytecodeMagic):])
+	if version != bytecodeVersion {
+		return nil, fmt.Errorf("不支持的字节码版本%d, 当前版本为%d", version, bytecodeVersion)
+	}
+
+	if data[headerSize-1] != kind {
+		return nil, ErrBadBytecode
+	}
+
+	return &bytecodeReader{data: data, offset: headerSize}, nil
+}
+
+func (r *bytecodeReader) recover(err *error) {
+	if e := recover(); e != nil {
+		if _, ok := e.(*bytecodeReadError); !ok {
+			panic(e)
+		}
+		*err = ErrBadBytecode
+	}
+}
+
+func (r *bytecodeReader) fail() {
+	panic(&bytecodeReadError{})
+}
+
+func (r *bytecodeReader) checkEOF() {
+	if r.offset != len(r.data) {
+		r.fail()
+	}
+}
+
+func (r *bytecodeReader) readByte() byte {
+	if r.offset >= len(r.data) {
+		r.fail()
+	}
+	b := r.data[r.offset]
+	r.offset++
+	return b
+}
+
+func (r *bytecodeReader) readInt() int {
+	value, n := binary.Varint(r.data[r.offset:])
+	if n <= 0 {
+		r.fail()
+	}
+	r.offset += n
+	return int(value)
+}
+
+// 读取列表长度, 长度不会超过剩余的字节数
+func (r *bytecodeReader) readLength() int {
+	length := r.readInt()
+	if length < 0 || length > len(r.data)-r.offset {
+		r.fail()
+	}
+	return length
+}
+
+func (r *bytecodeReader) readBool() bool {
+	return r.readByte() != 0
+}
+
+func (r *bytecodeReader) readDouble() float64 {
+	if len(r.data)-r.offset < 8 {
+		r.fail()
+	}
+	bits := binary.BigEndian.Uint64(r.data[r.offset:])
+	r.offset += 8
+	return math.Float64frombits(bits)
+}
+
+func (r *bytecodeReader) readByteList() []byte {
+	length := r.readLength()
+
+	b := make([]byte, length)
+	copy(b, r.data[r.offset:])
+	r.offset += length
+
+	return b
+}
+
+func (r *bytecodeReader) readString() string {
+	return string(r.readByteList())
+}
+
+func (r *bytecodeReader) readExecutable() *Executable {
+	exe := NewExecutable()
+
+	exe.PackageName = r.readString()
+	exe.IsRequired = r.readBool()
+	exe.Path = r.readString()
+
+	exe.ConstantPool = r.readConstantPool()
+
+	for i := r.readLength(); i > 0; i-- {
+		name := r.readString()
+		exe.GlobalVariableList = append(exe.GlobalVariableList, NewVmVariable(name, r.readTypeSpecifier()))
+	}
+
+	for i := r.readLength(); i > 0; i-- {
+		packageName := r.readString()
+		exe.ImportedVariableList = append(exe.ImportedVariableList, &ImportedVariable{PackageName: packageName, Name: r.readString()})
+	}
+
+	for i := r.readLength(); i > 0; i-- {
+		exe.FunctionList = append(exe.FunctionList, r.readFunction())
+	}
+
+	for i := r.readLength(); i > 0; i-- {
+		exe.TypeSpecifierList = append(exe.TypeSpecifierList, r.readTypeSpecifier())
+	}
+
+	exe.CodeList = r.readByteList()
+
+	for i := r.readLength(); i > 0; i-- {
+		exe.ClassDefinitionList = append(exe.ClassDefinitionList, r.readClass())
+	}
+
+	exe.LineNumberList = r.readLineNumberList()
+	exe.TryList = r.readTryList()
+	exe.SwitchTableList = r.readSwitchTableList()
+
+	return exe
+}
+
+func (r *bytecodeReader) readConstantPool() ConstantPool {
+	cp := NewConstantPool()
+
+	for i := r.readLength(); i > 0; i-- {
+		switch r.readByte() {
+		case constantKindInt:
+			cp.Append(NewConstantInt(r.readInt()))
+		case constantKindDouble:
+			cp.Append(NewConstantDouble(r.readDouble()))
+		case constantKindString:
+			cp.Append(NewConstantString(r.readString()))
+		default:
+			r.fail()
+		}
+	}
+
+	return cp
+}
+
+func (r *bytecodeReader) readTypeSpecifier() *TypeSpecifier {
+	if !r.readBool() {
+		return nil
+	}
+
+	typ := &TypeSpecifier{BasicType: BasicType(r.readInt())}
+
+	for i := r.readLength(); i > 0; i-- {
+		switch r.readByte() {
+		case deriveKindFunction:
+			typ.AppendDerive(&FunctionDerive{ParameterList: r.readLocalVariableList()})
+		case deriveKindArray:
+			typ.AppendDerive(&ArrayDerive{})
+		case deriveKindMap:
+			typ.AppendDerive(&MapDerive{KeyType: r.readTypeSpecifier()})
+		default:
+			r.fail()
+		}
+	}
+
+	return typ
+}
+
+func (r *bytecodeReader) readLocalVariableList() []*LocalVariable {
+	list := []*LocalVariable{}
+
+	for i := r.readLength(); i > 0; i-- {
+		v := &LocalVariable{Name: r.readString()}
+		v.TypeSpecifier = r.readTypeSpecifier()
+		list = append(list, v)
+	}
+
+	return list
+}
+
+func (r *bytecodeReader) readFunction() *Function {
+	f := &Function{}
+
+	f.TypeSpecifier = r.readTypeSpecifier()
+	f.PackageName = r.readString()
+	f.Name = r.readString()
+	f.ParameterList = r.readLocalVariableList()
+	f.IsImplemented = r.readBool()
+	f.IsMethod = r.readBool()
+	f.LocalVariableList = r.readLocalVariableList()
+	f.CodeList = r.readByteList()
+	f.LineNumberList = r.readLineNumberList()
+	f.TryList = r.readTryList()
+	f.SwitchTableList = r.readSwitchTableList()
+
+	return f
+}
+
+func (r *bytecodeReader) readClass() *Class {
+	class := &Class{}
+
+	class.PackageName = r.readString()
+	class.Name = r.readString()
+	class.IsImplemented = r.readBool()
+	class.IsAbstract = r.readBool()
+	class.IsInterface = r.readBool()
+
+	if r.readBool() {
+		class.SuperClass = &ClassIdentifier{PackageName: r.readString()}
+		class.SuperClass.Name = r.readString()
+	}
+
+	for i := r.readLength(); i > 0; i-- {
+		iface := &ClassIdentifier{PackageName: r.readString()}
+		iface.Name = r.readString()
+		class.InterfaceList = append(class.InterfaceList, iface)
+	}
+
+	for i := r.readLength(); i > 0; i-- {
+		field := &Field{Name: r.readString()}
+		field.Typ = r.readTypeSpecifier()
+		class.FieldList = append(class.FieldList, field)
+	}
+
+	for i := r.readLength(); i > 0; i-- {
+		method := &Method{Name: r.readString()}
+		method.IsAbstract = r.readBool()
+		class.MethodList = append(class.MethodList, method)
+	}
+
+	for i := r.readLength(); i > 0; i-- {
+		field := &StaticField{Name: r.readString()}
+		field.Typ = r.readTypeSpecifier()
+		field.ConstantIndex = r.readInt()
+		class.StaticFieldList = append(class.StaticFieldList, field)
+	}
+
+	return class
+}
+
+func (r *bytecodeReader) readLineNumberList() []*LineNumber {
+	list := []*LineNumber{}
+
+	for i := r.readLength(); i > 0; i-- {
+		line := &LineNumber{LineNumber: r.readInt()}
+		line.StartPc = r.readInt()
+		line.PcCount = r.readInt()
+		list = append(list, line)
+	}
+
+	return list
+}
+
+func (r *bytecodeReader) readTryList() []*Try {
+	list := []*Try{}
+
+	for i := r.readLength(); i > 0; i-- {
+		try := &Try{StartPc: r.readInt()}
+		try.EndPc = r.readInt()
+
+		try.CatchList = []*Catch{}
+		for j := r.readLength(); j > 0; j-- {
+			catch := &Catch{ClassIndex: r.readInt()}
+			catch.StartPc = r.readInt()
+			catch.EndPc = r.readInt()
+			try.CatchList = append(try.CatchList, catch)
+		}
+
+		try.FinallyStartPc = r.readInt()
+		try.FinallyEndPc = r.readInt()
+
+		list = append(list, try)
+	}
+
+	return list
+}
+
+func (r *bytecodeReader) readSwitchTableList() []*SwitchTable {
+	list := []*SwitchTable{}
+
+	for i := r.readLength(); i > 0; i-- {
+		table := &SwitchTable{Low: r.readInt()}
+
+		for j := r.readLength(); j > 0; j-- {
+			table.KeyList = append(table.KeyList, r.readInt())
+		}
+		for j := r.readLength(); j > 0; j-- {
+			table.StringKeyList = append(table.StringKeyList, r.readString())
+		}
+		for j := r.readLength(); j > 0; j-- {
+			table.AddressList = append(table.AddressList, r.readInt())
+		}
+
+		table.DefaultPc = r.readInt()
+
+		list = append(list, table)
+	}
+
+	return list
+}
@@ -2,20 +2,364 @@ package vm
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 )
 
-func (vm *VirtualMachine) AddNativeFunctions() {
-	vm.addNativeFunction("print", printProc, 1)
+// ==============================
+// 原生函数注册表
+// ==============================
+//
+// 所有虚拟机共享, 创建虚拟机时复制到函数列表, 编译器根据其中的签名检查调用
+
+var nativeRegistry = struct {
+	sync.RWMutex
+	list []*NativeFunction
+}{}
+
+var (
+	vmType    = reflect.TypeOf((*VirtualMachine)(nil))
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// RegisterNative 注册原生函数, 需要在创建虚拟机和编译之前调用
+// packageName为空时可以在脚本中直接调用, 否则需要先require对应的包
+// fn必须是函数, 参数和返回值支持int, float64, string, bool以及它们的切片
+// 第一个参数为*VirtualMachine时传入当前虚拟机
+// 最后一个返回值可以是error, 不为nil时抛出RuntimeException
+func RegisterNative(packageName string, name string, fn interface{}) error {
+	return registerNative(packageName, name, fn, "RuntimeException")
 }
 
-func (vm *VirtualMachine) addNativeFunction(funcName string, proc NativeFunctionProc, argCount int) {
+// 返回的error转换为exceptionClassName类的异常
+func registerNative(packageName string, name string, fn interface{}, exceptionClassName string) error {
+	fnValue := reflect.ValueOf(fn)
+	fnType := fnValue.Type()
+
+	if fnType.Kind() != reflect.Func {
+		return fmt.Errorf("原生函数%s不是函数: %s", name, fnType)
+	}
+	if fnType.IsVariadic() {
+		return fmt.Errorf("原生函数%s不支持可变参数", name)
+	}
+
+	// 是否传入虚拟机
+	withVM := fnType.NumIn() > 0 && fnType.In(0) == vmType
+	// 是否返回error
+	withError := fnType.NumOut() > 0 && fnType.Out(fnType.NumOut()-1) == errorType
+
+	resultCount := fnType.NumOut()
+	if withError {
+		resultCount--
+	}
+	if resultCount > 1 {
+		return fmt.Errorf("原生函数%s最多只能有一个返回值", name)
+	}
+
+	signature := &Function{
+		PackageName:   packageName,
+		Name:          name,
+		TypeSpecifier: &TypeSpecifier{BasicType: VoidType},
+		ParameterList: []*LocalVariable{},
+	}
+
+	paramStart := 0
+	if withVM {
+		paramStart = 1
+	}
+
+	for i := paramStart; i < fnType.NumIn(); i++ {
+		typ, ok := goTypeToTypeSpecifier(fnType.In(i))
+		if !ok {
+			return fmt.Errorf("原生函数%s的第%d个参数类型不支持: %s", name, i+1, fnType.In(i))
+		}
+		param := &LocalVariable{Name: fmt.Sprintf("arg%d", i-paramStart), TypeSpecifier: typ}
+		signature.ParameterList = append(signature.ParameterList, param)
+	}
+
+	if resultCount == 1 {
+		typ, ok := goTypeToTypeSpecifier(fnType.Out(0))
+		if !ok {
+			return fmt.Errorf("原生函数%s的返回值类型不支持: %s", name, fnType.Out(0))
+		}
+		signature.TypeSpecifier = typ
+	}
+
+	proc := func(vm *VirtualMachine, argCount int, args []Value) Value {
+		in := make([]reflect.Value, 0, fnType.NumIn())
+		if withVM {
+			in = append(in, reflect.ValueOf(vm))
+		}
+		for i := 0; i < argCount; i++ {
+			in = append(in, vm.valueToGo(args[i], fnType.In(i+paramStart)))
+		}
+
+		out := fnValue.Call(in)
+
+		if withError {
+			if err, _ := out[len(out)-1].Interface().(error); err != nil {
+				vm.throwNativeException(exceptionClassName, err.Error())
+			}
+			out = out[:len(out)-1]
+		}
+
+		if len(out) == 0 {
+			return &IntValue{intValue: 0}
+		}
+
+		ret := vm.goToValue(out[0])
+		ret.setPointer(isReferenceType(signature.TypeSpecifier))
+
+		return ret
+	}
+
+	return registerNativeFunction(&NativeFunction{
+		Name:        name,
+		PackageName: packageName,
+		proc:        proc,
+		argCount:    len(signature.ParameterList),
+		signature:   signature,
+	})
+}
+
+// 注册直接操作虚拟机值的原生函数, 用于无法用Go类型表示签名的内置函数
+func registerBuiltin(packageName string, name string, proc NativeFunctionProc, typ *TypeSpecifier, parameterList ...*LocalVariable) {
 	function := &NativeFunction{
-		Name:     funcName,
-		proc:     proc,
-		argCount: argCount,
+		Name:        name,
+		PackageName: packageName,
+		proc:        proc,
+		argCount:    len(parameterList),
+		signature: &Function{
+			PackageName:   packageName,
+			Name:          name,
+			TypeSpecifier: typ,
+			ParameterList: parameterList,
+		},
+	}
+
+	if err := registerNativeFunction(function); err != nil {
+		panic(err)
+	}
+}
+
+func mustRegisterNative(packageName string, name string, fn interface{}) {
+	if err := RegisterNative(packageName, name, fn); err != nil {
+		panic(err)
+	}
+}
+
+func registerNativeFunction(function *NativeFunction) error {
+	nativeRegistry.Lock()
+	defer nativeRegistry.Unlock()
+
+	for _, f := range nativeRegistry.list {
+		if f.PackageName == function.PackageName && f.Name == function.Name {
+			return fmt.Errorf("重复定义了原生函数%s", qualifiedName(f.PackageName, f.Name))
+		}
 	}
 
-	vm.functionList = append(vm.functionList, function)
+	nativeRegistry.list = append(nativeRegistry.list, function)
+
+	return nil
+}
+
+// SearchNative 查找原生函数的签名, 没有找到时返回nil
+func SearchNative(packageName string, name string) *Function {
+	nativeRegistry.RLock()
+	defer nativeRegistry.RUnlock()
+
+	for _, f := range nativeRegistry.list {
+		if f.PackageName == packageName && f.Name == name {
+			return f.signature
+		}
+	}
+	return nil
+}
+
+// IsNativePackage 是否注册了属于该包的原生函数
+func IsNativePackage(packageName string) bool {
+	nativeRegistry.RLock()
+	defer nativeRegistry.RUnlock()
+
+	for _, f := range nativeRegistry.list {
+		if f.PackageName != "" && f.PackageName == packageName {
+			return true
+		}
+	}
+	return false
+}
+
+// AddNativeFunctions 添加已注册的原生函数
+func (vm *VirtualMachine) AddNativeFunctions() {
+	nativeRegistry.RLock()
+	defer nativeRegistry.RUnlock()
+
+	for _, f := range nativeRegistry.list {
+		vm.functionList = append(vm.functionList, f)
+	}
+}
+
+// ==============================
+// Go值和虚拟机值的转换
+// ==============================
+
+func goTypeToTypeSpecifier(t reflect.Type) (*TypeSpecifier, bool) {
+	switch t.Kind() {
+	case reflect.Bool:
+		return &TypeSpecifier{BasicType: BooleanType}, true
+	case reflect.Int:
+		return &TypeSpecifier{BasicType: IntType}, true
+	case reflect.Int64:
+		return &TypeSpecifier{BasicType: LongType}, true
+	case reflect.Int32:
+		return &TypeSpecifier{BasicType: Int32Type}, true
+	case reflect.Uint8:
+		return &TypeSpecifier{BasicType: ByteType}, true
+	case reflect.Float64:
+		return &TypeSpecifier{BasicType: DoubleType}, true
+	case reflect.String:
+		return &TypeSpecifier{BasicType: StringType}, true
+	case reflect.Slice:
+		typ, ok := goTypeToTypeSpecifier(t.Elem())
+		if !ok {
+			return nil, false
+		}
+		typ.AppendDerive(&ArrayDerive{})
+		return typ, true
+	}
+	return nil, false
+}
+
+func (vm *VirtualMachine) valueToGo(value Value, t reflect.Type) reflect.Value {
+	switch t.Kind() {
+	case reflect.Bool:
+		return reflect.ValueOf(intToBool(value.(*IntValue).intValue)).Convert(t)
+	case reflect.Int, reflect.Int64, reflect.Int32, reflect.Uint8:
+		return reflect.ValueOf(value.(*IntValue).intValue).Convert(t)
+	case reflect.Float64:
+		return reflect.ValueOf(value.(*DoubleValue).doubleValue).Convert(t)
+	case reflect.String:
+		ref := value.(*ObjectRef)
+		checkNullPointer(ref)
+		return reflect.ValueOf(ref.data.(*ObjectString).stringValue).Convert(t)
+	case reflect.Slice:
+		ref := value.(*ObjectRef)
+		if ref.data == nil {
+			return reflect.Zero(t)
+		}
+
+		var elemList []Value
+		switch array := ref.data.(type) {
+		case *ObjectArrayInt:
+			for _, v := range array.intArray {
+				elemList = append(elemList, &IntValue{intValue: v})
+			}
+		case *ObjectArrayDouble:
+			for _, v := range array.doubleArray {
+				elemList = append(elemList, &DoubleValue{doubleValue: v})
+			}
+		case *ObjectArrayObject:
+			for _, v := range array.objectArray {
+				if v == nil {
+					v = vm.nullObjectRef
+				}
+				elemList = append(elemList, v)
+			}
+		}
+
+		slice := reflect.MakeSlice(t, len(elemList), len(elemList))
+		for i, elem := range elemList {
+			slice.Index(i).Set(vm.valueToGo(elem, t.Elem()))
+		}
+		return slice
+	}
+	panic("TODO")
+}
+
+func (vm *VirtualMachine) goToValue(v reflect.Value) Value {
+	switch v.Kind() {
+	case reflect.Bool:
+		return &IntValue{intValue: boolToInt(v.Bool())}
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		return &IntValue{intValue: int(v.Int())}
+	case reflect.Uint8:
+		return &IntValue{intValue: int(v.Uint())}
+	case reflect.Float64:
+		return &DoubleValue{doubleValue: v.Float()}
+	case reflect.String:
+		return vm.createStringObject(v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			return &ObjectRef{}
+		}
+
+		var ref *ObjectRef
+
+		switch v.Type().Elem().Kind() {
+		case reflect.Bool, reflect.Int, reflect.Int64, reflect.Int32, reflect.Uint8:
+			ref = vm.createArrayInt(v.Len())
+			array := ref.data.(*ObjectArrayInt)
+			for i := range array.intArray {
+				array.intArray[i] = vm.goToValue(v.Index(i)).(*IntValue).intValue
+			}
+		case reflect.Float64:
+			ref = vm.createArrayDouble(v.Len())
+			array := ref.data.(*ObjectArrayDouble)
+			for i := range array.doubleArray {
+				array.doubleArray[i] = v.Index(i).Float()
+			}
+		default:
+			ref = vm.createArrayObject(v.Len())
+			array := ref.data.(*ObjectArrayObject)
+			for i := range array.objectArray {
+				array.objectArray[i] = vm.goToValue(v.Index(i)).(*ObjectRef)
+			}
+		}
+		return ref
+	}
+	panic("TODO")
+}
+
+// ==============================
+// 内置函数
+// ==============================
+//
+// 除print外都属于标准库的包, 需要先require
+//   math:    数学函数
+//   strings: 字符串处理和数字解析
+//   array:   数组, 参数可以是任意类型的数组
+//   io:      输入输出, 文件, 命令行参数和环境变量, 失败时抛出IOException
+
+func init() {
+	registerBuiltin("", "print", printProc, basicTypeSpecifier(VoidType), nativeParameter("str", basicTypeSpecifier(StringType)))
+
+	registerMathNatives()
+	registerStringsNatives()
+	registerArrayNatives()
+	registerIONatives()
+}
+
+func basicTypeSpecifier(basicType BasicType) *TypeSpecifier {
+	return &TypeSpecifier{BasicType: basicType}
+}
+
+// 任意类型的数组, 作为返回值时与第一个参数的类型相同
+func anyArrayTypeSpecifier() *TypeSpecifier {
+	return &TypeSpecifier{BasicType: BaseType, DeriveList: []TypeDerive{&ArrayDerive{}}}
+}
+
+func nativeParameter(name string, typ *TypeSpecifier) *LocalVariable {
+	return &LocalVariable{Name: name, TypeSpecifier: typ}
 }
 
 func printProc(vm *VirtualMachine, argCount int, args []Value) Value {
@@ -31,7 +375,265 @@ func printProc(vm *VirtualMachine, argCount int, args []Value) Value {
 		str = obj.(*ObjectString).stringValue
 	}
 
-	fmt.Println(str)
+	fmt.Fprintln(vm.stdout, str)
 
 	return ret
 }
+
+//
+// math
+//
+
+// 多个虚拟机共用, 需要加锁
+var randomSource = struct {
+	sync.Mutex
+	*rand.Rand
+}{Rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+func registerMathNatives() {
+	for name, fn := range map[string]interface{}{
+		"sqrt":  math.Sqrt,
+		"pow":   math.Pow,
+		"floor": math.Floor,
+		"ceil":  math.Ceil,
+		"abs":   math.Abs,
+		"sin":   math.Sin,
+		"cos":   math.Cos,
+		"tan":   math.Tan,
+		"asin":  math.Asin,
+		"acos":  math.Acos,
+		"atan":  math.Atan,
+		"atan2": math.Atan2,
+		"exp":   math.Exp,
+		"log":   math.Log,
+		"random": func() float64 {
+			randomSource.Lock()
+			defer randomSource.Unlock()
+			return randomSource.Float64()
+		},
+	} {
+		mustRegisterNative("math", name, fn)
+	}
+}
+
+//
+// strings
+//
+
+func registerStringsNatives() {
+	for name, fn := range map[string]interface{}{
+		"length":    utf8.RuneCountInString,
+		"substring": substring,
+		"indexOf":   indexOf,
+		"split":     strings.Split,
+		"replace": func(str, old, new string) string {
+			return strings.Replace(str, old, new, -1)
+		},
+		"toUpper":     strings.ToUpper,
+		"toLower":     strings.ToLower,
+		"trim":        strings.TrimSpace,
+		"parseInt":    parseInt,
+		"parseDouble": parseDouble,
+	} {
+		mustRegisterNative("strings", name, fn)
+	}
+}
+
+// 按字符计算下标, 包含begin, 不包含end
+func substring(str string, begin int, end int) string {
+	runeList := []rune(str)
+	if begin < 0 || begin > len(runeList) {
+		vmError(INDEX_OUT_OF_BOUNDS_ERR, len(runeList), begin)
+	}
+	if end < begin || end > len(runeList) {
+		vmError(INDEX_OUT_OF_BOUNDS_ERR, len(runeList), end)
+	}
+	return string(runeList[begin:end])
+}
+
+// 返回字符的下标, 不存在时返回-1
+func indexOf(str string, substr string) int {
+	index := strings.Index(str, substr)
+	if index < 0 {
+		return index
+	}
+	return utf8.RuneCountInString(str[:index])
+}
+
+func parseInt(str string) int {
+	value, err := strconv.Atoi(strings.TrimSpace(str))
+	if err != nil {
+		vmError(NUMBER_FORMAT_ERR, str)
+	}
+	return value
+}
+
+func parseDouble(str string) float64 {
+	value, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+	if err != nil {
+		vmError(NUMBER_FORMAT_ERR, str)
+	}
+	return value
+}
+
+//
+// array
+//
+
+func registerArrayNatives() {
+	registerBuiltin("array", "arrayLength", arrayLengthProc, basicTypeSpecifier(IntType), nativeParameter("array", anyArrayTypeSpecifier()))
+	registerBuiltin("array", "copy", arrayCopyProc, anyArrayTypeSpecifier(), nativeParameter("array", anyArrayTypeSpecifier()))
+	registerBuiltin("array", "sort", arraySortProc, basicTypeSpecifier(VoidType), nativeParameter("array", anyArrayTypeSpecifier()))
+}
+
+func getNativeArray(value Value) ObjectArray {
+	ref := value.(*ObjectRef)
+	checkNullPointer(ref)
+
+	return ref.data.(ObjectArray)
+}
+
+func arrayLengthProc(vm *VirtualMachine, argCount int, args []Value) Value {
+	return &IntValue{intValue: getNativeArray(args[0]).getArraySize()}
+}
+
+// 浅复制
+func arrayCopyProc(vm *VirtualMachine, argCount int, args []Value) Value {
+	var ret *ObjectRef
+
+	switch array := getNativeArray(args[0]).(type) {
+	case *ObjectArrayInt:
+		ret = vm.createArrayInt(len(array.intArray))
+		copy(ret.data.(*ObjectArrayInt).intArray, array.intArray)
+	case *ObjectArrayDouble:
+		ret = vm.createArrayDouble(len(array.doubleArray))
+		copy(ret.data.(*ObjectArrayDouble).doubleArray, array.doubleArray)
+	case *ObjectArrayObject:
+		ret = vm.createArrayObject(len(array.objectArray))
+		copy(ret.data.(*ObjectArrayObject).objectArray, array.objectArray)
+	}
+
+	ret.setPointer(true)
+
+	return ret
+}
+
+// 只能排序基本类型和字符串的数组, null排在最前
+func arraySortProc(vm *VirtualMachine, argCount int, args []Value) Value {
+	switch array := getNativeArray(args[0]).(type) {
+	case *ObjectArrayInt:
+		sort.Ints(array.intArray)
+	case *ObjectArrayDouble:
+		sort.Float64s(array.doubleArray)
+	case *ObjectArrayObject:
+		for _, ref := range array.objectArray {
+			if ref == nil || ref.data == nil {
+				continue
+			}
+			if _, ok := ref.data.(*ObjectString); !ok {
+				vmError(ILLEGAL_ARGUMENT_ERR, "sort", "只能排序int, double, boolean和string数组")
+			}
+		}
+
+		sort.SliceStable(array.objectArray, func(i, j int) bool {
+			return lessNullableString(array.objectArray[i], array.objectArray[j])
+		})
+	}
+
+	return &IntValue{intValue: 0}
+}
+
+func lessNullableString(ref1 *ObjectRef, ref2 *ObjectRef) bool {
+	if ref2 == nil || ref2.data == nil {
+		return false
+	}
+	if ref1 == nil || ref1.data == nil {
+		return true
+	}
+	return ref1.data.(*ObjectString).stringValue < ref2.data.(*ObjectString).stringValue
+}
+
+//
+// io
+//
+
+func registerIONatives() {
+	registerBuiltin("io", "readLine", readLineProc, basicTypeSpecifier(StringType))
+
+	for name, fn := range map[string]interface{}{
+		"print": func(vm *VirtualMachine, str string) error {
+			_, err := io.WriteString(vm.stdout, str)
+			return err
+		},
+		"readFile": func(path string) (string, error) {
+			data, err := ioutil.ReadFile(path)
+			return string(data), err
+		},
+		"writeFile": func(path string, content string) error {
+			return ioutil.WriteFile(path, []byte(content), 0644)
+		},
+		"appendFile": appendFile,
+		"exists": func(path string) bool {
+			_, err := os.Stat(path)
+			return err == nil
+		},
+		"listDir": listDir,
+		"args": func(vm *VirtualMachine) []string {
+			return append([]string{}, vm.args...)
+		},
+		"getenv": os.Getenv,
+	} {
+		if err := registerNative("io", name, fn, "IOException"); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// 读取一行, 不包含换行符, 输入结束时返回null
+func readLineProc(vm *VirtualMachine, argCount int, args []Value) Value {
+	line, err := vm.stdin.ReadString('\n')
+	if err != nil && err != io.EOF {
+		vm.throwNativeException("IOException", err.Error())
+	}
+
+	if err == io.EOF && line == "" {
+		ret := &ObjectRef{}
+		ret.setPointer(true)
+		return ret
+	}
+
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+
+	ret := vm.createStringObject(line)
+	ret.setPointer(true)
+
+	return ret
+}
+
+func appendFile(path string, content string) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.WriteString(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// 按名称排序
+func listDir(path string) ([]string, error) {
+	infoList, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	nameList := make([]string, 0, len(infoList))
+	for _, info := range infoList {
+		nameList = append(nameList, info.Name())
+	}
+	return nameList, nil
+}
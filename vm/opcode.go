@@ -34,6 +34,36 @@ const (
 	VM_POP_ARRAY_INT
 	VM_POP_ARRAY_DOUBLE
 	VM_POP_ARRAY_OBJECT
+	VM_ARRAY_LENGTH
+	VM_ARRAY_SLICE
+	VM_ARRAY_APPEND_INT
+	VM_ARRAY_APPEND_DOUBLE
+	VM_ARRAY_APPEND_OBJECT
+	VM_ARRAY_INSERT_INT
+	VM_ARRAY_INSERT_DOUBLE
+	VM_ARRAY_INSERT_OBJECT
+	VM_ARRAY_REMOVE_INT
+	VM_ARRAY_REMOVE_DOUBLE
+	VM_ARRAY_REMOVE_OBJECT
+	VM_STRING_LENGTH
+	VM_STRING_TO_CHARS
+	VM_PUSH_STRING_CHAR
+	/**********/
+	VM_PUSH_MAP_INT
+	VM_PUSH_MAP_DOUBLE
+	VM_PUSH_MAP_OBJECT
+	VM_POP_MAP_INT
+	VM_POP_MAP_DOUBLE
+	VM_POP_MAP_OBJECT
+	VM_MAP_SIZE
+	VM_MAP_CONTAINS
+	VM_MAP_DELETE
+	VM_MAP_KEYS_INT
+	VM_MAP_KEYS_DOUBLE
+	VM_MAP_KEYS_OBJECT
+	VM_MAP_VALUES_INT
+	VM_MAP_VALUES_DOUBLE
+	VM_MAP_VALUES_OBJECT
 	/**********/
 	VM_PUSH_FIELD_INT
 	VM_PUSH_FIELD_DOUBLE
@@ -42,6 +72,31 @@ const (
 	VM_POP_FIELD_DOUBLE
 	VM_POP_FIELD_OBJECT
 	/**********/
+	VM_PUSH_CLASS_STATIC_INT
+	VM_PUSH_CLASS_STATIC_DOUBLE
+	VM_PUSH_CLASS_STATIC_OBJECT
+	VM_POP_CLASS_STATIC_INT
+	VM_POP_CLASS_STATIC_DOUBLE
+	VM_POP_CLASS_STATIC_OBJECT
+	/**********/
+	VM_PUSH_PACKAGE_STATIC_INT
+	VM_PUSH_PACKAGE_STATIC_DOUBLE
+	VM_PUSH_PACKAGE_STATIC_OBJECT
+	VM_POP_PACKAGE_STATIC_INT
+	VM_POP_PACKAGE_STATIC_DOUBLE
+	VM_POP_PACKAGE_STATIC_OBJECT
+	/**********/
+	VM_NEW_CELL_INT
+	VM_NEW_CELL_DOUBLE
+	VM_NEW_CELL_OBJECT
+	VM_PUSH_CELL_INT
+	VM_PUSH_CELL_DOUBLE
+	VM_PUSH_CELL_OBJECT
+	VM_POP_CELL_INT
+	VM_POP_CELL_DOUBLE
+	VM_POP_CELL_OBJECT
+	VM_PUSH_ENV
+	/**********/
 	VM_ADD_INT
 	VM_ADD_DOUBLE
 	VM_ADD_STRING
@@ -57,11 +112,20 @@ const (
 	VM_MINUS_DOUBLE
 	VM_INCREMENT
 	VM_DECREMENT
+	VM_BIT_AND
+	VM_BIT_OR
+	VM_BIT_XOR
+	VM_BIT_NOT
+	VM_LEFT_SHIFT
+	VM_RIGHT_SHIFT
 	VM_CAST_INT_TO_DOUBLE
 	VM_CAST_DOUBLE_TO_INT
 	VM_CAST_BOOLEAN_TO_STRING
 	VM_CAST_INT_TO_STRING
 	VM_CAST_DOUBLE_TO_STRING
+	VM_CAST_CHAR_TO_STRING
+	VM_CAST_INT_TO_BYTE
+	VM_CAST_INT_TO_INT32
 	VM_UP_CAST
 	VM_DOWN_CAST
 	VM_EQ_INT
@@ -90,13 +154,20 @@ const (
 	VM_POP
 	VM_DUPLICATE
 	VM_DUPLICATE_OFFSET
+	// 栈顶的值移动到下面n个值之下
+	VM_ROTATE
 	VM_JUMP
 	VM_JUMP_IF_TRUE
 	VM_JUMP_IF_FALSE
+	VM_TABLESWITCH
+	VM_LOOKUPSWITCH
+	VM_LOOKUPSWITCH_STRING
 	/**********/
 	VM_PUSH_FUNCTION
 	VM_PUSH_METHOD
+	VM_PUSH_INTERFACE_METHOD
 	VM_INVOKE
+	VM_INVOKE_CLOSURE
 	VM_RETURN
 	/**********/
 	VM_NEW
@@ -104,8 +175,14 @@ const (
 	VM_NEW_ARRAY_LITERAL_INT
 	VM_NEW_ARRAY_LITERAL_DOUBLE
 	VM_NEW_ARRAY_LITERAL_OBJECT
+	VM_NEW_MAP_LITERAL
+	VM_NEW_CLOSURE
 	VM_SUPER
 	VM_INSTANCEOF
+	/**********/
+	VM_THROW
+	VM_GO_FINALLY
+	VM_FINALLY_END
 )
 
 type opcodeInfo struct {
@@ -150,6 +227,36 @@ var OpcodeInfo []opcodeInfo = []opcodeInfo{
 	{"pop_array_int", "", -1},
 	{"pop_array_double", "", -1},
 	{"pop_array_object", "", -1},
+	{"array_length", "", 0},
+	{"array_slice", "", -2},
+	{"array_append_int", "", -1},
+	{"array_append_double", "", -1},
+	{"array_append_object", "", -1},
+	{"array_insert_int", "", -2},
+	{"array_insert_double", "", -2},
+	{"array_insert_object", "", -2},
+	{"array_remove_int", "", -1},
+	{"array_remove_double", "", -1},
+	{"array_remove_object", "", -1},
+	{"string_length", "", 0},
+	{"string_to_chars", "", 0},
+	{"push_string_char", "", -1},
+	/**********/
+	{"push_map_int", "", 1},
+	{"push_map_double", "", 1},
+	{"push_map_object", "", 1},
+	{"pop_map_int", "", -1},
+	{"pop_map_double", "", -1},
+	{"pop_map_object", "", -1},
+	{"map_size", "", 0},
+	{"map_contains", "", -1},
+	{"map_delete", "", -1},
+	{"map_keys_int", "", 0},
+	{"map_keys_double", "", 0},
+	{"map_keys_object", "", 1},
+	{"map_values_int", "", 0},
+	{"map_values_double", "", 0},
+	{"map_values_object", "", 0},
 	/**********/
 	{"push_field_int", "s", 1},
 	{"push_field_double", "s", 1},
@@ -158,6 +265,31 @@ var OpcodeInfo []opcodeInfo = []opcodeInfo{
 	{"pop_field_double", "s", -1},
 	{"pop_field_object", "s", -1},
 	/**********/
+	{"push_class_static_int", "ss", 1},
+	{"push_class_static_double", "ss", 1},
+	{"push_class_static_object", "ss", 1},
+	{"pop_class_static_int", "ss", -1},
+	{"pop_class_static_double", "ss", -1},
+	{"pop_class_static_object", "ss", -1},
+	/**********/
+	{"push_package_static_int", "s", 1},
+	{"push_package_static_double", "s", 1},
+	{"push_package_static_object", "s", 1},
+	{"pop_package_static_int", "s", -1},
+	{"pop_package_static_double", "s", -1},
+	{"pop_package_static_object", "s", -1},
+	/**********/
+	{"new_cell_int", "", 0},
+	{"new_cell_double", "", 0},
+	{"new_cell_object", "", 0},
+	{"push_cell_int", "", 0},
+	{"push_cell_double", "", 0},
+	{"push_cell_object", "", 0},
+	{"pop_cell_int", "", -2},
+	{"pop_cell_double", "", -2},
+	{"pop_cell_object", "", -2},
+	{"push_env", "s", 0},
+	/**********/
 	{"add_int", "", -1},
 	{"add_double", "", -1},
 	{"add_string", "", -1},
@@ -173,11 +305,20 @@ var OpcodeInfo []opcodeInfo = []opcodeInfo{
 	{"minus_double", "", 0},
 	{"increment", "", 0},
 	{"decrement", "", 0},
+	{"bit_and", "", -1},
+	{"bit_or", "", -1},
+	{"bit_xor", "", -1},
+	{"bit_not", "", 0},
+	{"left_shift", "", -1},
+	{"right_shift", "", -1},
 	{"cast_int_to_double", "", 0},
 	{"cast_double_to_int", "", 0},
 	{"cast_boolean_to_string", "", 0},
 	{"cast_int_to_string", "", 0},
 	{"cast_double_to_string", "", 0},
+	{"cast_char_to_string", "", 0},
+	{"cast_int_to_byte", "", 0},
+	{"cast_int_to_int32", "", 0},
 	{"up_cast", "s", 0},
 	{"down_cast", "s", 0},
 	{"eq_int", "", -1},
@@ -206,13 +347,20 @@ var OpcodeInfo []opcodeInfo = []opcodeInfo{
 	{"pop", "", -1},
 	{"duplicate", "", 1},
 	{"duplicate_offset", "s", 1},
+	{"rotate", "s", 0},
 	{"jump", "s", 0},
 	{"jump_if_true", "s", -1},
 	{"jump_if_false", "s", -1},
+	{"tableswitch", "s", -1},
+	{"lookupswitch", "s", -1},
+	{"lookupswitch_string", "s", -1},
 	/**********/
 	{"push_function", "s", 1},
 	{"push_method", "s", 1},
+	{"push_interface_method", "ss", 1},
 	{"invoke", "", -1},
+	// 调用lambda时closure作为this, 需要额外压入函数下标
+	{"invoke_closure", "", 1},
 	{"return", "", -1},
 	/**********/
 	{"new", "s", 1},
@@ -220,6 +368,13 @@ var OpcodeInfo []opcodeInfo = []opcodeInfo{
 	{"new_array_literal_int", "s", 1},
 	{"new_array_literal_double", "s", 1},
 	{"new_array_literal_object", "s", 1},
-	{"super", "", 0},
+	// 参数为键值对的数量, 键和值交替位于栈顶
+	{"new_map_literal", "s", 1},
+	{"new_closure", "ss", 1},
+	{"super", "ss", 1},
 	{"instanceof", "s", 0},
+	/**********/
+	{"throw", "", -1},
+	{"go_finally", "s", 1},
+	{"finally_end", "", -1},
 }
@@ -134,27 +134,48 @@ func (s *Stack) setObjectI(sp int, value *ObjectRef) {
 }
 
 // other get
+// 获取引用对象, 引用为null时报错
+func (s *Stack) getNotNullObject(sp int) *ObjectRef {
+	obj := s.getObject(sp)
+	checkNullPointer(obj)
+	return obj
+}
+
 func (s *Stack) getString(sp int) string {
-	index := s.getIndexOverSp(sp)
-	return s.getObjectI(index).data.(*ObjectString).stringValue
+	return s.getNotNullObject(sp).data.(*ObjectString).stringValue
+}
+
+func (s *Stack) getStringObject(sp int) *ObjectString {
+	return s.getNotNullObject(sp).data.(*ObjectString)
 }
 
 func (s *Stack) getArrayInt(sp int) *ObjectArrayInt {
-	index := s.getIndexOverSp(sp)
-	return s.getObjectI(index).data.(*ObjectArrayInt)
+	return s.getNotNullObject(sp).data.(*ObjectArrayInt)
 }
 
 func (s *Stack) getArrayDouble(sp int) *ObjectArrayDouble {
-	index := s.getIndexOverSp(sp)
-	return s.getObjectI(index).data.(*ObjectArrayDouble)
+	return s.getNotNullObject(sp).data.(*ObjectArrayDouble)
 }
 
 func (s *Stack) getArrayObject(sp int) *ObjectArrayObject {
-	index := s.getIndexOverSp(sp)
-	return s.getObjectI(index).data.(*ObjectArrayObject)
+	return s.getNotNullObject(sp).data.(*ObjectArrayObject)
+}
+
+func (s *Stack) getMap(sp int) *ObjectMap {
+	return s.getNotNullObject(sp).data.(*ObjectMap)
+}
+
+// map的键, int和boolean为int, double为float64, string为string
+func (s *Stack) getMapKey(sp int) interface{} {
+	switch value := s.stack[s.getIndexOverSp(sp)].(type) {
+	case *IntValue:
+		return value.intValue
+	case *DoubleValue:
+		return value.doubleValue
+	}
+	return s.getString(sp)
 }
 
 func (s *Stack) getClassObject(sp int) *ObjectClassObject {
-	index := s.getIndexOverSp(sp)
-	return s.getObjectI(index).data.(*ObjectClassObject)
+	return s.getNotNullObject(sp).data.(*ObjectClassObject)
 }
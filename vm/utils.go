@@ -22,6 +22,11 @@ func intToBool(i int) bool {
 	return true
 }
 
+// 移位运算只使用位数的低6位
+func shiftCount(count int) uint {
+	return uint(count) & 63
+}
+
 func get2ByteInt(b []byte) int {
 	return int(binary.BigEndian.Uint16(b))
 }
@@ -29,23 +34,24 @@ func set2ByteInt(b []byte, value int) {
 	binary.BigEndian.PutUint16(b, uint16(value))
 }
 
-func initializeValue(typ *TypeSpecifier) Value {
+func (vm *VirtualMachine) initializeValue(typ *TypeSpecifier) Value {
 	var value Value
 
-	if typ.isArrayDerive() {
-		value = vmNullObjectRef
+	// 数组和函数
+	if len(typ.DeriveList) > 0 {
+		value = vm.nullObjectRef
 		return value
 	}
 
 	switch typ.BasicType {
-	case VoidType, BooleanType, IntType:
+	case VoidType, BooleanType, IntType, CharType, ByteType, Int32Type, LongType:
 		value = &IntValue{intValue: 0}
 
 	case DoubleType:
 		value = &DoubleValue{doubleValue: 0.0}
 
 	case StringType, ClassType:
-		value = vmNullObjectRef
+		value = vm.nullObjectRef
 
 	case NullType, BaseType:
 		fallthrough
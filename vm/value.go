@@ -1,5 +1,9 @@
 package vm
 
+import (
+	"unicode/utf8"
+)
+
 //
 // Value
 //
@@ -42,6 +46,7 @@ type CallInfo struct {
 //
 // IntValue
 //
+// boolean, char和所有整数类型都保存为int, long需要int为64位
 type IntValue struct {
 	ValueImpl
 	intValue int
@@ -107,6 +112,31 @@ type ObjectString struct {
 	ObjectImpl
 
 	stringValue string
+	// 按下标访问时才解码, 字符串不可变, 解码后缓存
+	runeList []rune
+}
+
+func (s *ObjectString) getRuneList() []rune {
+	if s.runeList == nil {
+		s.runeList = []rune(s.stringValue)
+	}
+	return s.runeList
+}
+
+// 字符数, 而不是字节数
+func (s *ObjectString) length() int {
+	if s.runeList != nil {
+		return len(s.runeList)
+	}
+	return utf8.RuneCountInString(s.stringValue)
+}
+
+func (s *ObjectString) getChar(index int) rune {
+	runeList := s.getRuneList()
+	if index < 0 || index >= len(runeList) {
+		vmError(INDEX_OUT_OF_BOUNDS_ERR, len(runeList), index)
+	}
+	return runeList[index]
 }
 
 //
@@ -141,6 +171,33 @@ func (array *ObjectArrayInt) setInt(index int, value int) {
 	array.intArray[index] = value
 }
 
+func (array *ObjectArrayInt) appendInt(value int) {
+	array.intArray = append(array.intArray, value)
+}
+
+// 插入和删除都重新分配存储, 不影响共享存储的切片
+func (array *ObjectArrayInt) insertInt(index int, value int) {
+	checkArrayInsert(array, index)
+
+	list := make([]int, len(array.intArray)+1)
+	copy(list, array.intArray[:index])
+	list[index] = value
+	copy(list[index+1:], array.intArray[index:])
+	array.intArray = list
+}
+
+func (array *ObjectArrayInt) removeInt(index int) int {
+	checkArray(array, index)
+
+	value := array.intArray[index]
+	list := make([]int, len(array.intArray)-1)
+	copy(list, array.intArray[:index])
+	copy(list[index:], array.intArray[index+1:])
+	array.intArray = list
+
+	return value
+}
+
 // array double
 type ObjectArrayDouble struct {
 	ObjectImpl
@@ -165,6 +222,32 @@ func (obj *ObjectArrayDouble) setDouble(index int, value float64) {
 	obj.doubleArray[index] = value
 }
 
+func (obj *ObjectArrayDouble) appendDouble(value float64) {
+	obj.doubleArray = append(obj.doubleArray, value)
+}
+
+func (obj *ObjectArrayDouble) insertDouble(index int, value float64) {
+	checkArrayInsert(obj, index)
+
+	list := make([]float64, len(obj.doubleArray)+1)
+	copy(list, obj.doubleArray[:index])
+	list[index] = value
+	copy(list[index+1:], obj.doubleArray[index:])
+	obj.doubleArray = list
+}
+
+func (obj *ObjectArrayDouble) removeDouble(index int) float64 {
+	checkArray(obj, index)
+
+	value := obj.doubleArray[index]
+	list := make([]float64, len(obj.doubleArray)-1)
+	copy(list, obj.doubleArray[:index])
+	copy(list[index:], obj.doubleArray[index+1:])
+	obj.doubleArray = list
+
+	return value
+}
+
 // array object
 type ObjectArrayObject struct {
 	ObjectImpl
@@ -190,6 +273,71 @@ func (obj *ObjectArrayObject) setObject(index int, value *ObjectRef) {
 	obj.objectArray[index] = value
 }
 
+func (obj *ObjectArrayObject) appendObject(value *ObjectRef) {
+	obj.objectArray = append(obj.objectArray, value)
+}
+
+func (obj *ObjectArrayObject) insertObject(index int, value *ObjectRef) {
+	checkArrayInsert(obj, index)
+
+	list := make([]*ObjectRef, len(obj.objectArray)+1)
+	copy(list, obj.objectArray[:index])
+	list[index] = value
+	copy(list[index+1:], obj.objectArray[index:])
+	obj.objectArray = list
+}
+
+func (obj *ObjectArrayObject) removeObject(index int) *ObjectRef {
+	checkArray(obj, index)
+
+	value := obj.objectArray[index]
+	list := make([]*ObjectRef, len(obj.objectArray)-1)
+	copy(list, obj.objectArray[:index])
+	copy(list[index:], obj.objectArray[index+1:])
+	obj.objectArray = list
+
+	return value
+}
+
+//
+// ObjectMap
+//
+// map<K, V>, 键为int, double或string, 按插入的顺序遍历
+type ObjectMap struct {
+	ObjectImpl
+
+	keyList  []interface{}
+	valueMap map[interface{}]Value
+}
+
+func (obj *ObjectMap) get(key interface{}) (Value, bool) {
+	value, ok := obj.valueMap[key]
+	return value, ok
+}
+
+func (obj *ObjectMap) set(key interface{}, value Value) {
+	if _, ok := obj.valueMap[key]; !ok {
+		obj.keyList = append(obj.keyList, key)
+	}
+	obj.valueMap[key] = value
+}
+
+// 删除成功返回true
+func (obj *ObjectMap) delete(key interface{}) bool {
+	if _, ok := obj.valueMap[key]; !ok {
+		return false
+	}
+	delete(obj.valueMap, key)
+
+	for i, k := range obj.keyList {
+		if k == key {
+			obj.keyList = append(obj.keyList[:i], obj.keyList[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
 //
 // ObjectClassObject
 //
@@ -197,6 +345,9 @@ type ObjectClassObject struct {
 	ObjectImpl
 
 	fieldList []Value
+
+	// 作为异常抛出时, 记录首次抛出的位置
+	runtimeError *RuntimeError
 }
 
 func (obj *ObjectClassObject) getInt(index int) int {
@@ -232,6 +383,30 @@ func (obj *ObjectClassObject) writeObject(sp int, value *ObjectRef) {
 	obj.fieldList[sp] = value
 }
 
+//
+// ObjectClosure
+//
+// 函数类型的值, lambda还保存捕获的变量
+type ObjectClosure struct {
+	ObjectImpl
+
+	// 虚拟机中的函数下标
+	functionIndex int
+
+	// lambda捕获的变量的cell, 捕获this时为对象本身
+	environment []*ObjectRef
+}
+
+//
+// ObjectCell
+//
+// 被lambda捕获的局部变量保存在堆上, 函数返回后仍然可以访问
+type ObjectCell struct {
+	ObjectImpl
+
+	value Value
+}
+
 // utils
 func checkArray(array ObjectArray, index int) {
 
@@ -241,7 +416,15 @@ func checkArray(array ObjectArray, index int) {
 	}
 
 	arraySize := array.getArraySize()
-	if arraySize < 0 || index >= arraySize {
-		vmError(INDEX_OUT_OF_BOUNDS_ERR, index, arraySize)
+	if index < 0 || index >= arraySize {
+		vmError(INDEX_OUT_OF_BOUNDS_ERR, arraySize, index)
+	}
+}
+
+// 插入的位置可以是数组末尾
+func checkArrayInsert(array ObjectArray, index int) {
+	arraySize := array.getArraySize()
+	if index < 0 || index > arraySize {
+		vmError(INDEX_OUT_OF_BOUNDS_ERR, arraySize, index)
 	}
 }
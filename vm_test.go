@@ -1,7 +1,13 @@
 package main
 
 import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/lth-go/gogogogo/compiler"
@@ -13,7 +19,10 @@ var testFile = "test/test.4g"
 func TestVmMachine(t *testing.T) {
 	os.Setenv("REQUIRE_SEARCH_PATH", "./test")
 
-	exeList := compiler.CompileFile(testFile)
+	exeList, err := compiler.CompileFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	//// 打印字节码
 	//for _, exe := range exeList.List {
@@ -25,5 +34,462 @@ func TestVmMachine(t *testing.T) {
 
 	VM.SetExecutableList(exeList)
 
-	VM.Execute()
+	err = VM.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestException(t *testing.T) {
+	os.Setenv("REQUIRE_SEARCH_PATH", "./test")
+
+	exeList, err := compiler.CompileFile("test/exception.4g")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	VM := vm.NewVirtualMachine()
+
+	VM.SetExecutableList(exeList)
+
+	err = VM.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRuntimeError(t *testing.T) {
+	exeList, err := compiler.CompileFile("test/runtime_error.4g")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	VM := vm.NewVirtualMachine()
+
+	VM.SetExecutableList(exeList)
+
+	err = VM.Execute()
+
+	runtimeError, ok := err.(*vm.RuntimeError)
+	if !ok {
+		t.Fatalf("runtime error expected, got %v", err)
+	}
+
+	if runtimeError.Code != vm.INDEX_OUT_OF_BOUNDS_ERR {
+		t.Errorf("code: %d", runtimeError.Code)
+	}
+	if runtimeError.Message != "数组下标越界。数组大小为3，访问的下标为[3]。" {
+		t.Errorf("message: %s", runtimeError.Message)
+	}
+
+	expectList := []struct {
+		name string
+		line int
+	}{
+		{"Holder#get", 6},
+		{"getFrom", 12},
+		{"", 17},
+	}
+
+	if len(runtimeError.StackTrace) != len(expectList) {
+		t.Fatalf("stack trace:\n%v", runtimeError)
+	}
+
+	for i, expect := range expectList {
+		frame := runtimeError.StackTrace[i]
+		if frame.FunctionName != expect.name || frame.Line != expect.line {
+			t.Errorf("frame %d: %s, expect %s (line %d)", i, frame, expect.name, expect.line)
+		}
+	}
+}
+
+// 只需要执行成功的脚本, 脚本中用check检查结果
+func TestScript(t *testing.T) {
+	for _, name := range []string{
+		"loop",
+		"operator",
+		"inherit",
+		"interface",
+		"constructor",
+		"static",
+		"closure",
+		"generic",
+		"map",
+		"array",
+		"foreach",
+		"char",
+		"numeric",
+	} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			exeList, _, err := compiler.Compile("test/"+name+".4g", compiler.Options{SearchPath: "./test"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			VM := vm.NewVirtualMachine()
+			VM.SetExecutableList(exeList)
+
+			err = VM.Execute()
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestSwitch(t *testing.T) {
+	exeList, _, err := compiler.Compile("test/switch.4g", compiler.Options{SearchPath: "./test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	exeList.Disassemble(buf)
+	for _, expect := range []string{"tableswitch", "lookupswitch ", "lookupswitch_string", "instanceof"} {
+		if !strings.Contains(buf.String(), expect) {
+			t.Errorf("%q not found in disassemble result", expect)
+		}
+	}
+
+	VM := vm.NewVirtualMachine()
+	VM.SetExecutableList(exeList)
+
+	err = VM.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBytecodeFile(t *testing.T) {
+	os.Setenv("REQUIRE_SEARCH_PATH", "./test")
+
+	exeList, err := compiler.CompileFile("test/exception.4g")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := exeList.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loadedExeList := vm.NewExecutableList()
+	err = loadedExeList.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新序列化应该得到相同的结果
+	reData, err := loadedExeList.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, reData) {
+		t.Fatal("marshal result changed after unmarshal")
+	}
+
+	if loadedExeList.Unmarshal(data[:len(data)-1]) == nil {
+		t.Fatal("truncated bytecode should fail")
+	}
+
+	VM := vm.NewVirtualMachine()
+
+	VM.SetExecutableList(loadedExeList)
+
+	err = VM.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDisassemble(t *testing.T) {
+	os.Setenv("REQUIRE_SEARCH_PATH", "./test")
+
+	exeList, err := compiler.CompileFile("test/exception.4g")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	exeList.Disassemble(buf)
+	text := buf.String()
+
+	for _, expect := range []string{
+		"-- function int callThrower(int code) --",
+		"push_string                #1              ; string \"my exception\"",
+		"jump                       L0",
+		"go_finally",
+		"; line 58",
+		"catch MyException",
+		"-- function void gogogogo.lang.Exception#Exception(string message) --",
+	} {
+		if !strings.Contains(text, expect) {
+			t.Errorf("%q not found in:\n%s", expect, text)
+		}
+	}
+
+	// 折叠的常量和隐式的return都保留源码位置
+	if strings.Contains(text, "; line 0") {
+		t.Errorf("\"; line 0\" found in:\n%s", text)
+	}
+}
+
+func TestRepl(t *testing.T) {
+	input := strings.Join([]string{
+		"int a = 1;",
+		"a + 2",
+		"int add(int x, int y) {",
+		"    return x + y;",
+		"}",
+		"add(a, 10);",
+		"b;",
+		"class Point {",
+		"    int x;",
+		"    Point(int x) { this.x = x; }",
+		"}",
+		"Point p = new Point(3);",
+		"p.x * 2;",
+		"int[] arr = {1};",
+		"arr[5];",
+		"a = a + 1;",
+		"\"a..\" + a;",
+		"char c = '{';",
+		"c;",
+		"'\"';",
+		"'(' == c;",
+		"print(\"\");",
+	}, "\n")
+
+	out := &bytes.Buffer{}
+	runRepl(strings.NewReader(input), out)
+	text := out.String()
+
+	// 按顺序输出
+	for _, expect := range []string{
+		"3\n",
+		"11\n",
+		"找不到变量或函数b。",
+		"6\n",
+		"数组下标越界。",
+		"a..2\n",
+		"{\n",
+		"\"\n",
+		// void函数的返回值不输出
+		"false\n>>> >>> ",
+	} {
+		index := strings.Index(text, expect)
+		if index < 0 {
+			t.Fatalf("%q not found in:\n%s", expect, out.String())
+		}
+		text = text[index+len(expect):]
+	}
+}
+
+// 多个compiler和虚拟机在不同goroutine中同时运行, 需要用-race检查
+func TestParallel(t *testing.T) {
+	fileList := []string{"test/test.4g", "test/exception.4g", "test/runtime_error.4g"}
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(fileList)*4+8)
+
+	for i := 0; i < 4; i++ {
+		for _, file := range fileList {
+			wg.Add(1)
+			go func(file string) {
+				defer wg.Done()
+
+				exeList, _, err := compiler.Compile(file, compiler.Options{SearchPath: "./test"})
+				if err != nil {
+					errCh <- err
+					return
+				}
+
+				VM := vm.NewVirtualMachine()
+				VM.SetExecutableList(exeList)
+
+				err = VM.Execute()
+				if _, ok := err.(*vm.RuntimeError); ok && file == "test/runtime_error.4g" {
+					return
+				}
+				if err != nil {
+					errCh <- fmt.Errorf("%s: %v", file, err)
+				}
+			}(file)
+		}
+	}
+
+	// 编译一次, 在多个虚拟机中同时执行
+	for _, file := range []string{"test/switch.4g", "test/closure.4g"} {
+		exeList, _, err := compiler.Compile(file, compiler.Options{SearchPath: "./test"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(file string) {
+				defer wg.Done()
+
+				VM := vm.NewVirtualMachine()
+				VM.SetExecutableList(exeList)
+
+				if err := VM.Execute(); err != nil {
+					errCh <- fmt.Errorf("%s: %v", file, err)
+				}
+			}(file)
+		}
+	}
+
+	wg.Wait()
+	close(errCh)
+
+	for err := range errCh {
+		t.Error(err)
+	}
+}
+
+var nativeRecordList []string
+var registerNativeOnce sync.Once
+
+func registerTestNatives(t *testing.T) {
+	registerNativeOnce.Do(func() {
+		for _, native := range []struct {
+			packageName string
+			name        string
+			fn          interface{}
+		}{
+			{"", "record", func(str string) { nativeRecordList = append(nativeRecordList, str) }},
+			{"", "hypot", math.Hypot},
+			{"", "join", strings.Join},
+			{"", "reverse", func(list []int) []int {
+				ret := make([]int, len(list))
+				for i, v := range list {
+					ret[len(list)-1-i] = v
+				}
+				return ret
+			}},
+			{"", "isEven", func(i int) bool { return i%2 == 0 }},
+			{"testnative", "repeat", strings.Repeat},
+		} {
+			if err := vm.RegisterNative(native.packageName, native.name, native.fn); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestRegisterNative(t *testing.T) {
+	registerTestNatives(t)
+
+	if vm.RegisterNative("", "record", func() {}) == nil {
+		t.Error("duplicate native should fail")
+	}
+	if vm.RegisterNative("", "notFunction", 1) == nil {
+		t.Error("non-function native should fail")
+	}
+	if vm.RegisterNative("", "badParam", func(m map[string]int) {}) == nil {
+		t.Error("unsupported parameter type should fail")
+	}
+
+	nativeRecordList = nil
+
+	exeList, _, err := compiler.Compile("test/native.4g", compiler.Options{SearchPath: "./test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	VM := vm.NewVirtualMachine()
+	VM.SetExecutableList(exeList)
+
+	err = VM.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectList := []string{"5.000000", "a-b-c", "321", "true,false", "ababab", "catch null"}
+
+	if strings.Join(nativeRecordList, "\n") != strings.Join(expectList, "\n") {
+		t.Errorf("got %q, expect %q", nativeRecordList, expectList)
+	}
+}
+
+func TestStdlib(t *testing.T) {
+	registerTestNatives(t)
+
+	nativeRecordList = nil
+
+	exeList, _, err := compiler.Compile("test/stdlib.4g", compiler.Options{SearchPath: "./test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	VM := vm.NewVirtualMachine()
+	VM.SetExecutableList(exeList)
+
+	err = VM.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectList := []string{
+		"4.000000",
+		"1024.000000",
+		"2.000000,3.000000,1.500000",
+		"1.000000",
+		"true",
+		"Hello, World",
+		"12",
+		"World",
+		"7,-1",
+		"HELLO, WORLDhello, world",
+		"a+b+c",
+		"z",
+		"43",
+		"2.500000",
+		"不能将\"abc\"转换为数字。",
+		"substring out of bounds",
+		"123,312",
+		"3",
+		"apple fig pear",
+		"-1.000000",
+	}
+
+	if strings.Join(nativeRecordList, "\n") != strings.Join(expectList, "\n") {
+		t.Errorf("got:\n%s\nexpect:\n%s", strings.Join(nativeRecordList, "\n"), strings.Join(expectList, "\n"))
+	}
+}
+
+func TestIO(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gogogogo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Setenv("GOGOGOGO_TEST_DIR", dir)
+
+	// test目录下的io.4g会覆盖原生的io包, 搜索路径使用临时目录
+	exeList, _, err := compiler.Compile("test/io.4g", compiler.Options{SearchPath: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+
+	VM := vm.NewVirtualMachine()
+	VM.SetArgs([]string{"a", "b"})
+	VM.SetStdin(strings.NewReader("line1\r\nline2"))
+	VM.SetStdout(out)
+	VM.SetExecutableList(exeList)
+
+	err = VM.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := "a,b|false|first\nsecond\ntrue|out.txt|line1|line2|eof|IOException"
+	if out.String() != expect {
+		t.Errorf("got %q, expect %q", out.String(), expect)
+	}
 }